@@ -1,9 +1,13 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"ticketing-api/auth"
+	"ticketing-api/data"
 	"ticketing-api/types"
+	"time"
 )
 
 func (s *APIServer) handleCreateAccount(w http.ResponseWriter, r *http.Request) error {
@@ -14,6 +18,22 @@ func (s *APIServer) handleCreateAccount(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
+	if req.Role == "" {
+		req.Role = types.RoleUser
+	}
+
+	if req.Role != types.RoleUser {
+		err = s.policy.Authorize(r, types.PermissionRoleManage, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = checkRole(r.Context(), s.db, req.Role)
+	if err != nil {
+		return err
+	}
+
 	passwordHash, err := auth.CreateHash(req.Password)
 	if err != nil {
 		return err
@@ -24,7 +44,24 @@ func (s *APIServer) handleCreateAccount(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
-	account, err = s.db.Account.Create(account)
+	account.OrgID, err = auth.GetOrganizationID(r)
+	if err != nil {
+		return err
+	}
+
+	actorID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		account, err = tx.Account.Create(r.Context(), account)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, types.AccountChanges(actorID, nil, account))
+	})
 	if err != nil {
 		return err
 	}
@@ -33,12 +70,17 @@ func (s *APIServer) handleCreateAccount(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *APIServer) handleGetAccounts(w http.ResponseWriter, r *http.Request) error {
-	accounts, err := s.db.Account.Get()
+	page, err := getPage(r, data.AccountSorts)
+	if err != nil {
+		return err
+	}
+
+	accounts, info, err := s.db.Account.Get(r.Context(), page)
 	if err != nil {
 		return err
 	}
 
-	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "accounts found", Data: accounts})
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "accounts found", Data: accounts, NextCursor: info.NextCursor, Total: info.Total})
 }
 
 func (s *APIServer) handleGetAccountByID(w http.ResponseWriter, r *http.Request) error {
@@ -47,16 +89,18 @@ func (s *APIServer) handleGetAccountByID(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
-	err = auth.IsAccountID(r, id, types.RoleAdmin, types.RoleEditor)
+	err = s.policy.Authorize(r, types.PermissionAccountRead, &types.Account{ID: id})
 	if err != nil {
 		return err
 	}
 
-	account, err := s.db.Account.GetByID(id)
+	account, err := s.db.Account.GetByID(r.Context(), id)
 	if err != nil {
 		return err
 	}
 
+	setETag(w, account.Version)
+
 	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "account found", Data: account})
 }
 
@@ -66,12 +110,12 @@ func (s *APIServer) handleUpdateAccount(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
-	err = auth.IsAccountID(r, id, types.RoleAdmin)
+	subject, err := s.policy.Subject(r)
 	if err != nil {
 		return err
 	}
 
-	account, err := s.db.Account.GetByID(id)
+	err = subject.Can(types.PermissionAccountManage, &types.Account{ID: id, OrgID: subject.OrgID})
 	if err != nil {
 		return err
 	}
@@ -83,29 +127,175 @@ func (s *APIServer) handleUpdateAccount(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
-	if req.Username != "" {
-		account.Username = req.Username
+	if req.Role != "" {
+		err = subject.Can(types.PermissionRoleManage, nil)
+		if err != nil {
+			return err
+		}
+
+		err = checkRole(r.Context(), s.db, req.Role)
+		if err != nil {
+			return err
+		}
 	}
 
+	passwordHash := ""
+
 	if req.Password != "" {
-		passwordHash, err := auth.CreateHash(req.Password)
+		passwordHash, err = auth.CreateHash(req.Password)
 		if err != nil {
 			return err
 		}
+	}
+
+	account := &types.Account{}
 
-		account.Password = passwordHash
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		account, err = tx.Account.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		err = subject.Can(types.PermissionAccountManage, account)
+		if err != nil {
+			return err
+		}
+
+		before := *account
+
+		account.Version, err = getIfMatch(r, account.Version)
+		if err != nil {
+			return err
+		}
+
+		if req.Username != "" {
+			account.Username = req.Username
+		}
+
+		if passwordHash != "" {
+			account.Password = passwordHash
+		}
+
+		if req.Role != "" {
+			account.Role = req.Role
+		}
+
+		account, err = tx.Account.Update(r.Context(), account)
+		if err != nil {
+			return err
+		}
+
+		err = revokeSessionsOnChange(r.Context(), tx, &before, account)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, types.AccountChanges(subject.ID, &before, account))
+	})
+	if err != nil {
+		return err
 	}
 
-	if req.Role != "" {
-		account.Role = req.Role
+	setETag(w, account.Version)
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "account updated", Data: account})
+}
+
+func (s *APIServer) handlePatchAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
 	}
 
-	account, err = s.db.Account.Update(account)
+	subject, err := s.policy.Subject(r)
 	if err != nil {
 		return err
 	}
 
-	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "account updated", Data: account})
+	err = subject.Can(types.PermissionAccountManage, &types.Account{ID: id, OrgID: subject.OrgID})
+	if err != nil {
+		return err
+	}
+
+	patch, err := decodePatch(r)
+	if err != nil {
+		return err
+	}
+
+	account := &types.Account{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		current, err := tx.Account.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		err = subject.Can(types.PermissionAccountManage, current)
+		if err != nil {
+			return err
+		}
+
+		version, err := getIfMatch(r, current.Version)
+		if err != nil {
+			return err
+		}
+
+		patched, fields, err := applyPatch(patch, current)
+		if err != nil {
+			return err
+		}
+
+		for _, field := range fields {
+			switch field {
+			case "username":
+				if strings.TrimSpace(patched.Username) == "" {
+					return &types.BadRequest{Message: "username is required"}
+				}
+			case "password":
+				if patched.Password == "" {
+					return &types.BadRequest{Message: "password is required"}
+				}
+
+				patched.Password, err = auth.CreateHash(patched.Password)
+				if err != nil {
+					return err
+				}
+			case "role":
+				err = subject.Can(types.PermissionRoleManage, nil)
+				if err != nil {
+					return err
+				}
+
+				err = checkRole(r.Context(), tx, patched.Role)
+				if err != nil {
+					return err
+				}
+			default:
+				return &types.BadRequest{Message: fmt.Sprintf("%s cannot be patched", field)}
+			}
+		}
+
+		patched.Version = version
+
+		account, err = tx.Account.Update(r.Context(), patched)
+		if err != nil {
+			return err
+		}
+
+		err = revokeSessionsOnChange(r.Context(), tx, current, account)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, types.AccountChanges(subject.ID, current, account))
+	})
+	if err != nil {
+		return err
+	}
+
+	setETag(w, account.Version)
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "account patched", Data: account})
 }
 
 func (s *APIServer) handleDeleteAccount(w http.ResponseWriter, r *http.Request) error {
@@ -114,12 +304,40 @@ func (s *APIServer) handleDeleteAccount(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
-	err = auth.IsAccountID(r, id, types.RoleAdmin)
+	subject, err := s.policy.Subject(r)
 	if err != nil {
 		return err
 	}
 
-	err = s.db.Account.Delete(id)
+	err = subject.Can(types.PermissionAccountManage, &types.Account{ID: id, OrgID: subject.OrgID})
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		// deleting an account that is already gone stays a no-op
+		account, err := tx.Account.GetByID(r.Context(), id)
+		if err != nil {
+			return nil
+		}
+
+		err = subject.Can(types.PermissionAccountManage, account)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Account.Delete(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Session.RevokeByAccountID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, []*types.Change{types.CreateChange(types.EntityAccount, id, subject.ID, "deleted", "false", "true")})
+	})
 	if err != nil {
 		return err
 	}
@@ -127,6 +345,39 @@ func (s *APIServer) handleDeleteAccount(w http.ResponseWriter, r *http.Request)
 	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "account deleted"})
 }
 
+func (s *APIServer) handleRestoreAccount(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	actorID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
+
+	account := &types.Account{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := tx.Account.Restore(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		account, err = tx.Account.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, []*types.Change{types.CreateChange(types.EntityAccount, id, actorID, "deleted", "true", "false")})
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "account restored", Data: account})
+}
+
 func (s *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) error {
 	req := LoginRequest{}
 	err := decodeRequest(r, &req)
@@ -134,7 +385,7 @@ func (s *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	account, err := s.db.Account.GetByUsername(req.Username)
+	account, err := s.db.Account.GetByUsername(r.Context(), req.Username)
 	if err != nil {
 		return err
 	}
@@ -144,12 +395,19 @@ func (s *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	token, err := auth.GenerateJWT(account)
+	if req.OrgID != 0 {
+		account, err = s.joinOrganization(r.Context(), account, req.OrgID)
+		if err != nil {
+			return err
+		}
+	}
+
+	res, err := s.createSession(r.Context(), account)
 	if err != nil {
 		return err
 	}
 
-	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "login successful", Data: &LoginResponse{Token: token}})
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "login successful", Data: res})
 }
 
 type CreateAccountRequest struct {
@@ -164,11 +422,16 @@ type UpdateAccountRequest struct {
 	Role     types.Role `json:"role"`
 }
 
+// LoginRequest logs into the account's home organization unless OrgID names
+// another organization the account is a member of.
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	OrgID    int    `json:"org_id"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
 }
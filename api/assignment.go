@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+func (s *APIServer) handleGetAgents(w http.ResponseWriter, r *http.Request) error {
+	agents, err := s.db.Agent.Get(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "agents found", Data: agents})
+}
+
+func (s *APIServer) handleGetAgent(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.policy.Authorize(r, types.PermissionAccountRead, &types.Account{ID: id})
+	if err != nil {
+		return err
+	}
+
+	agent, err := s.db.Agent.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "agent found", Data: agent})
+}
+
+// handleSetAgent makes an account an agent of the organization, or changes
+// its availability, capacity and skills. Only subjects whose role can assign
+// tickets or manage accounts enroll agents, and only accounts whose role can
+// work tickets are eligible. Agents can change their own availability.
+func (s *APIServer) handleSetAgent(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.db.Agent.GetByID(r.Context(), id)
+	if err != nil && !errors.As(err, new(*types.NotFound)) {
+		return err
+	}
+
+	manager := subject.Can(types.PermissionTicketAssign, nil) == nil || subject.Can(types.PermissionAccountManage, nil) == nil
+	if !manager && (id != subject.ID || existing == nil) {
+		return &types.Forbidden{Message: fmt.Sprintf("missing permission %s", types.PermissionTicketAssign)}
+	}
+
+	req := AgentRequest{}
+
+	err = decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	if req.Availability == "" {
+		req.Availability = types.AvailabilityAvailable
+	}
+
+	if !slices.Contains(types.Availabilities, req.Availability) {
+		return &types.BadRequest{Message: fmt.Sprintf("unknown availability %s", req.Availability)}
+	}
+
+	if req.Capacity < 0 {
+		return &types.BadRequest{Message: "capacity must not be negative"}
+	}
+
+	skills := []string{}
+
+	for _, skill := range req.Skills {
+		skill = strings.ToLower(strings.TrimSpace(skill))
+		if skill != "" && !slices.Contains(skills, skill) {
+			skills = append(skills, skill)
+		}
+	}
+
+	if !manager {
+		req.Capacity = existing.Capacity
+		skills = existing.Skills
+	}
+
+	account, err := s.db.Account.GetByID(r.Context(), id)
+	if err != nil {
+		return &types.BadRequest{Message: fmt.Sprintf("account %d is not a member of this organization", id)}
+	}
+
+	account, err = s.joinOrganization(r.Context(), account, subject.OrgID)
+	if err != nil {
+		return err
+	}
+
+	permissions, err := s.policy.Permissions(r.Context(), account.Role)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(permissions, types.PermissionTicketStatus) {
+		return &types.BadRequest{Message: fmt.Sprintf("role %s cannot work tickets", account.Role)}
+	}
+
+	_, err = s.db.Agent.Set(r.Context(), types.CreateAgent(subject.OrgID, id, req.Availability, req.Capacity, skills))
+	if err != nil {
+		return err
+	}
+
+	agent, err := s.db.Agent.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "agent updated", Data: agent})
+}
+
+func (s *APIServer) handleGetAssignments(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	err = s.policy.Authorize(r, types.PermissionTicketRead, ticket)
+	if err != nil {
+		return err
+	}
+
+	assignments, err := s.db.Assignment.Get(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "assignments found", Data: assignments})
+}
+
+// autoAssign hands a ticket nobody can work on to an agent picked by the
+// assigner, recording the decision even when nobody was picked. Tickets with
+// an assignee who is not away or offline are left alone.
+func (s *APIServer) autoAssign(ctx context.Context, tx *data.DataAdapter, actorID int, ticket *types.Ticket) (*types.Ticket, error) {
+	if s.assigner == nil || !slices.Contains(types.OpenStatuses, ticket.Status) {
+		return ticket, nil
+	}
+
+	agents, err := tx.Agent.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ticket.AssigneeIDs {
+		index := slices.IndexFunc(agents, func(agent *types.Agent) bool { return agent.AccountID == id })
+		if index < 0 || agents[index].Availability == types.AvailabilityAvailable {
+			return ticket, nil
+		}
+	}
+
+	var team *types.Team
+
+	if ticket.TeamID != 0 {
+		team, err = tx.Team.GetByID(ctx, ticket.TeamID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decision := s.assigner.Decide(ticket, team, agents)
+
+	assignment := types.CreateAssignment(ticket.OrgID, ticket.ID, 0, decision.Strategy, decision.Reason)
+	if decision.Agent != nil {
+		assignment.AccountID = decision.Agent.AccountID
+	}
+
+	_, err = tx.Assignment.Create(ctx, assignment)
+	if err != nil {
+		return nil, err
+	}
+
+	if decision.Agent == nil {
+		return ticket, nil
+	}
+
+	before := *ticket
+	ticket.AssigneeIDs = []int{decision.Agent.AccountID}
+
+	ticket, err = tx.Ticket.Update(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticket, recordChanges(ctx, tx, types.TicketChanges(actorID, &before, ticket))
+}
+
+// reopened reports whether a change brought a resolved or closed ticket back
+// into work.
+func reopened(before *types.Ticket, after *types.Ticket) bool {
+	return !slices.Contains(types.OpenStatuses, before.Status) && slices.Contains(types.OpenStatuses, after.Status)
+}
+
+type AgentRequest struct {
+	Availability types.Availability `json:"availability"`
+	Capacity     int                `json:"capacity"`
+	Skills       []string           `json:"skills"`
+}
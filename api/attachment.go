@@ -0,0 +1,410 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"ticketing-api/auth"
+	"ticketing-api/types"
+)
+
+const DefaultAttachmentMaxSize = 10 << 20
+
+// DefaultAttachmentTypes are the content types attachments may have unless
+// configured otherwise.
+var DefaultAttachmentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"application/zip",
+	"application/json",
+	"text/plain",
+	"text/csv",
+}
+
+// multipartOverhead is what an upload may spend on multipart headers and
+// boundaries on top of its files.
+const multipartOverhead = 1 << 20
+
+type AttachmentLimits struct {
+	MaxSize      int64
+	ContentTypes []string
+}
+
+// ParseAttachmentLimits reads the most bytes one upload may carry and a comma
+// separated list of the content types attachments may have, falling back to
+// the defaults for either when empty.
+func ParseAttachmentLimits(maxSize string, contentTypes string) (*AttachmentLimits, error) {
+	limits := &AttachmentLimits{
+		MaxSize:      DefaultAttachmentMaxSize,
+		ContentTypes: DefaultAttachmentTypes,
+	}
+
+	if maxSize != "" {
+		size, err := strconv.ParseInt(maxSize, 10, 64)
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid attachment size limit: %s", maxSize)
+		}
+
+		limits.MaxSize = size
+	}
+
+	if list := splitList(contentTypes, ","); len(list) > 0 {
+		limits.ContentTypes = list
+	}
+
+	return limits, nil
+}
+
+// handleUploadAttachments stores the files of a multipart/form-data body sent
+// in "file" fields. Every file is checked before any is stored, so an upload
+// is rejected as a whole.
+func (s *APIServer) handleUploadAttachments(w http.ResponseWriter, r *http.Request) error {
+	if s.blobs == nil {
+		return &types.NotFound{Message: "attachments are not enabled"}
+	}
+
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	err = s.policy.Authorize(r, types.PermissionTicketRead, ticket)
+	if err != nil {
+		return err
+	}
+
+	actorID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.attachmentLimits.MaxSize+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return &types.BadRequest{Message: "expected a multipart/form-data body"}
+	}
+
+	uploads := []*upload{}
+
+	defer func() {
+		for _, upload := range uploads {
+			upload.file.Close()
+			os.Remove(upload.file.Name())
+		}
+	}()
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return s.uploadError(err)
+		}
+
+		if part.FormName() != "file" {
+			continue
+		}
+
+		upload, err := s.spool(part)
+		if upload != nil {
+			uploads = append(uploads, upload)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(uploads) == 0 {
+		return &types.BadRequest{Message: "no file was uploaded"}
+	}
+
+	total := int64(0)
+	for _, upload := range uploads {
+		total += upload.size
+	}
+
+	if total > s.attachmentLimits.MaxSize {
+		return &types.TooLarge{Message: fmt.Sprintf("upload is larger than %d bytes", s.attachmentLimits.MaxSize)}
+	}
+
+	attachments := []*types.Attachment{}
+
+	for _, upload := range uploads {
+		attachment, err := s.storeAttachment(r.Context(), ticket, actorID, upload)
+		if err != nil {
+			return err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "attachments uploaded", Data: attachments})
+}
+
+func (s *APIServer) handleGetAttachments(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	err = s.policy.Authorize(r, types.PermissionTicketRead, ticket)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := s.db.Attachment.Get(r.Context(), ticket.ID)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "attachments found", Data: attachments})
+}
+
+// handleDownloadAttachment streams an attachment as a download. Browsers are
+// told not to sniff it, so that an upload can never be run as a page.
+func (s *APIServer) handleDownloadAttachment(w http.ResponseWriter, r *http.Request) error {
+	attachment, err := s.getAttachment(r)
+	if err != nil {
+		return err
+	}
+
+	body, err := s.blobs.Get(r.Context(), attachment.Key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	// the status is sent, so a failed copy can only show as a short body
+	io.Copy(w, body)
+
+	return nil
+}
+
+// handleDeleteAttachment removes an attachment, which its uploader may do
+// like with their own chat messages. Messages referencing it keep the id.
+func (s *APIServer) handleDeleteAttachment(w http.ResponseWriter, r *http.Request) error {
+	attachment, err := s.getAttachment(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.policy.Authorize(r, types.PermissionMessageModerate, attachment)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Attachment.Delete(r.Context(), attachment.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.blobs.Delete(r.Context(), attachment.Key)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "attachment deleted"})
+}
+
+// getAttachment reads the attachment of the path, which has to belong to the
+// ticket of the path and the ticket be readable.
+func (s *APIServer) getAttachment(r *http.Request) (*types.Attachment, error) {
+	if s.blobs == nil {
+		return nil, &types.NotFound{Message: "attachments are not enabled"}
+	}
+
+	id, err := getID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	attachmentID, err := strconv.Atoi(r.PathValue("attachment_id"))
+	if err != nil {
+		return nil, &types.BadRequest{}
+	}
+
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.policy.Authorize(r, types.PermissionTicketRead, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := s.db.Attachment.GetByID(r.Context(), attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attachment.TicketID != ticket.ID {
+		return nil, &types.NotFound{Message: fmt.Sprintf("attachment %d not found", attachmentID)}
+	}
+
+	return attachment, nil
+}
+
+// deleteAttachments removes the attachments of a ticket along with their
+// blobs.
+func (s *APIServer) deleteAttachments(ctx context.Context, ticketID int) error {
+	attachments, err := s.db.Attachment.Get(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		if s.blobs != nil {
+			err = s.blobs.Delete(ctx, attachment.Key)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.db.Attachment.Delete(ctx, attachment.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// upload is a file of an upload, spooled to disk until every file is checked.
+type upload struct {
+	name        string
+	contentType string
+	size        int64
+	file        *os.File
+}
+
+func (s *APIServer) spool(part *multipart.Part) (*upload, error) {
+	name := path.Base(strings.ReplaceAll(part.FileName(), `\`, "/"))
+	if name == "." || name == "/" {
+		return nil, &types.BadRequest{Message: "every file needs a name"}
+	}
+
+	file, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, fmt.Errorf("error uploading attachment")
+	}
+
+	upload := &upload{name: name, file: file}
+
+	size, err := io.Copy(file, io.LimitReader(part, s.attachmentLimits.MaxSize+1))
+	if err != nil {
+		return upload, s.uploadError(err)
+	}
+
+	if size > s.attachmentLimits.MaxSize {
+		return upload, &types.TooLarge{Message: fmt.Sprintf("%s is larger than %d bytes", name, s.attachmentLimits.MaxSize)}
+	}
+
+	head := make([]byte, 512)
+	n, _ := file.ReadAt(head, 0)
+
+	upload.size = size
+	upload.contentType = attachmentType(part.Header.Get("Content-Type"), head[:n])
+
+	if !slices.Contains(s.attachmentLimits.ContentTypes, upload.contentType) {
+		return upload, &types.UnsupportedMediaType{Message: fmt.Sprintf("%s files are not allowed", upload.contentType)}
+	}
+
+	return upload, nil
+}
+
+func (s *APIServer) storeAttachment(ctx context.Context, ticket *types.Ticket, authorID int, upload *upload) (*types.Attachment, error) {
+	key, err := blobKey(ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = upload.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, fmt.Errorf("error uploading attachment")
+	}
+
+	err = s.blobs.Put(ctx, key, upload.file, upload.size, upload.contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := s.db.Attachment.Create(ctx, types.CreateAttachment(ticket.OrgID, ticket.ID, authorID, upload.name, upload.contentType, upload.size, key))
+	if err != nil {
+		s.blobs.Delete(ctx, key)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (s *APIServer) uploadError(err error) error {
+	maxBytesErr := &http.MaxBytesError{}
+	if errors.As(err, &maxBytesErr) {
+		return &types.TooLarge{Message: fmt.Sprintf("upload is larger than %d bytes", s.attachmentLimits.MaxSize)}
+	}
+
+	return &types.BadRequest{Message: "error reading upload"}
+}
+
+// attachmentType goes by the content of a file rather than what the client
+// declared. Sniffing cannot tell text and binary formats such as JSON or CSV
+// apart from plain text and unknown data though, and for those the declared
+// type is taken.
+func attachmentType(declared string, head []byte) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if sniffed != "text/plain" && sniffed != "application/octet-stream" {
+		return sniffed
+	}
+
+	declaredType, _, err := mime.ParseMediaType(declared)
+	if err != nil || declaredType == "application/octet-stream" {
+		return sniffed
+	}
+
+	return declaredType
+}
+
+// blobKey names the blob of a new attachment of ticket.
+func blobKey(ticket *types.Ticket) (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error uploading attachment")
+	}
+
+	return fmt.Sprintf("%d/%d/%s", ticket.OrgID, ticket.ID, hex.EncodeToString(b)), nil
+}
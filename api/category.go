@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+func (s *APIServer) handleGetCategories(w http.ResponseWriter, r *http.Request) error {
+	categories, err := s.db.Category.Get(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "categories found", Data: categories})
+}
+
+func (s *APIServer) handleGetCategory(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	category, err := s.db.Category.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "category found", Data: category})
+}
+
+func (s *APIServer) handleCreateCategory(w http.ResponseWriter, r *http.Request) error {
+	req := CategoryRequest{}
+
+	err := decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	category := types.CreateCategory(subject.OrgID, req.ParentID, strings.TrimSpace(req.Name))
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := checkCategory(r.Context(), tx, category)
+		if err != nil {
+			return err
+		}
+
+		category, err = tx.Category.Create(r.Context(), category)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "category created", Data: category})
+}
+
+// handleUpdateCategory renames a category or moves it, along with its
+// subcategories, under another parent.
+func (s *APIServer) handleUpdateCategory(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	req := CategoryRequest{}
+
+	err = decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	category := &types.Category{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		category, err = tx.Category.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		category.Name = strings.TrimSpace(req.Name)
+		category.ParentID = req.ParentID
+		category.UpdatedAt = time.Now()
+
+		err = checkCategory(r.Context(), tx, category)
+		if err != nil {
+			return err
+		}
+
+		category, err = tx.Category.Update(r.Context(), category)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "category updated", Data: category})
+}
+
+// handleDeleteCategory removes a category without subcategories. Its tickets
+// become uncategorized.
+func (s *APIServer) handleDeleteCategory(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		categories, err := tx.Category.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		for _, category := range categories {
+			if category.ParentID == id {
+				return &types.BadRequest{Message: fmt.Sprintf("category %d has subcategories", id)}
+			}
+		}
+
+		return tx.Category.Delete(r.Context(), id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "category deleted"})
+}
+
+// checkCategory makes sure a category has a name no sibling has, and that its
+// parent is a category of the organization that is not the category itself
+// or one of its subcategories.
+func checkCategory(ctx context.Context, db *data.DataAdapter, category *types.Category) error {
+	if category.Name == "" {
+		return &types.BadRequest{Message: "name is required"}
+	}
+
+	categories, err := db.Category.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	parents := map[int]int{}
+	for _, existing := range categories {
+		parents[existing.ID] = existing.ParentID
+	}
+
+	if category.ParentID != 0 {
+		if _, ok := parents[category.ParentID]; !ok {
+			return &types.BadRequest{Message: fmt.Sprintf("category %d is not a category of this organization", category.ParentID)}
+		}
+
+		for id := category.ParentID; id != 0; id = parents[id] {
+			if id == category.ID {
+				return &types.BadRequest{Message: "category cannot be moved under itself"}
+			}
+		}
+	}
+
+	for _, existing := range categories {
+		if existing.ID != category.ID && existing.ParentID == category.ParentID && strings.EqualFold(existing.Name, category.Name) {
+			return &types.BadRequest{Message: fmt.Sprintf("category %s already exists", category.Name)}
+		}
+	}
+
+	return nil
+}
+
+// categorySubtree returns the ids of the categories ids name along with all
+// of their subcategories.
+func categorySubtree(categories []*types.Category, ids []int) []int {
+	subtree := append([]int{}, ids...)
+
+	for i := 0; i < len(subtree); i++ {
+		for _, category := range categories {
+			if category.ParentID == subtree[i] {
+				subtree = append(subtree, category.ID)
+			}
+		}
+	}
+
+	return subtree
+}
+
+type CategoryRequest struct {
+	Name     string `json:"name"`
+	ParentID int    `json:"parent_id"`
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"ticketing-api/types"
+)
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// getIfMatch returns the version an update must be applied to. Without an
+// If-Match header, or with *, that is the version just read. A single ETag is
+// passed through as is so the adapter's version check decides the outcome.
+func getIfMatch(r *http.Request, current int) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return current, nil
+	}
+
+	versions := []int{}
+
+	for _, tag := range splitList(header, ",") {
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err == nil {
+			versions = append(versions, version)
+		}
+	}
+
+	if len(versions) == 1 {
+		return versions[0], nil
+	}
+
+	for _, version := range versions {
+		if version == current {
+			return current, nil
+		}
+	}
+
+	return 0, &types.PreconditionFailed{Message: fmt.Sprintf("If-Match does not match the current version %d", current)}
+}
@@ -0,0 +1,405 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+func (s *APIServer) handleGetCustomFields(w http.ResponseWriter, r *http.Request) error {
+	fields, err := s.db.CustomField.Get(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "custom fields found", Data: fields})
+}
+
+func (s *APIServer) handleGetCustomField(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	field, err := s.db.CustomField.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "custom field found", Data: field})
+}
+
+func (s *APIServer) handleCreateCustomField(w http.ResponseWriter, r *http.Request) error {
+	req := CustomFieldRequest{}
+
+	err := decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	field := types.CreateCustomField(subject.OrgID, req.Key, strings.TrimSpace(req.Name), req.Type, getOptions(req.Options), req.Required)
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := checkCustomField(r.Context(), tx, field)
+		if err != nil {
+			return err
+		}
+
+		field, err = tx.CustomField.Create(r.Context(), field)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "custom field created", Data: field})
+}
+
+// handleUpdateCustomField changes a field's name, options and whether it is
+// required. Tickets keep values whose option was removed until they change
+// them.
+func (s *APIServer) handleUpdateCustomField(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	req := CustomFieldRequest{}
+
+	err = decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	field := &types.CustomField{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		field, err = tx.CustomField.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		if req.Key != "" && req.Key != field.Key {
+			return &types.BadRequest{Message: "key cannot be changed"}
+		}
+
+		if req.Type != "" && req.Type != field.Type {
+			return &types.BadRequest{Message: "type cannot be changed"}
+		}
+
+		field.Name = strings.TrimSpace(req.Name)
+		field.Options = getOptions(req.Options)
+		field.Required = req.Required
+		field.UpdatedAt = time.Now()
+
+		err = checkCustomField(r.Context(), tx, field)
+		if err != nil {
+			return err
+		}
+
+		field, err = tx.CustomField.Update(r.Context(), field)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "custom field updated", Data: field})
+}
+
+// handleDeleteCustomField removes a field. Tickets keep their values for it,
+// but can no longer be filtered on it.
+func (s *APIServer) handleDeleteCustomField(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.CustomField.Delete(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "custom field deleted"})
+}
+
+// checkCustomField validates a field's definition and makes sure no other
+// field of the organization has its key.
+func checkCustomField(ctx context.Context, db *data.DataAdapter, field *types.CustomField) error {
+	if !fieldKeyPattern.MatchString(field.Key) {
+		return &types.BadRequest{Message: "key must start with a lowercase letter followed by up to 63 lowercase letters, digits or underscores"}
+	}
+
+	if field.Name == "" {
+		return &types.BadRequest{Message: "name is required"}
+	}
+
+	if !slices.Contains(types.FieldTypes, field.Type) {
+		return &types.BadRequest{Message: fmt.Sprintf("unknown field type %s", field.Type)}
+	}
+
+	selects := field.Type == types.FieldSelect || field.Type == types.FieldMultiSelect
+
+	if selects && len(field.Options) == 0 {
+		return &types.BadRequest{Message: fmt.Sprintf("%s fields need options", field.Type)}
+	}
+
+	if !selects && len(field.Options) > 0 {
+		return &types.BadRequest{Message: fmt.Sprintf("%s fields have no options", field.Type)}
+	}
+
+	fields, err := db.CustomField.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range fields {
+		if existing.ID != field.ID && existing.Key == field.Key {
+			return &types.BadRequest{Message: fmt.Sprintf("custom field %s already exists", field.Key)}
+		}
+	}
+
+	return nil
+}
+
+// checkTicketFields makes sure the category a ticket is filed under is one of
+// the organization's and that every custom field value changed since before
+// fits its field, storing values in their canonical form and dropping empty
+// ones. A nil before checks a new ticket, which must have every required
+// field.
+func checkTicketFields(ctx context.Context, db *data.DataAdapter, before *types.Ticket, after *types.Ticket) error {
+	created := before == nil
+	if created {
+		before = &types.Ticket{}
+	}
+
+	if after.CategoryID != 0 && after.CategoryID != before.CategoryID {
+		_, err := db.Category.GetByID(ctx, after.CategoryID)
+		if err != nil {
+			return &types.BadRequest{Message: fmt.Sprintf("category %d is not a category of this organization", after.CategoryID)}
+		}
+	}
+
+	if after.Fields == nil {
+		after.Fields = types.Fields{}
+	}
+
+	fields, err := getCustomFields(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range after.Fields {
+		if value == nil {
+			delete(after.Fields, key)
+			continue
+		}
+
+		if reflect.DeepEqual(value, before.Fields[key]) {
+			continue
+		}
+
+		field, ok := fields[key]
+		if !ok {
+			return &types.BadRequest{Message: fmt.Sprintf("unknown field %s", key)}
+		}
+
+		value, err := fieldValue(field, value)
+		if err != nil {
+			return err
+		}
+
+		if value == nil {
+			delete(after.Fields, key)
+		} else {
+			after.Fields[key] = value
+		}
+	}
+
+	if !created {
+		return nil
+	}
+
+	for _, field := range fields {
+		if _, ok := after.Fields[field.Key]; field.Required && !ok {
+			return &types.BadRequest{Message: fmt.Sprintf("field %s is required", field.Key)}
+		}
+	}
+
+	return nil
+}
+
+// fieldValue checks value against field and returns it in the form tickets
+// store it in, or nil when it is empty.
+func fieldValue(field *types.CustomField, value any) (any, error) {
+	switch field.Type {
+	case types.FieldNumber:
+		if _, ok := value.(float64); !ok {
+			return nil, &types.BadRequest{Message: fmt.Sprintf("field %s must be a number", field.Key)}
+		}
+
+		return value, nil
+	case types.FieldMultiSelect:
+		list, ok := value.([]any)
+		if !ok {
+			return nil, &types.BadRequest{Message: fmt.Sprintf("field %s must be a list of options", field.Key)}
+		}
+
+		for _, item := range list {
+			if option, ok := item.(string); !ok || !slices.Contains(field.Options, option) {
+				return nil, &types.BadRequest{Message: fmt.Sprintf("field %s must only hold %s", field.Key, strings.Join(field.Options, ", "))}
+			}
+		}
+
+		selected := []any{}
+		for _, option := range field.Options {
+			if slices.Contains(list, any(option)) {
+				selected = append(selected, option)
+			}
+		}
+
+		if len(selected) == 0 {
+			return nil, nil
+		}
+
+		return selected, nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, &types.BadRequest{Message: fmt.Sprintf("field %s must be a string", field.Key)}
+	}
+
+	if text == "" {
+		return nil, nil
+	}
+
+	return fieldText(field, text)
+}
+
+// fieldText checks the text given for a text, date or select field, as in a
+// ticket or a filter.
+func fieldText(field *types.CustomField, text string) (any, error) {
+	switch field.Type {
+	case types.FieldDate:
+		_, err := time.Parse(time.DateOnly, text)
+		if err != nil {
+			return nil, &types.BadRequest{Message: fmt.Sprintf("field %s must be a date like 2006-01-02", field.Key)}
+		}
+	case types.FieldSelect:
+		if !slices.Contains(field.Options, text) {
+			return nil, &types.BadRequest{Message: fmt.Sprintf("field %s must be one of %s", field.Key, strings.Join(field.Options, ", "))}
+		}
+	}
+
+	return text, nil
+}
+
+// getFieldFilters reads the field.<key>=value filters from the query string.
+// A multi-select matches tickets holding the option.
+func getFieldFilters(ctx context.Context, db *data.DataAdapter, r *http.Request) ([]types.FieldFilter, error) {
+	keys := []string{}
+
+	for param := range r.URL.Query() {
+		if key, ok := strings.CutPrefix(param, "field."); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	slices.Sort(keys)
+
+	fields, err := getCustomFields(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []types.FieldFilter{}
+
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			return nil, &types.BadRequest{Message: fmt.Sprintf("unknown field %s", key)}
+		}
+
+		text := r.URL.Query().Get("field." + key)
+
+		var value any = text
+
+		switch field.Type {
+		case types.FieldNumber:
+			value, err = strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, &types.BadRequest{Message: fmt.Sprintf("field %s must be a number", key)}
+			}
+		case types.FieldMultiSelect:
+			if !slices.Contains(field.Options, text) {
+				return nil, &types.BadRequest{Message: fmt.Sprintf("field %s must be one of %s", key, strings.Join(field.Options, ", "))}
+			}
+		default:
+			value, err = fieldText(field, text)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		filters = append(filters, types.FieldFilter{Key: key, Value: value})
+	}
+
+	return filters, nil
+}
+
+func getCustomFields(ctx context.Context, db *data.DataAdapter) (map[string]*types.CustomField, error) {
+	fields, err := db.CustomField.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]*types.CustomField{}
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	return byKey, nil
+}
+
+// getOptions trims the options of a select field, dropping empty and repeated
+// ones.
+func getOptions(options []string) []string {
+	trimmed := []string{}
+
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option != "" && !slices.Contains(trimmed, option) {
+			trimmed = append(trimmed, option)
+		}
+	}
+
+	return trimmed
+}
+
+type CustomFieldRequest struct {
+	Key      string          `json:"key"`
+	Name     string          `json:"name"`
+	Type     types.FieldType `json:"type"`
+	Options  []string        `json:"options"`
+	Required bool            `json:"required"`
+}
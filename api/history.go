@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+func (s *APIServer) handleGetTicketHistory(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	// accounts that restore tickets can still read the history of deleted and
+	// purged ones
+	if s.policy.Authorize(r, types.PermissionTicketRestore, nil) != nil {
+		ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		err = s.policy.Authorize(r, types.PermissionTicketRead, ticket)
+		if err != nil {
+			return err
+		}
+	}
+
+	changes, err := s.db.History.Get(r.Context(), types.EntityTicket, id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "history found", Data: changes})
+}
+
+func (s *APIServer) handleGetAccountHistory(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	changes, err := s.db.History.Get(r.Context(), types.EntityAccount, id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "history found", Data: changes})
+}
+
+// recordChanges logs changes in the organization ctx is scoped to, which is
+// the organization their history is later read from.
+func recordChanges(ctx context.Context, tx *data.DataAdapter, changes []*types.Change) error {
+	for _, change := range changes {
+		change.OrgID, _ = data.Organization(ctx)
+
+		_, err := tx.History.Create(ctx, change)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
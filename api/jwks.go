@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"ticketing-api/auth"
+)
+
+// handleJWKS publishes the public keys tokens are verified with, so other
+// services can check them without sharing a secret.
+func (s *APIServer) handleJWKS(w http.ResponseWriter, r *http.Request) error {
+	jwks, err := auth.GetJWKS()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	encodeResponse(w, http.StatusOK, jwks)
+
+	return nil
+}
@@ -0,0 +1,556 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/policy"
+	"ticketing-api/types"
+)
+
+func (s *APIServer) handleGetLinks(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	err = subject.Can(types.PermissionTicketRead, ticket)
+	if err != nil {
+		return err
+	}
+
+	links, err := s.getLinks(r.Context(), subject, ticket.ID)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "links found", Data: links})
+}
+
+// handleCreateLink links the ticket of the path to another ticket. The type is
+// read from the ticket of the path, so {"type": "blocked_by", "ticket_id": 3}
+// stores that ticket 3 blocks it.
+func (s *APIServer) handleCreateLink(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	req := &LinkRequest{}
+
+	err = decodeRequest(r, req)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	linkType, sourceID, targetID, err := parseLink(req.Type, id, req.TicketID)
+	if err != nil {
+		return err
+	}
+
+	link := &types.TicketLink{}
+	other := &types.Ticket{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		ticket, err := tx.Ticket.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		err = subject.Can(types.PermissionTicketUpdate, ticket)
+		if err != nil {
+			return err
+		}
+
+		other, err = getLinkedTicket(r.Context(), tx, subject, req.TicketID)
+		if err != nil {
+			return err
+		}
+
+		link = types.CreateTicketLink(ticket.OrgID, sourceID, targetID, linkType, subject.ID)
+
+		err = checkLink(r.Context(), tx, link)
+		if err != nil {
+			return err
+		}
+
+		link, err = tx.Link.Create(r.Context(), link)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, linkChanges(link, subject.ID, false))
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "link created", Data: createLinkResponse(link, id, other)})
+}
+
+func (s *APIServer) handleDeleteLink(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	linkID, err := strconv.Atoi(r.PathValue("link_id"))
+	if err != nil {
+		return &types.BadRequest{}
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		ticket, err := tx.Ticket.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		err = subject.Can(types.PermissionTicketUpdate, ticket)
+		if err != nil {
+			return err
+		}
+
+		link, err := tx.Link.GetByID(r.Context(), linkID)
+		if err != nil {
+			return err
+		}
+
+		if link.SourceID != ticket.ID && link.TargetID != ticket.ID {
+			return &types.NotFound{Message: fmt.Sprintf("link %d not found", linkID)}
+		}
+
+		err = tx.Link.Delete(r.Context(), link.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, linkChanges(link, subject.ID, true))
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "link deleted"})
+}
+
+// handleMergeTicket folds the ticket of the path, a duplicate, into the
+// ticket of the request. The primary ticket takes over the duplicate's
+// assignees, chat messages and attachments, while the duplicate is closed,
+// whatever the workflow says, with a duplicate_of link and a resolution
+// pointing at the primary.
+func (s *APIServer) handleMergeTicket(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	req := &MergeRequest{}
+
+	err = decodeRequest(r, req)
+	if err != nil {
+		return err
+	}
+
+	if req.TicketID == id {
+		return &types.BadRequest{Message: "a ticket cannot be merged into itself"}
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	primary := &types.Ticket{}
+	before := types.Ticket{}
+	duplicate := &types.Ticket{}
+	closed := &types.Ticket{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		var link *types.TicketLink
+
+		duplicate, link, err = s.checkMerge(r.Context(), tx, subject, id, req.TicketID)
+		if err != nil {
+			return err
+		}
+
+		primary, err = tx.Ticket.GetByID(r.Context(), req.TicketID)
+		if err != nil {
+			return err
+		}
+
+		before = *primary
+
+		err = tx.Attachment.Move(r.Context(), duplicate.ID, primary.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, assigneeID := range duplicate.AssigneeIDs {
+			if !slices.Contains(primary.AssigneeIDs, assigneeID) {
+				primary.AssigneeIDs = append(primary.AssigneeIDs, assigneeID)
+			}
+		}
+
+		primary, err = tx.Ticket.Update(r.Context(), primary)
+		if err != nil {
+			return err
+		}
+
+		err = recordChanges(r.Context(), tx, types.TicketChanges(subject.ID, &before, primary))
+		if err != nil {
+			return err
+		}
+
+		merged := *duplicate
+		merged.AssigneeIDs = []int{}
+		merged.Status = types.StatusClosed
+		merged.Resolution = fmt.Sprintf("duplicate of #%d", primary.ID)
+
+		err = applySLA(r.Context(), tx, duplicate, &merged)
+		if err != nil {
+			return err
+		}
+
+		closed, err = tx.Ticket.Update(r.Context(), &merged)
+		if err != nil {
+			return err
+		}
+
+		changes := types.TicketChanges(subject.ID, duplicate, closed)
+		changes = append(changes, types.CreateChange(types.EntityTicket, duplicate.ID, subject.ID, "merged_into", "", strconv.Itoa(primary.ID)))
+
+		if link == nil {
+			link, err = tx.Link.Create(r.Context(), types.CreateTicketLink(duplicate.OrgID, duplicate.ID, primary.ID, types.LinkDuplicateOf, subject.ID))
+			if err != nil {
+				return err
+			}
+
+			changes = append(changes, linkChanges(link, subject.ID, false)...)
+		}
+
+		return recordChanges(r.Context(), tx, changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	// messages may be kept outside the database, so they follow only once the
+	// merge has committed, and merging again finishes a move cut short
+	err = moveMessages(r.Context(), s.db, duplicate.ID, primary.ID)
+	if err != nil {
+		return err
+	}
+
+	s.notifyTicket(r.Context(), subject.ID, duplicate, closed)
+	s.notifyTicket(r.Context(), subject.ID, &before, primary)
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket merged", Data: primary})
+}
+
+// checkMerge makes sure subject may close the duplicate and update the
+// primary ticket, assigning it when the duplicate brings new assignees. It
+// returns the duplicate and, when it is linked as a duplicate of the primary
+// already, that link.
+func (s *APIServer) checkMerge(ctx context.Context, db *data.DataAdapter, subject *policy.Subject, id int, primaryID int) (*types.Ticket, *types.TicketLink, error) {
+	duplicate, err := db.Ticket.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = subject.Can(types.PermissionTicketStatus, duplicate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	primary, err := getLinkedTicket(ctx, db, subject, primaryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = subject.Can(types.PermissionTicketUpdate, primary)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, assigneeID := range duplicate.AssigneeIDs {
+		if !slices.Contains(primary.AssigneeIDs, assigneeID) {
+			err = subject.Can(types.PermissionTicketAssign, primary)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			break
+		}
+	}
+
+	links, err := db.Link.Get(ctx, duplicate.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, link := range links {
+		if link.Type == types.LinkDuplicateOf && link.SourceID == duplicate.ID && link.TargetID == primary.ID {
+			return duplicate, link, nil
+		}
+	}
+
+	err = checkLink(ctx, db, types.CreateTicketLink(duplicate.OrgID, duplicate.ID, primary.ID, types.LinkDuplicateOf, subject.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return duplicate, nil, nil
+}
+
+// moveMessages copies each chat message of a ticket over to another before
+// deleting it, so that a move cut short can be run again without losing or
+// doubling any.
+func moveMessages(ctx context.Context, db *data.DataAdapter, fromID int, toID int) error {
+	messages, err := db.Message.Get(ctx, fromID)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		moved := *message
+		moved.TicketID = toID
+
+		_, err = db.Message.Create(ctx, &moved)
+		if err != nil {
+			return err
+		}
+
+		err = db.Message.Delete(ctx, message.ID, message.CreatedAt, fromID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getLinks returns the links of a ticket as seen from it, each with the other
+// ticket when subject may read it.
+func (s *APIServer) getLinks(ctx context.Context, subject *policy.Subject, id int) ([]*LinkResponse, error) {
+	links, err := s.db.Link.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []*LinkResponse{}
+
+	for _, link := range links {
+		_, otherID := link.LinkAs(id)
+
+		other, err := s.db.Ticket.GetByID(ctx, otherID)
+		if err != nil || subject.Can(types.PermissionTicketRead, other) != nil {
+			other = nil
+		}
+
+		responses = append(responses, createLinkResponse(link, id, other))
+	}
+
+	return responses, nil
+}
+
+// checkLink rejects links of a ticket to itself, links that exist already, a
+// second duplicate_of or parent_of for the same ticket, and any link but a
+// related one that would close a cycle of its type.
+func checkLink(ctx context.Context, db *data.DataAdapter, link *types.TicketLink) error {
+	if link.SourceID == link.TargetID {
+		return &types.BadRequest{Message: "a ticket cannot be linked to itself"}
+	}
+
+	for _, id := range []int{link.SourceID, link.TargetID} {
+		links, err := db.Link.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, existing := range links {
+			if existing.Type != link.Type {
+				continue
+			}
+
+			same := existing.SourceID == link.SourceID && existing.TargetID == link.TargetID
+			reversed := existing.SourceID == link.TargetID && existing.TargetID == link.SourceID
+
+			if same || (reversed && link.Type == types.LinkRelated) {
+				return &types.BadRequest{Message: fmt.Sprintf("tickets %d and %d are already linked as %s", link.SourceID, link.TargetID, link.Type)}
+			}
+
+			if link.Type == types.LinkDuplicateOf && existing.SourceID == link.SourceID {
+				return &types.BadRequest{Message: fmt.Sprintf("ticket %d is already a duplicate of %d", link.SourceID, existing.TargetID)}
+			}
+
+			if link.Type == types.LinkParentOf && existing.TargetID == link.TargetID {
+				return &types.BadRequest{Message: fmt.Sprintf("ticket %d already has parent %d", link.TargetID, existing.SourceID)}
+			}
+		}
+	}
+
+	if link.Type == types.LinkRelated {
+		return nil
+	}
+
+	cycle, err := reaches(ctx, db, link.TargetID, link.SourceID, link.Type)
+	if err != nil {
+		return err
+	}
+
+	if cycle {
+		return &types.BadRequest{Message: fmt.Sprintf("linking would create a cycle of %s links", link.Type)}
+	}
+
+	return nil
+}
+
+// reaches reports whether following links of a type from ticket from leads to
+// ticket to.
+func reaches(ctx context.Context, db *data.DataAdapter, from int, to int, linkType types.LinkType) (bool, error) {
+	seen := map[int]bool{from: true}
+	queue := []int{from}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		links, err := db.Link.Get(ctx, id)
+		if err != nil {
+			return false, err
+		}
+
+		for _, link := range links {
+			if link.Type != linkType || link.SourceID != id || seen[link.TargetID] {
+				continue
+			}
+
+			if link.TargetID == to {
+				return true, nil
+			}
+
+			seen[link.TargetID] = true
+			queue = append(queue, link.TargetID)
+		}
+	}
+
+	return false, nil
+}
+
+// getLinkedTicket reads the ticket a request refers to, which subject has to
+// be able to read.
+func getLinkedTicket(ctx context.Context, db *data.DataAdapter, subject *policy.Subject, id int) (*types.Ticket, error) {
+	ticket, err := db.Ticket.GetByID(ctx, id)
+	if err != nil {
+		return nil, &types.BadRequest{Message: fmt.Sprintf("ticket %d not found", id)}
+	}
+
+	err = subject.Can(types.PermissionTicketRead, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticket, nil
+}
+
+// parseLink reads a link type as seen from ticket id, an inverse such as
+// blocked_by included, into the type and the source and target it is stored
+// with.
+func parseLink(name types.LinkType, id int, otherID int) (types.LinkType, int, int, error) {
+	names := []string{}
+
+	for _, linkType := range types.LinkTypes {
+		if name == linkType {
+			return linkType, id, otherID, nil
+		}
+
+		if name == types.LinkInverses[linkType] {
+			return linkType, otherID, id, nil
+		}
+
+		names = append(names, string(linkType))
+		if types.LinkInverses[linkType] != linkType {
+			names = append(names, string(types.LinkInverses[linkType]))
+		}
+	}
+
+	return "", 0, 0, &types.BadRequest{Message: fmt.Sprintf("link type must be one of: %s", strings.Join(names, ", "))}
+}
+
+// linkChanges records a link being added or removed in the history of both
+// its tickets, each as seen from it, e.g. "blocks #3" and "blocked_by #2".
+func linkChanges(link *types.TicketLink, actorID int, removed bool) []*types.Change {
+	changes := []*types.Change{}
+
+	for _, id := range []int{link.SourceID, link.TargetID} {
+		linkType, otherID := link.LinkAs(id)
+		description := fmt.Sprintf("%s #%d", linkType, otherID)
+
+		if removed {
+			changes = append(changes, types.CreateChange(types.EntityTicket, id, actorID, "links", description, ""))
+		} else {
+			changes = append(changes, types.CreateChange(types.EntityTicket, id, actorID, "links", "", description))
+		}
+	}
+
+	return changes
+}
+
+func createLinkResponse(link *types.TicketLink, id int, other *types.Ticket) *LinkResponse {
+	linkType, otherID := link.LinkAs(id)
+
+	return &LinkResponse{
+		ID:       link.ID,
+		Type:     linkType,
+		TicketID: otherID,
+		Ticket:   other,
+	}
+}
+
+type LinkRequest struct {
+	Type     types.LinkType `json:"type"`
+	TicketID int            `json:"ticket_id"`
+}
+
+type MergeRequest struct {
+	TicketID int `json:"ticket_id"`
+}
+
+// LinkResponse is a link as seen from one of its tickets. Ticket is left out
+// when the caller cannot read it.
+type LinkResponse struct {
+	ID       int            `json:"id"`
+	Type     types.LinkType `json:"type"`
+	TicketID int            `json:"ticket_id"`
+	Ticket   *types.Ticket  `json:"ticket,omitempty"`
+}
+
+// TicketWithLinks is a ticket with its links embedded, for ?embed=links.
+type TicketWithLinks struct {
+	*types.Ticket
+	Links []*LinkResponse `json:"links"`
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"ticketing-api/auth"
 	"ticketing-api/chat"
+	"ticketing-api/data"
 	"ticketing-api/types"
 
 	"golang.org/x/net/websocket"
@@ -15,22 +16,39 @@ func (s *APIServer) handleChatGroup(w http.ResponseWriter, r *http.Request) erro
 		return err
 	}
 
-	ticket, err := s.db.Ticket.GetByID(id)
+	if r.Header.Get("Authorization") == "" {
+		r.Header.Set("Authorization", r.Header.Get("Sec-WebSocket-Protocol"))
+		r = auth.Authenticate(r)
+	}
+
+	// browsers cannot set headers on websockets, so the request is scoped here
+	// rather than by ScopeOrganization
+	orgID, err := auth.GetOrganizationID(r)
 	if err != nil {
 		return err
 	}
 
-	if r.Header.Get("Authorization") == "" {
-		r.Header.Set("Authorization", r.Header.Get("Sec-WebSocket-Protocol"))
+	r = r.WithContext(data.WithOrganization(r.Context(), orgID))
+
+	accountID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+	if err != nil {
+		return err
 	}
 
-	err = auth.IsAccountID(r, ticket.AuthorID, types.RoleAdmin, types.RoleEditor)
+	err = s.policy.Authorize(r, types.PermissionTicketRead, ticket)
 	if err != nil {
 		return err
 	}
 
-	group, ok := s.chatGroups.LoadOrStore(ticket.ID, chat.CreateGroup(ticket.ID, func() {
-		s.chatGroups.Delete(ticket.ID)
+	key := chatGroupKey{orgID: ticket.OrgID, ticketID: ticket.ID}
+
+	group, ok := s.chatGroups.LoadOrStore(key, chat.CreateGroup(ticket.OrgID, ticket.ID, func() {
+		s.chatGroups.Delete(key)
 	}))
 	if !ok {
 		go group.(*chat.Group).Start()
@@ -38,7 +56,9 @@ func (s *APIServer) handleChatGroup(w http.ResponseWriter, r *http.Request) erro
 
 	websocket.Server{
 		Handler: websocket.Handler(func(conn *websocket.Conn) {
-			client := chat.CreateClient(conn, group.(*chat.Group), s.db)
+			client := chat.CreateClient(conn, group.(*chat.Group), accountID, s.db, s.policy, func(message *types.Message) {
+				s.notifyMessage(r.Context(), message)
+			})
 			client.Connect()
 		}),
 	}.ServeHTTP(w, r)
@@ -52,17 +72,17 @@ func (s *APIServer) handleGetMessages(w http.ResponseWriter, r *http.Request) er
 		return err
 	}
 
-	ticket, err := s.db.Ticket.GetByID(id)
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
 	if err != nil {
 		return err
 	}
 
-	err = auth.IsAccountID(r, ticket.AuthorID, types.RoleAdmin, types.RoleEditor)
+	err = s.policy.Authorize(r, types.PermissionTicketRead, ticket)
 	if err != nil {
 		return err
 	}
 
-	messages, err := s.db.Message.Get(ticket.ID)
+	messages, err := s.db.Message.Get(r.Context(), ticket.ID)
 	if err != nil {
 		return err
 	}
@@ -70,6 +90,14 @@ func (s *APIServer) handleGetMessages(w http.ResponseWriter, r *http.Request) er
 	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "messages found", Data: messages})
 }
 
+// chatGroupKey identifies a chat group in APIServer.chatGroups. Ticket ids
+// are unique across organizations, but keying by both keeps a group from
+// ever being shared between them.
+type chatGroupKey struct {
+	orgID    int
+	ticketID int
+}
+
 type CreateMessageRequest struct {
 	TicketID int    `json:"ticket_id"`
 	AuthorID int    `json:"author_id"`
@@ -1,30 +1,35 @@
 package api
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"ticketing-api/auth"
+	"ticketing-api/data"
 	"ticketing-api/types"
 	"time"
 )
 
-func IsAdmin(next http.Handler) http.HandlerFunc {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		err := auth.IsRole(r, types.RoleAdmin)
+// HasPermission lets a request through when its account holds permission,
+// for routes that do not act on one particular record.
+func (s *APIServer) HasPermission(permission types.Permission, next http.Handler) http.HandlerFunc {
+	return makeHTTPHandleFunc(func(w http.ResponseWriter, r *http.Request) error {
+		err := s.policy.Authorize(r, permission, nil)
 		if err != nil {
-			encodeResponse(w, http.StatusForbidden, &APIResponse{Status: http.StatusForbidden, Message: err.Error()})
-			return
+			return err
 		}
 
 		next.ServeHTTP(w, r)
+
+		return nil
 	})
 }
 
-func IsEditor(next http.Handler) http.HandlerFunc {
+func IsAuthenticated(next http.Handler) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		err := auth.IsRole(r, types.RoleEditor, types.RoleAdmin)
+		err := auth.IsAuthenticated(r)
 		if err != nil {
-			encodeResponse(w, http.StatusForbidden, &APIResponse{Status: http.StatusForbidden, Message: err.Error()})
+			encodeResponse(w, http.StatusUnauthorized, &APIResponse{Status: http.StatusUnauthorized, Message: err.Error()})
 			return
 		}
 
@@ -32,12 +37,21 @@ func IsEditor(next http.Handler) http.HandlerFunc {
 	})
 }
 
-func IsAuthenticated(next http.Handler) http.HandlerFunc {
+// Authenticate checks the token of every request once, leaving the outcome in
+// its context for the handlers to read.
+func Authenticate(next http.Handler) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		err := auth.IsAuthenticated(r)
-		if err != nil {
-			encodeResponse(w, http.StatusUnauthorized, &APIResponse{Status: http.StatusUnauthorized, Message: err.Error()})
-			return
+		next.ServeHTTP(w, auth.Authenticate(r))
+	})
+}
+
+// ScopeOrganization scopes the data queries of requests carrying a valid token
+// to the organization the token was issued for. Requests without one, such as
+// logins, run unscoped.
+func ScopeOrganization(next http.Handler) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if orgID, err := auth.GetOrganizationID(r); err == nil {
+			r = r.WithContext(data.WithOrganization(r.Context(), orgID))
 		}
 
 		next.ServeHTTP(w, r)
@@ -52,6 +66,25 @@ func Logging(next http.Handler) http.HandlerFunc {
 	})
 }
 
+func Timeout(router *http.ServeMux, timeouts *Timeouts) middleware {
+	return func(next http.Handler) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, pattern := router.Handler(r)
+
+			timeout := timeouts.For(pattern)
+			if timeout <= 0 || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 type middleware func(http.Handler) http.HandlerFunc
 
 func CreateStack(mw ...middleware) middleware {
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"ticketing-api/auth"
+	"ticketing-api/chat"
+	"ticketing-api/types"
+	"time"
+)
+
+// handleGetNotificationPreferences returns the caller's notification
+// preferences, the defaults when they never set any.
+func (s *APIServer) handleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) error {
+	accountID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := s.db.Notification.GetPreferences(r.Context(), accountID)
+
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		prefs, err = types.DefaultNotificationPreferences(accountID), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "notification preferences found", Data: prefs})
+}
+
+func (s *APIServer) handleSetNotificationPreferences(w http.ResponseWriter, r *http.Request) error {
+	accountID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
+
+	req := &NotificationPreferencesRequest{}
+
+	err = decodeRequest(r, req)
+	if err != nil {
+		return err
+	}
+
+	prefs := types.DefaultNotificationPreferences(accountID)
+	prefs.UpdatedAt = time.Now()
+
+	if req.Email != "" {
+		addr, err := mail.ParseAddress(req.Email)
+		if err != nil {
+			return &types.BadRequest{Message: fmt.Sprintf("invalid email %s", req.Email)}
+		}
+
+		prefs.Email = addr.Address
+	}
+
+	if req.Events != nil {
+		prefs.Events = []types.Event{}
+
+		for _, event := range req.Events {
+			if !slices.Contains(types.Events, event) {
+				return &types.BadRequest{Message: fmt.Sprintf("unknown event %s", event)}
+			}
+
+			if !slices.Contains(prefs.Events, event) {
+				prefs.Events = append(prefs.Events, event)
+			}
+		}
+	}
+
+	if req.Digest != "" {
+		if !slices.Contains(types.Digests, req.Digest) {
+			return &types.BadRequest{Message: fmt.Sprintf("digest must be one of: %s", strings.Join(digestNames(), ", "))}
+		}
+
+		prefs.Digest = req.Digest
+	}
+
+	prefs, err = s.db.Notification.SetPreferences(r.Context(), prefs)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "notification preferences updated", Data: prefs})
+}
+
+// notifyTicket queues notifications of a ticket change made by actorID, before
+// being nil for new tickets. The change is committed by then, so failing to
+// queue them is logged rather than failing the request.
+func (s *APIServer) notifyTicket(ctx context.Context, actorID int, before *types.Ticket, after *types.Ticket) {
+	if s.notifier == nil {
+		return
+	}
+
+	err := s.notifier.TicketChanged(ctx, actorID, before, after)
+	if err != nil {
+		log.Printf("failed to queue notifications of ticket %d: %v", after.ID, err)
+	}
+}
+
+func (s *APIServer) notifyMessage(ctx context.Context, message *types.Message) {
+	if s.notifier == nil {
+		return
+	}
+
+	err := s.notifier.MessageCreated(ctx, message)
+	if err != nil {
+		log.Printf("failed to queue notifications of message %s: %v", message.ID, err)
+	}
+}
+
+// inChat reports whether accountID is connected to the chat of a ticket.
+func (s *APIServer) inChat(orgID int, ticketID int, accountID int) bool {
+	group, ok := s.chatGroups.Load(chatGroupKey{orgID: orgID, ticketID: ticketID})
+	return ok && group.(*chat.Group).Connected(accountID)
+}
+
+func digestNames() []string {
+	names := []string{}
+	for _, digest := range types.Digests {
+		names = append(names, string(digest))
+	}
+
+	return names
+}
+
+// NotificationPreferencesRequest replaces the caller's preferences. Events
+// left out mean every event and an empty list none.
+type NotificationPreferencesRequest struct {
+	Email  string        `json:"email"`
+	Events []types.Event `json:"events"`
+	Digest types.Digest  `json:"digest"`
+}
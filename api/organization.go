@@ -0,0 +1,291 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"ticketing-api/auth"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+func (s *APIServer) handleGetOrganizations(w http.ResponseWriter, r *http.Request) error {
+	orgs, err := s.db.Organization.Get(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "organizations found", Data: orgs})
+}
+
+func (s *APIServer) handleGetOrganization(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	org, err := s.db.Organization.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "organization found", Data: org})
+}
+
+func (s *APIServer) handleCreateOrganization(w http.ResponseWriter, r *http.Request) error {
+	req := OrganizationRequest{}
+
+	err := decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkOrganizationName(r.Context(), req.Name, 0)
+	if err != nil {
+		return err
+	}
+
+	org, err := s.db.Organization.Create(r.Context(), types.CreateOrganization(strings.TrimSpace(req.Name)))
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "organization created", Data: org})
+}
+
+func (s *APIServer) handleUpdateOrganization(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	req := OrganizationRequest{}
+
+	err = decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	org, err := s.db.Organization.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkOrganizationName(r.Context(), req.Name, id)
+	if err != nil {
+		return err
+	}
+
+	org.Name = strings.TrimSpace(req.Name)
+	org.UpdatedAt = time.Now()
+
+	org, err = s.db.Organization.Update(r.Context(), org)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "organization updated", Data: org})
+}
+
+func (s *APIServer) handleGetMembers(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Organization.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	memberships, err := s.db.Membership.Get(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "members found", Data: memberships})
+}
+
+// handleSetMember gives an account from another organization a role in this
+// one, recording the change in the organization's history. The account is
+// logged out everywhere so that its tokens pick up the change.
+func (s *APIServer) handleSetMember(w http.ResponseWriter, r *http.Request) error {
+	id, accountID, err := getMemberID(r)
+	if err != nil {
+		return err
+	}
+
+	req := MemberRequest{}
+
+	err = decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Organization.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.db.Account.GetByID(data.WithoutOrganization(r.Context()), accountID)
+	if err != nil {
+		return &types.NotFound{Message: fmt.Sprintf("account %d not found", accountID)}
+	}
+
+	if account.OrgID == id {
+		return &types.BadRequest{Message: fmt.Sprintf("organization %d is the home organization of account %d", id, accountID)}
+	}
+
+	err = checkRole(r.Context(), s.db, req.Role)
+	if err != nil {
+		return err
+	}
+
+	actorID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
+
+	membership := &types.Membership{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		oldRole := ""
+
+		existing, err := tx.Membership.GetByID(r.Context(), id, accountID)
+		switch {
+		case err == nil:
+			oldRole = string(existing.Role)
+		case !errors.As(err, new(*types.NotFound)):
+			return err
+		}
+
+		membership, err = tx.Membership.Set(r.Context(), types.CreateMembership(id, accountID, req.Role))
+		if err != nil {
+			return err
+		}
+
+		if oldRole != string(req.Role) {
+			err = recordChanges(data.WithOrganization(r.Context(), id), tx, []*types.Change{types.CreateChange(types.EntityAccount, accountID, actorID, "role", oldRole, string(req.Role))})
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Session.RevokeByAccountID(r.Context(), accountID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "member set", Data: membership})
+}
+
+func (s *APIServer) handleDeleteMember(w http.ResponseWriter, r *http.Request) error {
+	id, accountID, err := getMemberID(r)
+	if err != nil {
+		return err
+	}
+
+	actorID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		membership, err := tx.Membership.GetByID(r.Context(), id, accountID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Membership.Delete(r.Context(), id, accountID)
+		if err != nil {
+			return err
+		}
+
+		err = recordChanges(data.WithOrganization(r.Context(), id), tx, []*types.Change{types.CreateChange(types.EntityAccount, accountID, actorID, "role", string(membership.Role), "")})
+		if err != nil {
+			return err
+		}
+
+		return tx.Session.RevokeByAccountID(r.Context(), accountID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "member removed"})
+}
+
+// joinOrganization returns account as it acts in orgID: with its own role in
+// its home organization, and with its membership role anywhere else.
+// Super-admins act as admin in organizations they are not a member of.
+func (s *APIServer) joinOrganization(ctx context.Context, account *types.Account, orgID int) (*types.Account, error) {
+	if orgID == account.OrgID {
+		return account, nil
+	}
+
+	_, err := s.db.Organization.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	joined := *account
+	joined.OrgID = orgID
+
+	membership, err := s.db.Membership.GetByID(ctx, orgID, account.ID)
+	switch {
+	case err == nil:
+		joined.Role = membership.Role
+	case account.SuperAdmin:
+		joined.Role = types.RoleAdmin
+	default:
+		return nil, &types.Forbidden{Message: fmt.Sprintf("not a member of organization %d", orgID)}
+	}
+
+	return &joined, nil
+}
+
+func (s *APIServer) checkOrganizationName(ctx context.Context, name string, exceptID int) error {
+	if strings.TrimSpace(name) == "" {
+		return &types.BadRequest{Message: "name is required"}
+	}
+
+	orgs, err := s.db.Organization.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, org := range orgs {
+		if org.ID != exceptID && strings.EqualFold(org.Name, strings.TrimSpace(name)) {
+			return &types.BadRequest{Message: fmt.Sprintf("organization %s already exists", org.Name)}
+		}
+	}
+
+	return nil
+}
+
+func getMemberID(r *http.Request) (int, int, error) {
+	id, err := getID(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	accountID, err := strconv.Atoi(r.PathValue("account_id"))
+	if err != nil {
+		return 0, 0, &types.BadRequest{}
+	}
+
+	return id, accountID, nil
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type MemberRequest struct {
+	Role types.Role `json:"role"`
+}
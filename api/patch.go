@@ -0,0 +1,386 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"ticketing-api/types"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+type documentPatch struct {
+	merge      any
+	operations []*patchOperation
+}
+
+// decodePatch reads an RFC 7396 merge patch from the request body, or an RFC
+// 6902 JSON patch when it is sent as application/json-patch+json.
+func decodePatch(r *http.Request) (*documentPatch, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	patch := &documentPatch{}
+
+	switch mediaType {
+	case "", "application/json", mergePatchType:
+		err := json.NewDecoder(r.Body).Decode(&patch.merge)
+		if err != nil {
+			return nil, &types.BadRequest{Message: "invalid merge patch"}
+		}
+	case jsonPatchType:
+		err := json.NewDecoder(r.Body).Decode(&patch.operations)
+		if err != nil {
+			return nil, &types.BadRequest{Message: "invalid json patch"}
+		}
+	default:
+		return nil, &types.BadRequest{Message: fmt.Sprintf("Content-Type must be %s or %s", mergePatchType, jsonPatchType)}
+	}
+
+	return patch, nil
+}
+
+// applyPatch returns a patched copy of current along with the top level fields
+// the patch changed.
+func applyPatch[T any](patch *documentPatch, current *T) (*T, []string, error) {
+	b, err := json.Marshal(current)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := map[string]any{}
+
+	err = json.Unmarshal(b, &before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc := clone(before)
+
+	if patch.operations != nil {
+		doc, err = jsonPatch(doc, patch.operations)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		doc = mergePatch(doc, clone(patch.merge))
+	}
+
+	after, ok := doc.(map[string]any)
+	if !ok {
+		return nil, nil, &types.BadRequest{Message: "patch must produce an object"}
+	}
+
+	fields := []string{}
+
+	for key := range before {
+		if !reflect.DeepEqual(before[key], after[key]) {
+			fields = append(fields, key)
+		}
+	}
+
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+
+	slices.Sort(fields)
+
+	b, err = json.Marshal(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	patched := new(T)
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(patched)
+	if err != nil {
+		return nil, nil, &types.BadRequest{Message: fmt.Sprintf("invalid patch result: %s", err)}
+	}
+
+	return patched, fields, nil
+}
+
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func jsonPatch(doc any, operations []*patchOperation) (any, error) {
+	for i, operation := range operations {
+		path, err := parsePointer(operation.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := operation.value()
+		if err != nil {
+			return nil, err
+		}
+
+		switch operation.Op {
+		case "add":
+			doc, err = addPointer(doc, path, value)
+		case "remove":
+			doc, _, err = removePointer(doc, path)
+		case "replace":
+			if len(path) == 0 {
+				doc = value
+				break
+			}
+
+			doc, _, err = removePointer(doc, path)
+			if err == nil {
+				doc, err = addPointer(doc, path, value)
+			}
+		case "move", "copy":
+			var from []string
+
+			from, err = parsePointer(operation.From)
+			if err != nil {
+				return nil, err
+			}
+
+			if operation.Op == "move" && strings.HasPrefix(operation.Path+"/", operation.From+"/") && operation.Path != operation.From {
+				return nil, &types.BadRequest{Message: fmt.Sprintf("operation %d cannot move a value into itself", i)}
+			}
+
+			var moved any
+			if operation.Op == "move" {
+				doc, moved, err = removePointer(doc, from)
+			} else {
+				moved, err = getPointer(doc, from)
+				moved = clone(moved)
+			}
+
+			if err == nil {
+				doc, err = addPointer(doc, path, moved)
+			}
+		case "test":
+			var current any
+
+			current, err = getPointer(doc, path)
+			if err == nil && !reflect.DeepEqual(current, value) {
+				err = &types.PreconditionFailed{Message: fmt.Sprintf("test failed at %s", operation.Path)}
+			}
+		default:
+			err = &types.BadRequest{Message: fmt.Sprintf("unknown operation %q", operation.Op)}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+func (o *patchOperation) value() (any, error) {
+	if len(o.Value) == 0 {
+		if o.Op == "add" || o.Op == "replace" || o.Op == "test" {
+			return nil, &types.BadRequest{Message: fmt.Sprintf("%s requires a value", o.Op)}
+		}
+
+		return nil, nil
+	}
+
+	var value any
+
+	err := json.Unmarshal(o.Value, &value)
+	if err != nil {
+		return nil, &types.BadRequest{Message: "invalid value"}
+	}
+
+	return value, nil
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &types.BadRequest{Message: fmt.Sprintf("invalid path %q", pointer)}
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func getPointer(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, pointerNotFound(path)
+			}
+
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(container)-1, path)
+			if err != nil {
+				return nil, err
+			}
+
+			doc = container[i]
+		default:
+			return nil, pointerNotFound(path)
+		}
+	}
+
+	return doc, nil
+}
+
+func addPointer(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[token] = value
+			return container, nil
+		case []any:
+			if token == "-" {
+				return append(container, value), nil
+			}
+
+			i, err := arrayIndex(token, len(container), path)
+			if err != nil {
+				return nil, err
+			}
+
+			return slices.Insert(container, i, value), nil
+		}
+
+		return nil, pointerNotFound(path)
+	})
+}
+
+func removePointer(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, &types.BadRequest{Message: "cannot remove the whole document"}
+	}
+
+	var removed any
+
+	doc, err := updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, pointerNotFound(path)
+			}
+
+			removed = value
+			delete(container, token)
+
+			return container, nil
+		case []any:
+			i, err := arrayIndex(token, len(container)-1, path)
+			if err != nil {
+				return nil, err
+			}
+
+			removed = container[i]
+
+			return slices.Delete(container, i, i+1), nil
+		}
+
+		return nil, pointerNotFound(path)
+	})
+
+	return doc, removed, err
+}
+
+// updateParent walks to the container holding the last token of path and
+// replaces it with the result of update, rebuilding arrays on the way back.
+func updateParent(doc any, path []string, update func(any, string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+
+	child, err := getPointer(doc, path[:1])
+	if err != nil {
+		return nil, pointerNotFound(path)
+	}
+
+	child, err = updateParent(child, path[1:], update)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := doc.(type) {
+	case map[string]any:
+		container[path[0]] = child
+	case []any:
+		i, _ := strconv.Atoi(path[0])
+		container[i] = child
+	}
+
+	return doc, nil
+}
+
+func arrayIndex(token string, max int, path []string) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, pointerNotFound(path)
+	}
+
+	return i, nil
+}
+
+func pointerNotFound(path []string) error {
+	return &types.BadRequest{Message: fmt.Sprintf("path /%s does not exist", strings.Join(path, "/"))}
+}
+
+func clone(v any) any {
+	b, _ := json.Marshal(v)
+
+	var copied any
+	json.Unmarshal(b, &copied)
+
+	return copied
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"ticketing-api/auth"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+var roleName = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+func (s *APIServer) handleGetRoles(w http.ResponseWriter, r *http.Request) error {
+	roles, err := s.db.Role.Get(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "roles found", Data: roles})
+}
+
+func (s *APIServer) handleGetRole(w http.ResponseWriter, r *http.Request) error {
+	role, err := s.db.Role.GetByName(r.Context(), types.Role(r.PathValue("name")))
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "role found", Data: role})
+}
+
+func (s *APIServer) handleCreateRole(w http.ResponseWriter, r *http.Request) error {
+	req := &RoleRequest{Permissions: []types.Permission{}}
+
+	err := decodeRequest(r, req)
+	if err != nil {
+		return err
+	}
+
+	if !roleName.MatchString(string(req.Name)) {
+		return &types.BadRequest{Message: "name must be lowercase letters, digits, _ or - and start with a letter"}
+	}
+
+	err = validatePermissions(req.Permissions)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Role.GetByName(r.Context(), req.Name)
+	if err == nil {
+		return &types.BadRequest{Message: fmt.Sprintf("role %s already exists", req.Name)}
+	}
+
+	if !errors.As(err, new(*types.NotFound)) {
+		return err
+	}
+
+	orgID, err := auth.GetOrganizationID(r)
+	if err != nil {
+		return err
+	}
+
+	role := types.CreateRoleDefinition(req.Name, req.Permissions)
+	role.OrgID = orgID
+
+	role, err = s.db.Role.Create(r.Context(), role)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "role created", Data: role})
+}
+
+func (s *APIServer) handleUpdateRole(w http.ResponseWriter, r *http.Request) error {
+	name := types.Role(r.PathValue("name"))
+
+	if name == types.RoleAdmin {
+		return &types.BadRequest{Message: "the admin role always holds every permission"}
+	}
+
+	req := &RoleRequest{Permissions: []types.Permission{}}
+
+	err := decodeRequest(r, req)
+	if err != nil {
+		return err
+	}
+
+	err = validatePermissions(req.Permissions)
+	if err != nil {
+		return err
+	}
+
+	role, err := s.db.Role.GetByName(r.Context(), name)
+	if err != nil {
+		return err
+	}
+
+	role.Permissions = req.Permissions
+	role.UpdatedAt = time.Now()
+
+	role, err = s.db.Role.Update(r.Context(), role)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "role updated", Data: role})
+}
+
+// handleDeleteRole removes a custom role. Accounts still holding it keep the
+// name but are granted nothing until they are given another role.
+func (s *APIServer) handleDeleteRole(w http.ResponseWriter, r *http.Request) error {
+	name := types.Role(r.PathValue("name"))
+
+	if slices.Contains(types.Roles, name) {
+		return &types.BadRequest{Message: fmt.Sprintf("built in role %s cannot be deleted", name)}
+	}
+
+	err := s.db.Role.Delete(r.Context(), name)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "role deleted"})
+}
+
+// checkRole makes sure role exists before it is given to an account.
+func checkRole(ctx context.Context, db *data.DataAdapter, role types.Role) error {
+	_, err := db.Role.GetByName(ctx, role)
+	if errors.As(err, new(*types.NotFound)) {
+		return &types.BadRequest{Message: fmt.Sprintf("unknown role %q", role)}
+	}
+
+	return err
+}
+
+func validatePermissions(permissions []types.Permission) error {
+	for _, permission := range permissions {
+		if !slices.Contains(types.Permissions, permission) {
+			return &types.BadRequest{Message: fmt.Sprintf("unknown permission %q", permission)}
+		}
+	}
+
+	return nil
+}
+
+type RoleRequest struct {
+	Name        types.Role         `json:"name"`
+	Permissions []types.Permission `json:"permissions"`
+}
@@ -1,59 +1,180 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"ticketing-api/assignment"
+	"ticketing-api/blob"
 	"ticketing-api/data"
+	"ticketing-api/notify"
+	"ticketing-api/policy"
+	"ticketing-api/search"
 	"ticketing-api/types"
+	"ticketing-api/workflow"
 )
 
 type APIServer struct {
-	addr       string
-	db         *data.DataAdapter
-	chatGroups *sync.Map
+	addr             string
+	db               *data.DataAdapter
+	timeouts         *Timeouts
+	search           *search.Index
+	workflow         *workflow.Workflow
+	policy           *policy.Engine
+	assigner         *assignment.Assigner
+	blobs            blob.BlobStore
+	attachmentLimits *AttachmentLimits
+	notifier         *notify.Notifier
+	chatGroups       *sync.Map
 }
 
-func CreateAPIServer(addr string, db *data.DataAdapter) *APIServer {
+func CreateAPIServer(addr string, db *data.DataAdapter, timeouts *Timeouts, index *search.Index, workflow *workflow.Workflow) *APIServer {
 	return &APIServer{
 		addr:       addr,
 		db:         db,
+		timeouts:   timeouts,
+		search:     index,
+		workflow:   workflow,
+		policy:     policy.CreateEngine(db.Role, db.Team),
 		chatGroups: &sync.Map{},
 	}
 }
 
+// UseAssigner turns on automatic assignment of new and reopened tickets that
+// nobody works.
+func (s *APIServer) UseAssigner(assigner *assignment.Assigner) {
+	s.assigner = assigner
+}
+
+// UseBlobStore turns on attachments, keeping their content in store. Without
+// limits the defaults apply.
+func (s *APIServer) UseBlobStore(store blob.BlobStore, limits *AttachmentLimits) {
+	if limits == nil {
+		limits = &AttachmentLimits{MaxSize: DefaultAttachmentMaxSize, ContentTypes: DefaultAttachmentTypes}
+	}
+
+	s.blobs = store
+	s.attachmentLimits = limits
+}
+
+// UseNotifier turns on notifying watchers of what happens to their tickets.
+// Accounts connected to a ticket's chat are not notified of its messages.
+func (s *APIServer) UseNotifier(notifier *notify.Notifier) {
+	notifier.UsePresence(s.inChat)
+	s.notifier = notifier
+}
+
 func (s *APIServer) Start() error {
+	server := &http.Server{
+		Addr:    s.addr,
+		Handler: s.Handler(),
+	}
+
+	log.Println("Starting server on", s.addr)
+
+	return server.ListenAndServe()
+}
+
+func (s *APIServer) Handler() http.Handler {
 	router := http.NewServeMux()
 
 	router.HandleFunc("GET /ping", makeHTTPHandleFunc(s.handlePing))
+	router.HandleFunc("GET /.well-known/jwks.json", makeHTTPHandleFunc(s.handleJWKS))
 
 	router.HandleFunc("POST /account/login", makeHTTPHandleFunc(s.handleLogin))
+	router.HandleFunc("POST /account/refresh", makeHTTPHandleFunc(s.handleRefresh))
+	router.HandleFunc("POST /account/logout", IsAuthenticated(makeHTTPHandleFunc(s.handleLogout)))
 
-	router.HandleFunc("POST /account", IsAdmin(makeHTTPHandleFunc(s.handleCreateAccount)))
-	router.HandleFunc("GET /account", IsEditor(makeHTTPHandleFunc(s.handleGetAccounts)))
+	router.HandleFunc("POST /account", s.HasPermission(types.PermissionAccountManage, makeHTTPHandleFunc(s.handleCreateAccount)))
+	router.HandleFunc("GET /account", s.HasPermission(types.PermissionAccountRead, makeHTTPHandleFunc(s.handleGetAccounts)))
 	router.HandleFunc("GET /account/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleGetAccountByID)))
 	router.HandleFunc("PUT /account/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleUpdateAccount)))
+	router.HandleFunc("PATCH /account/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handlePatchAccount)))
 	router.HandleFunc("DELETE /account/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleDeleteAccount)))
+	router.HandleFunc("DELETE /account/{id}/sessions", s.HasPermission(types.PermissionAccountManage, makeHTTPHandleFunc(s.handleRevokeSessions)))
+	router.HandleFunc("POST /account/{id}/restore", s.HasPermission(types.PermissionAccountManage, makeHTTPHandleFunc(s.handleRestoreAccount)))
+	router.HandleFunc("GET /account/{id}/history", s.HasPermission(types.PermissionAccountManage, makeHTTPHandleFunc(s.handleGetAccountHistory)))
 
-	router.HandleFunc("POST /ticket", IsAuthenticated(makeHTTPHandleFunc(s.handleCreateTicket)))
-	router.HandleFunc("GET /ticket", IsEditor(makeHTTPHandleFunc(s.handleGetTickets)))
+	router.HandleFunc("POST /ticket", s.HasPermission(types.PermissionTicketCreate, makeHTTPHandleFunc(s.handleCreateTicket)))
+	router.HandleFunc("GET /ticket", s.HasPermission(types.PermissionTicketRead, makeHTTPHandleFunc(s.handleGetTickets)))
+	router.HandleFunc("GET /me/tickets", IsAuthenticated(makeHTTPHandleFunc(s.handleGetMyTickets)))
+	router.HandleFunc("GET /me/notifications/preferences", IsAuthenticated(makeHTTPHandleFunc(s.handleGetNotificationPreferences)))
+	router.HandleFunc("PUT /me/notifications/preferences", IsAuthenticated(makeHTTPHandleFunc(s.handleSetNotificationPreferences)))
 	router.HandleFunc("GET /ticket/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleGetTicketByID)))
 	router.HandleFunc("PUT /ticket/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleUpdateTicket)))
+	router.HandleFunc("PATCH /ticket/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handlePatchTicket)))
 	router.HandleFunc("DELETE /ticket/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleDeleteTicket)))
+	router.HandleFunc("POST /ticket/{id}/restore", s.HasPermission(types.PermissionTicketRestore, makeHTTPHandleFunc(s.handleRestoreTicket)))
+	router.HandleFunc("POST /ticket/{id}/purge", s.HasPermission(types.PermissionTicketPurge, makeHTTPHandleFunc(s.handlePurgeTicket)))
+	router.HandleFunc("GET /ticket/{id}/history", IsAuthenticated(makeHTTPHandleFunc(s.handleGetTicketHistory)))
+	router.HandleFunc("POST /ticket/{id}/attachment", IsAuthenticated(makeHTTPHandleFunc(s.handleUploadAttachments)))
+	router.HandleFunc("GET /ticket/{id}/attachment", IsAuthenticated(makeHTTPHandleFunc(s.handleGetAttachments)))
+	router.HandleFunc("GET /ticket/{id}/attachment/{attachment_id}", IsAuthenticated(makeHTTPHandleFunc(s.handleDownloadAttachment)))
+	router.HandleFunc("DELETE /ticket/{id}/attachment/{attachment_id}", IsAuthenticated(makeHTTPHandleFunc(s.handleDeleteAttachment)))
+	router.HandleFunc("GET /ticket/{id}/links", IsAuthenticated(makeHTTPHandleFunc(s.handleGetLinks)))
+	router.HandleFunc("POST /ticket/{id}/links", IsAuthenticated(makeHTTPHandleFunc(s.handleCreateLink)))
+	router.HandleFunc("DELETE /ticket/{id}/links/{link_id}", IsAuthenticated(makeHTTPHandleFunc(s.handleDeleteLink)))
+	router.HandleFunc("POST /ticket/{id}/merge", IsAuthenticated(makeHTTPHandleFunc(s.handleMergeTicket)))
+	router.HandleFunc("POST /ticket/{id}/watchers", IsAuthenticated(makeHTTPHandleFunc(s.handleWatchTicket)))
+	router.HandleFunc("DELETE /ticket/{id}/watchers", IsAuthenticated(makeHTTPHandleFunc(s.handleUnwatchTicket)))
 
-	router.HandleFunc("GET /ticket/{id}/chat", makeHTTPHandleFunc(s.handleChatGroup))
-	router.HandleFunc("GET /ticket/{id}/chat/message", makeHTTPHandleFunc(s.handleGetMessages))
+	router.HandleFunc("GET /role", s.HasPermission(types.PermissionRoleManage, makeHTTPHandleFunc(s.handleGetRoles)))
+	router.HandleFunc("POST /role", s.HasPermission(types.PermissionRoleManage, makeHTTPHandleFunc(s.handleCreateRole)))
+	router.HandleFunc("GET /role/{name}", s.HasPermission(types.PermissionRoleManage, makeHTTPHandleFunc(s.handleGetRole)))
+	router.HandleFunc("PUT /role/{name}", s.HasPermission(types.PermissionRoleManage, makeHTTPHandleFunc(s.handleUpdateRole)))
+	router.HandleFunc("DELETE /role/{name}", s.HasPermission(types.PermissionRoleManage, makeHTTPHandleFunc(s.handleDeleteRole)))
 
-	server := &http.Server{
-		Addr:    s.addr,
-		Handler: CreateStack(Logging)(router),
-	}
+	router.HandleFunc("GET /org", s.HasPermission(types.PermissionOrgManage, makeHTTPHandleFunc(s.handleGetOrganizations)))
+	router.HandleFunc("POST /org", s.HasPermission(types.PermissionOrgManage, makeHTTPHandleFunc(s.handleCreateOrganization)))
+	router.HandleFunc("GET /org/{id}", s.HasPermission(types.PermissionOrgManage, makeHTTPHandleFunc(s.handleGetOrganization)))
+	router.HandleFunc("PUT /org/{id}", s.HasPermission(types.PermissionOrgManage, makeHTTPHandleFunc(s.handleUpdateOrganization)))
+	router.HandleFunc("GET /org/{id}/member", s.HasPermission(types.PermissionOrgManage, makeHTTPHandleFunc(s.handleGetMembers)))
+	router.HandleFunc("PUT /org/{id}/member/{account_id}", s.HasPermission(types.PermissionOrgManage, makeHTTPHandleFunc(s.handleSetMember)))
+	router.HandleFunc("DELETE /org/{id}/member/{account_id}", s.HasPermission(types.PermissionOrgManage, makeHTTPHandleFunc(s.handleDeleteMember)))
 
-	log.Println("Starting server on", s.addr)
+	router.HandleFunc("GET /team", IsAuthenticated(makeHTTPHandleFunc(s.handleGetTeams)))
+	router.HandleFunc("POST /team", s.HasPermission(types.PermissionTeamManage, makeHTTPHandleFunc(s.handleCreateTeam)))
+	router.HandleFunc("GET /team/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleGetTeam)))
+	router.HandleFunc("PUT /team/{id}", s.HasPermission(types.PermissionTeamManage, makeHTTPHandleFunc(s.handleUpdateTeam)))
+	router.HandleFunc("DELETE /team/{id}", s.HasPermission(types.PermissionTeamManage, makeHTTPHandleFunc(s.handleDeleteTeam)))
+	router.HandleFunc("GET /team/{id}/queue", IsAuthenticated(makeHTTPHandleFunc(s.handleGetTeamQueue)))
+	router.HandleFunc("POST /team/{id}/queue/claim", IsAuthenticated(makeHTTPHandleFunc(s.handleClaimTicket)))
 
-	return server.ListenAndServe()
+	router.HandleFunc("GET /agent", s.HasPermission(types.PermissionTicketAssign, makeHTTPHandleFunc(s.handleGetAgents)))
+	router.HandleFunc("GET /agent/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleGetAgent)))
+	router.HandleFunc("PUT /agent/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleSetAgent)))
+	router.HandleFunc("GET /ticket/{id}/assignments", IsAuthenticated(makeHTTPHandleFunc(s.handleGetAssignments)))
+
+	router.HandleFunc("GET /sla", IsAuthenticated(makeHTTPHandleFunc(s.handleGetSLAPolicies)))
+	router.HandleFunc("POST /sla", s.HasPermission(types.PermissionSLAManage, makeHTTPHandleFunc(s.handleCreateSLAPolicy)))
+	router.HandleFunc("GET /sla/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleGetSLAPolicy)))
+	router.HandleFunc("PUT /sla/{id}", s.HasPermission(types.PermissionSLAManage, makeHTTPHandleFunc(s.handleUpdateSLAPolicy)))
+	router.HandleFunc("DELETE /sla/{id}", s.HasPermission(types.PermissionSLAManage, makeHTTPHandleFunc(s.handleDeleteSLAPolicy)))
+
+	router.HandleFunc("GET /category", IsAuthenticated(makeHTTPHandleFunc(s.handleGetCategories)))
+	router.HandleFunc("POST /category", s.HasPermission(types.PermissionCategoryManage, makeHTTPHandleFunc(s.handleCreateCategory)))
+	router.HandleFunc("GET /category/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleGetCategory)))
+	router.HandleFunc("PUT /category/{id}", s.HasPermission(types.PermissionCategoryManage, makeHTTPHandleFunc(s.handleUpdateCategory)))
+	router.HandleFunc("DELETE /category/{id}", s.HasPermission(types.PermissionCategoryManage, makeHTTPHandleFunc(s.handleDeleteCategory)))
+
+	router.HandleFunc("GET /field", IsAuthenticated(makeHTTPHandleFunc(s.handleGetCustomFields)))
+	router.HandleFunc("POST /field", s.HasPermission(types.PermissionFieldManage, makeHTTPHandleFunc(s.handleCreateCustomField)))
+	router.HandleFunc("GET /field/{id}", IsAuthenticated(makeHTTPHandleFunc(s.handleGetCustomField)))
+	router.HandleFunc("PUT /field/{id}", s.HasPermission(types.PermissionFieldManage, makeHTTPHandleFunc(s.handleUpdateCustomField)))
+	router.HandleFunc("DELETE /field/{id}", s.HasPermission(types.PermissionFieldManage, makeHTTPHandleFunc(s.handleDeleteCustomField)))
+
+	router.HandleFunc("GET /search", IsAuthenticated(makeHTTPHandleFunc(s.handleSearch)))
+
+	router.HandleFunc("GET /ticket/{id}/chat", makeHTTPHandleFunc(s.handleChatGroup))
+	router.HandleFunc("GET /ticket/{id}/chat/message", makeHTTPHandleFunc(s.handleGetMessages))
+
+	return CreateStack(Logging, Timeout(router, s.timeouts), Authenticate, ScopeOrganization)(router)
 }
 
 func (s *APIServer) handlePing(w http.ResponseWriter, r *http.Request) error {
@@ -72,6 +193,39 @@ func getID(r *http.Request) (int, error) {
 	return id, nil
 }
 
+func getPage(r *http.Request, sorts []string) (*types.Page, error) {
+	query := r.URL.Query()
+
+	page := &types.Page{
+		Cursor: query.Get("cursor"),
+		Sort:   query.Get("sort"),
+		Total:  query.Get("total") == "true",
+	}
+
+	if page.Sort != "" && !slices.Contains(sorts, page.Sort) {
+		return nil, &types.BadRequest{Message: fmt.Sprintf("sort must be one of: %s", strings.Join(sorts, ", "))}
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		page.Desc = true
+	default:
+		return nil, &types.BadRequest{Message: "order must be asc or desc"}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > data.MaxPageLimit {
+			return nil, &types.BadRequest{Message: fmt.Sprintf("limit must be between 1 and %d", data.MaxPageLimit)}
+		}
+
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
 func decodeRequest(r *http.Request, v any) error {
 	err := json.NewDecoder(r.Body).Decode(v)
 	if err != nil {
@@ -88,9 +242,11 @@ func encodeResponse(w http.ResponseWriter, status int, v any) error {
 }
 
 type APIResponse struct {
-	Status  int    `json:"status"`
-	Message string `json:"message"`
-	Data    any    `json:"data"`
+	Status     int    `json:"status"`
+	Message    string `json:"message"`
+	Data       any    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
 }
 
 type apiFunc func(w http.ResponseWriter, r *http.Request) error
@@ -99,13 +255,29 @@ func makeHTTPHandleFunc(f apiFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		err := f(w, r)
 		if err != nil {
+			switch r.Context().Err() {
+			case context.Canceled:
+				return
+			case context.DeadlineExceeded:
+				encodeResponse(w, http.StatusGatewayTimeout, &APIResponse{Status: http.StatusGatewayTimeout, Message: "request timed out"})
+				return
+			}
+
 			switch err.(type) {
 			case *types.Unauthorized:
 				encodeResponse(w, http.StatusUnauthorized, &APIResponse{Status: http.StatusUnauthorized, Message: err.Error()})
 			case *types.Forbidden:
 				encodeResponse(w, http.StatusForbidden, &APIResponse{Status: http.StatusForbidden, Message: err.Error()})
+			case *types.BadRequest:
+				encodeResponse(w, http.StatusBadRequest, &APIResponse{Status: http.StatusBadRequest, Message: err.Error()})
+			case *types.PreconditionFailed:
+				encodeResponse(w, http.StatusPreconditionFailed, &APIResponse{Status: http.StatusPreconditionFailed, Message: err.Error()})
 			case *types.NotFound:
 				encodeResponse(w, http.StatusNotFound, &APIResponse{Status: http.StatusNotFound, Message: err.Error()})
+			case *types.TooLarge:
+				encodeResponse(w, http.StatusRequestEntityTooLarge, &APIResponse{Status: http.StatusRequestEntityTooLarge, Message: err.Error()})
+			case *types.UnsupportedMediaType:
+				encodeResponse(w, http.StatusUnsupportedMediaType, &APIResponse{Status: http.StatusUnsupportedMediaType, Message: err.Error()})
 			default:
 				encodeResponse(w, http.StatusInternalServerError, &APIResponse{Status: http.StatusInternalServerError, Message: err.Error()})
 			}
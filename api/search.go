@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"ticketing-api/search"
+	"ticketing-api/types"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func (s *APIServer) handleSearch(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		return &types.BadRequest{Message: "q is required"}
+	}
+
+	limit := defaultSearchLimit
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > maxSearchLimit {
+			return &types.BadRequest{Message: "limit must be between 1 and 100"}
+		}
+
+		limit = l
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	results := []*SearchResult{}
+	visible := map[int]*types.Ticket{}
+
+	for _, hit := range s.search.Search(query) {
+		if len(results) >= limit {
+			break
+		}
+
+		ticket, checked := visible[hit.TicketID]
+		if !checked {
+			t, err := s.db.Ticket.GetByID(r.Context(), hit.TicketID)
+			if err != nil && r.Context().Err() != nil {
+				return err
+			}
+
+			if err == nil && subject.Can(types.PermissionTicketRead, t) == nil {
+				ticket = t
+			}
+
+			visible[hit.TicketID] = ticket
+		}
+
+		if ticket == nil {
+			continue
+		}
+
+		results = append(results, &SearchResult{Hit: hit, Ticket: ticket})
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "search results", Data: results})
+}
+
+type SearchResult struct {
+	*search.Hit
+	Ticket *types.Ticket `json:"ticket"`
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"ticketing-api/auth"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+func (s *APIServer) handleRefresh(w http.ResponseWriter, r *http.Request) error {
+	req := RefreshRequest{}
+
+	err := decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	sessionID, tokenHash, err := auth.ParseRefreshToken(req.RefreshToken)
+	if err != nil {
+		return &types.Unauthorized{Message: "invalid refresh token"}
+	}
+
+	session, err := s.db.Session.GetByID(r.Context(), sessionID)
+	if err != nil || session.RevokedAt != nil || session.ExpiresAt.Before(time.Now()) {
+		return &types.Unauthorized{Message: "invalid refresh token"}
+	}
+
+	// a rotated token being replayed means it has leaked, so the whole session
+	// is revoked rather than just this request refused
+	if !auth.CompareTokenHash(session.TokenHash, tokenHash) {
+		err = s.db.Session.Revoke(r.Context(), sessionID)
+		if err != nil {
+			return err
+		}
+
+		return &types.Unauthorized{Message: "invalid refresh token"}
+	}
+
+	account, err := s.db.Account.GetByID(r.Context(), session.AccountID)
+	if err != nil {
+		return &types.Unauthorized{Message: "invalid refresh token"}
+	}
+
+	account, err = s.joinOrganization(r.Context(), account, session.OrgID)
+	if err != nil {
+		return &types.Unauthorized{Message: "invalid refresh token"}
+	}
+
+	refreshToken, newHash, err := auth.GenerateRefreshToken(sessionID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Session.Rotate(r.Context(), sessionID, tokenHash, newHash)
+	if err != nil {
+		return &types.Unauthorized{Message: "invalid refresh token"}
+	}
+
+	token, err := auth.GenerateSessionJWT(account, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "token refreshed", Data: &LoginResponse{Token: token, RefreshToken: refreshToken, ExpiresAt: time.Now().Add(auth.AccessTokenTTL)}})
+}
+
+func (s *APIServer) handleLogout(w http.ResponseWriter, r *http.Request) error {
+	sessionID, err := auth.GetSessionID(r)
+	if err != nil {
+		return err
+	}
+
+	if sessionID == "" {
+		return &types.BadRequest{Message: "token does not belong to a session"}
+	}
+
+	err = s.db.Session.Revoke(r.Context(), sessionID)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "logged out"})
+}
+
+func (s *APIServer) handleRevokeSessions(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.db.Account.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	err = s.policy.Authorize(r, types.PermissionAccountManage, account)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Session.RevokeByAccountID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "sessions revoked"})
+}
+
+// createSession starts a session for account and returns its first access and
+// refresh tokens.
+func (s *APIServer) createSession(ctx context.Context, account *types.Account) (*LoginResponse, error) {
+	sessionID, err := auth.GenerateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, tokenHash, err := auth.GenerateRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := types.CreateSession(sessionID, account.ID, tokenHash, time.Now().Add(auth.RefreshTokenTTL))
+	session.OrgID = account.OrgID
+
+	_, err = s.db.Session.Create(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateSessionJWT(account, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{Token: token, RefreshToken: refreshToken, ExpiresAt: time.Now().Add(auth.AccessTokenTTL)}, nil
+}
+
+// revokeSessionsOnChange logs an account out everywhere when its password or
+// role changed, so that stale tokens cannot keep the old privileges.
+func revokeSessionsOnChange(ctx context.Context, tx *data.DataAdapter, before *types.Account, after *types.Account) error {
+	if before.Password == after.Password && before.Role == after.Role {
+		return nil
+	}
+
+	return tx.Session.RevokeByAccountID(ctx, after.ID)
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
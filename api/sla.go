@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/sla"
+	"ticketing-api/types"
+	"time"
+)
+
+func (s *APIServer) handleGetSLAPolicies(w http.ResponseWriter, r *http.Request) error {
+	policies, err := s.db.SLAPolicy.Get(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "sla policies found", Data: policies})
+}
+
+func (s *APIServer) handleGetSLAPolicy(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	policy, err := s.db.SLAPolicy.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "sla policy found", Data: policy})
+}
+
+func (s *APIServer) handleCreateSLAPolicy(w http.ResponseWriter, r *http.Request) error {
+	req := SLAPolicyRequest{}
+
+	err := decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	policy := types.CreateSLAPolicy(subject.OrgID, strings.TrimSpace(req.Name), req.Default, req.Calendar, req.Targets, req.AtRiskMinutes)
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := checkSLAPolicy(r.Context(), tx, policy)
+		if err != nil {
+			return err
+		}
+
+		policy, err = tx.SLAPolicy.Create(r.Context(), policy)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "sla policy created", Data: policy})
+}
+
+// handleUpdateSLAPolicy replaces a policy. Tickets keep the due times they
+// were given, new targets apply to tickets created or reprioritized later.
+func (s *APIServer) handleUpdateSLAPolicy(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	req := SLAPolicyRequest{}
+
+	err = decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	policy := &types.SLAPolicy{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		policy, err = tx.SLAPolicy.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		policy.Name = strings.TrimSpace(req.Name)
+		policy.Default = req.Default
+		policy.Calendar = req.Calendar
+		policy.Targets = req.Targets
+		policy.AtRiskMinutes = req.AtRiskMinutes
+		policy.UpdatedAt = time.Now()
+
+		err = checkSLAPolicy(r.Context(), tx, policy)
+		if err != nil {
+			return err
+		}
+
+		policy, err = tx.SLAPolicy.Update(r.Context(), policy)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "sla policy updated", Data: policy})
+}
+
+func (s *APIServer) handleDeleteSLAPolicy(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.SLAPolicy.Delete(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "sla policy deleted"})
+}
+
+// checkSLAPolicy validates a policy and makes sure no other policy of the
+// organization has its name.
+func checkSLAPolicy(ctx context.Context, db *data.DataAdapter, policy *types.SLAPolicy) error {
+	if policy.Targets == nil {
+		policy.Targets = []types.SLATarget{}
+	}
+
+	err := sla.Validate(policy)
+	if err != nil {
+		return &types.BadRequest{Message: err.Error()}
+	}
+
+	policies, err := db.SLAPolicy.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range policies {
+		if existing.ID != policy.ID && strings.EqualFold(existing.Name, policy.Name) {
+			return &types.BadRequest{Message: fmt.Sprintf("sla policy %s already exists", policy.Name)}
+		}
+	}
+
+	return nil
+}
+
+// applySLA checks the ticket's priority, normal when unset, and sets its SLA
+// timers for the changes since before, nil for a new ticket, under the
+// organization's default policy.
+func applySLA(ctx context.Context, tx *data.DataAdapter, before *types.Ticket, after *types.Ticket) error {
+	if after.Priority == "" {
+		after.Priority = types.PriorityNormal
+	}
+
+	if !slices.Contains(types.Priorities, after.Priority) {
+		return &types.BadRequest{Message: fmt.Sprintf("unknown priority %s", after.Priority)}
+	}
+
+	policies, err := tx.SLAPolicy.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	sla.Apply(sla.Default(policies), before, after, time.Now())
+
+	return nil
+}
+
+type SLAPolicyRequest struct {
+	Name          string            `json:"name"`
+	Default       bool              `json:"default"`
+	Calendar      types.Calendar    `json:"calendar"`
+	Targets       []types.SLATarget `json:"targets"`
+	AtRiskMinutes int               `json:"at_risk_minutes"`
+}
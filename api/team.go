@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+func (s *APIServer) handleGetTeams(w http.ResponseWriter, r *http.Request) error {
+	teams, err := s.db.Team.Get(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "teams found", Data: teams})
+}
+
+func (s *APIServer) handleGetTeam(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	team, err := s.db.Team.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "team found", Data: team})
+}
+
+func (s *APIServer) handleCreateTeam(w http.ResponseWriter, r *http.Request) error {
+	req := TeamRequest{}
+
+	err := decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	team := types.CreateTeam(subject.OrgID, strings.TrimSpace(req.Name), getMemberIDs(req.MemberIDs))
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := checkTeam(r.Context(), tx, team)
+		if err != nil {
+			return err
+		}
+
+		team, err = tx.Team.Create(r.Context(), team)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "team created", Data: team})
+}
+
+func (s *APIServer) handleUpdateTeam(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	req := TeamRequest{}
+
+	err = decodeRequest(r, &req)
+	if err != nil {
+		return err
+	}
+
+	team := &types.Team{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		team, err = tx.Team.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		team.Name = strings.TrimSpace(req.Name)
+		team.MemberIDs = getMemberIDs(req.MemberIDs)
+		team.UpdatedAt = time.Now()
+
+		err = checkTeam(r.Context(), tx, team)
+		if err != nil {
+			return err
+		}
+
+		team, err = tx.Team.Update(r.Context(), team)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "team updated", Data: team})
+}
+
+// handleDeleteTeam removes a team. Tickets in its queue stay where they are,
+// routed to nobody.
+func (s *APIServer) handleDeleteTeam(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Team.Delete(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "team deleted"})
+}
+
+// handleGetTeamQueue lists the unassigned tickets routed to a team, oldest
+// first. Team members see their queue without holding ticket:read.
+func (s *APIServer) handleGetTeamQueue(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(subject.TeamIDs, id) {
+		err = subject.Can(types.PermissionTicketRead, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.db.Team.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	page, err := getPage(r, data.TicketSorts)
+	if err != nil {
+		return err
+	}
+
+	page.Sort = "created_at"
+	page.Desc = false
+
+	filter := &types.TicketFilter{Statuses: types.QueueStatuses, TeamIDs: []int{id}, Unassigned: true}
+
+	tickets, info, err := s.db.Ticket.Get(r.Context(), filter, page)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "tickets found", Data: tickets, NextCursor: info.NextCursor, Total: info.Total})
+}
+
+// handleClaimTicket assigns the oldest ticket in a team's queue to the team
+// member asking for it.
+func (s *APIServer) handleClaimTicket(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(subject.TeamIDs, id) {
+		return &types.Forbidden{Message: fmt.Sprintf("not a member of team %d", id)}
+	}
+
+	ticket := &types.Ticket{}
+	before := types.Ticket{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		ticket, err = tx.Ticket.Claim(r.Context(), id, subject.ID)
+		if err != nil {
+			return err
+		}
+
+		before = *ticket
+		before.AssigneeIDs = []int{}
+
+		return recordChanges(r.Context(), tx, types.TicketChanges(subject.ID, &before, ticket))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyTicket(r.Context(), subject.ID, &before, ticket)
+
+	setETag(w, ticket.Version)
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket claimed", Data: ticket})
+}
+
+// checkTeam makes sure a team has a name no other team in its organization
+// uses and that its members belong to the organization.
+func checkTeam(ctx context.Context, db *data.DataAdapter, team *types.Team) error {
+	if team.Name == "" {
+		return &types.BadRequest{Message: "name is required"}
+	}
+
+	teams, err := db.Team.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range teams {
+		if existing.ID != team.ID && strings.EqualFold(existing.Name, team.Name) {
+			return &types.BadRequest{Message: fmt.Sprintf("team %s already exists", existing.Name)}
+		}
+	}
+
+	for _, id := range team.MemberIDs {
+		_, err := db.Account.GetByID(ctx, id)
+		if err != nil {
+			return &types.BadRequest{Message: fmt.Sprintf("account %d is not a member of this organization", id)}
+		}
+	}
+
+	return nil
+}
+
+func getMemberIDs(ids []int) []int {
+	memberIDs := append([]int{}, ids...)
+	slices.Sort(memberIDs)
+
+	return slices.Compact(memberIDs)
+}
+
+type TeamRequest struct {
+	Name      string `json:"name"`
+	MemberIDs []int  `json:"member_ids"`
+}
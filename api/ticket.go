@@ -1,12 +1,20 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"ticketing-api/auth"
+	"ticketing-api/data"
+	"ticketing-api/policy"
+	"ticketing-api/sla"
 	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
 )
 
 func (s *APIServer) handleCreateTicket(w http.ResponseWriter, r *http.Request) error {
@@ -17,230 +25,823 @@ func (s *APIServer) handleCreateTicket(w http.ResponseWriter, r *http.Request) e
 		return err
 	}
 
-	if req.AuthorID > 0 {
-		err = auth.IsAccountID(r, req.AuthorID, types.RoleAdmin)
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	if req.AuthorID == 0 {
+		req.AuthorID = subject.ID
+	}
+
+	status, err := s.workflow.CheckInitial(req.Status)
+	if err != nil {
+		return err
+	}
+
+	ticket := types.CreateTicket(req.Title, req.Description, req.AuthorID, status, req.AssigneeIDs)
+	ticket.OrgID = subject.OrgID
+	ticket.TeamID = req.TeamID
+	ticket.DueAt = req.DueAt
+	ticket.CategoryID = req.CategoryID
+	ticket.Labels = getLabels(req.Labels)
+
+	if req.Fields != nil {
+		ticket.Fields = req.Fields
+	}
+
+	if req.Priority != "" {
+		ticket.Priority = req.Priority
+	}
+
+	if req.AuthorID != subject.ID || len(req.AssigneeIDs) > 0 || req.TeamID != 0 || ticket.Priority != types.PriorityNormal || req.DueAt != nil {
+		err = subject.Can(types.PermissionTicketAssign, ticket)
 		if err != nil {
 			return err
 		}
 	}
 
 	if req.Status != "" {
-		err = auth.IsAccountID(r, req.AuthorID, types.RoleAdmin, types.RoleEditor)
+		err = subject.Can(types.PermissionTicketStatus, ticket)
 		if err != nil {
 			return err
 		}
 	}
 
-	if len(req.AssigneeIDs) > 0 {
-		err = auth.IsAccountID(r, req.AuthorID, types.RoleAdmin, types.RoleEditor)
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := checkMembers(r.Context(), tx, nil, ticket)
+		if err != nil {
+			return err
+		}
+
+		err = checkTicketFields(r.Context(), tx, nil, ticket)
+		if err != nil {
+			return err
+		}
+
+		err = applySLA(r.Context(), tx, nil, ticket)
+		if err != nil {
+			return err
+		}
+
+		ticket, err = tx.Ticket.Create(r.Context(), ticket)
+		if err != nil {
+			return err
+		}
+
+		err = recordChanges(r.Context(), tx, types.TicketChanges(subject.ID, nil, ticket))
 		if err != nil {
 			return err
 		}
-	}
 
-	ticket := types.CreateTicket(req.Title, req.Description, req.AuthorID, req.Status, req.AssigneeIDs)
+		if len(ticket.AssigneeIDs) > 0 {
+			return nil
+		}
 
-	ticket, err = s.db.Ticket.Create(ticket)
+		ticket, err = s.autoAssign(r.Context(), tx, subject.ID, ticket)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
+	s.notifyTicket(r.Context(), subject.ID, nil, ticket)
+
 	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket created", Data: ticket})
 }
 
 func (s *APIServer) handleGetTickets(w http.ResponseWriter, r *http.Request) error {
+	filter, err := s.getTicketFilter(r)
+	if err != nil {
+		return err
+	}
 
-	authorID, err := getAuthorID(r)
+	page, err := getPage(r, data.TicketSorts)
 	if err != nil {
 		return err
 	}
 
-	err = auth.IsAccountID(r, authorID, types.RoleAdmin, types.RoleEditor)
+	tickets, info, err := s.db.Ticket.Get(r.Context(), filter, page)
 	if err != nil {
 		return err
 	}
 
-	assigneeIDs, err := getAssigneeIDs(r)
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "tickets found", Data: tickets, NextCursor: info.NextCursor, Total: info.Total})
+}
+
+// handleGetMyTickets lists the tickets the caller wrote or is assigned to,
+// accepting the same filters as GET /ticket.
+func (s *APIServer) handleGetMyTickets(w http.ResponseWriter, r *http.Request) error {
+	filter, err := s.getTicketFilter(r)
 	if err != nil {
 		return err
 	}
 
-	tickets := []*types.Ticket{}
+	filter.ParticipantID, err = auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
 
-	if authorID != 0 && len(assigneeIDs) > 0 {
-		tickets, err = s.db.Ticket.GetByAuthorIDAssigneeIDs(authorID, assigneeIDs)
+	page, err := getPage(r, data.TicketSorts)
+	if err != nil {
+		return err
+	}
+
+	tickets, info, err := s.db.Ticket.Get(r.Context(), filter, page)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "tickets found", Data: tickets, NextCursor: info.NextCursor, Total: info.Total})
+}
+
+func (s *APIServer) handleGetTicketByID(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	err = subject.Can(types.PermissionTicketRead, ticket)
+	if err != nil {
+		return err
+	}
+
+	setETag(w, ticket.Version)
+
+	if r.URL.Query().Get("embed") == "links" {
+		links, err := s.getLinks(r.Context(), subject, ticket.ID)
 		if err != nil {
 			return err
 		}
-	} else if authorID != 0 {
-		tickets, err = s.db.Ticket.GetByAuthorID(authorID)
+
+		return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket found", Data: &TicketWithLinks{Ticket: ticket, Links: links}})
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket found", Data: ticket})
+}
+
+func (s *APIServer) handleUpdateTicket(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
+	}
+
+	req := &CreateTicketRequest{}
+
+	err = decodeRequest(r, req)
+	if err != nil {
+		return err
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	ticket := &types.Ticket{}
+	before := types.Ticket{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		ticket, err = tx.Ticket.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		err = subject.Can(types.PermissionTicketRead, ticket)
+		if err != nil {
+			return err
+		}
+
+		before = *ticket
+
+		ticket.Version, err = getIfMatch(r, ticket.Version)
+		if err != nil {
+			return err
+		}
+
+		if req.Title != "" {
+			ticket.Title = req.Title
+		}
+
+		if req.Description != "" {
+			ticket.Description = req.Description
+		}
+
+		if req.AuthorID > 0 {
+			ticket.AuthorID = req.AuthorID
+		}
+
+		if len(req.AssigneeIDs) > 0 {
+			ticket.AssigneeIDs = req.AssigneeIDs
+		}
+
+		if req.TeamID > 0 {
+			ticket.TeamID = req.TeamID
+		}
+
+		if req.Resolution != "" {
+			ticket.Resolution = req.Resolution
+		}
+
+		if req.Status != "" {
+			ticket.Status = req.Status
+		}
+
+		if req.Priority != "" {
+			ticket.Priority = req.Priority
+		}
+
+		if req.DueAt != nil {
+			ticket.DueAt = req.DueAt
+		}
+
+		if req.CategoryID > 0 {
+			ticket.CategoryID = req.CategoryID
+		}
+
+		if req.Labels != nil {
+			ticket.Labels = getLabels(req.Labels)
+		}
+
+		// a copy, as before shares the map; checkTicketFields drops nulls
+		fields := types.Fields{}
+		maps.Copy(fields, ticket.Fields)
+		maps.Copy(fields, req.Fields)
+		ticket.Fields = fields
+
+		err = s.checkTicketChanges(subject, &before, ticket)
+		if err != nil {
+			return err
+		}
+
+		// a reopened ticket has to be resolved afresh
+		if reopened(&before, ticket) {
+			ticket.Resolution = ""
+		}
+
+		err = checkMembers(r.Context(), tx, &before, ticket)
+		if err != nil {
+			return err
+		}
+
+		err = checkTicketFields(r.Context(), tx, &before, ticket)
 		if err != nil {
 			return err
 		}
-	} else if len(assigneeIDs) > 0 {
-		tickets, err = s.db.Ticket.GetByAssigneeIDs(assigneeIDs)
+
+		err = applySLA(r.Context(), tx, &before, ticket)
+		if err != nil {
+			return err
+		}
+
+		ticket, err = tx.Ticket.Update(r.Context(), ticket)
 		if err != nil {
 			return err
 		}
-	} else {
-		tickets, err = s.db.Ticket.Get()
+
+		err = recordChanges(r.Context(), tx, types.TicketChanges(subject.ID, &before, ticket))
 		if err != nil {
 			return err
 		}
+
+		if !reopened(&before, ticket) {
+			return nil
+		}
+
+		ticket, err = s.autoAssign(r.Context(), tx, subject.ID, ticket)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "tickets found", Data: tickets})
+	s.notifyTicket(r.Context(), subject.ID, &before, ticket)
+
+	setETag(w, ticket.Version)
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket updated", Data: ticket})
 }
 
-func (s *APIServer) handleGetTicketByID(w http.ResponseWriter, r *http.Request) error {
+func (s *APIServer) handlePatchTicket(w http.ResponseWriter, r *http.Request) error {
 	id, err := getID(r)
 	if err != nil {
 		return err
 	}
 
-	ticket, err := s.db.Ticket.GetByID(id)
+	subject, err := s.policy.Subject(r)
 	if err != nil {
 		return err
 	}
 
-	err = auth.IsAccountID(r, ticket.AuthorID, types.RoleAdmin, types.RoleEditor)
+	patch, err := decodePatch(r)
 	if err != nil {
 		return err
 	}
 
-	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket found", Data: ticket})
+	ticket := &types.Ticket{}
+	before := &types.Ticket{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		current, err := tx.Ticket.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		before = current
+
+		err = subject.Can(types.PermissionTicketRead, current)
+		if err != nil {
+			return err
+		}
+
+		version, err := getIfMatch(r, current.Version)
+		if err != nil {
+			return err
+		}
+
+		patched, fields, err := applyPatch(patch, current)
+		if err != nil {
+			return err
+		}
+
+		for _, field := range fields {
+			if _, ok := ticketFieldPermissions[field]; !ok {
+				return &types.BadRequest{Message: fmt.Sprintf("%s cannot be patched", field)}
+			}
+		}
+
+		if patched.AssigneeIDs == nil {
+			patched.AssigneeIDs = []int{}
+		}
+
+		patched.Labels = getLabels(patched.Labels)
+
+		if strings.TrimSpace(patched.Title) == "" {
+			return &types.BadRequest{Message: "title is required"}
+		}
+
+		if patched.AuthorID <= 0 {
+			return &types.BadRequest{Message: "author_id is required"}
+		}
+
+		err = s.checkTicketChanges(subject, current, patched)
+		if err != nil {
+			return err
+		}
+
+		// a reopened ticket has to be resolved afresh
+		if reopened(current, patched) {
+			patched.Resolution = ""
+		}
+
+		err = checkMembers(r.Context(), tx, current, patched)
+		if err != nil {
+			return err
+		}
+
+		err = checkTicketFields(r.Context(), tx, current, patched)
+		if err != nil {
+			return err
+		}
+
+		err = applySLA(r.Context(), tx, current, patched)
+		if err != nil {
+			return err
+		}
+
+		patched.Version = version
+
+		ticket, err = tx.Ticket.Update(r.Context(), patched)
+		if err != nil {
+			return err
+		}
+
+		err = recordChanges(r.Context(), tx, types.TicketChanges(subject.ID, current, ticket))
+		if err != nil {
+			return err
+		}
+
+		if !reopened(current, ticket) {
+			return nil
+		}
+
+		ticket, err = s.autoAssign(r.Context(), tx, subject.ID, ticket)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyTicket(r.Context(), subject.ID, before, ticket)
+
+	setETag(w, ticket.Version)
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket patched", Data: ticket})
 }
 
-func (s *APIServer) handleUpdateTicket(w http.ResponseWriter, r *http.Request) error {
+// ticketFieldPermissions names the permission needed to change each ticket
+// field. Status changes are decided by the workflow instead.
+var ticketFieldPermissions = map[string]types.Permission{
+	"title":        types.PermissionTicketUpdate,
+	"description":  types.PermissionTicketUpdate,
+	"author_id":    types.PermissionTicketAssign,
+	"assignee_ids": types.PermissionTicketAssign,
+	"team_id":      types.PermissionTicketAssign,
+	"priority":     types.PermissionTicketAssign,
+	"due_at":       types.PermissionTicketAssign,
+	"category_id":  types.PermissionTicketUpdate,
+	"labels":       types.PermissionTicketUpdate,
+	"fields":       types.PermissionTicketUpdate,
+	"resolution":   types.PermissionTicketStatus,
+	"status":       "",
+}
+
+// checkTicketChanges asks whether subject may make each change between before
+// and after, then runs any status change through the workflow. A change to
+// fields.<key> needs the permission of fields.
+func (s *APIServer) checkTicketChanges(subject *policy.Subject, before *types.Ticket, after *types.Ticket) error {
+	for _, change := range types.TicketChanges(subject.ID, before, after) {
+		field, _, _ := strings.Cut(change.Field, ".")
+		if permission := ticketFieldPermissions[field]; permission != "" {
+			err := subject.Can(permission, before)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.workflow.Check(getActor(subject), after, before.Status, after.Status)
+}
+
+// checkMembers makes sure the author and assignees added to a ticket belong
+// to, or are members of, the organization the request is scoped to, and that
+// the team it is routed to is one of the organization's. A nil before checks
+// every account on a new ticket.
+func checkMembers(ctx context.Context, db *data.DataAdapter, before *types.Ticket, after *types.Ticket) error {
+	if before == nil {
+		before = &types.Ticket{}
+	}
+
+	for _, id := range append([]int{after.AuthorID}, after.AssigneeIDs...) {
+		if id == before.AuthorID || slices.Contains(before.AssigneeIDs, id) {
+			continue
+		}
+
+		_, err := db.Account.GetByID(ctx, id)
+		if err != nil {
+			return &types.BadRequest{Message: fmt.Sprintf("account %d is not a member of this organization", id)}
+		}
+	}
+
+	if after.TeamID != 0 && after.TeamID != before.TeamID {
+		_, err := db.Team.GetByID(ctx, after.TeamID)
+		if err != nil {
+			return &types.BadRequest{Message: fmt.Sprintf("team %d is not a team of this organization", after.TeamID)}
+		}
+	}
+
+	return nil
+}
+
+func (s *APIServer) handleDeleteTicket(w http.ResponseWriter, r *http.Request) error {
 	id, err := getID(r)
 	if err != nil {
 		return err
 	}
 
-	ticket, err := s.db.Ticket.GetByID(id)
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
 	if err != nil {
 		return err
 	}
 
-	err = auth.IsAccountID(r, ticket.AuthorID, types.RoleAdmin, types.RoleEditor)
+	err = s.policy.Authorize(r, types.PermissionTicketDelete, ticket)
 	if err != nil {
 		return err
 	}
 
-	req := &CreateTicketRequest{}
+	actorID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
+	}
 
-	err = decodeRequest(r, req)
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := tx.Ticket.Delete(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, []*types.Change{types.CreateChange(types.EntityTicket, id, actorID, "deleted", "false", "true")})
+	})
 	if err != nil {
 		return err
 	}
 
-	if req.Title != "" {
-		ticket.Title = req.Title
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket deleted"})
+}
+
+func (s *APIServer) handleRestoreTicket(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
 	}
 
-	if req.Description != "" {
-		ticket.Description = req.Description
+	actorID, err := auth.GetAccountID(r)
+	if err != nil {
+		return err
 	}
 
-	if req.AuthorID > 0 {
-		err = auth.IsRole(r, types.RoleEditor, types.RoleAdmin)
+	ticket := &types.Ticket{}
+
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := tx.Ticket.Restore(r.Context(), id)
 		if err != nil {
 			return err
 		}
 
-		ticket.AuthorID = req.AuthorID
-	}
-
-	if req.Status != "" {
-		err = auth.IsAccountID(r, req.AuthorID, types.RoleAdmin, types.RoleEditor)
+		ticket, err = tx.Ticket.GetByID(r.Context(), id)
 		if err != nil {
 			return err
 		}
 
-		ticket.Status = req.Status
+		return recordChanges(r.Context(), tx, []*types.Change{types.CreateChange(types.EntityTicket, id, actorID, "deleted", "true", "false")})
+	})
+	if err != nil {
+		return err
 	}
 
-	if len(req.AssigneeIDs) > 0 {
-		err = auth.IsAccountID(r, req.AuthorID, types.RoleAdmin, types.RoleEditor)
-		if err != nil {
-			return err
-		}
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket restored", Data: ticket})
+}
 
-		ticket.AssigneeIDs = req.AssigneeIDs
+// handlePurgeTicket permanently removes a deleted ticket, its attachments and
+// its chat messages. Those go once the ticket is found and the purge allowed,
+// but before the ticket itself, so that a failed purge can simply be retried.
+func (s *APIServer) handlePurgeTicket(w http.ResponseWriter, r *http.Request) error {
+	id, err := getID(r)
+	if err != nil {
+		return err
 	}
 
-	ticket, err = s.db.Ticket.Update(ticket)
+	actorID, err := auth.GetAccountID(r)
 	if err != nil {
 		return err
 	}
 
-	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket updated", Data: ticket})
-}
+	ticket, err := s.db.Ticket.GetDeleted(r.Context(), id)
+	if err != nil {
+		return err
+	}
 
-func (s *APIServer) handleDeleteTicket(w http.ResponseWriter, r *http.Request) error {
-	id, err := getID(r)
+	err = s.policy.Authorize(r, types.PermissionTicketPurge, ticket)
 	if err != nil {
 		return err
 	}
 
-	ticket, err := s.db.Ticket.GetByID(id)
+	err = s.deleteAttachments(r.Context(), id)
 	if err != nil {
 		return err
 	}
 
-	err = auth.IsAccountID(r, ticket.AuthorID, types.RoleAdmin, types.RoleEditor)
+	err = s.db.Message.DeleteByTicketID(r.Context(), id)
 	if err != nil {
 		return err
 	}
 
-	err = s.db.Ticket.Delete(id)
+	err = s.db.Transaction(r.Context(), func(tx *data.DataAdapter) error {
+		err := tx.Ticket.Purge(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return recordChanges(r.Context(), tx, []*types.Change{types.CreateChange(types.EntityTicket, id, actorID, "purged", "false", "true")})
+	})
 	if err != nil {
 		return err
 	}
 
-	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket deleted"})
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "ticket purged"})
 }
 
-func getAuthorID(r *http.Request) (int, error) {
-	authorIDStr := r.URL.Query().Get("author_id")
+func getActor(subject *policy.Subject) *workflow.Actor {
+	return &workflow.Actor{ID: subject.ID, Role: subject.Role, Permissions: subject.Permissions}
+}
 
-	if authorIDStr == "" {
-		return 0, nil
+// getTicketFilter reads the ticket filter from the query string, e.g.
+// ?status=open,pending&assignee=me&created_after=2026-01-01&sla=at_risk&
+// label=vip&category=3&field.customer_id=ACME. author_id and the space
+// separated assignee_ids are still accepted. Tickets are at risk within the
+// window of the organization's default SLA policy, and a category matches
+// its subcategories too.
+func (s *APIServer) getTicketFilter(r *http.Request) (*types.TicketFilter, error) {
+	query := r.URL.Query()
+	filter := &types.TicketFilter{
+		Title: query.Get("title"),
 	}
 
-	authorID, err := strconv.Atoi(authorIDStr)
+	for _, status := range splitList(query.Get("status"), ",") {
+		filter.Statuses = append(filter.Statuses, types.Status(status))
+	}
+
+	authorIDs, err := getAccountIDs(r, "author", ",")
 	if err != nil {
-		return authorID, fmt.Errorf("error getting author_id")
+		return nil, err
 	}
 
-	return authorID, nil
-}
+	legacyAuthorIDs, err := getAccountIDs(r, "author_id", ",")
+	if err != nil {
+		return nil, err
+	}
+
+	filter.AuthorIDs = append(authorIDs, legacyAuthorIDs...)
+
+	assigneeIDs, err := getAccountIDs(r, "assignee", ",")
+	if err != nil {
+		return nil, err
+	}
+
+	legacyAssigneeIDs, err := getAccountIDs(r, "assignee_ids", " ")
+	if err != nil {
+		return nil, err
+	}
+
+	filter.AssigneeIDs = append(assigneeIDs, legacyAssigneeIDs...)
+
+	for _, idStr := range splitList(query.Get("team"), ",") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return nil, &types.BadRequest{Message: "invalid team"}
+		}
+
+		filter.TeamIDs = append(filter.TeamIDs, id)
+	}
+
+	for _, priority := range splitList(query.Get("priority"), ",") {
+		if !slices.Contains(types.Priorities, types.Priority(priority)) {
+			return nil, &types.BadRequest{Message: fmt.Sprintf("unknown priority %s", priority)}
+		}
+
+		filter.Priorities = append(filter.Priorities, types.Priority(priority))
+	}
+
+	categoryIDs := []int{}
+
+	for _, idStr := range splitList(query.Get("category"), ",") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			return nil, &types.BadRequest{Message: "invalid category"}
+		}
+
+		categoryIDs = append(categoryIDs, id)
+	}
+
+	if len(categoryIDs) > 0 {
+		categories, err := s.db.Category.Get(r.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		filter.CategoryIDs = categorySubtree(categories, categoryIDs)
+	}
 
-func getAssigneeIDs(r *http.Request) ([]int, error) {
-	assigneeIDsStr := r.URL.Query().Get("assignee_ids")
+	filter.Labels = splitList(query.Get("label"), ",")
 
-	assigneeIDs := []int{}
+	filter.Fields, err = getFieldFilters(r.Context(), s.db, r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch state := types.SLAState(query.Get("sla")); state {
+	case "":
+	case types.SLABreached, types.SLAAtRisk:
+		policies, err := s.db.SLAPolicy.Get(r.Context())
+		if err != nil {
+			return nil, err
+		}
 
-	if assigneeIDsStr == "" {
-		return assigneeIDs, nil
+		filter.SLA = state
+		filter.SLANow = time.Now()
+		filter.SLAWindow = sla.AtRiskWindow(sla.Default(policies))
+	default:
+		return nil, &types.BadRequest{Message: "sla must be breached or at_risk"}
 	}
 
-	for _, idStr := range strings.Split(assigneeIDsStr, " ") {
+	switch query.Get("unassigned") {
+	case "", "false":
+	case "true":
+		filter.Unassigned = true
+	default:
+		return nil, &types.BadRequest{Message: "unassigned must be true or false"}
+	}
+
+	dates := map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+		"updated_after":  &filter.UpdatedAfter,
+		"updated_before": &filter.UpdatedBefore,
+	}
+
+	for key, date := range dates {
+		*date, err = getDate(r, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return filter, nil
+}
+
+// getAccountIDs reads a list of account ids from the query string, where "me"
+// stands for the authenticated account.
+func getAccountIDs(r *http.Request, key string, sep string) ([]int, error) {
+	ids := []int{}
+
+	for _, idStr := range splitList(r.URL.Query().Get(key), sep) {
+		if idStr == "me" {
+			id, err := auth.GetAccountID(r)
+			if err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, id)
+			continue
+		}
+
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			return assigneeIDs, fmt.Errorf("error getting assignee_ids")
+			return nil, &types.BadRequest{Message: fmt.Sprintf("invalid %s", key)}
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func getDate(r *http.Request, key string) (*time.Time, error) {
+	dateStr := r.URL.Query().Get(key)
+	if dateStr == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		date, err := time.Parse(layout, dateStr)
+		if err == nil {
+			return &date, nil
+		}
+	}
+
+	return nil, &types.BadRequest{Message: fmt.Sprintf("%s must be a date or RFC 3339 timestamp", key)}
+}
+
+// getLabels trims labels, dropping empty and repeated ones, and sorts them.
+func getLabels(labels []string) []string {
+	trimmed := []string{}
+
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label != "" && !slices.Contains(trimmed, label) {
+			trimmed = append(trimmed, label)
 		}
+	}
 
-		assigneeIDs = append(assigneeIDs, id)
+	slices.Sort(trimmed)
+
+	return trimmed
+}
+
+func splitList(s string, sep string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(s, sep) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
 	}
 
-	return assigneeIDs, nil
+	return items
 }
 
 type CreateTicketRequest struct {
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	AuthorID    int          `json:"author_id"`
-	Status      types.Status `json:"status"`
-	AssigneeIDs []int        `json:"assignee_ids"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	AuthorID    int            `json:"author_id"`
+	Status      types.Status   `json:"status"`
+	AssigneeIDs []int          `json:"assignee_ids"`
+	TeamID      int            `json:"team_id"`
+	Priority    types.Priority `json:"priority"`
+	DueAt       *time.Time     `json:"due_at"`
+	Resolution  string         `json:"resolution"`
+	CategoryID  int            `json:"category_id"`
+	Labels      []string       `json:"labels"`
+	Fields      types.Fields   `json:"fields"`
 }
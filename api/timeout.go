@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Timeouts struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// ParseTimeouts reads a default query deadline and a comma separated list of
+// per-route overrides keyed by route pattern, e.g. "GET /ticket=30s".
+func ParseTimeouts(defaultTimeout string, routeTimeouts string) (*Timeouts, error) {
+	timeouts := &Timeouts{
+		Routes: make(map[string]time.Duration),
+	}
+
+	if defaultTimeout != "" {
+		d, err := time.ParseDuration(defaultTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default timeout: %s", defaultTimeout)
+		}
+
+		timeouts.Default = d
+	}
+
+	for _, route := range strings.Split(routeTimeouts, ",") {
+		if strings.TrimSpace(route) == "" {
+			continue
+		}
+
+		pattern, timeout, ok := strings.Cut(route, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route timeout: %s", route)
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(timeout))
+		if err != nil {
+			return nil, fmt.Errorf("invalid route timeout: %s", route)
+		}
+
+		timeouts.Routes[strings.TrimSpace(pattern)] = d
+	}
+
+	return timeouts, nil
+}
+
+func (t *Timeouts) For(pattern string) time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	if d, ok := t.Routes[pattern]; ok {
+		return d
+	}
+
+	return t.Default
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"ticketing-api/types"
+)
+
+// handleWatchTicket subscribes an account to a ticket, which lets it read the
+// ticket and its chat. Accounts may watch tickets they can read, and those
+// who can update a ticket may add any member of the organization.
+func (s *APIServer) handleWatchTicket(w http.ResponseWriter, r *http.Request) error {
+	ticket, accountID, err := s.getWatcher(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Account.GetByID(r.Context(), accountID)
+	if err != nil {
+		return &types.BadRequest{Message: fmt.Sprintf("account %d is not a member of this organization", accountID)}
+	}
+
+	err = s.db.Ticket.Watch(r.Context(), ticket.ID, accountID)
+	if err != nil {
+		return err
+	}
+
+	ticket, err = s.db.Ticket.GetByID(r.Context(), ticket.ID)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "watcher added", Data: ticket.WatcherIDs})
+}
+
+// handleUnwatchTicket unsubscribes an account, the author and assignees
+// included, who keep reading the ticket as such.
+func (s *APIServer) handleUnwatchTicket(w http.ResponseWriter, r *http.Request) error {
+	ticket, accountID, err := s.getWatcher(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Ticket.Unwatch(r.Context(), ticket.ID, accountID)
+	if err != nil {
+		return err
+	}
+
+	ticket, err = s.db.Ticket.GetByID(r.Context(), ticket.ID)
+	if err != nil {
+		return err
+	}
+
+	return encodeResponse(w, http.StatusOK, &APIResponse{Status: http.StatusOK, Message: "watcher removed", Data: ticket.WatcherIDs})
+}
+
+// getWatcher reads the ticket of the path and the account of an optional
+// WatcherRequest body, the caller when left out, and checks the caller may
+// change whether that account watches the ticket.
+func (s *APIServer) getWatcher(r *http.Request) (*types.Ticket, int, error) {
+	id, err := getID(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req := &WatcherRequest{}
+
+	if r.ContentLength != 0 {
+		err = decodeRequest(r, req)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	subject, err := s.policy.Subject(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ticket, err := s.db.Ticket.GetByID(r.Context(), id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.AccountID == 0 || req.AccountID == subject.ID {
+		err = subject.Can(types.PermissionTicketRead, ticket)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return ticket, subject.ID, nil
+	}
+
+	err = subject.Can(types.PermissionTicketUpdate, ticket)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ticket, req.AccountID, nil
+}
+
+type WatcherRequest struct {
+	AccountID int `json:"account_id"`
+}
@@ -0,0 +1,206 @@
+package assignment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"ticketing-api/search"
+	"ticketing-api/types"
+)
+
+// Strategy picks which of the eligible agents a ticket goes to and says why.
+type Strategy interface {
+	Name() string
+	Pick(ticket *types.Ticket, agents []*types.Agent) (*types.Agent, string)
+}
+
+var strategies = []Strategy{RoundRobin{}, LeastLoaded{}, SkillBased{}}
+
+// Parse returns the strategy called name.
+func Parse(name string) (Strategy, error) {
+	names := []string{}
+
+	for _, strategy := range strategies {
+		if strategy.Name() == name {
+			return strategy, nil
+		}
+
+		names = append(names, strategy.Name())
+	}
+
+	return nil, fmt.Errorf("assignment strategy must be one of: %s", strings.Join(names, ", "))
+}
+
+type Assigner struct {
+	strategy Strategy
+}
+
+func CreateAssigner(strategy Strategy) *Assigner {
+	return &Assigner{
+		strategy: strategy,
+	}
+}
+
+// Decision is the agent a ticket went to, nil when nobody could take it, and
+// the reason why.
+type Decision struct {
+	Agent    *types.Agent
+	Strategy string
+	Reason   string
+}
+
+// skipReasons orders the reasons agents were passed over in a decision.
+var skipReasons = []string{string(types.AvailabilityAway), string(types.AvailabilityOffline), "at capacity", "the author"}
+
+// Decide picks an agent for ticket. Agents that are away, offline, at
+// capacity or the ticket's author are passed over, and a ticket routed to a
+// team only goes to the team's members.
+func (a *Assigner) Decide(ticket *types.Ticket, team *types.Team, agents []*types.Agent) *Decision {
+	eligible := []*types.Agent{}
+	skipped := map[string]int{}
+
+	for _, agent := range agents {
+		switch {
+		case team != nil && !slices.Contains(team.MemberIDs, agent.AccountID):
+		case agent.Availability != types.AvailabilityAvailable:
+			skipped[string(agent.Availability)]++
+		case agent.Capacity > 0 && agent.Load >= agent.Capacity:
+			skipped["at capacity"]++
+		case agent.AccountID == ticket.AuthorID:
+			skipped["the author"]++
+		default:
+			eligible = append(eligible, agent)
+		}
+	}
+
+	decision := &Decision{Strategy: a.strategy.Name()}
+
+	if len(eligible) == 0 {
+		decision.Reason = "no eligible agent"
+
+		details := []string{}
+		for _, reason := range skipReasons {
+			if skipped[reason] > 0 {
+				details = append(details, fmt.Sprintf("%d %s", skipped[reason], reason))
+			}
+		}
+
+		if len(details) > 0 {
+			decision.Reason += ": " + strings.Join(details, ", ")
+		}
+
+		if team != nil {
+			decision.Reason += fmt.Sprintf(" in team %s", team.Name)
+		}
+
+		return decision
+	}
+
+	decision.Agent, decision.Reason = a.strategy.Pick(ticket, eligible)
+
+	return decision
+}
+
+// RoundRobin rotates through agents by picking the one automatic assignment
+// picked longest ago, agents never picked first.
+type RoundRobin struct{}
+
+func (RoundRobin) Name() string {
+	return "round_robin"
+}
+
+func (RoundRobin) Pick(ticket *types.Ticket, agents []*types.Agent) (*types.Agent, string) {
+	agent := slices.MinFunc(agents, compareLastAssigned)
+
+	return agent, fmt.Sprintf("next in rotation of %d eligible agents", len(agents))
+}
+
+// LeastLoaded picks the agent with the fewest open tickets, rotating between
+// agents with as few.
+type LeastLoaded struct{}
+
+func (LeastLoaded) Name() string {
+	return "least_loaded"
+}
+
+func (LeastLoaded) Pick(ticket *types.Ticket, agents []*types.Agent) (*types.Agent, string) {
+	agent := slices.MinFunc(agents, compareLoad)
+
+	return agent, fmt.Sprintf("fewest open tickets (%d) of %d eligible agents", agent.Load, len(agents))
+}
+
+// SkillBased picks the agent with the most skills mentioned in the ticket's
+// title and description, least loaded first among equals. A skill of several
+// words matches when the ticket mentions all of them.
+type SkillBased struct{}
+
+func (SkillBased) Name() string {
+	return "skill"
+}
+
+func (SkillBased) Pick(ticket *types.Ticket, agents []*types.Agent) (*types.Agent, string) {
+	terms := search.Terms(ticket.Title + " " + ticket.Description)
+	matches := map[int][]string{}
+
+	for _, agent := range agents {
+		matches[agent.AccountID] = matchSkills(agent.Skills, terms)
+	}
+
+	agent := slices.MinFunc(agents, func(a *types.Agent, b *types.Agent) int {
+		if diff := len(matches[b.AccountID]) - len(matches[a.AccountID]); diff != 0 {
+			return diff
+		}
+
+		return compareLoad(a, b)
+	})
+
+	if len(matches[agent.AccountID]) == 0 {
+		agent, reason := LeastLoaded{}.Pick(ticket, agents)
+		return agent, "no skill matched, " + reason
+	}
+
+	return agent, fmt.Sprintf("matched skills %s with %d open tickets", strings.Join(matches[agent.AccountID], ", "), agent.Load)
+}
+
+func matchSkills(skills []string, terms []string) []string {
+	matched := []string{}
+
+	for _, skill := range skills {
+		skillTerms := search.Terms(skill)
+		if len(skillTerms) == 0 {
+			continue
+		}
+
+		found := true
+		for _, term := range skillTerms {
+			found = found && slices.Contains(terms, term)
+		}
+
+		if found {
+			matched = append(matched, skill)
+		}
+	}
+
+	return matched
+}
+
+func compareLoad(a *types.Agent, b *types.Agent) int {
+	if a.Load != b.Load {
+		return a.Load - b.Load
+	}
+
+	return compareLastAssigned(a, b)
+}
+
+func compareLastAssigned(a *types.Agent, b *types.Agent) int {
+	switch {
+	case a.LastAssignedAt == nil && b.LastAssignedAt != nil:
+		return -1
+	case a.LastAssignedAt != nil && b.LastAssignedAt == nil:
+		return 1
+	case a.LastAssignedAt != nil && !a.LastAssignedAt.Equal(*b.LastAssignedAt):
+		return a.LastAssignedAt.Compare(*b.LastAssignedAt)
+	}
+
+	return a.AccountID - b.AccountID
+}
@@ -1,9 +1,9 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"os"
 	"ticketing-api/types"
 	"time"
 
@@ -70,7 +70,70 @@ func GetRole(r *http.Request) (types.Role, error) {
 	return types.Role(claims["role"].(string)), nil
 }
 
+// GetOrganizationID returns the organization the token was issued for.
+func GetOrganizationID(r *http.Request) (int, error) {
+	claims, err := getClaims(r)
+	if err != nil {
+		return 0, err
+	}
+
+	orgID, _ := claims["org"].(float64)
+
+	return int(orgID), nil
+}
+
+func IsSuperAdmin(r *http.Request) (bool, error) {
+	claims, err := getClaims(r)
+	if err != nil {
+		return false, err
+	}
+
+	superAdmin, _ := claims["super"].(bool)
+
+	return superAdmin, nil
+}
+
+// GetSessionID returns the session the access token was issued for.
+func GetSessionID(r *http.Request) (string, error) {
+	claims, err := getClaims(r)
+	if err != nil {
+		return "", err
+	}
+
+	sessionID, _ := claims["sid"].(string)
+
+	return sessionID, nil
+}
+
+type authenticationKey struct{}
+
+// authentication is what Authenticate found out about a request: the claims
+// of its token, or why it has none.
+type authentication struct {
+	claims jwt.MapClaims
+	err    error
+}
+
+// Authenticate checks the token of r, returning r with the outcome in its
+// context for the functions above to read, so that a request is checked once
+// and answered consistently however often it asks.
+func Authenticate(r *http.Request) *http.Request {
+	claims, err := parseClaims(r)
+	return r.WithContext(context.WithValue(r.Context(), authenticationKey{}, &authentication{claims: claims, err: err}))
+}
+
 func getClaims(r *http.Request) (jwt.MapClaims, error) {
+	authn, ok := r.Context().Value(authenticationKey{}).(*authentication)
+	if !ok {
+		return nil, &types.Unauthorized{
+			Message: "invalid token",
+		}
+	}
+
+	return authn.claims, authn.err
+}
+
+func parseClaims(r *http.Request) (jwt.MapClaims, error) {
 	tokenStr := r.Header.Get("Authorization")
 	if len(tokenStr) > 7 && tokenStr[:7] == "Bearer " {
 		tokenStr = tokenStr[7:]
@@ -83,17 +146,60 @@ func getClaims(r *http.Request) (jwt.MapClaims, error) {
 		}
 	}
 
-	return token.Claims.(jwt.MapClaims), nil
+	claims := token.Claims.(jwt.MapClaims)
+
+	// every token belongs to a session, so that none escapes revocation
+	sessionID, _ := claims["sid"].(string)
+	if sessionID == "" {
+		return nil, &types.Unauthorized{
+			Message: "invalid token",
+		}
+	}
+
+	if isRevoked(r.Context(), sessionID) {
+		return nil, &types.Unauthorized{
+			Message: "session revoked",
+		}
+	}
+
+	return claims, nil
 }
 
-func GenerateJWT(a *types.Account) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"exp":  time.Now().Add(time.Hour * 24).Unix(),
+// GenerateSessionJWT issues a short lived access token tied to sessionID, so
+// that revoking the session also rejects the token. The token is scoped to
+// the account's OrgID with the account's Role in it.
+func GenerateSessionJWT(a *types.Account, sessionID string) (string, error) {
+	if sessionID == "" {
+		return "", fmt.Errorf("failed to generate token: missing session")
+	}
+
+	claims := jwt.MapClaims{
+		"exp":  time.Now().Add(AccessTokenTTL).Unix(),
+		"iat":  time.Now().Unix(),
 		"id":   a.ID,
+		"org":  a.OrgID,
 		"role": a.Role,
-	})
+		"sid":  sessionID,
+	}
 
-	signedToken, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if a.SuperAdmin {
+		claims["super"] = true
+	}
+
+	keys, err := currentKeys()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token")
+	}
+
+	key, err := keys.signingKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token")
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	signedToken, err := token.SignedString(key.private)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate token")
 	}
@@ -101,14 +207,28 @@ func GenerateJWT(a *types.Account) (string, error) {
 	return signedToken, nil
 }
 
+// ValidateJWT verifies t with the key named by its kid header, which must
+// match the algorithm the token claims.
 func ValidateJWT(t string) (*jwt.Token, error) {
 	return jwt.Parse(t, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		keys, err := currentKeys()
+		if err != nil {
+			return nil, err
+		}
+
+		kid, _ := t.Header["kid"].(string)
+
+		key, ok := keys.verificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key: %v", t.Header["kid"])
+		}
+
+		if t.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
 
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
 }
 
 func CompareHashAndPassword(hash, password string) error {
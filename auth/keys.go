@@ -0,0 +1,395 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a token signing key identified by its kid. Keys without a private
+// half can only verify tokens.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	path      string
+	retiredAt *time.Time
+}
+
+// KeySet signs with its newest private key and verifies with every key it
+// holds. Rotated out keys stay for verification until tokens signed with them
+// have expired.
+type KeySet struct {
+	mu   sync.RWMutex
+	dir  string
+	keys []*Key
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+var (
+	keysMu sync.Mutex
+	keys   *KeySet
+)
+
+func UseKeys(k *KeySet) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	keys = k
+}
+
+// currentKeys returns the configured key set, falling back to a single
+// ephemeral key whose tokens stop verifying when the process exits.
+func currentKeys() (*KeySet, error) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	if keys == nil {
+		k, err := GenerateKeySet()
+		if err != nil {
+			return nil, err
+		}
+
+		keys = k
+	}
+
+	return keys, nil
+}
+
+func GetJWKS() (*JWKS, error) {
+	k, err := currentKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return k.JWKS(), nil
+}
+
+func GenerateKeySet() (*KeySet, error) {
+	k := &KeySet{}
+
+	err := k.Rotate()
+	if err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// LoadKeys reads every .pem file in dir, using the file name as the kid. RSA
+// keys sign with RS256 and Ed25519 keys with EdDSA. The most recently written
+// private key signs, and older private keys count as retired from when the
+// next one was written. Public keys are accepted for verification only. A dir
+// without a private key gets a new one.
+func LoadKeys(dir string) (*KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	modified := map[string]time.Time{}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading key %s: %w", file, err)
+		}
+
+		modified[file] = info.ModTime()
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if !modified[files[i]].Equal(modified[files[j]]) {
+			return modified[files[i]].Before(modified[files[j]])
+		}
+
+		return files[i] < files[j]
+	})
+
+	k := &KeySet{dir: dir}
+
+	for _, file := range files {
+		key, err := readKey(file)
+		if err != nil {
+			return nil, err
+		}
+
+		k.keys = append(k.keys, key)
+	}
+
+	// every private key but the newest was retired when the one after it was
+	// written, and can be pruned like the keys Rotate retires
+	var next *time.Time
+
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		if k.keys[i].private == nil {
+			continue
+		}
+
+		k.keys[i].path = files[i]
+		k.keys[i].retiredAt = next
+
+		written := modified[files[i]]
+		next = &written
+	}
+
+	if _, err := k.signingKey(); err != nil {
+		err = k.Rotate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// Rotate adds a new signing key of the same algorithm as the current one and
+// retires the old one. The new key is written to the key directory, if any.
+func (k *KeySet) Rotate() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	current, _ := k.signingKeyLocked()
+
+	var private crypto.Signer
+	var err error
+
+	if current != nil && current.Method == jwt.SigningMethodRS256 {
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	} else {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return fmt.Errorf("error generating signing key")
+	}
+
+	id, err := randomHex(4)
+	if err != nil {
+		return err
+	}
+
+	key, err := createKey(time.Now().UTC().Format("20060102T150405Z")+"-"+id, private, private.Public())
+	if err != nil {
+		return err
+	}
+
+	if k.dir != "" {
+		b, err := x509.MarshalPKCS8PrivateKey(private)
+		if err != nil {
+			return fmt.Errorf("error encoding signing key")
+		}
+
+		key.path = filepath.Join(k.dir, key.ID+".pem")
+
+		err = os.WriteFile(key.path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b}), 0o600)
+		if err != nil {
+			return fmt.Errorf("error writing signing key: %w", err)
+		}
+	}
+
+	if current != nil {
+		now := time.Now()
+		current.retiredAt = &now
+	}
+
+	k.keys = append(k.keys, key)
+
+	return nil
+}
+
+// Prune drops keys that were retired long enough ago that every token they
+// signed has expired, deleting the files rotation wrote for them.
+func (k *KeySet) Prune() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	kept := []*Key{}
+
+	for _, key := range k.keys {
+		if key.retiredAt == nil || time.Since(*key.retiredAt) <= AccessTokenTTL {
+			kept = append(kept, key)
+			continue
+		}
+
+		if key.path != "" {
+			err := os.Remove(key.path)
+			if err != nil {
+				log.Println("failed to remove retired key:", err)
+			}
+		}
+	}
+
+	k.keys = kept
+}
+
+// RotateEvery rotates the signing key every interval until ctx is done,
+// pruning expired keys as it goes.
+func (k *KeySet) RotateEvery(ctx context.Context, interval time.Duration) {
+	rotate := time.NewTicker(interval)
+	defer rotate.Stop()
+
+	prune := time.NewTicker(AccessTokenTTL)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-rotate.C:
+			err := k.Rotate()
+			if err != nil {
+				log.Println("failed to rotate signing key:", err)
+			}
+		case <-prune.C:
+			k.Prune()
+		}
+	}
+}
+
+func (k *KeySet) JWKS() *JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := &JWKS{Keys: []*JWK{}}
+
+	for _, key := range k.keys {
+		jwk := &JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+func (k *KeySet) signingKey() (*Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.signingKeyLocked()
+}
+
+func (k *KeySet) signingKeyLocked() (*Key, error) {
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		if k.keys[i].private != nil && k.keys[i].retiredAt == nil {
+			return k.keys[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no signing key")
+}
+
+func (k *KeySet) verificationKey(id string) (*Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+func readKey(file string) (*Key, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key %s: %w", file, err)
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in key %s", file)
+	}
+
+	id := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+	var parsed any
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %s in key %s", block.Type, file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing key %s: %w", file, err)
+	}
+
+	var key *Key
+
+	switch parsed := parsed.(type) {
+	case *rsa.PrivateKey:
+		key, err = createKey(id, parsed, parsed.Public())
+	case ed25519.PrivateKey:
+		key, err = createKey(id, parsed, parsed.Public())
+	default:
+		key, err = createKey(id, nil, parsed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading key %s: %w", file, err)
+	}
+
+	return key, nil
+}
+
+func createKey(id string, private crypto.Signer, public crypto.PublicKey) (*Key, error) {
+	key := &Key{ID: id, private: private, public: public}
+
+	switch public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// RevocationList tells Authenticate whether the session behind an otherwise
+// valid access token has been revoked.
+type RevocationList interface {
+	IsRevoked(ctx context.Context, sessionID string) bool
+}
+
+var (
+	revocationsMu sync.RWMutex
+	revocations   RevocationList
+)
+
+func UseRevocationList(list RevocationList) {
+	revocationsMu.Lock()
+	defer revocationsMu.Unlock()
+
+	revocations = list
+}
+
+func isRevoked(ctx context.Context, sessionID string) bool {
+	revocationsMu.RLock()
+	defer revocationsMu.RUnlock()
+
+	return revocations != nil && revocations.IsRevoked(ctx, sessionID)
+}
+
+// GenerateRefreshToken returns a new refresh token for sessionID along with
+// the hash of its secret to store.
+func GenerateRefreshToken(sessionID string) (string, string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	return sessionID + "." + secret, hashSecret(secret), nil
+}
+
+// ParseRefreshToken splits a refresh token into its session id and the hash
+// of its secret.
+func ParseRefreshToken(token string) (string, string, error) {
+	sessionID, secret, ok := strings.Cut(token, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return "", "", fmt.Errorf("invalid refresh token")
+	}
+
+	return sessionID, hashSecret(secret), nil
+}
+
+func CompareTokenHash(a string, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func GenerateSessionID() (string, error) {
+	return randomHex(16)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating token")
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,15 @@
+package blob
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore keeps the content of attachments under slash separated keys.
+// Get reports a missing key as types.NotFound, while deleting one is not an
+// error.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,104 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"ticketing-api/types"
+)
+
+// LocalStore keeps blobs as files below a directory.
+type LocalStore struct {
+	dir string
+}
+
+func CreateLocalStore(dir string) (*LocalStore, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("error creating blob directory: %w", err)
+	}
+
+	return &LocalStore{
+		dir: dir,
+	}, nil
+}
+
+// Put writes the blob to a temporary file first and renames it into place, so
+// that readers never see a partial blob.
+func (l *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return fmt.Errorf("error storing blob %s", key)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error storing blob %s", key)
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, r)
+	closeErr := file.Close()
+	if err != nil || closeErr != nil {
+		return fmt.Errorf("error storing blob %s", key)
+	}
+
+	if size >= 0 && written != size {
+		return fmt.Errorf("error storing blob %s: expected %d bytes, got %d", key, size, written)
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return fmt.Errorf("error storing blob %s", key)
+	}
+
+	return nil
+}
+
+func (l *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("blob %s not found", key)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob %s", key)
+	}
+
+	return file, nil
+}
+
+func (l *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting blob %s", key)
+	}
+
+	return nil
+}
+
+// path maps key to a file, refusing keys that would escape the directory.
+func (l *LocalStore) path(key string) (string, error) {
+	path := filepath.FromSlash(key)
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("invalid blob key %s", key)
+	}
+
+	return filepath.Join(l.dir, path), nil
+}
@@ -5,6 +5,7 @@ import (
 )
 
 type Group struct {
+	orgID      int
 	ticketID   int
 	clients    *sync.Map
 	register   chan *Client
@@ -14,8 +15,9 @@ type Group struct {
 	once       *sync.Once
 }
 
-func CreateGroup(ticketID int, onStop func()) *Group {
+func CreateGroup(orgID int, ticketID int, onStop func()) *Group {
 	return &Group{
+		orgID:      orgID,
 		ticketID:   ticketID,
 		clients:    &sync.Map{},
 		register:   make(chan *Client),
@@ -28,12 +30,25 @@ func CreateGroup(ticketID int, onStop func()) *Group {
 
 func (g *Group) Start() error {
 	for {
+		// the channels are closed together by Stop
 		select {
-		case client := <-g.register:
+		case client, ok := <-g.register:
+			if !ok {
+				return nil
+			}
+
 			g.registerClient(client)
-		case client := <-g.unregister:
+		case client, ok := <-g.unregister:
+			if !ok {
+				return nil
+			}
+
 			g.unregisterClient(client)
-		case message := <-g.broadcast:
+		case message, ok := <-g.broadcast:
+			if !ok {
+				return nil
+			}
+
 			g.broadcastMessage(message)
 		}
 	}
@@ -51,6 +66,20 @@ func (g *Group) Stop() {
 	})
 }
 
+// Connected reports whether accountID has a client in the group.
+func (g *Group) Connected(accountID int) bool {
+	connected := false
+	g.clients.Range(func(client, _ any) bool {
+		if c, ok := client.(*Client); ok && c != nil && c.accountID == accountID {
+			connected = true
+			return false
+		}
+
+		return true
+	})
+	return connected
+}
+
 func (g *Group) registerClient(client *Client) {
 	g.clients.Store(client, true)
 }
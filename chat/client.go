@@ -2,8 +2,13 @@ package chat
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
 	"ticketing-api/auth"
 	"ticketing-api/data"
+	"ticketing-api/policy"
 	"ticketing-api/types"
 	"time"
 
@@ -23,26 +28,89 @@ func (c *Client) handleCreateMessage(data json.RawMessage) error {
 		return err
 	}
 
-	accountID, err := auth.GetAccountID(c.conn.Request())
+	message, err := types.CreateMessage(id.String(), c.group.ticketID, c.accountID, req.Content)
 	if err != nil {
 		return err
 	}
 
-	message, err := types.CreateMessage(id.String(), c.group.ticketID, accountID, req.Content)
+	message.OrgID = c.group.orgID
+
+	message.AttachmentIDs, err = c.checkAttachments(req.AttachmentIDs)
 	if err != nil {
 		return err
 	}
 
-	message, err = c.db.Message.Create(message)
+	message, err = c.db.Message.Create(c.request.Context(), message)
 	if err != nil {
 		return err
 	}
 
+	err = c.recordFirstResponse(message)
+	if err != nil {
+		return err
+	}
+
+	if c.onCreate != nil {
+		c.onCreate(message)
+	}
+
 	c.group.broadcast <- &WSMessage{Status: StatusSuccess, Action: ActionCreate, Message: "message created", Data: message}
 
 	return nil
 }
 
+// checkAttachments makes sure a message only references attachments uploaded
+// to its ticket, dropping repeated ids.
+func (c *Client) checkAttachments(ids []int) ([]int, error) {
+	checked := []int{}
+
+	for _, id := range ids {
+		if slices.Contains(checked, id) {
+			continue
+		}
+
+		attachment, err := c.db.Attachment.GetByID(c.request.Context(), id)
+		if err != nil {
+			return nil, err
+		}
+
+		if attachment.TicketID != c.group.ticketID {
+			return nil, fmt.Errorf("attachment %d not found", id)
+		}
+
+		checked = append(checked, id)
+	}
+
+	return checked, nil
+}
+
+// recordFirstResponse counts a message as the first response to its ticket
+// when it comes from staff, meaning anyone but the author who may work the
+// ticket's status.
+func (c *Client) recordFirstResponse(message *types.Message) error {
+	ctx := c.request.Context()
+
+	ticket, err := c.db.Ticket.GetByID(ctx, message.TicketID)
+	if err != nil {
+		return err
+	}
+
+	if ticket.SLA.FirstResponseAt != nil || ticket.AuthorID == message.AuthorID {
+		return nil
+	}
+
+	subject, err := c.policy.Subject(c.request)
+	if err != nil {
+		return err
+	}
+
+	if subject.Can(types.PermissionTicketStatus, ticket) != nil {
+		return nil
+	}
+
+	return c.db.Ticket.RecordFirstResponse(ctx, ticket.ID, message.CreatedAt)
+}
+
 func (c *Client) handleDeleteMessage(data json.RawMessage) error {
 	req := &DeleteMessageRequest{}
 	err := json.Unmarshal(data, req)
@@ -50,17 +118,17 @@ func (c *Client) handleDeleteMessage(data json.RawMessage) error {
 		return err
 	}
 
-	message, err := c.db.Message.GetByID(req.ID, req.CreatedAt, req.TicketID)
+	message, err := c.db.Message.GetByID(c.request.Context(), req.ID, req.CreatedAt, req.TicketID)
 	if err != nil {
 		return err
 	}
 
-	err = auth.IsAccountID(c.conn.Request(), message.AuthorID, types.RoleAdmin)
+	err = c.policy.Authorize(c.request, types.PermissionMessageModerate, message)
 	if err != nil {
 		return err
 	}
 
-	err = c.db.Message.Delete(message.ID, message.CreatedAt, message.TicketID)
+	err = c.db.Message.Delete(c.request.Context(), message.ID, message.CreatedAt, message.TicketID)
 	if err != nil {
 		return err
 	}
@@ -77,19 +145,19 @@ func (c *Client) handleUpdateMessage(data []byte) error {
 		return err
 	}
 
-	message, err := c.db.Message.GetByID(req.ID, req.CreatedAt, req.TicketID)
+	message, err := c.db.Message.GetByID(c.request.Context(), req.ID, req.CreatedAt, req.TicketID)
 	if err != nil {
 		return err
 	}
 
-	err = auth.IsAccountID(c.conn.Request(), message.AuthorID, types.RoleAdmin)
+	err = c.policy.Authorize(c.request, types.PermissionMessageModerate, message)
 	if err != nil {
 		return err
 	}
 
 	message.Content = req.Content
 
-	message, err = c.db.Message.Update(message)
+	message, err = c.db.Message.Update(c.request.Context(), message)
 	if err != nil {
 		return err
 	}
@@ -100,18 +168,28 @@ func (c *Client) handleUpdateMessage(data []byte) error {
 }
 
 type Client struct {
-	conn  *websocket.Conn
-	group *Group
-	db    *data.DataAdapter
-	send  chan *WSMessage
+	conn      *websocket.Conn
+	request   *http.Request
+	group     *Group
+	accountID int
+	db        *data.DataAdapter
+	policy    *policy.Engine
+	onCreate  func(*types.Message)
+	send      chan *WSMessage
 }
 
-func CreateClient(conn *websocket.Conn, group *Group, db *data.DataAdapter) *Client {
+// CreateClient connects accountID to the chat of group. onCreate, when set, is
+// called with every message the client creates before it is broadcast.
+func CreateClient(conn *websocket.Conn, group *Group, accountID int, db *data.DataAdapter, policy *policy.Engine, onCreate func(*types.Message)) *Client {
 	return &Client{
-		conn:  conn,
-		group: group,
-		db:    db,
-		send:  make(chan *WSMessage),
+		conn:      conn,
+		request:   conn.Request(),
+		group:     group,
+		accountID: accountID,
+		db:        db,
+		policy:    policy,
+		onCreate:  onCreate,
+		send:      make(chan *WSMessage),
 	}
 }
 
@@ -128,6 +206,22 @@ func (c *Client) Disconnect() {
 	close(c.send)
 }
 
+// authenticate checks the token the client connected with again, so that a
+// connection ends once its session is revoked or its token expires rather
+// than outliving them.
+func (c *Client) authenticate() error {
+	r := auth.Authenticate(c.conn.Request())
+
+	err := auth.IsAuthenticated(r)
+	if err != nil {
+		return err
+	}
+
+	c.request = r
+
+	return nil
+}
+
 func (c *Client) Read() {
 	defer c.Disconnect()
 
@@ -135,10 +229,23 @@ func (c *Client) Read() {
 		req := &MessageRequest{}
 		err := websocket.JSON.Receive(c.conn, &req)
 		if err != nil {
+			// only a malformed message leaves the connection usable
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) {
+				return
+			}
+
 			c.send <- &WSMessage{Status: StatusError, Message: "error reading message"}
 			continue
 		}
 
+		err = c.authenticate()
+		if err != nil {
+			c.send <- &WSMessage{Status: StatusError, Action: req.Action, Message: err.Error()}
+			return
+		}
+
 		switch req.Action {
 		case ActionCreate:
 			err = c.handleCreateMessage(req.Data)
@@ -159,11 +266,19 @@ func (c *Client) Read() {
 	}
 }
 
+// Write sends the client its messages. Once the connection fails the rest are
+// dropped rather than left to block the group until the client disconnects.
 func (c *Client) Write() {
+	failed := false
+
 	for message := range c.send {
+		if failed {
+			continue
+		}
+
 		err := websocket.JSON.Send(c.conn, message)
 		if err != nil {
-			return
+			failed = true
 		}
 	}
 }
@@ -182,7 +297,8 @@ type MessageRequest struct {
 }
 
 type CreateMessageRequest struct {
-	Content string `json:"content"`
+	Content       string `json:"content"`
+	AttachmentIDs []int  `json:"attachment_ids"`
 }
 
 type UpdateMessageRequest struct {
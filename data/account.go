@@ -1,6 +1,7 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"ticketing-api/types"
@@ -9,31 +10,44 @@ import (
 )
 
 type AccountAdapter struct {
-	db *sql.DB
+	db DBTX
 }
 
-func CreateAccountAdapter(db *sql.DB) *AccountAdapter {
+func CreateAccountAdapter(db DBTX) *AccountAdapter {
 	return &AccountAdapter{
 		db: db,
 	}
 }
 
-func (a *AccountAdapter) Create(account *types.Account) (*types.Account, error) {
+func (a *AccountAdapter) Create(ctx context.Context, account *types.Account) (*types.Account, error) {
 	id := 0
-	err := a.db.QueryRow("INSERT INTO account (username, password, role) VALUES ($1, $2, $3) RETURNING id", account.Username, account.Password, account.Role).Scan(&id)
+	err := a.db.QueryRowContext(ctx, "INSERT INTO account (org_id, username, password, role, super_admin) VALUES ($1, $2, $3, $4, $5) RETURNING id", account.OrgID, account.Username, account.Password, account.Role, account.SuperAdmin).Scan(&id)
 	if err != nil {
 		return nil, fmt.Errorf("error creating account")
 	}
 
 	account.ID = id
+	account.Version = 1
 
 	return account, nil
 }
 
-func (a *AccountAdapter) Get() ([]*types.Account, error) {
-	rows, err := a.db.Query(`SELECT * FROM account`)
+func (a *AccountAdapter) Get(ctx context.Context, page *types.Page) ([]*types.Account, *types.PageInfo, error) {
+	query := ScopeAccountQuery(ctx, CreateQuery(PostgresPlaceholder, bindPostgres).Where("deleted_at IS NULL"))
+
+	total, err := CountRows(ctx, a.db, page, "SELECT COUNT(*) FROM account", query)
 	if err != nil {
-		return nil, fmt.Errorf("error getting accounts")
+		return nil, nil, err
+	}
+
+	paged, err := query.Paged(page, accountSortColumns, "id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := a.db.QueryContext(ctx, `SELECT id, org_id, username, password, role, super_admin, version, created_at, updated_at FROM account`+query.Clause()+paged, query.Args()...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting accounts")
 	}
 	defer rows.Close()
 
@@ -42,17 +56,20 @@ func (a *AccountAdapter) Get() ([]*types.Account, error) {
 	for rows.Next() {
 		account, err := scanIntoAccount(rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		accounts = append(accounts, account)
 	}
 
-	return accounts, nil
+	accounts, info := Paginate(page, accounts, AccountSortValue(page))
+	info.Total = total
+
+	return accounts, info, nil
 }
 
-func (a *AccountAdapter) GetByID(id int) (*types.Account, error) {
-	rows, err := a.db.Query(`SELECT * FROM account WHERE id = $1`, id)
+func (a *AccountAdapter) GetByID(ctx context.Context, id int) (*types.Account, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT id, org_id, username, password, role, super_admin, version, created_at, updated_at FROM account WHERE id = $1 AND deleted_at IS NULL AND (org_id = COALESCE($2, org_id) OR id IN (SELECT account_id FROM membership WHERE org_id = $2))`, id, OrganizationArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("error getting account")
 	}
@@ -65,8 +82,8 @@ func (a *AccountAdapter) GetByID(id int) (*types.Account, error) {
 	return nil, fmt.Errorf("account with id: %d not found", id)
 }
 
-func (a *AccountAdapter) GetByUsername(username string) (*types.Account, error) {
-	rows, err := a.db.Query(`SELECT * FROM account WHERE username = $1`, username)
+func (a *AccountAdapter) GetByUsername(ctx context.Context, username string) (*types.Account, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT id, org_id, username, password, role, super_admin, version, created_at, updated_at FROM account WHERE username = $1 AND deleted_at IS NULL`, username)
 	if err != nil {
 		return nil, fmt.Errorf("error getting account")
 	}
@@ -79,17 +96,22 @@ func (a *AccountAdapter) GetByUsername(username string) (*types.Account, error)
 	return nil, fmt.Errorf("account with the username: %s not found", username)
 }
 
-func (a *AccountAdapter) Update(account *types.Account) (*types.Account, error) {
-	_, err := a.db.Exec(`UPDATE account SET username = $1, password = $2, role = $3 WHERE id = $4`, account.Username, account.Password, account.Role, account.ID)
+func (a *AccountAdapter) Update(ctx context.Context, account *types.Account) (*types.Account, error) {
+	err := a.db.QueryRowContext(ctx, `UPDATE account SET username = $1, password = $2, role = $3, version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $4 AND version = $5 AND deleted_at IS NULL AND org_id = COALESCE($6, org_id) RETURNING updated_at`, account.Username, account.Password, account.Role, account.ID, account.Version, OrganizationArg(ctx)).Scan(&account.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &types.PreconditionFailed{Message: fmt.Sprintf("account %d has changed since version %d", account.ID, account.Version)}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error updating account: %w", err)
 	}
 
+	account.Version++
+
 	return account, nil
 }
 
-func (a *AccountAdapter) Delete(id int) error {
-	_, err := a.db.Exec(`DELETE FROM account WHERE id = $1`, id)
+func (a *AccountAdapter) Delete(ctx context.Context, id int) error {
+	_, err := a.db.ExecContext(ctx, `UPDATE account SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL AND org_id = COALESCE($2, org_id)`, id, OrganizationArg(ctx))
 	if err != nil {
 		return fmt.Errorf("error deleting account")
 	}
@@ -97,10 +119,26 @@ func (a *AccountAdapter) Delete(id int) error {
 	return nil
 }
 
+func (a *AccountAdapter) Restore(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, `UPDATE account SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL AND org_id = COALESCE($2, org_id)`, id, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error restoring account")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("deleted account with id: %d not found", id))
+}
+
+var accountSortColumns = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"username":   "username",
+}
+
 func scanIntoAccount(rows *sql.Rows) (*types.Account, error) {
 	account := &types.Account{}
 
-	err := rows.Scan(&account.ID, &account.Username, &account.Password, &account.Role, &account.CreatedAt, &account.UpdatedAt)
+	err := rows.Scan(&account.ID, &account.OrgID, &account.Username, &account.Password, &account.Role, &account.SuperAdmin, &account.Version, &account.CreatedAt, &account.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("error reading account")
 	}
@@ -0,0 +1,143 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+
+	"github.com/lib/pq"
+)
+
+type AgentAdapter struct {
+	db DBTX
+}
+
+func CreateAgentAdapter(db DBTX) *AgentAdapter {
+	return &AgentAdapter{
+		db: db,
+	}
+}
+
+// agentQuery selects agents along with the open tickets assigned to them and
+// when automatic assignment last picked them.
+const agentQuery = "SELECT agent.org_id, agent.account_id, agent.availability, agent.capacity, agent.skills, agent.updated_at, (SELECT COUNT(*) FROM assignee JOIN ticket ON ticket.id = assignee.ticket_id WHERE assignee.account_id = agent.account_id AND ticket.org_id = agent.org_id AND ticket.deleted_at IS NULL AND ticket.status = ANY($1)), (SELECT MAX(created_at) FROM assignment WHERE assignment.account_id = agent.account_id AND assignment.org_id = agent.org_id) FROM agent WHERE agent.org_id = COALESCE($2, agent.org_id)"
+
+func (a *AgentAdapter) Get(ctx context.Context) ([]*types.Agent, error) {
+	rows, err := a.db.QueryContext(ctx, agentQuery+" ORDER BY agent.account_id", pq.Array(types.OpenStatuses), OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting agents")
+	}
+	defer rows.Close()
+
+	agents := []*types.Agent{}
+
+	for rows.Next() {
+		agent, err := scanIntoAgent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		agents = append(agents, agent)
+	}
+
+	return agents, nil
+}
+
+func (a *AgentAdapter) GetByID(ctx context.Context, accountID int) (*types.Agent, error) {
+	rows, err := a.db.QueryContext(ctx, agentQuery+" AND agent.account_id = $3", pq.Array(types.OpenStatuses), OrganizationArg(ctx), accountID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting agent")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoAgent(rows)
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("account %d is not an agent", accountID)}
+}
+
+// Set makes the account an agent of its organization, or updates its profile
+// when it already is one.
+func (a *AgentAdapter) Set(ctx context.Context, agent *types.Agent) (*types.Agent, error) {
+	_, err := a.db.ExecContext(ctx, "INSERT INTO agent (org_id, account_id, availability, capacity, skills, updated_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (org_id, account_id) DO UPDATE SET availability = EXCLUDED.availability, capacity = EXCLUDED.capacity, skills = EXCLUDED.skills, updated_at = EXCLUDED.updated_at", agent.OrgID, agent.AccountID, agent.Availability, agent.Capacity, pq.Array(agent.Skills), agent.UpdatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error setting agent")
+	}
+
+	return agent, nil
+}
+
+func scanIntoAgent(rows *sql.Rows) (*types.Agent, error) {
+	skills := pq.StringArray{}
+	lastAssignedAt := sql.NullTime{}
+	agent := &types.Agent{}
+
+	err := rows.Scan(&agent.OrgID, &agent.AccountID, &agent.Availability, &agent.Capacity, &skills, &agent.UpdatedAt, &agent.Load, &lastAssignedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading agent")
+	}
+
+	agent.Skills = append([]string{}, skills...)
+
+	if lastAssignedAt.Valid {
+		agent.LastAssignedAt = &lastAssignedAt.Time
+	}
+
+	return agent, nil
+}
+
+type AssignmentAdapter struct {
+	db DBTX
+}
+
+func CreateAssignmentAdapter(db DBTX) *AssignmentAdapter {
+	return &AssignmentAdapter{
+		db: db,
+	}
+}
+
+func (a *AssignmentAdapter) Create(ctx context.Context, assignment *types.Assignment) (*types.Assignment, error) {
+	id := 0
+	err := a.db.QueryRowContext(ctx, "INSERT INTO assignment (org_id, ticket_id, account_id, strategy, reason, created_at) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6) RETURNING id", assignment.OrgID, assignment.TicketID, assignment.AccountID, assignment.Strategy, assignment.Reason, assignment.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating assignment")
+	}
+
+	assignment.ID = id
+
+	return assignment, nil
+}
+
+func (a *AssignmentAdapter) Get(ctx context.Context, ticketID int) ([]*types.Assignment, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT id, org_id, ticket_id, COALESCE(account_id, 0), strategy, reason, created_at FROM assignment WHERE ticket_id = $1 AND org_id = COALESCE($2, org_id) ORDER BY id", ticketID, OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting assignments")
+	}
+	defer rows.Close()
+
+	assignments := []*types.Assignment{}
+
+	for rows.Next() {
+		assignment, err := scanIntoAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, nil
+}
+
+func scanIntoAssignment(rows *sql.Rows) (*types.Assignment, error) {
+	assignment := &types.Assignment{}
+
+	err := rows.Scan(&assignment.ID, &assignment.OrgID, &assignment.TicketID, &assignment.AccountID, &assignment.Strategy, &assignment.Reason, &assignment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading assignment")
+	}
+
+	return assignment, nil
+}
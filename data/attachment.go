@@ -0,0 +1,101 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+)
+
+type AttachmentAdapter struct {
+	db DBTX
+}
+
+func CreateAttachmentAdapter(db DBTX) *AttachmentAdapter {
+	return &AttachmentAdapter{
+		db: db,
+	}
+}
+
+func (a *AttachmentAdapter) Create(ctx context.Context, attachment *types.Attachment) (*types.Attachment, error) {
+	id := 0
+	err := a.db.QueryRowContext(ctx, "INSERT INTO attachment (org_id, ticket_id, author_id, name, content_type, size, storage_key, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id", attachment.OrgID, attachment.TicketID, attachment.AuthorID, attachment.Name, attachment.ContentType, attachment.Size, attachment.Key, attachment.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating attachment")
+	}
+
+	attachment.ID = id
+
+	return attachment, nil
+}
+
+func (a *AttachmentAdapter) Get(ctx context.Context, ticketID int) ([]*types.Attachment, error) {
+	return a.fetchAttachments(ctx, createAttachmentQuery(ctx).Where("attachment.ticket_id = ?", ticketID))
+}
+
+func (a *AttachmentAdapter) GetByID(ctx context.Context, id int) (*types.Attachment, error) {
+	attachments, err := a.fetchAttachments(ctx, createAttachmentQuery(ctx).Where("attachment.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attachments) > 0 {
+		return attachments[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("attachment %d not found", id)}
+}
+
+func (a *AttachmentAdapter) Delete(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM attachment WHERE id = $1 AND org_id = COALESCE($2, org_id)", id, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting attachment")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("attachment %d not found", id))
+}
+
+func (a *AttachmentAdapter) Move(ctx context.Context, fromTicketID int, toTicketID int) error {
+	_, err := a.db.ExecContext(ctx, "UPDATE attachment SET ticket_id = $1 WHERE ticket_id = $2 AND org_id = COALESCE($3, org_id)", toTicketID, fromTicketID, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error moving attachments")
+	}
+
+	return nil
+}
+
+func createAttachmentQuery(ctx context.Context) *Query {
+	return ScopeQuery(ctx, CreateQuery(PostgresPlaceholder, bindPostgres), "attachment.org_id")
+}
+
+func (a *AttachmentAdapter) fetchAttachments(ctx context.Context, query *Query) ([]*types.Attachment, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT attachment.id, attachment.org_id, attachment.ticket_id, attachment.author_id, attachment.name, attachment.content_type, attachment.size, attachment.storage_key, attachment.created_at FROM attachment"+query.Clause()+" ORDER BY attachment.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting attachments")
+	}
+	defer rows.Close()
+
+	attachments := []*types.Attachment{}
+
+	for rows.Next() {
+		attachment, err := scanIntoAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+func scanIntoAttachment(rows *sql.Rows) (*types.Attachment, error) {
+	attachment := &types.Attachment{}
+
+	err := rows.Scan(&attachment.ID, &attachment.OrgID, &attachment.TicketID, &attachment.AuthorID, &attachment.Name, &attachment.ContentType, &attachment.Size, &attachment.Key, &attachment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading attachment")
+	}
+
+	return attachment, nil
+}
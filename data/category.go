@@ -0,0 +1,108 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+)
+
+type CategoryAdapter struct {
+	db DBTX
+}
+
+func CreateCategoryAdapter(db DBTX) *CategoryAdapter {
+	return &CategoryAdapter{
+		db: db,
+	}
+}
+
+func (a *CategoryAdapter) Create(ctx context.Context, category *types.Category) (*types.Category, error) {
+	id := 0
+	err := a.db.QueryRowContext(ctx, "INSERT INTO category (org_id, parent_id, name, created_at, updated_at) VALUES ($1, NULLIF($2, 0), $3, $4, $5) RETURNING id", category.OrgID, category.ParentID, category.Name, category.CreatedAt.UTC(), category.UpdatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating category")
+	}
+
+	category.ID = id
+
+	return category, nil
+}
+
+func (a *CategoryAdapter) Get(ctx context.Context) ([]*types.Category, error) {
+	return a.fetchCategories(ctx, createCategoryQuery(ctx))
+}
+
+func (a *CategoryAdapter) GetByID(ctx context.Context, id int) (*types.Category, error) {
+	categories, err := a.fetchCategories(ctx, createCategoryQuery(ctx).Where("category.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(categories) > 0 {
+		return categories[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("category %d not found", id)}
+}
+
+func (a *CategoryAdapter) Update(ctx context.Context, category *types.Category) (*types.Category, error) {
+	res, err := a.db.ExecContext(ctx, "UPDATE category SET parent_id = NULLIF($1, 0), name = $2, updated_at = $3 WHERE id = $4 AND org_id = COALESCE($5, org_id)", category.ParentID, category.Name, category.UpdatedAt.UTC(), category.ID, OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error updating category")
+	}
+
+	err = ExpectRow(res, fmt.Sprintf("category %d not found", category.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// Delete removes the category. The foreign key on ticket.category_id leaves
+// its tickets uncategorized.
+func (a *CategoryAdapter) Delete(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM category WHERE id = $1 AND org_id = COALESCE($2, org_id)", id, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting category")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("category %d not found", id))
+}
+
+func createCategoryQuery(ctx context.Context) *Query {
+	return ScopeQuery(ctx, CreateQuery(PostgresPlaceholder, bindPostgres), "category.org_id")
+}
+
+func (a *CategoryAdapter) fetchCategories(ctx context.Context, query *Query) ([]*types.Category, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT category.id, category.org_id, COALESCE(category.parent_id, 0), category.name, category.created_at, category.updated_at FROM category"+query.Clause()+" ORDER BY category.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting categories")
+	}
+	defer rows.Close()
+
+	categories := []*types.Category{}
+
+	for rows.Next() {
+		category, err := scanIntoCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
+func scanIntoCategory(rows *sql.Rows) (*types.Category, error) {
+	category := &types.Category{}
+
+	err := rows.Scan(&category.ID, &category.OrgID, &category.ParentID, &category.Name, &category.CreatedAt, &category.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading category")
+	}
+
+	return category, nil
+}
@@ -1,48 +1,249 @@
 package data
 
 import (
+	"context"
 	"ticketing-api/types"
 	"time"
 )
 
 type AccountSocket interface {
-	Create(*types.Account) (*types.Account, error)
-	Get() ([]*types.Account, error)
-	GetByID(int) (*types.Account, error)
-	GetByUsername(string) (*types.Account, error)
-	Update(*types.Account) (*types.Account, error)
-	Delete(int) error
+	Create(context.Context, *types.Account) (*types.Account, error)
+	Get(context.Context, *types.Page) ([]*types.Account, *types.PageInfo, error)
+	GetByID(context.Context, int) (*types.Account, error)
+	GetByUsername(context.Context, string) (*types.Account, error)
+	Update(context.Context, *types.Account) (*types.Account, error)
+	Delete(context.Context, int) error
+	Restore(context.Context, int) error
 }
 
 type TicketSocket interface {
-	Create(*types.Ticket) (*types.Ticket, error)
-	Get() ([]*types.Ticket, error)
-	GetByAssigneeIDs([]int) ([]*types.Ticket, error)
-	GetByAuthorID(int) ([]*types.Ticket, error)
-	GetByAuthorIDAssigneeIDs(int, []int) ([]*types.Ticket, error)
-	GetByID(int) (*types.Ticket, error)
-	Update(*types.Ticket) (*types.Ticket, error)
-	Delete(int) error
+	Create(context.Context, *types.Ticket) (*types.Ticket, error)
+	Get(context.Context, *types.TicketFilter, *types.Page) ([]*types.Ticket, *types.PageInfo, error)
+	GetByID(context.Context, int) (*types.Ticket, error)
+	Update(context.Context, *types.Ticket) (*types.Ticket, error)
+	Delete(context.Context, int) error
+	// GetDeleted returns a ticket that was deleted but not purged yet.
+	GetDeleted(context.Context, int) (*types.Ticket, error)
+	Restore(context.Context, int) error
+	Purge(context.Context, int) error
+	// Claim assigns the oldest ticket waiting in a team's queue to an account,
+	// so that concurrent claims never pull the same ticket.
+	Claim(context.Context, int, int) (*types.Ticket, error)
+	// RecordFirstResponse sets when a ticket was first responded to, unless
+	// it already was.
+	RecordFirstResponse(context.Context, int, time.Time) error
+	// MarkBreached flags an SLA metric of a ticket as breached, reporting
+	// false when it already was flagged.
+	MarkBreached(context.Context, int, types.SLAMetric, time.Time) (bool, error)
+	// Watch subscribes an account to a ticket, unless it already watches it.
+	// Stores subscribe authors on create and assignees when they are added,
+	// and never write WatcherIDs on update.
+	Watch(context.Context, int, int) error
+	Unwatch(context.Context, int, int) error
 }
 
 type MessageSocket interface {
-	Create(*types.Message) (*types.Message, error)
-	Get(int) ([]*types.Message, error)
-	GetByID(string, time.Time, int) (*types.Message, error)
-	Update(*types.Message) (*types.Message, error)
-	Delete(string, time.Time, int) error
+	Create(context.Context, *types.Message) (*types.Message, error)
+	Get(context.Context, int) ([]*types.Message, error)
+	GetByID(context.Context, string, time.Time, int) (*types.Message, error)
+	Update(context.Context, *types.Message) (*types.Message, error)
+	Delete(context.Context, string, time.Time, int) error
+	DeleteByTicketID(context.Context, int) error
+}
+
+// HistorySocket stores changes as an append only log, there is deliberately no
+// way to update or delete them.
+type HistorySocket interface {
+	Create(context.Context, *types.Change) (*types.Change, error)
+	Get(context.Context, types.Entity, int) ([]*types.Change, error)
+}
+
+type SessionSocket interface {
+	Create(context.Context, *types.Session) (*types.Session, error)
+	GetByID(context.Context, string) (*types.Session, error)
+	Rotate(context.Context, string, string, string) error
+	Revoke(context.Context, string) error
+	RevokeByAccountID(context.Context, int) error
+}
+
+type RoleSocket interface {
+	Create(context.Context, *types.RoleDefinition) (*types.RoleDefinition, error)
+	Get(context.Context) ([]*types.RoleDefinition, error)
+	GetByName(context.Context, types.Role) (*types.RoleDefinition, error)
+	Update(context.Context, *types.RoleDefinition) (*types.RoleDefinition, error)
+	Delete(context.Context, types.Role) error
+}
+
+type OrganizationSocket interface {
+	Create(context.Context, *types.Organization) (*types.Organization, error)
+	Get(context.Context) ([]*types.Organization, error)
+	GetByID(context.Context, int) (*types.Organization, error)
+	Update(context.Context, *types.Organization) (*types.Organization, error)
+}
+
+type MembershipSocket interface {
+	Get(context.Context, int) ([]*types.Membership, error)
+	GetByID(context.Context, int, int) (*types.Membership, error)
+	Set(context.Context, *types.Membership) (*types.Membership, error)
+	Delete(context.Context, int, int) error
+}
+
+type TeamSocket interface {
+	Create(context.Context, *types.Team) (*types.Team, error)
+	Get(context.Context) ([]*types.Team, error)
+	GetByID(context.Context, int) (*types.Team, error)
+	GetByAccountID(context.Context, int) ([]*types.Team, error)
+	Update(context.Context, *types.Team) (*types.Team, error)
+	Delete(context.Context, int) error
+}
+
+type AgentSocket interface {
+	Get(context.Context) ([]*types.Agent, error)
+	GetByID(context.Context, int) (*types.Agent, error)
+	Set(context.Context, *types.Agent) (*types.Agent, error)
+}
+
+// AssignmentSocket keeps the decisions made by automatic assignment. Like
+// history, they are only ever appended.
+type AssignmentSocket interface {
+	Create(context.Context, *types.Assignment) (*types.Assignment, error)
+	Get(context.Context, int) ([]*types.Assignment, error)
+}
+
+type SLAPolicySocket interface {
+	Create(context.Context, *types.SLAPolicy) (*types.SLAPolicy, error)
+	Get(context.Context) ([]*types.SLAPolicy, error)
+	GetByID(context.Context, int) (*types.SLAPolicy, error)
+	Update(context.Context, *types.SLAPolicy) (*types.SLAPolicy, error)
+	Delete(context.Context, int) error
+}
+
+type CategorySocket interface {
+	Create(context.Context, *types.Category) (*types.Category, error)
+	Get(context.Context) ([]*types.Category, error)
+	GetByID(context.Context, int) (*types.Category, error)
+	Update(context.Context, *types.Category) (*types.Category, error)
+	Delete(context.Context, int) error
+}
+
+type CustomFieldSocket interface {
+	Create(context.Context, *types.CustomField) (*types.CustomField, error)
+	Get(context.Context) ([]*types.CustomField, error)
+	GetByID(context.Context, int) (*types.CustomField, error)
+	Update(context.Context, *types.CustomField) (*types.CustomField, error)
+	Delete(context.Context, int) error
+}
+
+// AttachmentSocket stores what is known of attachments, their content living
+// in blob storage. Move hands every attachment of a ticket over to another.
+type AttachmentSocket interface {
+	Create(context.Context, *types.Attachment) (*types.Attachment, error)
+	Get(context.Context, int) ([]*types.Attachment, error)
+	GetByID(context.Context, int) (*types.Attachment, error)
+	Delete(context.Context, int) error
+	Move(context.Context, int, int) error
+}
+
+// LinkSocket stores links between tickets. Get returns the links a ticket is
+// either end of.
+type LinkSocket interface {
+	Create(context.Context, *types.TicketLink) (*types.TicketLink, error)
+	Get(context.Context, int) ([]*types.TicketLink, error)
+	GetByID(context.Context, int) (*types.TicketLink, error)
+	Delete(context.Context, int) error
+}
+
+// NotificationSocket queues notifications until they are delivered. GetDue
+// returns the unsent ones due by the given time that have been attempted
+// fewer than the given number of times. Claim counts an attempt and pushes
+// the notification back to the given time in case it fails, reporting false
+// when another worker claimed it first. GetPreferences returns NotFound for
+// accounts that never set theirs.
+type NotificationSocket interface {
+	Create(context.Context, *types.Notification) (*types.Notification, error)
+	GetDue(context.Context, time.Time, int) ([]*types.Notification, error)
+	Claim(context.Context, *types.Notification, time.Time) (bool, error)
+	MarkSent(context.Context, int, time.Time) error
+	GetPreferences(context.Context, int) (*types.NotificationPreferences, error)
+	SetPreferences(context.Context, *types.NotificationPreferences) (*types.NotificationPreferences, error)
 }
 
 type DataAdapter struct {
-	Account AccountSocket
-	Ticket  TicketSocket
-	Message MessageSocket
+	Account      AccountSocket
+	Ticket       TicketSocket
+	Message      MessageSocket
+	History      HistorySocket
+	Session      SessionSocket
+	Role         RoleSocket
+	Organization OrganizationSocket
+	Membership   MembershipSocket
+	Team         TeamSocket
+	Agent        AgentSocket
+	Assignment   AssignmentSocket
+	SLAPolicy    SLAPolicySocket
+	Category     CategorySocket
+	CustomField  CustomFieldSocket
+	Attachment   AttachmentSocket
+	Link         LinkSocket
+	Notification NotificationSocket
+	uow          UnitOfWork
+	index        Indexer
 }
 
-func CreateDataAdapter(account AccountSocket, ticket TicketSocket, message MessageSocket) *DataAdapter {
+func CreateDataAdapter(account AccountSocket, ticket TicketSocket, message MessageSocket, history HistorySocket, session SessionSocket, role RoleSocket, organization OrganizationSocket, membership MembershipSocket, team TeamSocket, agent AgentSocket, assignment AssignmentSocket, slaPolicy SLAPolicySocket, category CategorySocket, customField CustomFieldSocket, attachment AttachmentSocket, link LinkSocket, notification NotificationSocket, uow UnitOfWork) *DataAdapter {
 	return &DataAdapter{
-		Account: account,
-		Ticket:  ticket,
-		Message: message,
+		Account:      account,
+		Ticket:       ticket,
+		Message:      message,
+		History:      history,
+		Session:      session,
+		Role:         role,
+		Organization: organization,
+		Membership:   membership,
+		Team:         team,
+		Agent:        agent,
+		Assignment:   assignment,
+		SLAPolicy:    slaPolicy,
+		Category:     category,
+		CustomField:  customField,
+		Attachment:   attachment,
+		Link:         link,
+		Notification: notification,
+		uow:          uow,
+	}
+}
+
+// Transaction runs fn with sockets that share a single transaction, committing
+// if fn returns nil. Adapters built without a unit of work, including the ones
+// handed to fn, run fn directly so that transactions can be nested.
+func (d *DataAdapter) Transaction(ctx context.Context, fn func(*DataAdapter) error) error {
+	if d.uow == nil {
+		return fn(d)
+	}
+
+	if d.index == nil {
+		return d.uow.Transaction(ctx, fn)
+	}
+
+	pending := &pendingIndex{}
+
+	err := d.uow.Transaction(ctx, func(tx *DataAdapter) error {
+		tx.UseIndexer(pending)
+		return fn(tx)
+	})
+	if err != nil {
+		return err
 	}
+
+	pending.flush(d.index)
+
+	return nil
+}
+
+// UseIndexer feeds every ticket and message write made through the adapter,
+// including those made in transactions, to index.
+func (d *DataAdapter) UseIndexer(index Indexer) {
+	d.index = index
+	d.Ticket = &IndexedTicketSocket{TicketSocket: d.Ticket, messages: d.Message, index: index}
+	d.Message = &IndexedMessageSocket{MessageSocket: d.Message, index: index}
 }
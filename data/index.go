@@ -0,0 +1,187 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"ticketing-api/types"
+	"time"
+)
+
+type Indexer interface {
+	IndexTicket(*types.Ticket)
+	RemoveTicket(int)
+	IndexMessage(*types.Message)
+	RemoveMessage(*types.Message)
+}
+
+type IndexedTicketSocket struct {
+	TicketSocket
+	messages MessageSocket
+	index    Indexer
+}
+
+func (t *IndexedTicketSocket) Create(ctx context.Context, ticket *types.Ticket) (*types.Ticket, error) {
+	ticket, err := t.TicketSocket.Create(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	t.index.IndexTicket(ticket)
+
+	return ticket, nil
+}
+
+func (t *IndexedTicketSocket) Update(ctx context.Context, ticket *types.Ticket) (*types.Ticket, error) {
+	ticket, err := t.TicketSocket.Update(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	t.index.IndexTicket(ticket)
+
+	return ticket, nil
+}
+
+func (t *IndexedTicketSocket) Delete(ctx context.Context, id int) error {
+	err := t.TicketSocket.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	t.index.RemoveTicket(id)
+
+	return nil
+}
+
+// Restore puts the ticket and its messages back in the index, since deleting
+// the ticket removed both.
+func (t *IndexedTicketSocket) Restore(ctx context.Context, id int) error {
+	err := t.TicketSocket.Restore(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	ticket, err := t.TicketSocket.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	messages, err := t.messages.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	t.index.IndexTicket(ticket)
+
+	for _, message := range messages {
+		t.index.IndexMessage(message)
+	}
+
+	return nil
+}
+
+func (t *IndexedTicketSocket) Purge(ctx context.Context, id int) error {
+	err := t.TicketSocket.Purge(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	t.index.RemoveTicket(id)
+
+	return nil
+}
+
+type IndexedMessageSocket struct {
+	MessageSocket
+	index Indexer
+}
+
+func (m *IndexedMessageSocket) Create(ctx context.Context, message *types.Message) (*types.Message, error) {
+	message, err := m.MessageSocket.Create(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	m.index.IndexMessage(message)
+
+	return message, nil
+}
+
+func (m *IndexedMessageSocket) Update(ctx context.Context, message *types.Message) (*types.Message, error) {
+	message, err := m.MessageSocket.Update(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	m.index.IndexMessage(message)
+
+	return message, nil
+}
+
+func (m *IndexedMessageSocket) Delete(ctx context.Context, id string, createdAt time.Time, ticketID int) error {
+	err := m.MessageSocket.Delete(ctx, id, createdAt, ticketID)
+	if err != nil {
+		return err
+	}
+
+	m.index.RemoveMessage(&types.Message{ID: id, TicketID: ticketID, CreatedAt: createdAt})
+
+	return nil
+}
+
+func (m *IndexedMessageSocket) DeleteByTicketID(ctx context.Context, ticketID int) error {
+	messages, err := m.MessageSocket.Get(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+
+	err = m.MessageSocket.DeleteByTicketID(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		m.index.RemoveMessage(message)
+	}
+
+	return nil
+}
+
+// pendingIndex holds index updates made inside a transaction until it
+// commits, so that rolled back writes never reach the index.
+type pendingIndex struct {
+	mu      sync.Mutex
+	updates []func(Indexer)
+}
+
+func (p *pendingIndex) enqueue(update func(Indexer)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.updates = append(p.updates, update)
+}
+
+func (p *pendingIndex) IndexTicket(ticket *types.Ticket) {
+	copied := *ticket
+	p.enqueue(func(i Indexer) { i.IndexTicket(&copied) })
+}
+
+func (p *pendingIndex) RemoveTicket(id int) {
+	p.enqueue(func(i Indexer) { i.RemoveTicket(id) })
+}
+
+func (p *pendingIndex) IndexMessage(message *types.Message) {
+	copied := *message
+	p.enqueue(func(i Indexer) { i.IndexMessage(&copied) })
+}
+
+func (p *pendingIndex) RemoveMessage(message *types.Message) {
+	copied := *message
+	p.enqueue(func(i Indexer) { i.RemoveMessage(&copied) })
+}
+
+func (p *pendingIndex) flush(index Indexer) {
+	for _, update := range p.updates {
+		update(index)
+	}
+}
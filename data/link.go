@@ -0,0 +1,92 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+)
+
+type LinkAdapter struct {
+	db DBTX
+}
+
+func CreateLinkAdapter(db DBTX) *LinkAdapter {
+	return &LinkAdapter{
+		db: db,
+	}
+}
+
+func (a *LinkAdapter) Create(ctx context.Context, link *types.TicketLink) (*types.TicketLink, error) {
+	id := 0
+	err := a.db.QueryRowContext(ctx, "INSERT INTO ticket_link (org_id, source_id, target_id, type, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id", link.OrgID, link.SourceID, link.TargetID, link.Type, link.CreatedBy, link.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating link")
+	}
+
+	link.ID = id
+
+	return link, nil
+}
+
+func (a *LinkAdapter) Get(ctx context.Context, ticketID int) ([]*types.TicketLink, error) {
+	return a.fetchLinks(ctx, createLinkQuery(ctx).Where("(ticket_link.source_id = ? OR ticket_link.target_id = ?)", ticketID, ticketID))
+}
+
+func (a *LinkAdapter) GetByID(ctx context.Context, id int) (*types.TicketLink, error) {
+	links, err := a.fetchLinks(ctx, createLinkQuery(ctx).Where("ticket_link.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(links) > 0 {
+		return links[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("link %d not found", id)}
+}
+
+func (a *LinkAdapter) Delete(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM ticket_link WHERE id = $1 AND org_id = COALESCE($2, org_id)", id, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting link")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("link %d not found", id))
+}
+
+func createLinkQuery(ctx context.Context) *Query {
+	return ScopeQuery(ctx, CreateQuery(PostgresPlaceholder, bindPostgres), "ticket_link.org_id")
+}
+
+func (a *LinkAdapter) fetchLinks(ctx context.Context, query *Query) ([]*types.TicketLink, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT ticket_link.id, ticket_link.org_id, ticket_link.source_id, ticket_link.target_id, ticket_link.type, ticket_link.created_by, ticket_link.created_at FROM ticket_link"+query.Clause()+" ORDER BY ticket_link.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting links")
+	}
+	defer rows.Close()
+
+	links := []*types.TicketLink{}
+
+	for rows.Next() {
+		link, err := scanIntoLink(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+func scanIntoLink(rows *sql.Rows) (*types.TicketLink, error) {
+	link := &types.TicketLink{}
+
+	err := rows.Scan(&link.ID, &link.OrgID, &link.SourceID, &link.TargetID, &link.Type, &link.CreatedBy, &link.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading link")
+	}
+
+	return link, nil
+}
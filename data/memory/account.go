@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+type AccountAdapter struct {
+	store *Store
+}
+
+func CreateAccountAdapter(store *Store) *AccountAdapter {
+	return &AccountAdapter{
+		store: store,
+	}
+}
+
+func (a *AccountAdapter) Create(ctx context.Context, account *types.Account) (*types.Account, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if a.store.usernameTaken(account.Username, 0) {
+		return nil, fmt.Errorf("error creating account")
+	}
+
+	a.store.accountID++
+	account.ID = a.store.accountID
+	account.Version = 1
+	a.store.accounts[account.ID] = copyAccount(account)
+
+	return account, nil
+}
+
+func (a *AccountAdapter) Get(ctx context.Context, page *types.Page) ([]*types.Account, *types.PageInfo, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	accounts := []*types.Account{}
+
+	for _, account := range a.store.accounts {
+		if account.DeletedAt == nil && a.store.accountInOrganization(ctx, account) {
+			accounts = append(accounts, copyAccount(account))
+		}
+	}
+
+	return paginate(page, accounts, data.AccountSorts, accountKey, data.AccountSortValue)
+}
+
+func (a *AccountAdapter) GetByID(ctx context.Context, id int) (*types.Account, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	account, ok := a.store.accounts[id]
+	if !ok || account.DeletedAt != nil || !a.store.accountInOrganization(ctx, account) {
+		return nil, fmt.Errorf("account with id: %d not found", id)
+	}
+
+	return copyAccount(account), nil
+}
+
+func (a *AccountAdapter) GetByUsername(ctx context.Context, username string) (*types.Account, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	for _, account := range a.store.accounts {
+		if account.Username == username && account.DeletedAt == nil {
+			return copyAccount(account), nil
+		}
+	}
+
+	return nil, fmt.Errorf("account with the username: %s not found", username)
+}
+
+func (a *AccountAdapter) Update(ctx context.Context, account *types.Account) (*types.Account, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	existing, ok := a.store.accounts[account.ID]
+	if !ok || existing.DeletedAt != nil || existing.Version != account.Version || !inOrganization(ctx, existing.OrgID) {
+		return nil, &types.PreconditionFailed{Message: fmt.Sprintf("account %d has changed since version %d", account.ID, account.Version)}
+	}
+
+	if a.store.usernameTaken(account.Username, account.ID) {
+		return nil, fmt.Errorf("error updating account: username %s already exists", account.Username)
+	}
+
+	existing.Username = account.Username
+	existing.Password = account.Password
+	existing.Role = account.Role
+	existing.Version++
+	existing.UpdatedAt = time.Now()
+	account.Version = existing.Version
+	account.UpdatedAt = existing.UpdatedAt
+
+	return account, nil
+}
+
+func (a *AccountAdapter) Delete(ctx context.Context, id int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if account, ok := a.store.accounts[id]; ok && account.DeletedAt == nil && inOrganization(ctx, account.OrgID) {
+		now := time.Now()
+		account.DeletedAt = &now
+	}
+
+	return nil
+}
+
+func (a *AccountAdapter) Restore(ctx context.Context, id int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	account, ok := a.store.accounts[id]
+	if !ok || account.DeletedAt == nil || !inOrganization(ctx, account.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("deleted account with id: %d not found", id)}
+	}
+
+	account.DeletedAt = nil
+
+	return nil
+}
+
+func (s *Store) usernameTaken(username string, exceptID int) bool {
+	for _, account := range s.accounts {
+		if account.Username == username && account.ID != exceptID {
+			return true
+		}
+	}
+
+	return false
+}
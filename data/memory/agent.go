@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"ticketing-api/types"
+)
+
+type AgentAdapter struct {
+	store *Store
+}
+
+func CreateAgentAdapter(store *Store) *AgentAdapter {
+	return &AgentAdapter{
+		store: store,
+	}
+}
+
+func (a *AgentAdapter) Get(ctx context.Context) ([]*types.Agent, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	agents := []*types.Agent{}
+
+	for _, agent := range a.store.agents {
+		if inOrganization(ctx, agent.OrgID) {
+			agents = append(agents, a.store.describeAgent(agent))
+		}
+	}
+
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].AccountID < agents[j].AccountID
+	})
+
+	return agents, nil
+}
+
+func (a *AgentAdapter) GetByID(ctx context.Context, accountID int) (*types.Agent, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	for _, agent := range a.store.agents {
+		if agent.AccountID == accountID && inOrganization(ctx, agent.OrgID) {
+			return a.store.describeAgent(agent), nil
+		}
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("account %d is not an agent", accountID)}
+}
+
+func (a *AgentAdapter) Set(ctx context.Context, agent *types.Agent) (*types.Agent, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.orgs[agent.OrgID]; !ok {
+		return nil, fmt.Errorf("error setting agent")
+	}
+
+	if _, ok := a.store.accounts[agent.AccountID]; !ok {
+		return nil, fmt.Errorf("error setting agent")
+	}
+
+	a.store.agents[membershipKey{agent.OrgID, agent.AccountID}] = copyAgent(agent)
+
+	return agent, nil
+}
+
+// describeAgent copies agent along with its open tickets and when it was last
+// picked by automatic assignment.
+func (s *Store) describeAgent(agent *types.Agent) *types.Agent {
+	described := copyAgent(agent)
+	described.Load = 0
+	described.LastAssignedAt = nil
+
+	for _, ticket := range s.tickets {
+		if ticket.OrgID == agent.OrgID && ticket.DeletedAt == nil && slices.Contains(types.OpenStatuses, ticket.Status) && slices.Contains(ticket.AssigneeIDs, agent.AccountID) {
+			described.Load++
+		}
+	}
+
+	for _, assignment := range s.assignments {
+		if assignment.OrgID == agent.OrgID && assignment.AccountID == agent.AccountID {
+			createdAt := assignment.CreatedAt
+			if described.LastAssignedAt == nil || createdAt.After(*described.LastAssignedAt) {
+				described.LastAssignedAt = &createdAt
+			}
+		}
+	}
+
+	return described
+}
+
+type AssignmentAdapter struct {
+	store *Store
+}
+
+func CreateAssignmentAdapter(store *Store) *AssignmentAdapter {
+	return &AssignmentAdapter{
+		store: store,
+	}
+}
+
+func (a *AssignmentAdapter) Create(ctx context.Context, assignment *types.Assignment) (*types.Assignment, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.assignID++
+	assignment.ID = a.store.assignID
+	a.store.assignments = append(a.store.assignments, copyAssignment(assignment))
+
+	return assignment, nil
+}
+
+func (a *AssignmentAdapter) Get(ctx context.Context, ticketID int) ([]*types.Assignment, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	assignments := []*types.Assignment{}
+
+	for _, assignment := range a.store.assignments {
+		if assignment.TicketID == ticketID && inOrganization(ctx, assignment.OrgID) {
+			assignments = append(assignments, copyAssignment(assignment))
+		}
+	}
+
+	return assignments, nil
+}
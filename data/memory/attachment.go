@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+)
+
+type AttachmentAdapter struct {
+	store *Store
+}
+
+func CreateAttachmentAdapter(store *Store) *AttachmentAdapter {
+	return &AttachmentAdapter{
+		store: store,
+	}
+}
+
+func (a *AttachmentAdapter) Create(ctx context.Context, attachment *types.Attachment) (*types.Attachment, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.tickets[attachment.TicketID]; !ok {
+		return nil, fmt.Errorf("error creating attachment")
+	}
+
+	a.store.attachmentID++
+	attachment.ID = a.store.attachmentID
+	a.store.attachments[attachment.ID] = copyAttachment(attachment)
+
+	return attachment, nil
+}
+
+func (a *AttachmentAdapter) Get(ctx context.Context, ticketID int) ([]*types.Attachment, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	attachments := []*types.Attachment{}
+
+	for _, attachment := range a.store.attachments {
+		if attachment.TicketID == ticketID && inOrganization(ctx, attachment.OrgID) {
+			attachments = append(attachments, copyAttachment(attachment))
+		}
+	}
+
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].ID < attachments[j].ID
+	})
+
+	return attachments, nil
+}
+
+func (a *AttachmentAdapter) GetByID(ctx context.Context, id int) (*types.Attachment, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	attachment, ok := a.store.attachments[id]
+	if !ok || !inOrganization(ctx, attachment.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("attachment %d not found", id)}
+	}
+
+	return copyAttachment(attachment), nil
+}
+
+func (a *AttachmentAdapter) Delete(ctx context.Context, id int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	attachment, ok := a.store.attachments[id]
+	if !ok || !inOrganization(ctx, attachment.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("attachment %d not found", id)}
+	}
+
+	delete(a.store.attachments, id)
+
+	return nil
+}
+
+func (a *AttachmentAdapter) Move(ctx context.Context, fromTicketID int, toTicketID int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	for _, attachment := range a.store.attachments {
+		if attachment.TicketID == fromTicketID && inOrganization(ctx, attachment.OrgID) {
+			attachment.TicketID = toTicketID
+		}
+	}
+
+	return nil
+}
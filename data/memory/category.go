@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+)
+
+type CategoryAdapter struct {
+	store *Store
+}
+
+func CreateCategoryAdapter(store *Store) *CategoryAdapter {
+	return &CategoryAdapter{
+		store: store,
+	}
+}
+
+func (a *CategoryAdapter) Create(ctx context.Context, category *types.Category) (*types.Category, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.orgs[category.OrgID]; !ok || !a.store.categoryExists(category.ParentID) {
+		return nil, fmt.Errorf("error creating category")
+	}
+
+	a.store.categoryID++
+	category.ID = a.store.categoryID
+	a.store.categories[category.ID] = copyCategory(category)
+
+	return category, nil
+}
+
+func (a *CategoryAdapter) Get(ctx context.Context) ([]*types.Category, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	categories := []*types.Category{}
+
+	for _, category := range a.store.categories {
+		if inOrganization(ctx, category.OrgID) {
+			categories = append(categories, copyCategory(category))
+		}
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].ID < categories[j].ID
+	})
+
+	return categories, nil
+}
+
+func (a *CategoryAdapter) GetByID(ctx context.Context, id int) (*types.Category, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	category, ok := a.store.categories[id]
+	if !ok || !inOrganization(ctx, category.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("category %d not found", id)}
+	}
+
+	return copyCategory(category), nil
+}
+
+func (a *CategoryAdapter) Update(ctx context.Context, category *types.Category) (*types.Category, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	existing, ok := a.store.categories[category.ID]
+	if !ok || !inOrganization(ctx, existing.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("category %d not found", category.ID)}
+	}
+
+	if !a.store.categoryExists(category.ParentID) {
+		return nil, fmt.Errorf("error updating category")
+	}
+
+	existing.ParentID = category.ParentID
+	existing.Name = category.Name
+	existing.UpdatedAt = category.UpdatedAt
+
+	return category, nil
+}
+
+func (a *CategoryAdapter) Delete(ctx context.Context, id int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	category, ok := a.store.categories[id]
+	if !ok || !inOrganization(ctx, category.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("category %d not found", id)}
+	}
+
+	for _, child := range a.store.categories {
+		if child.ParentID == id {
+			return fmt.Errorf("error deleting category")
+		}
+	}
+
+	delete(a.store.categories, id)
+
+	for _, ticket := range a.store.tickets {
+		if ticket.CategoryID == id {
+			ticket.CategoryID = 0
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) categoryExists(id int) bool {
+	if id == 0 {
+		return true
+	}
+
+	_, ok := s.categories[id]
+
+	return ok
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+)
+
+type CustomFieldAdapter struct {
+	store *Store
+}
+
+func CreateCustomFieldAdapter(store *Store) *CustomFieldAdapter {
+	return &CustomFieldAdapter{
+		store: store,
+	}
+}
+
+func (a *CustomFieldAdapter) Create(ctx context.Context, field *types.CustomField) (*types.CustomField, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.orgs[field.OrgID]; !ok || !a.store.fieldKeyFree(field) {
+		return nil, fmt.Errorf("error creating custom field")
+	}
+
+	a.store.fieldID++
+	field.ID = a.store.fieldID
+	a.store.fields[field.ID] = copyCustomField(field)
+
+	return field, nil
+}
+
+func (a *CustomFieldAdapter) Get(ctx context.Context) ([]*types.CustomField, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	fields := []*types.CustomField{}
+
+	for _, field := range a.store.fields {
+		if inOrganization(ctx, field.OrgID) {
+			fields = append(fields, copyCustomField(field))
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].ID < fields[j].ID
+	})
+
+	return fields, nil
+}
+
+func (a *CustomFieldAdapter) GetByID(ctx context.Context, id int) (*types.CustomField, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	field, ok := a.store.fields[id]
+	if !ok || !inOrganization(ctx, field.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("custom field %d not found", id)}
+	}
+
+	return copyCustomField(field), nil
+}
+
+func (a *CustomFieldAdapter) Update(ctx context.Context, field *types.CustomField) (*types.CustomField, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	existing, ok := a.store.fields[field.ID]
+	if !ok || !inOrganization(ctx, existing.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("custom field %d not found", field.ID)}
+	}
+
+	existing.Name = field.Name
+	existing.Options = append([]string{}, field.Options...)
+	existing.Required = field.Required
+	existing.UpdatedAt = field.UpdatedAt
+
+	return field, nil
+}
+
+func (a *CustomFieldAdapter) Delete(ctx context.Context, id int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	field, ok := a.store.fields[id]
+	if !ok || !inOrganization(ctx, field.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("custom field %d not found", id)}
+	}
+
+	delete(a.store.fields, id)
+
+	return nil
+}
+
+func (s *Store) fieldKeyFree(field *types.CustomField) bool {
+	for _, existing := range s.fields {
+		if existing.ID != field.ID && existing.OrgID == field.OrgID && existing.Key == field.Key {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"ticketing-api/types"
+)
+
+type HistoryAdapter struct {
+	store *Store
+}
+
+func CreateHistoryAdapter(store *Store) *HistoryAdapter {
+	return &HistoryAdapter{
+		store: store,
+	}
+}
+
+func (h *HistoryAdapter) Create(ctx context.Context, change *types.Change) (*types.Change, error) {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+
+	h.store.changeID++
+	change.ID = h.store.changeID
+	h.store.history = append(h.store.history, copyChange(change))
+
+	return change, nil
+}
+
+func (h *HistoryAdapter) Get(ctx context.Context, entity types.Entity, id int) ([]*types.Change, error) {
+	h.store.mu.RLock()
+	defer h.store.mu.RUnlock()
+
+	changes := []*types.Change{}
+
+	for _, change := range h.store.history {
+		if change.Entity == entity && change.EntityID == id && inOrganization(ctx, change.OrgID) {
+			changes = append(changes, copyChange(change))
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+)
+
+type LinkAdapter struct {
+	store *Store
+}
+
+func CreateLinkAdapter(store *Store) *LinkAdapter {
+	return &LinkAdapter{
+		store: store,
+	}
+}
+
+// Create keeps the constraints of the sql stores: a link exists once, a
+// ticket is a duplicate of one ticket at most and has one parent at most.
+func (a *LinkAdapter) Create(ctx context.Context, link *types.TicketLink) (*types.TicketLink, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	_, source := a.store.tickets[link.SourceID]
+	_, target := a.store.tickets[link.TargetID]
+	if !source || !target {
+		return nil, fmt.Errorf("error creating link")
+	}
+
+	for _, existing := range a.store.links {
+		if existing.Type != link.Type {
+			continue
+		}
+
+		if existing.SourceID == link.SourceID && existing.TargetID == link.TargetID {
+			return nil, fmt.Errorf("error creating link")
+		}
+
+		if (link.Type == types.LinkDuplicateOf && existing.SourceID == link.SourceID) || (link.Type == types.LinkParentOf && existing.TargetID == link.TargetID) {
+			return nil, fmt.Errorf("error creating link")
+		}
+	}
+
+	a.store.linkID++
+	link.ID = a.store.linkID
+	a.store.links[link.ID] = copyLink(link)
+
+	return link, nil
+}
+
+func (a *LinkAdapter) Get(ctx context.Context, ticketID int) ([]*types.TicketLink, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	links := []*types.TicketLink{}
+
+	for _, link := range a.store.links {
+		if (link.SourceID == ticketID || link.TargetID == ticketID) && inOrganization(ctx, link.OrgID) {
+			links = append(links, copyLink(link))
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID < links[j].ID
+	})
+
+	return links, nil
+}
+
+func (a *LinkAdapter) GetByID(ctx context.Context, id int) (*types.TicketLink, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	link, ok := a.store.links[id]
+	if !ok || !inOrganization(ctx, link.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("link %d not found", id)}
+	}
+
+	return copyLink(link), nil
+}
+
+func (a *LinkAdapter) Delete(ctx context.Context, id int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	link, ok := a.store.links[id]
+	if !ok || !inOrganization(ctx, link.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("link %d not found", id)}
+	}
+
+	delete(a.store.links, id)
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"ticketing-api/types"
+	"time"
+)
+
+type MessageAdapter struct {
+	store *Store
+}
+
+func CreateMessageAdapter(store *Store) *MessageAdapter {
+	return &MessageAdapter{
+		store: store,
+	}
+}
+
+func (m *MessageAdapter) Get(ctx context.Context, id int) ([]*types.Message, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	messages := []*types.Message{}
+
+	for _, message := range m.store.messages[id] {
+		if inOrganization(ctx, message.OrgID) {
+			messages = append(messages, copyMessage(message))
+		}
+	}
+
+	return messages, nil
+}
+
+func (m *MessageAdapter) Create(ctx context.Context, message *types.Message) (*types.Message, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	m.store.upsertMessage(message)
+
+	return message, nil
+}
+
+func (m *MessageAdapter) Delete(ctx context.Context, id string, created_at time.Time, ticket_id int) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	m.store.messages[ticket_id] = slices.DeleteFunc(m.store.messages[ticket_id], func(message *types.Message) bool {
+		return message.ID == id && message.CreatedAt.Equal(created_at) && inOrganization(ctx, message.OrgID)
+	})
+
+	return nil
+}
+
+func (m *MessageAdapter) DeleteByTicketID(ctx context.Context, ticketID int) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	m.store.messages[ticketID] = slices.DeleteFunc(m.store.messages[ticketID], func(message *types.Message) bool {
+		return inOrganization(ctx, message.OrgID)
+	})
+
+	return nil
+}
+
+func (m *MessageAdapter) GetByID(ctx context.Context, id string, created_at time.Time, ticket_id int) (*types.Message, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	for _, message := range m.store.messages[ticket_id] {
+		if message.ID == id && message.CreatedAt.Equal(created_at) && inOrganization(ctx, message.OrgID) {
+			return copyMessage(message), nil
+		}
+	}
+
+	return nil, fmt.Errorf("mesage %s not found", id)
+}
+
+func (m *MessageAdapter) Update(ctx context.Context, message *types.Message) (*types.Message, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	m.store.upsertMessage(message)
+
+	return message, nil
+}
+
+// upsertMessage mirrors Scylla, where INSERT and UPDATE both write the row
+// identified by (ticket_id, created_at, id) and keep the partition ordered by
+// created_at DESC, id ASC.
+func (s *Store) upsertMessage(message *types.Message) {
+	messages := s.messages[message.TicketID]
+
+	i, found := slices.BinarySearchFunc(messages, message, compareMessages)
+	if found {
+		messages[i] = copyMessage(message)
+		return
+	}
+
+	s.messages[message.TicketID] = slices.Insert(messages, i, copyMessage(message))
+}
+
+func compareMessages(x, y *types.Message) int {
+	if c := y.CreatedAt.Compare(x.CreatedAt); c != 0 {
+		return c
+	}
+
+	return strings.Compare(x.ID, y.ID)
+}
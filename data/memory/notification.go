@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+	"time"
+)
+
+type NotificationAdapter struct {
+	store *Store
+}
+
+func CreateNotificationAdapter(store *Store) *NotificationAdapter {
+	return &NotificationAdapter{
+		store: store,
+	}
+}
+
+func (a *NotificationAdapter) Create(ctx context.Context, notification *types.Notification) (*types.Notification, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.tickets[notification.TicketID]; !ok {
+		return nil, fmt.Errorf("error creating notification")
+	}
+
+	a.store.notificationID++
+	notification.ID = a.store.notificationID
+	a.store.notifications[notification.ID] = copyNotification(notification)
+
+	return notification, nil
+}
+
+func (a *NotificationAdapter) GetDue(ctx context.Context, now time.Time, maxAttempts int) ([]*types.Notification, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	notifications := []*types.Notification{}
+
+	for _, notification := range a.store.notifications {
+		if notification.SentAt == nil && !notification.DeliverAt.After(now) && notification.Attempts < maxAttempts && inOrganization(ctx, notification.OrgID) {
+			notifications = append(notifications, copyNotification(notification))
+		}
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		if notifications[i].AccountID != notifications[j].AccountID {
+			return notifications[i].AccountID < notifications[j].AccountID
+		}
+
+		return notifications[i].ID < notifications[j].ID
+	})
+
+	return notifications, nil
+}
+
+func (a *NotificationAdapter) Claim(ctx context.Context, notification *types.Notification, retryAt time.Time) (bool, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	stored, ok := a.store.notifications[notification.ID]
+	if !ok || stored.SentAt != nil || stored.Attempts != notification.Attempts {
+		return false, nil
+	}
+
+	stored.Attempts++
+	stored.DeliverAt = retryAt
+	notification.Attempts = stored.Attempts
+	notification.DeliverAt = retryAt
+
+	return true, nil
+}
+
+func (a *NotificationAdapter) MarkSent(ctx context.Context, id int, at time.Time) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	notification, ok := a.store.notifications[id]
+	if !ok {
+		return &types.NotFound{Message: fmt.Sprintf("notification %d not found", id)}
+	}
+
+	notification.SentAt = &at
+
+	return nil
+}
+
+func (a *NotificationAdapter) GetPreferences(ctx context.Context, accountID int) (*types.NotificationPreferences, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	prefs, ok := a.store.preferences[accountID]
+	if !ok {
+		return nil, &types.NotFound{Message: fmt.Sprintf("notification preferences of account %d not found", accountID)}
+	}
+
+	return copyPreferences(prefs), nil
+}
+
+func (a *NotificationAdapter) SetPreferences(ctx context.Context, prefs *types.NotificationPreferences) (*types.NotificationPreferences, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.accounts[prefs.AccountID]; !ok {
+		return nil, fmt.Errorf("error setting notification preferences")
+	}
+
+	a.store.preferences[prefs.AccountID] = copyPreferences(prefs)
+
+	return prefs, nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+)
+
+type OrganizationAdapter struct {
+	store *Store
+}
+
+func CreateOrganizationAdapter(store *Store) *OrganizationAdapter {
+	return &OrganizationAdapter{
+		store: store,
+	}
+}
+
+func (a *OrganizationAdapter) Create(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	for _, existing := range a.store.orgs {
+		if existing.Name == org.Name {
+			return nil, fmt.Errorf("error creating organization")
+		}
+	}
+
+	a.store.orgID++
+	org.ID = a.store.orgID
+	a.store.orgs[org.ID] = copyOrganization(org)
+
+	for _, role := range types.DefaultRoles(org.ID) {
+		a.store.roles[roleKey{role.OrgID, role.Name}] = role
+	}
+
+	return org, nil
+}
+
+func (a *OrganizationAdapter) Get(ctx context.Context) ([]*types.Organization, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	orgs := []*types.Organization{}
+
+	for _, org := range a.store.orgs {
+		orgs = append(orgs, copyOrganization(org))
+	}
+
+	sort.Slice(orgs, func(i, j int) bool {
+		return orgs[i].ID < orgs[j].ID
+	})
+
+	return orgs, nil
+}
+
+func (a *OrganizationAdapter) GetByID(ctx context.Context, id int) (*types.Organization, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	org, ok := a.store.orgs[id]
+	if !ok {
+		return nil, &types.NotFound{Message: fmt.Sprintf("organization %d not found", id)}
+	}
+
+	return copyOrganization(org), nil
+}
+
+func (a *OrganizationAdapter) Update(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	existing, ok := a.store.orgs[org.ID]
+	if !ok {
+		return nil, &types.NotFound{Message: fmt.Sprintf("organization %d not found", org.ID)}
+	}
+
+	existing.Name = org.Name
+	existing.UpdatedAt = org.UpdatedAt
+
+	return org, nil
+}
+
+type MembershipAdapter struct {
+	store *Store
+}
+
+func CreateMembershipAdapter(store *Store) *MembershipAdapter {
+	return &MembershipAdapter{
+		store: store,
+	}
+}
+
+func (a *MembershipAdapter) Get(ctx context.Context, orgID int) ([]*types.Membership, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	memberships := []*types.Membership{}
+
+	for key, membership := range a.store.memberships {
+		if key.orgID == orgID {
+			memberships = append(memberships, copyMembership(membership))
+		}
+	}
+
+	sort.Slice(memberships, func(i, j int) bool {
+		return memberships[i].AccountID < memberships[j].AccountID
+	})
+
+	return memberships, nil
+}
+
+func (a *MembershipAdapter) GetByID(ctx context.Context, orgID int, accountID int) (*types.Membership, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	membership, ok := a.store.memberships[membershipKey{orgID, accountID}]
+	if !ok {
+		return nil, &types.NotFound{Message: fmt.Sprintf("account %d is not a member of organization %d", accountID, orgID)}
+	}
+
+	return copyMembership(membership), nil
+}
+
+func (a *MembershipAdapter) Set(ctx context.Context, membership *types.Membership) (*types.Membership, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.orgs[membership.OrgID]; !ok {
+		return nil, fmt.Errorf("error setting membership")
+	}
+
+	if _, ok := a.store.accounts[membership.AccountID]; !ok {
+		return nil, fmt.Errorf("error setting membership")
+	}
+
+	key := membershipKey{membership.OrgID, membership.AccountID}
+
+	if existing, ok := a.store.memberships[key]; ok {
+		existing.Role = membership.Role
+		return membership, nil
+	}
+
+	a.store.memberships[key] = copyMembership(membership)
+
+	return membership, nil
+}
+
+func (a *MembershipAdapter) Delete(ctx context.Context, orgID int, accountID int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	key := membershipKey{orgID, accountID}
+
+	if _, ok := a.store.memberships[key]; !ok {
+		return &types.NotFound{Message: fmt.Sprintf("account %d is not a member of organization %d", accountID, orgID)}
+	}
+
+	delete(a.store.memberships, key)
+
+	return nil
+}
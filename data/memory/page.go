@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+// paginate sorts, filters and limits items the same way the SQL adapters do
+// with keyset pagination, ordering by the sort key and then by id.
+func paginate[T any](page *types.Page, items []T, sorts []string, key func(T, string) (any, int), value func(*types.Page) func(T) (string, int)) ([]T, *types.PageInfo, error) {
+	sort := data.SortField(page)
+	if !slices.Contains(sorts, sort) {
+		return nil, nil, &types.BadRequest{Message: fmt.Sprintf("invalid sort: %s", sort)}
+	}
+
+	compare := func(x, y T) int {
+		xKey, xID := key(x, sort)
+		yKey, yID := key(y, sort)
+
+		c := compareKeys(xKey, yKey)
+		if c == 0 {
+			c = cmp.Compare(xID, yID)
+		}
+
+		if page != nil && page.Desc {
+			return -c
+		}
+
+		return c
+	}
+
+	slices.SortFunc(items, compare)
+
+	total := len(items)
+
+	cursor, err := data.DecodeCursor(page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cursor != nil {
+		cursorKey, err := data.CursorValue(cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items = slices.DeleteFunc(items, func(item T) bool {
+			itemKey, itemID := key(item, sort)
+
+			c := compareKeys(itemKey, cursorKey)
+			if c == 0 {
+				c = cmp.Compare(itemID, cursor.ID)
+			}
+
+			if page.Desc {
+				return c >= 0
+			}
+
+			return c <= 0
+		})
+	}
+
+	if limit := data.PageLimit(page); limit > 0 && len(items) > limit+1 {
+		items = items[:limit+1]
+	}
+
+	items, info := data.Paginate(page, items, value(page))
+
+	if page != nil && page.Total {
+		info.Total = &total
+	}
+
+	return items, info, nil
+}
+
+func compareKeys(x, y any) int {
+	switch x := x.(type) {
+	case time.Time:
+		return x.Compare(y.(time.Time))
+	case int:
+		return cmp.Compare(x, y.(int))
+	case string:
+		return strings.Compare(x, y.(string))
+	}
+
+	return 0
+}
+
+func ticketKey(ticket *types.Ticket, sort string) (any, int) {
+	switch sort {
+	case "created_at":
+		return ticket.CreatedAt, ticket.ID
+	case "updated_at":
+		return ticket.UpdatedAt, ticket.ID
+	case "status":
+		return string(ticket.Status), ticket.ID
+	default:
+		return ticket.ID, ticket.ID
+	}
+}
+
+func accountKey(account *types.Account, sort string) (any, int) {
+	switch sort {
+	case "created_at":
+		return account.CreatedAt, account.ID
+	case "updated_at":
+		return account.UpdatedAt, account.ID
+	case "username":
+		return account.Username, account.ID
+	default:
+		return account.ID, account.ID
+	}
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+)
+
+type RoleAdapter struct {
+	store *Store
+}
+
+func CreateRoleAdapter(store *Store) *RoleAdapter {
+	return &RoleAdapter{
+		store: store,
+	}
+}
+
+func (a *RoleAdapter) Create(ctx context.Context, role *types.RoleDefinition) (*types.RoleDefinition, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	key := roleKey{role.OrgID, role.Name}
+
+	if _, ok := a.store.roles[key]; ok {
+		return nil, fmt.Errorf("error creating role")
+	}
+
+	a.store.roles[key] = copyRole(role)
+
+	return role, nil
+}
+
+func (a *RoleAdapter) Get(ctx context.Context) ([]*types.RoleDefinition, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	roles := []*types.RoleDefinition{}
+
+	for _, role := range a.store.roles {
+		if inOrganization(ctx, role.OrgID) {
+			roles = append(roles, copyRole(role))
+		}
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		if roles[i].OrgID != roles[j].OrgID {
+			return roles[i].OrgID < roles[j].OrgID
+		}
+
+		return roles[i].Name < roles[j].Name
+	})
+
+	return roles, nil
+}
+
+func (a *RoleAdapter) GetByName(ctx context.Context, name types.Role) (*types.RoleDefinition, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	role := a.store.role(ctx, name)
+	if role == nil {
+		return nil, &types.NotFound{Message: fmt.Sprintf("role %s not found", name)}
+	}
+
+	return copyRole(role), nil
+}
+
+func (a *RoleAdapter) Update(ctx context.Context, role *types.RoleDefinition) (*types.RoleDefinition, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	key := roleKey{role.OrgID, role.Name}
+
+	current, ok := a.store.roles[key]
+	if !ok || !inOrganization(ctx, current.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("role %s not found", role.Name)}
+	}
+
+	role.CreatedAt = current.CreatedAt
+	a.store.roles[key] = copyRole(role)
+
+	return role, nil
+}
+
+func (a *RoleAdapter) Delete(ctx context.Context, name types.Role) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	deleted := false
+
+	for key, role := range a.store.roles {
+		if role.Name == name && inOrganization(ctx, role.OrgID) {
+			delete(a.store.roles, key)
+			deleted = true
+		}
+	}
+
+	if !deleted {
+		return &types.NotFound{Message: fmt.Sprintf("role %s not found", name)}
+	}
+
+	return nil
+}
+
+// role returns the role called name in the organization ctx is scoped to, or
+// in the first organization having one when it is not.
+func (s *Store) role(ctx context.Context, name types.Role) *types.RoleDefinition {
+	var found *types.RoleDefinition
+
+	for key, role := range s.roles {
+		if key.name == name && inOrganization(ctx, key.orgID) && (found == nil || key.orgID < found.OrgID) {
+			found = role
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"ticketing-api/types"
+	"time"
+)
+
+type SessionAdapter struct {
+	store *Store
+}
+
+func CreateSessionAdapter(store *Store) *SessionAdapter {
+	return &SessionAdapter{
+		store: store,
+	}
+}
+
+func (s *SessionAdapter) Create(ctx context.Context, session *types.Session) (*types.Session, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.sessions[session.ID]; ok {
+		return nil, fmt.Errorf("error creating session")
+	}
+
+	s.store.sessions[session.ID] = copySession(session)
+
+	return session, nil
+}
+
+func (s *SessionAdapter) GetByID(ctx context.Context, id string) (*types.Session, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	session, ok := s.store.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+
+	return copySession(session), nil
+}
+
+func (s *SessionAdapter) Rotate(ctx context.Context, id string, oldHash string, newHash string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	session, ok := s.store.sessions[id]
+	if !ok || session.TokenHash != oldHash || session.RevokedAt != nil {
+		return &types.PreconditionFailed{Message: fmt.Sprintf("session %s has already been refreshed", id)}
+	}
+
+	session.TokenHash = newHash
+
+	return nil
+}
+
+func (s *SessionAdapter) Revoke(ctx context.Context, id string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if session, ok := s.store.sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+
+	return nil
+}
+
+func (s *SessionAdapter) RevokeByAccountID(ctx context.Context, accountID int) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	now := time.Now()
+
+	for _, session := range s.store.sessions {
+		if session.AccountID == accountID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+)
+
+type SLAPolicyAdapter struct {
+	store *Store
+}
+
+func CreateSLAPolicyAdapter(store *Store) *SLAPolicyAdapter {
+	return &SLAPolicyAdapter{
+		store: store,
+	}
+}
+
+func (a *SLAPolicyAdapter) Create(ctx context.Context, policy *types.SLAPolicy) (*types.SLAPolicy, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.orgs[policy.OrgID]; !ok || !a.store.policyNameFree(policy) {
+		return nil, fmt.Errorf("error creating sla policy")
+	}
+
+	a.store.policyID++
+	policy.ID = a.store.policyID
+	a.store.clearDefaultPolicy(policy)
+	a.store.policies[policy.ID] = copySLAPolicy(policy)
+
+	return policy, nil
+}
+
+func (a *SLAPolicyAdapter) Get(ctx context.Context) ([]*types.SLAPolicy, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	policies := []*types.SLAPolicy{}
+
+	for _, policy := range a.store.policies {
+		if inOrganization(ctx, policy.OrgID) {
+			policies = append(policies, copySLAPolicy(policy))
+		}
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].ID < policies[j].ID
+	})
+
+	return policies, nil
+}
+
+func (a *SLAPolicyAdapter) GetByID(ctx context.Context, id int) (*types.SLAPolicy, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	policy, ok := a.store.policies[id]
+	if !ok || !inOrganization(ctx, policy.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("sla policy %d not found", id)}
+	}
+
+	return copySLAPolicy(policy), nil
+}
+
+func (a *SLAPolicyAdapter) Update(ctx context.Context, policy *types.SLAPolicy) (*types.SLAPolicy, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	existing, ok := a.store.policies[policy.ID]
+	if !ok || !inOrganization(ctx, existing.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("sla policy %d not found", policy.ID)}
+	}
+
+	if !a.store.policyNameFree(&types.SLAPolicy{ID: policy.ID, OrgID: existing.OrgID, Name: policy.Name}) {
+		return nil, fmt.Errorf("error updating sla policy")
+	}
+
+	policy.OrgID = existing.OrgID
+	policy.CreatedAt = existing.CreatedAt
+	a.store.clearDefaultPolicy(policy)
+	a.store.policies[policy.ID] = copySLAPolicy(policy)
+
+	return policy, nil
+}
+
+func (a *SLAPolicyAdapter) Delete(ctx context.Context, id int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	policy, ok := a.store.policies[id]
+	if !ok || !inOrganization(ctx, policy.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("sla policy %d not found", id)}
+	}
+
+	delete(a.store.policies, id)
+
+	return nil
+}
+
+func (s *Store) policyNameFree(policy *types.SLAPolicy) bool {
+	for _, existing := range s.policies {
+		if existing.ID != policy.ID && existing.OrgID == policy.OrgID && existing.Name == policy.Name {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Store) clearDefaultPolicy(policy *types.SLAPolicy) {
+	if !policy.Default {
+		return
+	}
+
+	for _, existing := range s.policies {
+		if existing.ID != policy.ID && existing.OrgID == policy.OrgID {
+			existing.Default = false
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+type Store struct {
+	mu             sync.RWMutex
+	accounts       map[int]*types.Account
+	tickets        map[int]*types.Ticket
+	messages       map[int][]*types.Message
+	history        []*types.Change
+	sessions       map[string]*types.Session
+	roles          map[roleKey]*types.RoleDefinition
+	orgs           map[int]*types.Organization
+	memberships    map[membershipKey]*types.Membership
+	teams          map[int]*types.Team
+	agents         map[membershipKey]*types.Agent
+	assignments    []*types.Assignment
+	policies       map[int]*types.SLAPolicy
+	categories     map[int]*types.Category
+	fields         map[int]*types.CustomField
+	attachments    map[int]*types.Attachment
+	links          map[int]*types.TicketLink
+	notifications  map[int]*types.Notification
+	preferences    map[int]*types.NotificationPreferences
+	accountID      int
+	ticketID       int
+	changeID       int
+	orgID          int
+	teamID         int
+	assignID       int
+	policyID       int
+	categoryID     int
+	fieldID        int
+	attachmentID   int
+	linkID         int
+	notificationID int
+}
+
+type membershipKey struct {
+	orgID     int
+	accountID int
+}
+
+type roleKey struct {
+	orgID int
+	name  types.Role
+}
+
+// CreateStore returns an empty store holding only the built in roles and the
+// default organization.
+func CreateStore() *Store {
+	store := createStore()
+
+	for _, role := range types.DefaultRoles(types.DefaultOrganizationID) {
+		store.roles[roleKey{role.OrgID, role.Name}] = role
+	}
+
+	org := types.CreateOrganization("Default")
+	org.ID = types.DefaultOrganizationID
+	store.orgs[org.ID] = org
+	store.orgID = org.ID
+
+	return store
+}
+
+func createStore() *Store {
+	return &Store{
+		accounts:      make(map[int]*types.Account),
+		tickets:       make(map[int]*types.Ticket),
+		messages:      make(map[int][]*types.Message),
+		sessions:      make(map[string]*types.Session),
+		roles:         make(map[roleKey]*types.RoleDefinition),
+		orgs:          make(map[int]*types.Organization),
+		memberships:   make(map[membershipKey]*types.Membership),
+		teams:         make(map[int]*types.Team),
+		agents:        make(map[membershipKey]*types.Agent),
+		policies:      make(map[int]*types.SLAPolicy),
+		categories:    make(map[int]*types.Category),
+		fields:        make(map[int]*types.CustomField),
+		attachments:   make(map[int]*types.Attachment),
+		links:         make(map[int]*types.TicketLink),
+		notifications: make(map[int]*types.Notification),
+		preferences:   make(map[int]*types.NotificationPreferences),
+	}
+}
+
+// Transaction runs fn against a copy of the store and swaps the copy in when
+// fn succeeds. The store is locked for the duration, so fn must only use the
+// sockets it is given.
+func (s *Store) Transaction(ctx context.Context, fn func(*data.DataAdapter) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := s.clone()
+
+	err := fn(data.CreateDataAdapter(
+		CreateAccountAdapter(tx),
+		CreateTicketAdapter(tx),
+		CreateMessageAdapter(tx),
+		CreateHistoryAdapter(tx),
+		CreateSessionAdapter(tx),
+		CreateRoleAdapter(tx),
+		CreateOrganizationAdapter(tx),
+		CreateMembershipAdapter(tx),
+		CreateTeamAdapter(tx),
+		CreateAgentAdapter(tx),
+		CreateAssignmentAdapter(tx),
+		CreateSLAPolicyAdapter(tx),
+		CreateCategoryAdapter(tx),
+		CreateCustomFieldAdapter(tx),
+		CreateAttachmentAdapter(tx),
+		CreateLinkAdapter(tx),
+		CreateNotificationAdapter(tx),
+		nil,
+	))
+	if err != nil {
+		return err
+	}
+
+	s.accounts = tx.accounts
+	s.tickets = tx.tickets
+	s.messages = tx.messages
+	s.history = tx.history
+	s.sessions = tx.sessions
+	s.roles = tx.roles
+	s.orgs = tx.orgs
+	s.memberships = tx.memberships
+	s.teams = tx.teams
+	s.agents = tx.agents
+	s.assignments = tx.assignments
+	s.policies = tx.policies
+	s.categories = tx.categories
+	s.fields = tx.fields
+	s.attachments = tx.attachments
+	s.links = tx.links
+	s.notifications = tx.notifications
+	s.preferences = tx.preferences
+	s.accountID = tx.accountID
+	s.ticketID = tx.ticketID
+	s.changeID = tx.changeID
+	s.orgID = tx.orgID
+	s.teamID = tx.teamID
+	s.assignID = tx.assignID
+	s.policyID = tx.policyID
+	s.categoryID = tx.categoryID
+	s.fieldID = tx.fieldID
+	s.attachmentID = tx.attachmentID
+	s.linkID = tx.linkID
+	s.notificationID = tx.notificationID
+
+	return nil
+}
+
+func (s *Store) clone() *Store {
+	store := createStore()
+	store.accountID = s.accountID
+	store.ticketID = s.ticketID
+	store.changeID = s.changeID
+	store.orgID = s.orgID
+	store.teamID = s.teamID
+	store.assignID = s.assignID
+	store.policyID = s.policyID
+	store.categoryID = s.categoryID
+	store.fieldID = s.fieldID
+	store.attachmentID = s.attachmentID
+	store.linkID = s.linkID
+	store.notificationID = s.notificationID
+
+	for id, account := range s.accounts {
+		store.accounts[id] = copyAccount(account)
+	}
+
+	for id, ticket := range s.tickets {
+		store.tickets[id] = copyTicket(ticket)
+	}
+
+	for id, messages := range s.messages {
+		for _, message := range messages {
+			store.messages[id] = append(store.messages[id], copyMessage(message))
+		}
+	}
+
+	for id, session := range s.sessions {
+		store.sessions[id] = copySession(session)
+	}
+
+	for key, role := range s.roles {
+		store.roles[key] = copyRole(role)
+	}
+
+	for id, org := range s.orgs {
+		store.orgs[id] = copyOrganization(org)
+	}
+
+	for key, membership := range s.memberships {
+		store.memberships[key] = copyMembership(membership)
+	}
+
+	for id, team := range s.teams {
+		store.teams[id] = copyTeam(team)
+	}
+
+	for key, agent := range s.agents {
+		store.agents[key] = copyAgent(agent)
+	}
+
+	for _, assignment := range s.assignments {
+		store.assignments = append(store.assignments, copyAssignment(assignment))
+	}
+
+	for id, policy := range s.policies {
+		store.policies[id] = copySLAPolicy(policy)
+	}
+
+	for id, category := range s.categories {
+		store.categories[id] = copyCategory(category)
+	}
+
+	for id, field := range s.fields {
+		store.fields[id] = copyCustomField(field)
+	}
+
+	for id, attachment := range s.attachments {
+		store.attachments[id] = copyAttachment(attachment)
+	}
+
+	for id, link := range s.links {
+		store.links[id] = copyLink(link)
+	}
+
+	for id, notification := range s.notifications {
+		store.notifications[id] = copyNotification(notification)
+	}
+
+	for id, prefs := range s.preferences {
+		store.preferences[id] = copyPreferences(prefs)
+	}
+
+	for _, change := range s.history {
+		store.history = append(store.history, copyChange(change))
+	}
+
+	return store
+}
+
+func copyAccount(a *types.Account) *types.Account {
+	account := *a
+	return &account
+}
+
+func copyTicket(t *types.Ticket) *types.Ticket {
+	ticket := *t
+	ticket.AssigneeIDs = append([]int{}, t.AssigneeIDs...)
+	ticket.WatcherIDs = append([]int{}, t.WatcherIDs...)
+	ticket.Labels = append([]string{}, t.Labels...)
+	ticket.Fields = copyFields(t.Fields)
+	return &ticket
+}
+
+// copyFields copies the values of fields along with the lists multi-selects
+// hold.
+func copyFields(f types.Fields) types.Fields {
+	fields := types.Fields{}
+
+	for key, value := range f {
+		if list, ok := value.([]any); ok {
+			value = append([]any{}, list...)
+		}
+
+		fields[key] = value
+	}
+
+	return fields
+}
+
+func copyMessage(m *types.Message) *types.Message {
+	message := *m
+	message.AttachmentIDs = append([]int{}, m.AttachmentIDs...)
+	return &message
+}
+
+func copyChange(c *types.Change) *types.Change {
+	change := *c
+	return &change
+}
+
+func copySession(s *types.Session) *types.Session {
+	session := *s
+	return &session
+}
+
+func copyRole(r *types.RoleDefinition) *types.RoleDefinition {
+	role := *r
+	role.Permissions = append([]types.Permission{}, r.Permissions...)
+	return &role
+}
+
+func copyOrganization(o *types.Organization) *types.Organization {
+	org := *o
+	return &org
+}
+
+func copyMembership(m *types.Membership) *types.Membership {
+	membership := *m
+	return &membership
+}
+
+func copyTeam(t *types.Team) *types.Team {
+	team := *t
+	team.MemberIDs = append([]int{}, t.MemberIDs...)
+	return &team
+}
+
+func copyAgent(a *types.Agent) *types.Agent {
+	agent := *a
+	agent.Skills = append([]string{}, a.Skills...)
+	return &agent
+}
+
+func copyAssignment(a *types.Assignment) *types.Assignment {
+	assignment := *a
+	return &assignment
+}
+
+func copySLAPolicy(p *types.SLAPolicy) *types.SLAPolicy {
+	policy := *p
+	policy.Calendar.Hours = append([]types.BusinessHours{}, p.Calendar.Hours...)
+	policy.Calendar.Holidays = append([]string{}, p.Calendar.Holidays...)
+	policy.Targets = append([]types.SLATarget{}, p.Targets...)
+	return &policy
+}
+
+func copyCategory(c *types.Category) *types.Category {
+	category := *c
+	return &category
+}
+
+func copyCustomField(f *types.CustomField) *types.CustomField {
+	field := *f
+	field.Options = append([]string{}, f.Options...)
+	return &field
+}
+
+func copyAttachment(a *types.Attachment) *types.Attachment {
+	attachment := *a
+	return &attachment
+}
+
+func copyLink(l *types.TicketLink) *types.TicketLink {
+	link := *l
+	return &link
+}
+
+func copyNotification(n *types.Notification) *types.Notification {
+	notification := *n
+	if n.SentAt != nil {
+		sentAt := *n.SentAt
+		notification.SentAt = &sentAt
+	}
+	return &notification
+}
+
+func copyPreferences(p *types.NotificationPreferences) *types.NotificationPreferences {
+	prefs := *p
+	prefs.Events = append([]types.Event{}, p.Events...)
+	return &prefs
+}
+
+// inOrganization reports whether a row of orgID is visible to queries made
+// with ctx.
+func inOrganization(ctx context.Context, orgID int) bool {
+	scope, ok := data.Organization(ctx)
+	return !ok || scope == orgID
+}
+
+// accountInOrganization reports whether account belongs to, or is a member of,
+// the organization ctx is scoped to.
+func (s *Store) accountInOrganization(ctx context.Context, account *types.Account) bool {
+	scope, ok := data.Organization(ctx)
+	if !ok || scope == account.OrgID {
+		return true
+	}
+
+	_, member := s.memberships[membershipKey{scope, account.ID}]
+
+	return member
+}
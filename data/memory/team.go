@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"ticketing-api/types"
+)
+
+type TeamAdapter struct {
+	store *Store
+}
+
+func CreateTeamAdapter(store *Store) *TeamAdapter {
+	return &TeamAdapter{
+		store: store,
+	}
+}
+
+func (a *TeamAdapter) Create(ctx context.Context, team *types.Team) (*types.Team, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if _, ok := a.store.orgs[team.OrgID]; !ok || !a.store.teamNameFree(team) {
+		return nil, fmt.Errorf("error creating team")
+	}
+
+	if !a.store.accountsExist(team.MemberIDs) {
+		return nil, fmt.Errorf("error creating team member")
+	}
+
+	a.store.teamID++
+	team.ID = a.store.teamID
+	team.MemberIDs = sortedIDs(team.MemberIDs)
+	a.store.teams[team.ID] = copyTeam(team)
+
+	return team, nil
+}
+
+func (a *TeamAdapter) Get(ctx context.Context) ([]*types.Team, error) {
+	return a.fetchTeams(func(team *types.Team) bool {
+		return inOrganization(ctx, team.OrgID)
+	})
+}
+
+func (a *TeamAdapter) GetByID(ctx context.Context, id int) (*types.Team, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	team, ok := a.store.teams[id]
+	if !ok || !inOrganization(ctx, team.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("team %d not found", id)}
+	}
+
+	return copyTeam(team), nil
+}
+
+func (a *TeamAdapter) GetByAccountID(ctx context.Context, accountID int) ([]*types.Team, error) {
+	return a.fetchTeams(func(team *types.Team) bool {
+		return inOrganization(ctx, team.OrgID) && slices.Contains(team.MemberIDs, accountID)
+	})
+}
+
+func (a *TeamAdapter) Update(ctx context.Context, team *types.Team) (*types.Team, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	existing, ok := a.store.teams[team.ID]
+	if !ok || !inOrganization(ctx, existing.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("team %d not found", team.ID)}
+	}
+
+	if !a.store.teamNameFree(&types.Team{ID: team.ID, OrgID: existing.OrgID, Name: team.Name}) {
+		return nil, fmt.Errorf("error updating team")
+	}
+
+	if !a.store.accountsExist(team.MemberIDs) {
+		return nil, fmt.Errorf("error creating team member")
+	}
+
+	team.MemberIDs = sortedIDs(team.MemberIDs)
+	existing.Name = team.Name
+	existing.MemberIDs = append([]int{}, team.MemberIDs...)
+	existing.UpdatedAt = team.UpdatedAt
+
+	return team, nil
+}
+
+func (a *TeamAdapter) Delete(ctx context.Context, id int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	team, ok := a.store.teams[id]
+	if !ok || !inOrganization(ctx, team.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("team %d not found", id)}
+	}
+
+	delete(a.store.teams, id)
+
+	for _, ticket := range a.store.tickets {
+		if ticket.TeamID == id {
+			ticket.TeamID = 0
+		}
+	}
+
+	return nil
+}
+
+func (a *TeamAdapter) fetchTeams(match func(*types.Team) bool) ([]*types.Team, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	teams := []*types.Team{}
+
+	for _, team := range a.store.teams {
+		if match(team) {
+			teams = append(teams, copyTeam(team))
+		}
+	}
+
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].ID < teams[j].ID
+	})
+
+	return teams, nil
+}
+
+func (s *Store) teamNameFree(team *types.Team) bool {
+	for _, existing := range s.teams {
+		if existing.ID != team.ID && existing.OrgID == team.OrgID && existing.Name == team.Name {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortedIDs(ids []int) []int {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,431 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+type TicketAdapter struct {
+	store *Store
+}
+
+func CreateTicketAdapter(store *Store) *TicketAdapter {
+	return &TicketAdapter{
+		store: store,
+	}
+}
+
+func (t *TicketAdapter) Create(ctx context.Context, ticket *types.Ticket) (*types.Ticket, error) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if _, ok := t.store.accounts[ticket.AuthorID]; !ok {
+		return nil, fmt.Errorf("error creating ticket")
+	}
+
+	if !t.store.accountsExist(ticket.AssigneeIDs) {
+		return nil, fmt.Errorf("error creating assignee")
+	}
+
+	if !t.store.teamExists(ticket.TeamID) || !t.store.categoryExists(ticket.CategoryID) {
+		return nil, fmt.Errorf("error creating ticket")
+	}
+
+	t.store.ticketID++
+	ticket.ID = t.store.ticketID
+	ticket.Version = 1
+	ticket.WatcherIDs = addWatchers([]int{}, append([]int{ticket.AuthorID}, ticket.AssigneeIDs...))
+
+	stored := copyTicket(ticket)
+	stored.SLA.FirstResponseAt = nil
+	stored.SLA.FirstResponseBreachedAt = nil
+	stored.SLA.ResolutionBreachedAt = nil
+	t.store.tickets[ticket.ID] = stored
+
+	return ticket, nil
+}
+
+func (t *TicketAdapter) Get(ctx context.Context, filter *types.TicketFilter, page *types.Page) ([]*types.Ticket, *types.PageInfo, error) {
+	return t.fetchTickets(page, func(ticket *types.Ticket) bool {
+		return inOrganization(ctx, ticket.OrgID) && matchTicket(ticket, filter)
+	})
+}
+
+func (t *TicketAdapter) GetByID(ctx context.Context, id int) (*types.Ticket, error) {
+	t.store.mu.RLock()
+	defer t.store.mu.RUnlock()
+
+	ticket, ok := t.store.tickets[id]
+	if !ok || ticket.DeletedAt != nil || !inOrganization(ctx, ticket.OrgID) {
+		return nil, fmt.Errorf("ticket %d not found", id)
+	}
+
+	return copyTicket(ticket), nil
+}
+
+func (t *TicketAdapter) GetDeleted(ctx context.Context, id int) (*types.Ticket, error) {
+	t.store.mu.RLock()
+	defer t.store.mu.RUnlock()
+
+	ticket, ok := t.store.tickets[id]
+	if !ok || ticket.DeletedAt == nil || !inOrganization(ctx, ticket.OrgID) {
+		return nil, &types.NotFound{Message: fmt.Sprintf("deleted ticket %d not found", id)}
+	}
+
+	return copyTicket(ticket), nil
+}
+
+func (t *TicketAdapter) Update(ctx context.Context, ticket *types.Ticket) (*types.Ticket, error) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	existing, ok := t.store.tickets[ticket.ID]
+	if !ok || existing.DeletedAt != nil || existing.Version != ticket.Version || !inOrganization(ctx, existing.OrgID) {
+		return nil, &types.PreconditionFailed{Message: fmt.Sprintf("ticket %d has changed since version %d", ticket.ID, ticket.Version)}
+	}
+
+	if _, ok := t.store.accounts[ticket.AuthorID]; !ok {
+		return nil, fmt.Errorf("error updating ticket")
+	}
+
+	if !t.store.accountsExist(ticket.AssigneeIDs) {
+		return nil, fmt.Errorf("error creating assignee")
+	}
+
+	if !t.store.teamExists(ticket.TeamID) || !t.store.categoryExists(ticket.CategoryID) {
+		return nil, fmt.Errorf("error updating ticket")
+	}
+
+	for _, id := range ticket.AssigneeIDs {
+		if !slices.Contains(existing.AssigneeIDs, id) {
+			existing.WatcherIDs = addWatchers(existing.WatcherIDs, []int{id})
+		}
+	}
+
+	existing.Title = ticket.Title
+	existing.Description = ticket.Description
+	existing.AuthorID = ticket.AuthorID
+	existing.Status = ticket.Status
+	existing.Resolution = ticket.Resolution
+	existing.AssigneeIDs = append([]int{}, ticket.AssigneeIDs...)
+	existing.TeamID = ticket.TeamID
+	existing.CategoryID = ticket.CategoryID
+	existing.Labels = append([]string{}, ticket.Labels...)
+	existing.Fields = copyFields(ticket.Fields)
+	existing.Priority = ticket.Priority
+	existing.DueAt = ticket.DueAt
+	existing.SLA.FirstResponseDueAt = ticket.SLA.FirstResponseDueAt
+	existing.SLA.ResolutionDueAt = ticket.SLA.ResolutionDueAt
+	existing.SLA.ResolvedAt = ticket.SLA.ResolvedAt
+	existing.Version++
+	existing.UpdatedAt = time.Now()
+	ticket.Version = existing.Version
+	ticket.UpdatedAt = existing.UpdatedAt
+	ticket.WatcherIDs = append([]int{}, existing.WatcherIDs...)
+
+	return ticket, nil
+}
+
+func (t *TicketAdapter) Delete(ctx context.Context, id int) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if ticket, ok := t.store.tickets[id]; ok && ticket.DeletedAt == nil && inOrganization(ctx, ticket.OrgID) {
+		now := time.Now()
+		ticket.DeletedAt = &now
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) Restore(ctx context.Context, id int) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	ticket, ok := t.store.tickets[id]
+	if !ok || ticket.DeletedAt == nil || !inOrganization(ctx, ticket.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("deleted ticket %d not found", id)}
+	}
+
+	ticket.DeletedAt = nil
+
+	return nil
+}
+
+func (t *TicketAdapter) Purge(ctx context.Context, id int) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if ticket, ok := t.store.tickets[id]; !ok || !inOrganization(ctx, ticket.OrgID) {
+		return &types.NotFound{Message: fmt.Sprintf("ticket %d not found", id)}
+	}
+
+	delete(t.store.tickets, id)
+
+	// like the foreign keys of the sql stores
+	for attachmentID, attachment := range t.store.attachments {
+		if attachment.TicketID == id {
+			delete(t.store.attachments, attachmentID)
+		}
+	}
+
+	for linkID, link := range t.store.links {
+		if link.SourceID == id || link.TargetID == id {
+			delete(t.store.links, linkID)
+		}
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) Claim(ctx context.Context, teamID int, accountID int) (*types.Ticket, error) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if _, ok := t.store.accounts[accountID]; !ok {
+		return nil, fmt.Errorf("error creating assignee")
+	}
+
+	var next *types.Ticket
+
+	for _, ticket := range t.store.tickets {
+		if ticket.TeamID != teamID || ticket.DeletedAt != nil || len(ticket.AssigneeIDs) > 0 || !slices.Contains(types.QueueStatuses, ticket.Status) || !inOrganization(ctx, ticket.OrgID) {
+			continue
+		}
+
+		if next == nil || ticket.CreatedAt.Before(next.CreatedAt) || (ticket.CreatedAt.Equal(next.CreatedAt) && ticket.ID < next.ID) {
+			next = ticket
+		}
+	}
+
+	if next == nil {
+		return nil, &types.NotFound{Message: fmt.Sprintf("queue of team %d is empty", teamID)}
+	}
+
+	next.AssigneeIDs = []int{accountID}
+	next.WatcherIDs = addWatchers(next.WatcherIDs, []int{accountID})
+	next.Version++
+	next.UpdatedAt = time.Now()
+
+	return copyTicket(next), nil
+}
+
+func (t *TicketAdapter) Watch(ctx context.Context, id int, accountID int) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if _, ok := t.store.accounts[accountID]; !ok {
+		return fmt.Errorf("error creating watcher")
+	}
+
+	if ticket, ok := t.store.tickets[id]; ok && ticket.DeletedAt == nil && inOrganization(ctx, ticket.OrgID) {
+		ticket.WatcherIDs = addWatchers(ticket.WatcherIDs, []int{accountID})
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) Unwatch(ctx context.Context, id int, accountID int) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if ticket, ok := t.store.tickets[id]; ok && inOrganization(ctx, ticket.OrgID) {
+		ticket.WatcherIDs = slices.DeleteFunc(ticket.WatcherIDs, func(watcherID int) bool {
+			return watcherID == accountID
+		})
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) RecordFirstResponse(ctx context.Context, id int, at time.Time) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if ticket, ok := t.store.tickets[id]; ok && ticket.SLA.FirstResponseAt == nil && inOrganization(ctx, ticket.OrgID) {
+		ticket.SLA.FirstResponseAt = &at
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) MarkBreached(ctx context.Context, id int, metric types.SLAMetric, at time.Time) (bool, error) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	ticket, ok := t.store.tickets[id]
+	if !ok || !inOrganization(ctx, ticket.OrgID) {
+		return false, nil
+	}
+
+	var flag **time.Time
+
+	switch metric {
+	case types.SLAFirstResponse:
+		flag = &ticket.SLA.FirstResponseBreachedAt
+	case types.SLAResolution:
+		flag = &ticket.SLA.ResolutionBreachedAt
+	default:
+		return false, fmt.Errorf("unknown sla metric %s", metric)
+	}
+
+	if *flag != nil {
+		return false, nil
+	}
+
+	*flag = &at
+
+	return true, nil
+}
+
+func (t *TicketAdapter) fetchTickets(page *types.Page, match func(*types.Ticket) bool) ([]*types.Ticket, *types.PageInfo, error) {
+	t.store.mu.RLock()
+	defer t.store.mu.RUnlock()
+
+	tickets := []*types.Ticket{}
+
+	for _, ticket := range t.store.tickets {
+		if ticket.DeletedAt == nil && match(ticket) {
+			tickets = append(tickets, copyTicket(ticket))
+		}
+	}
+
+	return paginate(page, tickets, data.TicketSorts, ticketKey, data.TicketSortValue)
+}
+
+func (s *Store) accountsExist(ids []int) bool {
+	for _, id := range ids {
+		if _, ok := s.accounts[id]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addWatchers appends the accounts that do not watch yet, like the unique
+// watcher rows of the sql stores.
+func addWatchers(watcherIDs []int, accountIDs []int) []int {
+	for _, id := range accountIDs {
+		if !slices.Contains(watcherIDs, id) {
+			watcherIDs = append(watcherIDs, id)
+		}
+	}
+
+	return watcherIDs
+}
+
+func (s *Store) teamExists(id int) bool {
+	if id == 0 {
+		return true
+	}
+
+	_, ok := s.teams[id]
+
+	return ok
+}
+
+func matchTicket(ticket *types.Ticket, filter *types.TicketFilter) bool {
+	if filter == nil {
+		return true
+	}
+
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, ticket.Status) {
+		return false
+	}
+
+	if len(filter.AuthorIDs) > 0 && !slices.Contains(filter.AuthorIDs, ticket.AuthorID) {
+		return false
+	}
+
+	if len(filter.TeamIDs) > 0 && !slices.Contains(filter.TeamIDs, ticket.TeamID) {
+		return false
+	}
+
+	if len(filter.Priorities) > 0 && !slices.Contains(filter.Priorities, ticket.Priority) {
+		return false
+	}
+
+	if len(filter.CategoryIDs) > 0 && !slices.Contains(filter.CategoryIDs, ticket.CategoryID) {
+		return false
+	}
+
+	if len(filter.Labels) > 0 && !slices.ContainsFunc(filter.Labels, func(label string) bool { return slices.Contains(ticket.Labels, label) }) {
+		return false
+	}
+
+	for _, field := range filter.Fields {
+		if !hasFieldValue(ticket.Fields[field.Key], field.Value) {
+			return false
+		}
+	}
+
+	assigned := len(filter.AssigneeIDs) > 0 && hasAssignee(ticket, filter.AssigneeIDs)
+	unassigned := filter.Unassigned && len(ticket.AssigneeIDs) == 0
+
+	if (len(filter.AssigneeIDs) > 0 || filter.Unassigned) && !assigned && !unassigned {
+		return false
+	}
+
+	if filter.ParticipantID > 0 && ticket.AuthorID != filter.ParticipantID && !slices.Contains(ticket.AssigneeIDs, filter.ParticipantID) {
+		return false
+	}
+
+	if filter.CreatedAfter != nil && ticket.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+
+	if filter.CreatedBefore != nil && !ticket.CreatedAt.Before(*filter.CreatedBefore) {
+		return false
+	}
+
+	if filter.UpdatedAfter != nil && ticket.UpdatedAt.Before(*filter.UpdatedAfter) {
+		return false
+	}
+
+	if filter.UpdatedBefore != nil && !ticket.UpdatedAt.Before(*filter.UpdatedBefore) {
+		return false
+	}
+
+	if filter.Title != "" && !strings.Contains(strings.ToLower(ticket.Title), strings.ToLower(filter.Title)) {
+		return false
+	}
+
+	switch filter.SLA {
+	case types.SLABreached:
+		if filter.SLAUnflagged {
+			return len(ticket.SLA.Unflagged(filter.SLANow)) > 0
+		}
+
+		return len(ticket.SLA.Breached(filter.SLANow)) > 0
+	case types.SLAAtRisk:
+		return len(ticket.SLA.Breached(filter.SLANow)) == 0 && ticket.SLA.AtRisk(filter.SLANow.Add(filter.SLAWindow))
+	}
+
+	return true
+}
+
+func hasAssignee(ticket *types.Ticket, assigneeIDs []int) bool {
+	for _, id := range ticket.AssigneeIDs {
+		if slices.Contains(assigneeIDs, id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasFieldValue reports whether value equals want or, for a multi-select,
+// holds it.
+func hasFieldValue(value any, want any) bool {
+	if list, ok := value.([]any); ok {
+		return slices.Contains(list, want)
+	}
+
+	return value != nil && value == want
+}
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"ticketing-api/types"
 	"time"
@@ -18,8 +19,15 @@ func CreateMessageAdapter(db *gocql.Session) *MessageAdapter {
 	}
 }
 
-func (m *MessageAdapter) Get(id int) ([]*types.Message, error) {
-	scanner := m.db.Query("SELECT id, ticket_id, author_id, content, created_at, updated_at FROM message WHERE ticket_id = ? ORDER BY created_at DESC", id).Iter().Scanner()
+// Messages are partitioned by organization and ticket, so every read and
+// delete needs a context scoped to an organization.
+func (m *MessageAdapter) Get(ctx context.Context, id int) ([]*types.Message, error) {
+	orgID, err := messageOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := m.db.Query("SELECT id, org_id, ticket_id, author_id, content, attachment_ids, created_at, updated_at FROM org_message WHERE org_id = ? AND ticket_id = ? ORDER BY created_at DESC", orgID, id).WithContext(ctx).Iter().Scanner()
 
 	messages := []*types.Message{}
 
@@ -35,8 +43,8 @@ func (m *MessageAdapter) Get(id int) ([]*types.Message, error) {
 	return messages, nil
 }
 
-func (m *MessageAdapter) Create(message *types.Message) (*types.Message, error) {
-	err := m.db.Query("INSERT INTO message (id, ticket_id, author_id, content, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)", message.ID, message.TicketID, message.AuthorID, message.Content, message.CreatedAt, message.UpdatedAt).Exec()
+func (m *MessageAdapter) Create(ctx context.Context, message *types.Message) (*types.Message, error) {
+	err := m.db.Query("INSERT INTO org_message (id, org_id, ticket_id, author_id, content, attachment_ids, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", message.ID, message.OrgID, message.TicketID, message.AuthorID, message.Content, message.AttachmentIDs, message.CreatedAt, message.UpdatedAt).WithContext(ctx).Exec()
 	if err != nil {
 		return nil, fmt.Errorf("error creating message")
 	}
@@ -44,8 +52,13 @@ func (m *MessageAdapter) Create(message *types.Message) (*types.Message, error)
 	return message, nil
 }
 
-func (m *MessageAdapter) Delete(id string, created_at time.Time, ticket_id int) error {
-	err := m.db.Query("DELETE FROM message WHERE id = ? AND created_at = ? AND ticket_id = ?", id, created_at, ticket_id).Exec()
+func (m *MessageAdapter) Delete(ctx context.Context, id string, created_at time.Time, ticket_id int) error {
+	orgID, err := messageOrganization(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = m.db.Query("DELETE FROM org_message WHERE id = ? AND created_at = ? AND org_id = ? AND ticket_id = ?", id, created_at, orgID, ticket_id).WithContext(ctx).Exec()
 	if err != nil {
 		return fmt.Errorf("error deleting message")
 	}
@@ -53,8 +66,27 @@ func (m *MessageAdapter) Delete(id string, created_at time.Time, ticket_id int)
 	return nil
 }
 
-func (m *MessageAdapter) GetByID(id string, created_at time.Time, ticket_id int) (*types.Message, error) {
-	scanner := m.db.Query("SELECT id, ticket_id, author_id, content, created_at, updated_at FROM message WHERE id = ? AND created_at = ? AND ticket_id = ?", id, created_at, ticket_id).Iter().Scanner()
+func (m *MessageAdapter) DeleteByTicketID(ctx context.Context, ticketID int) error {
+	orgID, err := messageOrganization(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = m.db.Query("DELETE FROM org_message WHERE org_id = ? AND ticket_id = ?", orgID, ticketID).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("error deleting messages")
+	}
+
+	return nil
+}
+
+func (m *MessageAdapter) GetByID(ctx context.Context, id string, created_at time.Time, ticket_id int) (*types.Message, error) {
+	orgID, err := messageOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := m.db.Query("SELECT id, org_id, ticket_id, author_id, content, attachment_ids, created_at, updated_at FROM org_message WHERE id = ? AND created_at = ? AND org_id = ? AND ticket_id = ?", id, created_at, orgID, ticket_id).WithContext(ctx).Iter().Scanner()
 
 	for scanner.Next() {
 		return scanIntoMessage(scanner)
@@ -63,8 +95,8 @@ func (m *MessageAdapter) GetByID(id string, created_at time.Time, ticket_id int)
 	return nil, fmt.Errorf("mesage %s not found", id)
 }
 
-func (m *MessageAdapter) Update(message *types.Message) (*types.Message, error) {
-	err := m.db.Query("UPDATE message SET author_id = ?, content = ?, updated_at = ? WHERE id = ? AND created_at = ? AND ticket_id = ?", message.AuthorID, message.Content, message.UpdatedAt, message.ID, message.CreatedAt, message.TicketID).Exec()
+func (m *MessageAdapter) Update(ctx context.Context, message *types.Message) (*types.Message, error) {
+	err := m.db.Query("UPDATE org_message SET author_id = ?, content = ?, attachment_ids = ?, updated_at = ? WHERE id = ? AND created_at = ? AND org_id = ? AND ticket_id = ?", message.AuthorID, message.Content, message.AttachmentIDs, message.UpdatedAt, message.ID, message.CreatedAt, message.OrgID, message.TicketID).WithContext(ctx).Exec()
 	if err != nil {
 		return nil, fmt.Errorf("error updating message %w", err)
 	}
@@ -72,13 +104,27 @@ func (m *MessageAdapter) Update(message *types.Message) (*types.Message, error)
 	return message, nil
 }
 
+func messageOrganization(ctx context.Context) (int, error) {
+	orgID, ok := Organization(ctx)
+	if !ok {
+		return 0, fmt.Errorf("error reading messages: no organization")
+	}
+
+	return orgID, nil
+}
+
 func scanIntoMessage(scanner gocql.Scanner) (*types.Message, error) {
 	msg := &types.Message{}
 
-	err := scanner.Scan(&msg.ID, &msg.TicketID, &msg.AuthorID, &msg.Content, &msg.CreatedAt, &msg.UpdatedAt)
+	err := scanner.Scan(&msg.ID, &msg.OrgID, &msg.TicketID, &msg.AuthorID, &msg.Content, &msg.AttachmentIDs, &msg.CreatedAt, &msg.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("error reading message")
 	}
 
+	// an empty list is stored as null
+	if msg.AttachmentIDs == nil {
+		msg.AttachmentIDs = []int{}
+	}
+
 	return msg, nil
 }
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type NotificationAdapter struct {
+	db DBTX
+}
+
+func CreateNotificationAdapter(db DBTX) *NotificationAdapter {
+	return &NotificationAdapter{
+		db: db,
+	}
+}
+
+func (a *NotificationAdapter) Create(ctx context.Context, notification *types.Notification) (*types.Notification, error) {
+	id := 0
+	err := a.db.QueryRowContext(ctx, "INSERT INTO notification (org_id, account_id, ticket_id, event, summary, attempts, deliver_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id", notification.OrgID, notification.AccountID, notification.TicketID, notification.Event, notification.Summary, notification.Attempts, notification.DeliverAt.UTC(), notification.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating notification")
+	}
+
+	notification.ID = id
+
+	return notification, nil
+}
+
+func (a *NotificationAdapter) GetDue(ctx context.Context, now time.Time, maxAttempts int) ([]*types.Notification, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT id, org_id, account_id, ticket_id, event, summary, attempts, deliver_at, sent_at, created_at FROM notification WHERE sent_at IS NULL AND deliver_at <= $1 AND attempts < $2 AND org_id = COALESCE($3, org_id) ORDER BY account_id, id", now.UTC(), maxAttempts, OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting notifications")
+	}
+	defer rows.Close()
+
+	notifications := []*types.Notification{}
+
+	for rows.Next() {
+		notification, err := scanIntoNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+func (a *NotificationAdapter) Claim(ctx context.Context, notification *types.Notification, retryAt time.Time) (bool, error) {
+	res, err := a.db.ExecContext(ctx, "UPDATE notification SET attempts = attempts + 1, deliver_at = $1 WHERE id = $2 AND attempts = $3 AND sent_at IS NULL", retryAt.UTC(), notification.ID, notification.Attempts)
+	if err != nil {
+		return false, fmt.Errorf("error claiming notification")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error claiming notification")
+	}
+
+	if n == 0 {
+		return false, nil
+	}
+
+	notification.Attempts++
+	notification.DeliverAt = retryAt
+
+	return true, nil
+}
+
+func (a *NotificationAdapter) MarkSent(ctx context.Context, id int, at time.Time) error {
+	res, err := a.db.ExecContext(ctx, "UPDATE notification SET sent_at = $1 WHERE id = $2", at.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("error marking notification sent")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("notification %d not found", id))
+}
+
+func (a *NotificationAdapter) GetPreferences(ctx context.Context, accountID int) (*types.NotificationPreferences, error) {
+	prefs := &types.NotificationPreferences{}
+	events := pq.StringArray{}
+
+	err := a.db.QueryRowContext(ctx, "SELECT account_id, email, events, digest, updated_at FROM notification_preference WHERE account_id = $1", accountID).Scan(&prefs.AccountID, &prefs.Email, &events, &prefs.Digest, &prefs.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &types.NotFound{Message: fmt.Sprintf("notification preferences of account %d not found", accountID)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting notification preferences")
+	}
+
+	prefs.Events = []types.Event{}
+	for _, event := range events {
+		prefs.Events = append(prefs.Events, types.Event(event))
+	}
+
+	return prefs, nil
+}
+
+func (a *NotificationAdapter) SetPreferences(ctx context.Context, prefs *types.NotificationPreferences) (*types.NotificationPreferences, error) {
+	_, err := a.db.ExecContext(ctx, "INSERT INTO notification_preference (account_id, email, events, digest, updated_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (account_id) DO UPDATE SET email = excluded.email, events = excluded.events, digest = excluded.digest, updated_at = excluded.updated_at", prefs.AccountID, prefs.Email, pq.Array(prefs.Events), prefs.Digest, prefs.UpdatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error setting notification preferences")
+	}
+
+	return prefs, nil
+}
+
+func scanIntoNotification(rows *sql.Rows) (*types.Notification, error) {
+	sentAt := sql.NullTime{}
+	notification := &types.Notification{}
+
+	err := rows.Scan(&notification.ID, &notification.OrgID, &notification.AccountID, &notification.TicketID, &notification.Event, &notification.Summary, &notification.Attempts, &notification.DeliverAt, &sentAt, &notification.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading notification")
+	}
+
+	notification.SentAt = TimeOrNil(sentAt)
+
+	return notification, nil
+}
@@ -0,0 +1,231 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+)
+
+type organizationKey struct{}
+
+// WithOrganization scopes the queries made with the returned context to the
+// organization orgID. Queries made without one see every organization, which
+// is only meant for logins and background jobs.
+func WithOrganization(ctx context.Context, orgID int) context.Context {
+	return context.WithValue(ctx, organizationKey{}, orgID)
+}
+
+// WithoutOrganization lifts the organization scope from ctx, for super-admin
+// operations that span organizations.
+func WithoutOrganization(ctx context.Context) context.Context {
+	return context.WithValue(ctx, organizationKey{}, nil)
+}
+
+func Organization(ctx context.Context) (int, bool) {
+	orgID, ok := ctx.Value(organizationKey{}).(int)
+	return orgID, ok
+}
+
+// OrganizationArg returns the organization ctx is scoped to as a query
+// argument, or nil when it is not, for conditions written as
+// org_id = COALESCE(?, org_id).
+func OrganizationArg(ctx context.Context) any {
+	if orgID, ok := Organization(ctx); ok {
+		return orgID
+	}
+
+	return nil
+}
+
+// ScopeQuery limits query to rows whose column holds the organization ctx is
+// scoped to.
+func ScopeQuery(ctx context.Context, query *Query, column string) *Query {
+	if orgID, ok := Organization(ctx); ok {
+		query.Where(column+" = ?", orgID)
+	}
+
+	return query
+}
+
+// ScopeAccountQuery limits query to accounts that belong to, or are members
+// of, the organization ctx is scoped to.
+func ScopeAccountQuery(ctx context.Context, query *Query) *Query {
+	if orgID, ok := Organization(ctx); ok {
+		query.Where("(account.org_id = ? OR account.id IN (SELECT account_id FROM membership WHERE org_id = ?))", orgID, orgID)
+	}
+
+	return query
+}
+
+type OrganizationAdapter struct {
+	db DBTX
+}
+
+func CreateOrganizationAdapter(db DBTX) *OrganizationAdapter {
+	return &OrganizationAdapter{
+		db: db,
+	}
+}
+
+func (a *OrganizationAdapter) Create(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	err := WithTx(ctx, a.db, func(tx DBTX) error {
+		id := 0
+		err := tx.QueryRowContext(ctx, "INSERT INTO organization (name, created_at, updated_at) VALUES ($1, $2, $3) RETURNING id", org.Name, org.CreatedAt.UTC(), org.UpdatedAt.UTC()).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("error creating organization")
+		}
+
+		org.ID = id
+
+		for _, role := range types.DefaultRoles(org.ID) {
+			_, err = CreateRoleAdapter(tx).Create(ctx, role)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (a *OrganizationAdapter) Get(ctx context.Context) ([]*types.Organization, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT id, name, created_at, updated_at FROM organization ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error getting organizations")
+	}
+	defer rows.Close()
+
+	orgs := []*types.Organization{}
+
+	for rows.Next() {
+		org, err := scanIntoOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		orgs = append(orgs, org)
+	}
+
+	return orgs, nil
+}
+
+func (a *OrganizationAdapter) GetByID(ctx context.Context, id int) (*types.Organization, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT id, name, created_at, updated_at FROM organization WHERE id = $1", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting organization")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoOrganization(rows)
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("organization %d not found", id)}
+}
+
+func (a *OrganizationAdapter) Update(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	res, err := a.db.ExecContext(ctx, "UPDATE organization SET name = $1, updated_at = $2 WHERE id = $3", org.Name, org.UpdatedAt.UTC(), org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating organization")
+	}
+
+	err = ExpectRow(res, fmt.Sprintf("organization %d not found", org.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func scanIntoOrganization(rows *sql.Rows) (*types.Organization, error) {
+	org := &types.Organization{}
+
+	err := rows.Scan(&org.ID, &org.Name, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading organization")
+	}
+
+	return org, nil
+}
+
+type MembershipAdapter struct {
+	db DBTX
+}
+
+func CreateMembershipAdapter(db DBTX) *MembershipAdapter {
+	return &MembershipAdapter{
+		db: db,
+	}
+}
+
+func (a *MembershipAdapter) Get(ctx context.Context, orgID int) ([]*types.Membership, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT org_id, account_id, role, created_at FROM membership WHERE org_id = $1 ORDER BY account_id", orgID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting memberships")
+	}
+	defer rows.Close()
+
+	memberships := []*types.Membership{}
+
+	for rows.Next() {
+		membership, err := scanIntoMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		memberships = append(memberships, membership)
+	}
+
+	return memberships, nil
+}
+
+func (a *MembershipAdapter) GetByID(ctx context.Context, orgID int, accountID int) (*types.Membership, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT org_id, account_id, role, created_at FROM membership WHERE org_id = $1 AND account_id = $2", orgID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting membership")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoMembership(rows)
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("account %d is not a member of organization %d", accountID, orgID)}
+}
+
+// Set adds the account to the organization, or changes its role there when it
+// already is a member.
+func (a *MembershipAdapter) Set(ctx context.Context, membership *types.Membership) (*types.Membership, error) {
+	_, err := a.db.ExecContext(ctx, "INSERT INTO membership (org_id, account_id, role, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT (org_id, account_id) DO UPDATE SET role = EXCLUDED.role", membership.OrgID, membership.AccountID, membership.Role, membership.CreatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error setting membership")
+	}
+
+	return membership, nil
+}
+
+func (a *MembershipAdapter) Delete(ctx context.Context, orgID int, accountID int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM membership WHERE org_id = $1 AND account_id = $2", orgID, accountID)
+	if err != nil {
+		return fmt.Errorf("error deleting membership")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("account %d is not a member of organization %d", accountID, orgID))
+}
+
+func scanIntoMembership(rows *sql.Rows) (*types.Membership, error) {
+	membership := &types.Membership{}
+
+	err := rows.Scan(&membership.OrgID, &membership.AccountID, &membership.Role, &membership.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading membership")
+	}
+
+	return membership, nil
+}
@@ -0,0 +1,155 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"ticketing-api/types"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+var TicketSorts = []string{"id", "created_at", "updated_at", "status"}
+
+var AccountSorts = []string{"id", "created_at", "updated_at", "username"}
+
+type Cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    int    `json:"i"`
+}
+
+// DecodeCursor returns the position encoded in page.Cursor, or nil when the
+// page starts from the beginning. A cursor is only valid for the sort it was
+// issued for.
+func DecodeCursor(page *types.Page) (*Cursor, error) {
+	if page == nil || page.Cursor == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return nil, &types.BadRequest{Message: "invalid cursor"}
+	}
+
+	cursor := &Cursor{}
+
+	err = json.Unmarshal(b, cursor)
+	if err != nil || cursor.Sort != SortField(page) || cursor.Desc != page.Desc {
+		return nil, &types.BadRequest{Message: "invalid cursor"}
+	}
+
+	return cursor, nil
+}
+
+func EncodeCursor(page *types.Page, value string, id int) string {
+	b, _ := json.Marshal(&Cursor{Sort: SortField(page), Desc: page.Desc, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func SortField(page *types.Page) string {
+	if page == nil || page.Sort == "" {
+		return "id"
+	}
+
+	return page.Sort
+}
+
+func SortOrder(page *types.Page) string {
+	if page != nil && page.Desc {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+func PageLimit(page *types.Page) int {
+	if page == nil {
+		return 0
+	}
+
+	if page.Limit <= 0 {
+		return DefaultPageLimit
+	}
+
+	return min(page.Limit, MaxPageLimit)
+}
+
+// Paginate trims the extra row fetched past the page limit and, if there was
+// one, returns the cursor for the next page.
+func Paginate[T any](page *types.Page, items []T, value func(T) (string, int)) ([]T, *types.PageInfo) {
+	info := &types.PageInfo{}
+
+	limit := PageLimit(page)
+	if limit == 0 || len(items) <= limit {
+		return items, info
+	}
+
+	items = items[:limit]
+	v, id := value(items[limit-1])
+	info.NextCursor = EncodeCursor(page, v, id)
+
+	return items, info
+}
+
+func TicketSortValue(page *types.Page) func(*types.Ticket) (string, int) {
+	sort := SortField(page)
+
+	return func(ticket *types.Ticket) (string, int) {
+		switch sort {
+		case "created_at":
+			return ticket.CreatedAt.UTC().Format(time.RFC3339Nano), ticket.ID
+		case "updated_at":
+			return ticket.UpdatedAt.UTC().Format(time.RFC3339Nano), ticket.ID
+		case "status":
+			return string(ticket.Status), ticket.ID
+		default:
+			return strconv.Itoa(ticket.ID), ticket.ID
+		}
+	}
+}
+
+func AccountSortValue(page *types.Page) func(*types.Account) (string, int) {
+	sort := SortField(page)
+
+	return func(account *types.Account) (string, int) {
+		switch sort {
+		case "created_at":
+			return account.CreatedAt.UTC().Format(time.RFC3339Nano), account.ID
+		case "updated_at":
+			return account.UpdatedAt.UTC().Format(time.RFC3339Nano), account.ID
+		case "username":
+			return account.Username, account.ID
+		default:
+			return strconv.Itoa(account.ID), account.ID
+		}
+	}
+}
+
+// CursorValue converts the cursor value back into the type of its sort
+// column so it can be bound as a query argument.
+func CursorValue(cursor *Cursor) (any, error) {
+	switch cursor.Sort {
+	case "created_at", "updated_at":
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, &types.BadRequest{Message: "invalid cursor"}
+		}
+
+		return t, nil
+	case "id":
+		id, err := strconv.Atoi(cursor.Value)
+		if err != nil {
+			return nil, &types.BadRequest{Message: "invalid cursor"}
+		}
+
+		return id, nil
+	default:
+		return cursor.Value, nil
+	}
+}
@@ -0,0 +1,176 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"ticketing-api/types"
+	"time"
+)
+
+type Query struct {
+	where       []string
+	args        []any
+	placeholder func(int) string
+	value       func(any) any
+}
+
+// CreateQuery builds WHERE, ORDER BY and LIMIT clauses. Conditions are written
+// with ? markers, which placeholder rewrites for the target database, and
+// value converts bound arguments before they are added.
+func CreateQuery(placeholder func(int) string, value func(any) any) *Query {
+	return &Query{
+		placeholder: placeholder,
+		value:       value,
+	}
+}
+
+func PostgresPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (q *Query) Where(condition string, args ...any) *Query {
+	parts := strings.Split(condition, "?")
+
+	b := strings.Builder{}
+	b.WriteString(parts[0])
+
+	for i, arg := range args {
+		if q.value != nil {
+			arg = q.value(arg)
+		}
+
+		q.args = append(q.args, arg)
+		b.WriteString(q.placeholder(len(q.args)))
+		b.WriteString(parts[i+1])
+	}
+
+	q.where = append(q.where, b.String())
+
+	return q
+}
+
+func (q *Query) Clause() string {
+	if len(q.where) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(q.where, " AND ")
+}
+
+func (q *Query) Args() []any {
+	return q.args
+}
+
+// Paged adds the keyset condition for the page cursor and returns the ORDER BY
+// and LIMIT clauses. One row more than the limit is fetched so that Paginate
+// can tell whether there is a next page.
+func (q *Query) Paged(page *types.Page, columns map[string]string, idColumn string) (string, error) {
+	column, ok := columns[SortField(page)]
+	if !ok {
+		return "", &types.BadRequest{Message: fmt.Sprintf("invalid sort: %s", SortField(page))}
+	}
+
+	cursor, err := DecodeCursor(page)
+	if err != nil {
+		return "", err
+	}
+
+	if cursor != nil {
+		value, err := CursorValue(cursor)
+		if err != nil {
+			return "", err
+		}
+
+		op := ">"
+		if page.Desc {
+			op = "<"
+		}
+
+		q.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", column, idColumn, op), value, cursor.ID)
+	}
+
+	order := SortOrder(page)
+	clause := fmt.Sprintf(" ORDER BY %s %s, %s %s", column, order, idColumn, order)
+
+	if limit := PageLimit(page); limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d", limit+1)
+	}
+
+	return clause, nil
+}
+
+func bindPostgres(arg any) any {
+	if t, ok := arg.(time.Time); ok {
+		return t.UTC()
+	}
+
+	return arg
+}
+
+// CountRows returns the number of rows matching query when the page asks for
+// a total, ignoring any cursor.
+func CountRows(ctx context.Context, db DBTX, page *types.Page, count string, query *Query) (*int, error) {
+	if page == nil || !page.Total {
+		return nil, nil
+	}
+
+	total := 0
+
+	err := db.QueryRowContext(ctx, count+query.Clause(), query.Args()...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting rows")
+	}
+
+	return &total, nil
+}
+
+// ExpectRow returns a not found error carrying message when res affected no
+// rows.
+func ExpectRow(res sql.Result, message string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error reading affected rows")
+	}
+
+	if n == 0 {
+		return &types.NotFound{Message: message}
+	}
+
+	return nil
+}
+
+// ExpectVersion returns a precondition failed error carrying message when an
+// update guarded by a version check affected no rows.
+func ExpectVersion(res sql.Result, message string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error reading affected rows")
+	}
+
+	if n == 0 {
+		return &types.PreconditionFailed{Message: message}
+	}
+
+	return nil
+}
+
+// nullTime returns t in UTC as a query argument, or nil when t is.
+func nullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return t.UTC()
+}
+
+// TimeOrNil returns the time scanned into t, or nil when the column was NULL.
+func TimeOrNil(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
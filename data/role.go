@@ -0,0 +1,104 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+
+	"github.com/lib/pq"
+)
+
+type RoleAdapter struct {
+	db DBTX
+}
+
+func CreateRoleAdapter(db DBTX) *RoleAdapter {
+	return &RoleAdapter{
+		db: db,
+	}
+}
+
+func (a *RoleAdapter) Create(ctx context.Context, role *types.RoleDefinition) (*types.RoleDefinition, error) {
+	_, err := a.db.ExecContext(ctx, "INSERT INTO account_role (org_id, name, permissions, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)", role.OrgID, role.Name, pq.Array(role.Permissions), role.CreatedAt.UTC(), role.UpdatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error creating role")
+	}
+
+	return role, nil
+}
+
+func (a *RoleAdapter) Get(ctx context.Context) ([]*types.RoleDefinition, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT org_id, name, permissions, created_at, updated_at FROM account_role WHERE org_id = COALESCE($1, org_id) ORDER BY org_id, name", OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting roles")
+	}
+	defer rows.Close()
+
+	roles := []*types.RoleDefinition{}
+
+	for rows.Next() {
+		role, err := scanIntoRole(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+func (a *RoleAdapter) GetByName(ctx context.Context, name types.Role) (*types.RoleDefinition, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT org_id, name, permissions, created_at, updated_at FROM account_role WHERE name = $1 AND org_id = COALESCE($2, org_id) ORDER BY org_id LIMIT 1", name, OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting role")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoRole(rows)
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("role %s not found", name)}
+}
+
+func (a *RoleAdapter) Update(ctx context.Context, role *types.RoleDefinition) (*types.RoleDefinition, error) {
+	res, err := a.db.ExecContext(ctx, "UPDATE account_role SET permissions = $1, updated_at = $2 WHERE org_id = $3 AND name = $4 AND org_id = COALESCE($5, org_id)", pq.Array(role.Permissions), role.UpdatedAt.UTC(), role.OrgID, role.Name, OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error updating role")
+	}
+
+	err = ExpectRow(res, fmt.Sprintf("role %s not found", role.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (a *RoleAdapter) Delete(ctx context.Context, name types.Role) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM account_role WHERE name = $1 AND org_id = COALESCE($2, org_id)", name, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting role")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("role %s not found", name))
+}
+
+func scanIntoRole(rows *sql.Rows) (*types.RoleDefinition, error) {
+	role := &types.RoleDefinition{}
+	permissions := pq.StringArray{}
+
+	err := rows.Scan(&role.OrgID, &role.Name, &permissions, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading role")
+	}
+
+	role.Permissions = []types.Permission{}
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, types.Permission(permission))
+	}
+
+	return role, nil
+}
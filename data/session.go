@@ -0,0 +1,108 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+	"time"
+)
+
+type SessionAdapter struct {
+	db DBTX
+}
+
+func CreateSessionAdapter(db DBTX) *SessionAdapter {
+	return &SessionAdapter{
+		db: db,
+	}
+}
+
+func (s *SessionAdapter) Create(ctx context.Context, session *types.Session) (*types.Session, error) {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO account_session (id, account_id, org_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)", session.ID, session.AccountID, session.OrgID, session.TokenHash, session.ExpiresAt.UTC(), session.CreatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error creating session")
+	}
+
+	return session, nil
+}
+
+func (s *SessionAdapter) GetByID(ctx context.Context, id string) (*types.Session, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, account_id, org_id, token_hash, expires_at, created_at, revoked_at FROM account_session WHERE id = $1", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting session")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoSession(rows)
+	}
+
+	return nil, fmt.Errorf("session %s not found", id)
+}
+
+// Rotate swaps the refresh token hash of a live session, failing when the
+// hash has already been rotated by a concurrent refresh.
+func (s *SessionAdapter) Rotate(ctx context.Context, id string, oldHash string, newHash string) error {
+	res, err := s.db.ExecContext(ctx, "UPDATE account_session SET token_hash = $1 WHERE id = $2 AND token_hash = $3 AND revoked_at IS NULL", newHash, id, oldHash)
+	if err != nil {
+		return fmt.Errorf("error rotating session")
+	}
+
+	return ExpectVersion(res, fmt.Sprintf("session %s has already been refreshed", id))
+}
+
+func (s *SessionAdapter) Revoke(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE account_session SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("error revoking session")
+	}
+
+	return nil
+}
+
+func (s *SessionAdapter) RevokeByAccountID(ctx context.Context, accountID int) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE account_session SET revoked_at = $1 WHERE account_id = $2 AND revoked_at IS NULL", time.Now().UTC(), accountID)
+	if err != nil {
+		return fmt.Errorf("error revoking sessions")
+	}
+
+	return nil
+}
+
+func scanIntoSession(rows *sql.Rows) (*types.Session, error) {
+	session := &types.Session{}
+	revokedAt := sql.NullTime{}
+
+	err := rows.Scan(&session.ID, &session.AccountID, &session.OrgID, &session.TokenHash, &session.ExpiresAt, &session.CreatedAt, &revokedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading session")
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, nil
+}
+
+// RevocationList checks access tokens against the sessions they were issued
+// for. Sessions that are revoked, expired or missing count as revoked.
+type RevocationList struct {
+	sessions SessionSocket
+}
+
+func CreateRevocationList(sessions SessionSocket) *RevocationList {
+	return &RevocationList{
+		sessions: sessions,
+	}
+}
+
+func (l *RevocationList) IsRevoked(ctx context.Context, sessionID string) bool {
+	session, err := l.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return true
+	}
+
+	return session.RevokedAt != nil || session.ExpiresAt.Before(time.Now())
+}
@@ -0,0 +1,177 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"ticketing-api/types"
+)
+
+type SLAPolicyAdapter struct {
+	db DBTX
+}
+
+func CreateSLAPolicyAdapter(db DBTX) *SLAPolicyAdapter {
+	return &SLAPolicyAdapter{
+		db: db,
+	}
+}
+
+func (a *SLAPolicyAdapter) Create(ctx context.Context, policy *types.SLAPolicy) (*types.SLAPolicy, error) {
+	calendar, targets, err := marshalSLAPolicy(policy)
+	if err != nil {
+		return nil, fmt.Errorf("error creating sla policy")
+	}
+
+	err = WithTx(ctx, a.db, func(tx DBTX) error {
+		err := clearDefaultPolicy(ctx, tx, policy)
+		if err != nil {
+			return err
+		}
+
+		id := 0
+		err = tx.QueryRowContext(ctx, "INSERT INTO sla_policy (org_id, name, is_default, calendar, targets, at_risk_minutes, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id", policy.OrgID, policy.Name, policy.Default, calendar, targets, policy.AtRiskMinutes, policy.CreatedAt.UTC(), policy.UpdatedAt.UTC()).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("error creating sla policy")
+		}
+
+		policy.ID = id
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (a *SLAPolicyAdapter) Get(ctx context.Context) ([]*types.SLAPolicy, error) {
+	return a.fetchPolicies(ctx, createSLAPolicyQuery(ctx))
+}
+
+func (a *SLAPolicyAdapter) GetByID(ctx context.Context, id int) (*types.SLAPolicy, error) {
+	policies, err := a.fetchPolicies(ctx, createSLAPolicyQuery(ctx).Where("sla_policy.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(policies) > 0 {
+		return policies[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("sla policy %d not found", id)}
+}
+
+func (a *SLAPolicyAdapter) Update(ctx context.Context, policy *types.SLAPolicy) (*types.SLAPolicy, error) {
+	calendar, targets, err := marshalSLAPolicy(policy)
+	if err != nil {
+		return nil, fmt.Errorf("error updating sla policy")
+	}
+
+	err = WithTx(ctx, a.db, func(tx DBTX) error {
+		err := clearDefaultPolicy(ctx, tx, policy)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, "UPDATE sla_policy SET name = $1, is_default = $2, calendar = $3, targets = $4, at_risk_minutes = $5, updated_at = $6 WHERE id = $7 AND org_id = COALESCE($8, org_id)", policy.Name, policy.Default, calendar, targets, policy.AtRiskMinutes, policy.UpdatedAt.UTC(), policy.ID, OrganizationArg(ctx))
+		if err != nil {
+			return fmt.Errorf("error updating sla policy")
+		}
+
+		return ExpectRow(res, fmt.Sprintf("sla policy %d not found", policy.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (a *SLAPolicyAdapter) Delete(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM sla_policy WHERE id = $1 AND org_id = COALESCE($2, org_id)", id, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting sla policy")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("sla policy %d not found", id))
+}
+
+func createSLAPolicyQuery(ctx context.Context) *Query {
+	return ScopeQuery(ctx, CreateQuery(PostgresPlaceholder, bindPostgres), "sla_policy.org_id")
+}
+
+func (a *SLAPolicyAdapter) fetchPolicies(ctx context.Context, query *Query) ([]*types.SLAPolicy, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT sla_policy.id, sla_policy.org_id, sla_policy.name, sla_policy.is_default, sla_policy.calendar, sla_policy.targets, sla_policy.at_risk_minutes, sla_policy.created_at, sla_policy.updated_at FROM sla_policy"+query.Clause()+" ORDER BY sla_policy.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sla policies")
+	}
+	defer rows.Close()
+
+	policies := []*types.SLAPolicy{}
+
+	for rows.Next() {
+		policy, err := scanIntoSLAPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// clearDefaultPolicy takes the default from the organization's other policies
+// when policy becomes its default.
+func clearDefaultPolicy(ctx context.Context, tx DBTX, policy *types.SLAPolicy) error {
+	if !policy.Default {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, "UPDATE sla_policy SET is_default = FALSE WHERE org_id = $1 AND id != $2 AND is_default", policy.OrgID, policy.ID)
+	if err != nil {
+		return fmt.Errorf("error updating sla policy")
+	}
+
+	return nil
+}
+
+func marshalSLAPolicy(policy *types.SLAPolicy) (string, string, error) {
+	calendar, err := json.Marshal(policy.Calendar)
+	if err != nil {
+		return "", "", err
+	}
+
+	targets, err := json.Marshal(policy.Targets)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(calendar), string(targets), nil
+}
+
+func scanIntoSLAPolicy(rows *sql.Rows) (*types.SLAPolicy, error) {
+	calendar := []byte{}
+	targets := []byte{}
+	policy := &types.SLAPolicy{}
+
+	err := rows.Scan(&policy.ID, &policy.OrgID, &policy.Name, &policy.Default, &calendar, &targets, &policy.AtRiskMinutes, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading sla policy")
+	}
+
+	err = json.Unmarshal(calendar, &policy.Calendar)
+	if err != nil {
+		return nil, fmt.Errorf("error reading sla policy")
+	}
+
+	err = json.Unmarshal(targets, &policy.Targets)
+	if err != nil {
+		return nil, fmt.Errorf("error reading sla policy")
+	}
+
+	return policy, nil
+}
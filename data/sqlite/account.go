@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+type AccountAdapter struct {
+	db data.DBTX
+}
+
+func CreateAccountAdapter(db data.DBTX) *AccountAdapter {
+	return &AccountAdapter{
+		db: db,
+	}
+}
+
+func (a *AccountAdapter) Create(ctx context.Context, account *types.Account) (*types.Account, error) {
+	id := 0
+	err := a.db.QueryRowContext(ctx, "INSERT INTO account (org_id, username, password, role, super_admin) VALUES (?, ?, ?, ?, ?) RETURNING id", account.OrgID, account.Username, account.Password, account.Role, account.SuperAdmin).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating account")
+	}
+
+	account.ID = id
+	account.Version = 1
+
+	return account, nil
+}
+
+func (a *AccountAdapter) Get(ctx context.Context, page *types.Page) ([]*types.Account, *types.PageInfo, error) {
+	query := data.ScopeAccountQuery(ctx, createQuery().Where("deleted_at IS NULL"))
+
+	total, err := data.CountRows(ctx, a.db, page, "SELECT COUNT(*) FROM account", query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paged, err := query.Paged(page, accountSortColumns, "id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := a.db.QueryContext(ctx, `SELECT id, org_id, username, password, role, super_admin, version, created_at, updated_at FROM account`+query.Clause()+paged, query.Args()...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting accounts")
+	}
+	defer rows.Close()
+
+	accounts := []*types.Account{}
+
+	for rows.Next() {
+		account, err := scanIntoAccount(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	accounts, info := data.Paginate(page, accounts, data.AccountSortValue(page))
+	info.Total = total
+
+	return accounts, info, nil
+}
+
+func (a *AccountAdapter) GetByID(ctx context.Context, id int) (*types.Account, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT id, org_id, username, password, role, super_admin, version, created_at, updated_at FROM account WHERE id = ? AND deleted_at IS NULL AND (org_id = COALESCE(?, org_id) OR id IN (SELECT account_id FROM membership WHERE org_id = ?))`, id, data.OrganizationArg(ctx), data.OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting account")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoAccount(rows)
+	}
+
+	return nil, fmt.Errorf("account with id: %d not found", id)
+}
+
+func (a *AccountAdapter) GetByUsername(ctx context.Context, username string) (*types.Account, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT id, org_id, username, password, role, super_admin, version, created_at, updated_at FROM account WHERE username = ? AND deleted_at IS NULL`, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting account")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoAccount(rows)
+	}
+
+	return nil, fmt.Errorf("account with the username: %s not found", username)
+}
+
+func (a *AccountAdapter) Update(ctx context.Context, account *types.Account) (*types.Account, error) {
+	err := a.db.QueryRowContext(ctx, `UPDATE account SET username = ?, password = ?, role = ?, version = version + 1, updated_at = `+currentTimestamp+` WHERE id = ? AND version = ? AND deleted_at IS NULL AND org_id = COALESCE(?, org_id) RETURNING updated_at`, account.Username, account.Password, account.Role, account.ID, account.Version, data.OrganizationArg(ctx)).Scan(&account.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &types.PreconditionFailed{Message: fmt.Sprintf("account %d has changed since version %d", account.ID, account.Version)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating account: %w", err)
+	}
+
+	account.Version++
+
+	return account, nil
+}
+
+func (a *AccountAdapter) Delete(ctx context.Context, id int) error {
+	_, err := a.db.ExecContext(ctx, `UPDATE account SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL AND org_id = COALESCE(?, org_id)`, id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting account")
+	}
+
+	return nil
+}
+
+func (a *AccountAdapter) Restore(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, `UPDATE account SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL AND org_id = COALESCE(?, org_id)`, id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error restoring account")
+	}
+
+	return data.ExpectRow(res, fmt.Sprintf("deleted account with id: %d not found", id))
+}
+
+var accountSortColumns = map[string]string{
+	"id":         "id",
+	"created_at": timestamp("created_at"),
+	"updated_at": timestamp("updated_at"),
+	"username":   "username",
+}
+
+func scanIntoAccount(rows *sql.Rows) (*types.Account, error) {
+	account := &types.Account{}
+
+	err := rows.Scan(&account.ID, &account.OrgID, &account.Username, &account.Password, &account.Role, &account.SuperAdmin, &account.Version, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading account")
+	}
+
+	return account, nil
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+type AttachmentAdapter struct {
+	db data.DBTX
+}
+
+func CreateAttachmentAdapter(db data.DBTX) *AttachmentAdapter {
+	return &AttachmentAdapter{
+		db: db,
+	}
+}
+
+func (a *AttachmentAdapter) Create(ctx context.Context, attachment *types.Attachment) (*types.Attachment, error) {
+	id := 0
+	err := a.db.QueryRowContext(ctx, "INSERT INTO attachment (org_id, ticket_id, author_id, name, content_type, size, storage_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id", attachment.OrgID, attachment.TicketID, attachment.AuthorID, attachment.Name, attachment.ContentType, attachment.Size, attachment.Key, attachment.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating attachment")
+	}
+
+	attachment.ID = id
+
+	return attachment, nil
+}
+
+func (a *AttachmentAdapter) Get(ctx context.Context, ticketID int) ([]*types.Attachment, error) {
+	return a.fetchAttachments(ctx, createAttachmentQuery(ctx).Where("attachment.ticket_id = ?", ticketID))
+}
+
+func (a *AttachmentAdapter) GetByID(ctx context.Context, id int) (*types.Attachment, error) {
+	attachments, err := a.fetchAttachments(ctx, createAttachmentQuery(ctx).Where("attachment.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attachments) > 0 {
+		return attachments[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("attachment %d not found", id)}
+}
+
+func (a *AttachmentAdapter) Delete(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM attachment WHERE id = ? AND org_id = COALESCE(?, org_id)", id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting attachment")
+	}
+
+	return data.ExpectRow(res, fmt.Sprintf("attachment %d not found", id))
+}
+
+func (a *AttachmentAdapter) Move(ctx context.Context, fromTicketID int, toTicketID int) error {
+	_, err := a.db.ExecContext(ctx, "UPDATE attachment SET ticket_id = ? WHERE ticket_id = ? AND org_id = COALESCE(?, org_id)", toTicketID, fromTicketID, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error moving attachments")
+	}
+
+	return nil
+}
+
+func createAttachmentQuery(ctx context.Context) *data.Query {
+	return data.ScopeQuery(ctx, createQuery(), "attachment.org_id")
+}
+
+func (a *AttachmentAdapter) fetchAttachments(ctx context.Context, query *data.Query) ([]*types.Attachment, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT attachment.id, attachment.org_id, attachment.ticket_id, attachment.author_id, attachment.name, attachment.content_type, attachment.size, attachment.storage_key, attachment.created_at FROM attachment"+query.Clause()+" ORDER BY attachment.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting attachments")
+	}
+	defer rows.Close()
+
+	attachments := []*types.Attachment{}
+
+	for rows.Next() {
+		attachment, err := scanIntoAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+func scanIntoAttachment(rows *sql.Rows) (*types.Attachment, error) {
+	attachment := &types.Attachment{}
+
+	err := rows.Scan(&attachment.ID, &attachment.OrgID, &attachment.TicketID, &attachment.AuthorID, &attachment.Name, &attachment.ContentType, &attachment.Size, &attachment.Key, &attachment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading attachment")
+	}
+
+	return attachment, nil
+}
@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+type CustomFieldAdapter struct {
+	db data.DBTX
+}
+
+func CreateCustomFieldAdapter(db data.DBTX) *CustomFieldAdapter {
+	return &CustomFieldAdapter{
+		db: db,
+	}
+}
+
+func (a *CustomFieldAdapter) Create(ctx context.Context, field *types.CustomField) (*types.CustomField, error) {
+	options, err := json.Marshal(field.Options)
+	if err != nil {
+		return nil, fmt.Errorf("error creating custom field")
+	}
+
+	id := 0
+	err = a.db.QueryRowContext(ctx, "INSERT INTO custom_field (org_id, field_key, name, type, options, required, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id", field.OrgID, field.Key, field.Name, field.Type, string(options), field.Required, field.CreatedAt.UTC(), field.UpdatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating custom field")
+	}
+
+	field.ID = id
+
+	return field, nil
+}
+
+func (a *CustomFieldAdapter) Get(ctx context.Context) ([]*types.CustomField, error) {
+	return a.fetchFields(ctx, createCustomFieldQuery(ctx))
+}
+
+func (a *CustomFieldAdapter) GetByID(ctx context.Context, id int) (*types.CustomField, error) {
+	fields, err := a.fetchFields(ctx, createCustomFieldQuery(ctx).Where("custom_field.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) > 0 {
+		return fields[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("custom field %d not found", id)}
+}
+
+// Update changes the field's name, options and whether it is required. Its
+// key and type stay as created, tickets keep their values under them.
+func (a *CustomFieldAdapter) Update(ctx context.Context, field *types.CustomField) (*types.CustomField, error) {
+	options, err := json.Marshal(field.Options)
+	if err != nil {
+		return nil, fmt.Errorf("error updating custom field")
+	}
+
+	res, err := a.db.ExecContext(ctx, "UPDATE custom_field SET name = ?, options = ?, required = ?, updated_at = ? WHERE id = ? AND org_id = COALESCE(?, org_id)", field.Name, string(options), field.Required, field.UpdatedAt.UTC(), field.ID, data.OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error updating custom field")
+	}
+
+	err = data.ExpectRow(res, fmt.Sprintf("custom field %d not found", field.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return field, nil
+}
+
+func (a *CustomFieldAdapter) Delete(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM custom_field WHERE id = ? AND org_id = COALESCE(?, org_id)", id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting custom field")
+	}
+
+	return data.ExpectRow(res, fmt.Sprintf("custom field %d not found", id))
+}
+
+func createCustomFieldQuery(ctx context.Context) *data.Query {
+	return data.ScopeQuery(ctx, createQuery(), "custom_field.org_id")
+}
+
+func (a *CustomFieldAdapter) fetchFields(ctx context.Context, query *data.Query) ([]*types.CustomField, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT custom_field.id, custom_field.org_id, custom_field.field_key, custom_field.name, custom_field.type, custom_field.options, custom_field.required, custom_field.created_at, custom_field.updated_at FROM custom_field"+query.Clause()+" ORDER BY custom_field.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting custom fields")
+	}
+	defer rows.Close()
+
+	fields := []*types.CustomField{}
+
+	for rows.Next() {
+		field, err := scanIntoCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func scanIntoCustomField(rows *sql.Rows) (*types.CustomField, error) {
+	options := []byte{}
+	field := &types.CustomField{}
+
+	err := rows.Scan(&field.ID, &field.OrgID, &field.Key, &field.Name, &field.Type, &options, &field.Required, &field.CreatedAt, &field.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading custom field")
+	}
+
+	err = json.Unmarshal(options, &field.Options)
+	if err != nil {
+		return nil, fmt.Errorf("error reading custom field")
+	}
+
+	return field, nil
+}
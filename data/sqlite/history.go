@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+type HistoryAdapter struct {
+	db data.DBTX
+}
+
+func CreateHistoryAdapter(db data.DBTX) *HistoryAdapter {
+	return &HistoryAdapter{
+		db: db,
+	}
+}
+
+func (h *HistoryAdapter) Create(ctx context.Context, change *types.Change) (*types.Change, error) {
+	id := 0
+	err := h.db.QueryRowContext(ctx, "INSERT INTO history (org_id, entity, entity_id, actor_id, field, old_value, new_value, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id", change.OrgID, change.Entity, change.EntityID, change.ActorID, change.Field, change.OldValue, change.NewValue, change.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating change")
+	}
+
+	change.ID = id
+
+	return change, nil
+}
+
+func (h *HistoryAdapter) Get(ctx context.Context, entity types.Entity, id int) ([]*types.Change, error) {
+	rows, err := h.db.QueryContext(ctx, "SELECT id, org_id, entity, entity_id, actor_id, field, old_value, new_value, created_at FROM history WHERE entity = ? AND entity_id = ? AND org_id = COALESCE(?, org_id) ORDER BY id", entity, id, data.OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting history")
+	}
+	defer rows.Close()
+
+	changes := []*types.Change{}
+
+	for rows.Next() {
+		change, err := scanIntoChange(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func scanIntoChange(rows *sql.Rows) (*types.Change, error) {
+	change := &types.Change{}
+
+	err := rows.Scan(&change.ID, &change.OrgID, &change.Entity, &change.EntityID, &change.ActorID, &change.Field, &change.OldValue, &change.NewValue, &change.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading change")
+	}
+
+	return change, nil
+}
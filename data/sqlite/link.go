@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+type LinkAdapter struct {
+	db data.DBTX
+}
+
+func CreateLinkAdapter(db data.DBTX) *LinkAdapter {
+	return &LinkAdapter{
+		db: db,
+	}
+}
+
+func (a *LinkAdapter) Create(ctx context.Context, link *types.TicketLink) (*types.TicketLink, error) {
+	id := 0
+	err := a.db.QueryRowContext(ctx, "INSERT INTO ticket_link (org_id, source_id, target_id, type, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id", link.OrgID, link.SourceID, link.TargetID, link.Type, link.CreatedBy, link.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("error creating link")
+	}
+
+	link.ID = id
+
+	return link, nil
+}
+
+func (a *LinkAdapter) Get(ctx context.Context, ticketID int) ([]*types.TicketLink, error) {
+	return a.fetchLinks(ctx, createLinkQuery(ctx).Where("(ticket_link.source_id = ? OR ticket_link.target_id = ?)", ticketID, ticketID))
+}
+
+func (a *LinkAdapter) GetByID(ctx context.Context, id int) (*types.TicketLink, error) {
+	links, err := a.fetchLinks(ctx, createLinkQuery(ctx).Where("ticket_link.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(links) > 0 {
+		return links[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("link %d not found", id)}
+}
+
+func (a *LinkAdapter) Delete(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM ticket_link WHERE id = ? AND org_id = COALESCE(?, org_id)", id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting link")
+	}
+
+	return data.ExpectRow(res, fmt.Sprintf("link %d not found", id))
+}
+
+func createLinkQuery(ctx context.Context) *data.Query {
+	return data.ScopeQuery(ctx, createQuery(), "ticket_link.org_id")
+}
+
+func (a *LinkAdapter) fetchLinks(ctx context.Context, query *data.Query) ([]*types.TicketLink, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT ticket_link.id, ticket_link.org_id, ticket_link.source_id, ticket_link.target_id, ticket_link.type, ticket_link.created_by, ticket_link.created_at FROM ticket_link"+query.Clause()+" ORDER BY ticket_link.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting links")
+	}
+	defer rows.Close()
+
+	links := []*types.TicketLink{}
+
+	for rows.Next() {
+		link, err := scanIntoLink(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+func scanIntoLink(rows *sql.Rows) (*types.TicketLink, error) {
+	link := &types.TicketLink{}
+
+	err := rows.Scan(&link.ID, &link.OrgID, &link.SourceID, &link.TargetID, &link.Type, &link.CreatedBy, &link.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading link")
+	}
+
+	return link, nil
+}
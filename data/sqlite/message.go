@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+type MessageAdapter struct {
+	db data.DBTX
+}
+
+func CreateMessageAdapter(db data.DBTX) *MessageAdapter {
+	return &MessageAdapter{
+		db: db,
+	}
+}
+
+func (m *MessageAdapter) Get(ctx context.Context, id int) ([]*types.Message, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT id, org_id, ticket_id, author_id, content, attachment_ids, created_at, updated_at FROM message WHERE ticket_id = ? AND org_id = COALESCE(?, org_id) ORDER BY created_at DESC, id", id, data.OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting messages")
+	}
+	defer rows.Close()
+
+	messages := []*types.Message{}
+
+	for rows.Next() {
+		msg, err := scanIntoMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+func (m *MessageAdapter) Create(ctx context.Context, message *types.Message) (*types.Message, error) {
+	_, err := m.db.ExecContext(ctx, "INSERT OR REPLACE INTO message (id, org_id, ticket_id, author_id, content, attachment_ids, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", message.ID, message.OrgID, message.TicketID, message.AuthorID, message.Content, jsonArray(message.AttachmentIDs), message.CreatedAt.UTC(), message.UpdatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error creating message")
+	}
+
+	return message, nil
+}
+
+func (m *MessageAdapter) Delete(ctx context.Context, id string, created_at time.Time, ticket_id int) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM message WHERE id = ? AND created_at = ? AND ticket_id = ? AND org_id = COALESCE(?, org_id)", id, created_at.UTC(), ticket_id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting message")
+	}
+
+	return nil
+}
+
+func (m *MessageAdapter) DeleteByTicketID(ctx context.Context, ticketID int) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM message WHERE ticket_id = ? AND org_id = COALESCE(?, org_id)", ticketID, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting messages")
+	}
+
+	return nil
+}
+
+func (m *MessageAdapter) GetByID(ctx context.Context, id string, created_at time.Time, ticket_id int) (*types.Message, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT id, org_id, ticket_id, author_id, content, attachment_ids, created_at, updated_at FROM message WHERE id = ? AND created_at = ? AND ticket_id = ? AND org_id = COALESCE(?, org_id)", id, created_at.UTC(), ticket_id, data.OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting message")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoMessage(rows)
+	}
+
+	return nil, fmt.Errorf("mesage %s not found", id)
+}
+
+func (m *MessageAdapter) Update(ctx context.Context, message *types.Message) (*types.Message, error) {
+	_, err := m.db.ExecContext(ctx, "INSERT INTO message (id, org_id, ticket_id, author_id, content, attachment_ids, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (ticket_id, created_at, id) DO UPDATE SET author_id = excluded.author_id, content = excluded.content, attachment_ids = excluded.attachment_ids, updated_at = excluded.updated_at", message.ID, message.OrgID, message.TicketID, message.AuthorID, message.Content, jsonArray(message.AttachmentIDs), message.CreatedAt.UTC(), message.UpdatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error updating message %w", err)
+	}
+
+	return message, nil
+}
+
+func scanIntoMessage(rows *sql.Rows) (*types.Message, error) {
+	msg := &types.Message{}
+	attachmentIDs := ""
+
+	err := rows.Scan(&msg.ID, &msg.OrgID, &msg.TicketID, &msg.AuthorID, &msg.Content, &attachmentIDs, &msg.CreatedAt, &msg.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading message")
+	}
+
+	err = json.Unmarshal([]byte(attachmentIDs), &msg.AttachmentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading message")
+	}
+
+	return msg, nil
+}
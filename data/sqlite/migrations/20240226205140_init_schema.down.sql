@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS message;
+
+DROP TABLE IF EXISTS assignee;
+
+DROP TABLE IF EXISTS ticket;
+
+DROP TABLE IF EXISTS account;
@@ -0,0 +1 @@
+ALTER TABLE ticket DROP COLUMN resolution;
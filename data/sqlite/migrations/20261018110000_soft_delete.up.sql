@@ -0,0 +1,3 @@
+ALTER TABLE account ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE ticket ADD COLUMN deleted_at TIMESTAMP;
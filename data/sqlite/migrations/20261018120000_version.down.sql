@@ -0,0 +1,3 @@
+ALTER TABLE ticket DROP COLUMN version;
+
+ALTER TABLE account DROP COLUMN version;
@@ -0,0 +1,3 @@
+ALTER TABLE account ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE ticket ADD COLUMN version INT NOT NULL DEFAULT 1;
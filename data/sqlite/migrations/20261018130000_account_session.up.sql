@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS account_session (
+    id VARCHAR(255) PRIMARY KEY,
+    account_id INT NOT NULL,
+    token_hash VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES account(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS account_session_account_id ON account_session (account_id);
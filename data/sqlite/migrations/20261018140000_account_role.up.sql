@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS account_role (
+    name VARCHAR(255) PRIMARY KEY,
+    permissions TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO account_role (name, permissions) VALUES
+    ('admin', '["ticket:create","ticket:read","ticket:update","ticket:assign","ticket:status","ticket:delete","ticket:restore","ticket:purge","account:read","account:manage","message:moderate","role:manage"]'),
+    ('editor', '["ticket:create","ticket:read","ticket:update","ticket:assign","ticket:status","ticket:delete","account:read"]'),
+    ('user', '["ticket:create"]');
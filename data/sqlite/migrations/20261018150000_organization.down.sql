@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS membership;
+
+DROP INDEX IF EXISTS ticket_org_id;
+DROP INDEX IF EXISTS account_org_id;
+
+ALTER TABLE account_session DROP COLUMN org_id;
+ALTER TABLE history DROP COLUMN org_id;
+ALTER TABLE message DROP COLUMN org_id;
+ALTER TABLE ticket DROP COLUMN org_id;
+ALTER TABLE account DROP COLUMN super_admin;
+ALTER TABLE account DROP COLUMN org_id;
+
+DROP TABLE IF EXISTS organization;
@@ -0,0 +1,8 @@
+UPDATE account_role SET permissions = (SELECT json_group_array(value) FROM json_each(permissions) WHERE value != 'team:manage') WHERE name = 'admin';
+
+DROP INDEX IF EXISTS ticket_team_id;
+
+ALTER TABLE ticket DROP COLUMN team_id;
+
+DROP TABLE IF EXISTS team_member;
+DROP TABLE IF EXISTS team;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS team (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL REFERENCES organization(id),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (org_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS team_member (
+    team_id INTEGER NOT NULL,
+    account_id INTEGER NOT NULL,
+    PRIMARY KEY (team_id, account_id),
+    FOREIGN KEY (team_id) REFERENCES team(id) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES account(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS team_member_account_id ON team_member (account_id);
+
+-- sqlite cannot drop a column that references another table, so deleting a
+-- team clears ticket.team_id itself
+ALTER TABLE ticket ADD COLUMN team_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS ticket_team_id ON ticket (team_id, created_at);
+
+UPDATE account_role SET permissions = json_insert(permissions, '$[#]', 'team:manage') WHERE name = 'admin' AND 'team:manage' NOT IN (SELECT value FROM json_each(permissions));
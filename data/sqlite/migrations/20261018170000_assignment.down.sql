@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS assignment;
+DROP TABLE IF EXISTS agent;
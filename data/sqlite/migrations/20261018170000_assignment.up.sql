@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS agent (
+    org_id INTEGER NOT NULL,
+    account_id INTEGER NOT NULL,
+    availability VARCHAR(255) NOT NULL DEFAULT 'available',
+    capacity INTEGER NOT NULL DEFAULT 0,
+    skills TEXT NOT NULL DEFAULT '[]',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, account_id),
+    FOREIGN KEY (org_id) REFERENCES organization(id) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS assignment (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL,
+    ticket_id INTEGER NOT NULL,
+    account_id INTEGER,
+    strategy VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS assignment_ticket_id ON assignment (ticket_id);
+CREATE INDEX IF NOT EXISTS assignment_account_id ON assignment (org_id, account_id, created_at);
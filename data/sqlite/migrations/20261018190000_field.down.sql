@@ -0,0 +1,11 @@
+UPDATE account_role SET permissions = (SELECT json_group_array(value) FROM json_each(permissions) WHERE value != 'field:manage') WHERE name = 'admin';
+UPDATE account_role SET permissions = (SELECT json_group_array(value) FROM json_each(permissions) WHERE value != 'category:manage') WHERE name = 'admin';
+
+DROP INDEX IF EXISTS ticket_category_id;
+
+ALTER TABLE ticket DROP COLUMN fields;
+ALTER TABLE ticket DROP COLUMN labels;
+ALTER TABLE ticket DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS custom_field;
+DROP TABLE IF EXISTS category;
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS category (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL REFERENCES organization(id),
+    parent_id INTEGER REFERENCES category(id),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS category_parent_id ON category (org_id, parent_id);
+
+CREATE TABLE IF NOT EXISTS custom_field (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL REFERENCES organization(id),
+    field_key VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    options TEXT NOT NULL DEFAULT '[]',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (org_id, field_key)
+);
+
+-- like team_id, deleting a category clears ticket.category_id itself
+ALTER TABLE ticket ADD COLUMN category_id INTEGER;
+ALTER TABLE ticket ADD COLUMN labels TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE ticket ADD COLUMN fields TEXT NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS ticket_category_id ON ticket (category_id);
+
+UPDATE account_role SET permissions = json_insert(permissions, '$[#]', 'category:manage') WHERE name = 'admin' AND 'category:manage' NOT IN (SELECT value FROM json_each(permissions));
+UPDATE account_role SET permissions = json_insert(permissions, '$[#]', 'field:manage') WHERE name = 'admin' AND 'field:manage' NOT IN (SELECT value FROM json_each(permissions));
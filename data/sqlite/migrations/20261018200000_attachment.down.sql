@@ -0,0 +1,5 @@
+ALTER TABLE message DROP COLUMN attachment_ids;
+
+DROP INDEX IF EXISTS attachment_ticket_id;
+
+DROP TABLE IF EXISTS attachment;
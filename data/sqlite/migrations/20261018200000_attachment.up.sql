@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS attachment (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL REFERENCES organization(id),
+    ticket_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size INTEGER NOT NULL,
+    storage_key VARCHAR(1024) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (ticket_id) REFERENCES ticket(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS attachment_ticket_id ON attachment (ticket_id);
+
+ALTER TABLE message ADD COLUMN attachment_ids TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS ticket_link_parent_of;
+DROP INDEX IF EXISTS ticket_link_duplicate_of;
+DROP INDEX IF EXISTS ticket_link_target_id;
+
+DROP TABLE IF EXISTS ticket_link;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS ticket_link (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL REFERENCES organization(id),
+    source_id INTEGER NOT NULL,
+    target_id INTEGER NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_id, target_id, type),
+    FOREIGN KEY (source_id) REFERENCES ticket(id) ON DELETE CASCADE,
+    FOREIGN KEY (target_id) REFERENCES ticket(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ticket_link_target_id ON ticket_link (target_id);
+
+-- a ticket is a duplicate of one ticket and the child of one ticket at most
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_link_duplicate_of ON ticket_link (source_id) WHERE type = 'duplicate_of';
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_link_parent_of ON ticket_link (target_id) WHERE type = 'parent_of';
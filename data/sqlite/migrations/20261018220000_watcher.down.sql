@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS watcher_account_id;
+
+DROP TABLE IF EXISTS watcher;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS watcher (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id INTEGER NOT NULL,
+    account_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (ticket_id, account_id),
+    FOREIGN KEY (ticket_id) REFERENCES ticket(id) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES account(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS watcher_account_id ON watcher (account_id);
+
+-- authors and assignees of existing tickets watch them, like they would now
+INSERT OR IGNORE INTO watcher (ticket_id, account_id) SELECT id, author_id FROM ticket ORDER BY id;
+INSERT OR IGNORE INTO watcher (ticket_id, account_id) SELECT ticket_id, account_id FROM assignee ORDER BY id;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS notification_preference;
+
+DROP INDEX IF EXISTS notification_deliver_at;
+
+DROP TABLE IF EXISTS notification;
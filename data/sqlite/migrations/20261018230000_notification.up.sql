@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS notification (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL,
+    account_id INTEGER NOT NULL,
+    ticket_id INTEGER NOT NULL,
+    event VARCHAR(255) NOT NULL,
+    summary TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    deliver_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (org_id) REFERENCES organization(id),
+    FOREIGN KEY (account_id) REFERENCES account(id) ON DELETE CASCADE,
+    FOREIGN KEY (ticket_id) REFERENCES ticket(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS notification_deliver_at ON notification (deliver_at) WHERE sent_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_preference (
+    account_id INTEGER PRIMARY KEY,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    events TEXT NOT NULL DEFAULT '[]',
+    digest VARCHAR(255) NOT NULL DEFAULT 'off',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES account(id) ON DELETE CASCADE
+);
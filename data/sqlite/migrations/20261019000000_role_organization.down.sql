@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS account_role_shared (
+    name VARCHAR(255) PRIMARY KEY,
+    permissions TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the roles of the default organization become the shared ones again
+INSERT INTO account_role_shared (name, permissions, created_at, updated_at)
+SELECT name, permissions, created_at, updated_at FROM account_role WHERE org_id = 1;
+
+DROP TABLE account_role;
+
+ALTER TABLE account_role_shared RENAME TO account_role;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS account_role_organization (
+    org_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    permissions TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, name),
+    FOREIGN KEY (org_id) REFERENCES organization(id) ON DELETE CASCADE
+);
+
+-- every organization gets its own copy of the roles it used to share
+INSERT INTO account_role_organization (org_id, name, permissions, created_at, updated_at)
+SELECT organization.id, account_role.name, account_role.permissions, account_role.created_at, account_role.updated_at FROM account_role CROSS JOIN organization;
+
+DROP TABLE account_role;
+
+ALTER TABLE account_role_organization RENAME TO account_role;
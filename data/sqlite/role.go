@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+type RoleAdapter struct {
+	db data.DBTX
+}
+
+func CreateRoleAdapter(db data.DBTX) *RoleAdapter {
+	return &RoleAdapter{
+		db: db,
+	}
+}
+
+func (a *RoleAdapter) Create(ctx context.Context, role *types.RoleDefinition) (*types.RoleDefinition, error) {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return nil, fmt.Errorf("error creating role")
+	}
+
+	_, err = a.db.ExecContext(ctx, "INSERT INTO account_role (org_id, name, permissions, created_at, updated_at) VALUES (?, ?, ?, ?, ?)", role.OrgID, role.Name, string(permissions), role.CreatedAt.UTC(), role.UpdatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error creating role")
+	}
+
+	return role, nil
+}
+
+func (a *RoleAdapter) Get(ctx context.Context) ([]*types.RoleDefinition, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT org_id, name, permissions, created_at, updated_at FROM account_role WHERE org_id = COALESCE(?, org_id) ORDER BY org_id, name", data.OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting roles")
+	}
+	defer rows.Close()
+
+	roles := []*types.RoleDefinition{}
+
+	for rows.Next() {
+		role, err := scanIntoRole(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+func (a *RoleAdapter) GetByName(ctx context.Context, name types.Role) (*types.RoleDefinition, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT org_id, name, permissions, created_at, updated_at FROM account_role WHERE name = ? AND org_id = COALESCE(?, org_id) ORDER BY org_id LIMIT 1", name, data.OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting role")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		return scanIntoRole(rows)
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("role %s not found", name)}
+}
+
+func (a *RoleAdapter) Update(ctx context.Context, role *types.RoleDefinition) (*types.RoleDefinition, error) {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return nil, fmt.Errorf("error updating role")
+	}
+
+	res, err := a.db.ExecContext(ctx, "UPDATE account_role SET permissions = ?, updated_at = ? WHERE org_id = ? AND name = ? AND org_id = COALESCE(?, org_id)", string(permissions), role.UpdatedAt.UTC(), role.OrgID, role.Name, data.OrganizationArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error updating role")
+	}
+
+	err = data.ExpectRow(res, fmt.Sprintf("role %s not found", role.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (a *RoleAdapter) Delete(ctx context.Context, name types.Role) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM account_role WHERE name = ? AND org_id = COALESCE(?, org_id)", name, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting role")
+	}
+
+	return data.ExpectRow(res, fmt.Sprintf("role %s not found", name))
+}
+
+func scanIntoRole(rows *sql.Rows) (*types.RoleDefinition, error) {
+	role := &types.RoleDefinition{}
+	permissions := ""
+
+	err := rows.Scan(&role.OrgID, &role.Name, &permissions, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading role")
+	}
+
+	err = json.Unmarshal([]byte(permissions), &role.Permissions)
+	if err != nil {
+		return nil, fmt.Errorf("error reading role")
+	}
+
+	return role, nil
+}
@@ -0,0 +1,143 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"ticketing-api/data"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", path))
+	if err != nil {
+		return nil, err
+	}
+
+	if path == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
+
+const timestampFormat = "2006-01-02 15:04:05.000"
+
+func createQuery() *data.Query {
+	return data.CreateQuery(func(int) string { return "?" }, bindSQLite)
+}
+
+// bindSQLite formats times the way timestamp renders columns, so that
+// timestamps written by the driver and by CURRENT_TIMESTAMP compare equally.
+func bindSQLite(arg any) any {
+	if t, ok := arg.(time.Time); ok {
+		return t.UTC().Format(timestampFormat)
+	}
+
+	return arg
+}
+
+// nullTimestamp formats t like bindSQLite, or returns nil when t is.
+func nullTimestamp(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return bindSQLite(*t)
+}
+
+// currentTimestamp is CURRENT_TIMESTAMP to the millisecond, like the times
+// bindSQLite formats.
+const currentTimestamp = "strftime('%Y-%m-%d %H:%M:%f', 'now')"
+
+func timestamp(column string) string {
+	return fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:%%M:%%f', %s)", column)
+}
+
+// Migrate applies the embedded up migrations that have not yet been run,
+// tracking progress in the same schema_migrations table golang-migrate uses so
+// that the database can still be managed with the migrate CLI.
+func Migrate(db *sql.DB) error {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	current := uint64(0)
+	dirty := false
+
+	err = db.QueryRow("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&current, &dirty)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d", current)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.up.sql")
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+		name := strings.TrimPrefix(file, "migrations/")
+
+		version, err := strconv.ParseUint(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid migration name: %s", name)
+		}
+
+		if version <= current {
+			continue
+		}
+
+		err = applyMigration(db, file, version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, file string, version uint64) error {
+	query, err := migrations.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(string(query))
+	if err != nil {
+		return fmt.Errorf("error applying migration %s: %w", file, err)
+	}
+
+	_, err = tx.Exec("DELETE FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)", version, false)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
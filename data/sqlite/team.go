@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+type TeamAdapter struct {
+	db data.DBTX
+}
+
+func CreateTeamAdapter(db data.DBTX) *TeamAdapter {
+	return &TeamAdapter{
+		db: db,
+	}
+}
+
+func (a *TeamAdapter) Create(ctx context.Context, team *types.Team) (*types.Team, error) {
+	err := data.WithTx(ctx, a.db, func(tx data.DBTX) error {
+		id := 0
+		err := tx.QueryRowContext(ctx, "INSERT INTO team (org_id, name, created_at, updated_at) VALUES (?, ?, ?, ?) RETURNING id", team.OrgID, team.Name, team.CreatedAt.UTC(), team.UpdatedAt.UTC()).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("error creating team")
+		}
+
+		team.ID = id
+
+		return insertTeamMembers(ctx, tx, team)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+func (a *TeamAdapter) Get(ctx context.Context) ([]*types.Team, error) {
+	return a.fetchTeams(ctx, createTeamQuery(ctx))
+}
+
+func (a *TeamAdapter) GetByID(ctx context.Context, id int) (*types.Team, error) {
+	teams, err := a.fetchTeams(ctx, createTeamQuery(ctx).Where("team.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(teams) > 0 {
+		return teams[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("team %d not found", id)}
+}
+
+func (a *TeamAdapter) GetByAccountID(ctx context.Context, accountID int) ([]*types.Team, error) {
+	return a.fetchTeams(ctx, createTeamQuery(ctx).Where("team.id IN (SELECT team_id FROM team_member WHERE account_id = ?)", accountID))
+}
+
+func (a *TeamAdapter) Update(ctx context.Context, team *types.Team) (*types.Team, error) {
+	err := data.WithTx(ctx, a.db, func(tx data.DBTX) error {
+		res, err := tx.ExecContext(ctx, "UPDATE team SET name = ?, updated_at = ? WHERE id = ? AND org_id = COALESCE(?, org_id)", team.Name, team.UpdatedAt.UTC(), team.ID, data.OrganizationArg(ctx))
+		if err != nil {
+			return fmt.Errorf("error updating team")
+		}
+
+		err = data.ExpectRow(res, fmt.Sprintf("team %d not found", team.ID))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM team_member WHERE team_id = ?", team.ID)
+		if err != nil {
+			return fmt.Errorf("error deleting team member")
+		}
+
+		return insertTeamMembers(ctx, tx, team)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// Delete removes the team and takes its tickets out of the queue, which the
+// column's missing foreign key would otherwise leave pointing at nothing.
+func (a *TeamAdapter) Delete(ctx context.Context, id int) error {
+	return data.WithTx(ctx, a.db, func(tx data.DBTX) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM team WHERE id = ? AND org_id = COALESCE(?, org_id)", id, data.OrganizationArg(ctx))
+		if err != nil {
+			return fmt.Errorf("error deleting team")
+		}
+
+		err = data.ExpectRow(res, fmt.Sprintf("team %d not found", id))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE ticket SET team_id = NULL WHERE team_id = ?", id)
+		if err != nil {
+			return fmt.Errorf("error deleting team")
+		}
+
+		return nil
+	})
+}
+
+func createTeamQuery(ctx context.Context) *data.Query {
+	return data.ScopeQuery(ctx, createQuery(), "team.org_id")
+}
+
+func (a *TeamAdapter) fetchTeams(ctx context.Context, query *data.Query) ([]*types.Team, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT team.id, team.org_id, team.name, team.created_at, team.updated_at, COALESCE(json_group_array(team_member.account_id) FILTER (WHERE team_member.account_id IS NOT NULL), '[]') FROM team LEFT JOIN (SELECT * FROM team_member ORDER BY account_id) team_member ON team.id = team_member.team_id"+query.Clause()+" GROUP BY team.id ORDER BY team.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting teams")
+	}
+	defer rows.Close()
+
+	teams := []*types.Team{}
+
+	for rows.Next() {
+		team, err := scanIntoTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+func insertTeamMembers(ctx context.Context, tx data.DBTX, team *types.Team) error {
+	if len(team.MemberIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO team_member (team_id, account_id) SELECT DISTINCT ?, value FROM json_each(?)", team.ID, jsonArray(team.MemberIDs))
+	if err != nil {
+		return fmt.Errorf("error creating team member")
+	}
+
+	return nil
+}
+
+func scanIntoTeam(rows *sql.Rows) (*types.Team, error) {
+	memberIDs := ""
+	team := &types.Team{
+		MemberIDs: []int{},
+	}
+
+	err := rows.Scan(&team.ID, &team.OrgID, &team.Name, &team.CreatedAt, &team.UpdatedAt, &memberIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading team")
+	}
+
+	err = json.Unmarshal([]byte(memberIDs), &team.MemberIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading team")
+	}
+
+	return team, nil
+}
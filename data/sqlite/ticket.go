@@ -0,0 +1,480 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+type TicketAdapter struct {
+	db data.DBTX
+}
+
+func CreateTicketAdapter(db data.DBTX) *TicketAdapter {
+	return &TicketAdapter{
+		db: db,
+	}
+}
+
+func (t *TicketAdapter) Create(ctx context.Context, ticket *types.Ticket) (*types.Ticket, error) {
+	err := data.WithTx(ctx, t.db, func(tx data.DBTX) error {
+		id := 0
+		err := tx.QueryRowContext(ctx, "INSERT INTO ticket (org_id, title, description, author_id, status, resolution, team_id, priority, due_at, first_response_due_at, resolution_due_at, resolved_at, category_id, labels, fields) VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, 0), ?, ?, ?, ?, ?, NULLIF(?, 0), ?, ?) RETURNING id", ticket.OrgID, ticket.Title, ticket.Description, ticket.AuthorID, ticket.Status, ticket.Resolution, ticket.TeamID, ticket.Priority, nullTimestamp(ticket.DueAt), nullTimestamp(ticket.SLA.FirstResponseDueAt), nullTimestamp(ticket.SLA.ResolutionDueAt), nullTimestamp(ticket.SLA.ResolvedAt), ticket.CategoryID, jsonList(ticket.Labels), marshalFields(ticket.Fields)).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("error creating ticket")
+		}
+
+		ticket.ID = id
+		ticket.Version = 1
+
+		err = insertAssignees(ctx, tx, ticket)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO watcher (ticket_id, account_id) SELECT ?, value FROM json_each(?)", ticket.ID, jsonArray(append([]int{ticket.AuthorID}, ticket.AssigneeIDs...)))
+		if err != nil {
+			return fmt.Errorf("error creating watcher")
+		}
+
+		ticket.WatcherIDs, err = getWatchers(ctx, tx, ticket.ID)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ticket, nil
+}
+
+func (t *TicketAdapter) Get(ctx context.Context, filter *types.TicketFilter, page *types.Page) ([]*types.Ticket, *types.PageInfo, error) {
+	return t.fetchTickets(ctx, page, filterTickets(createTicketQuery(ctx), filter))
+}
+
+func (t *TicketAdapter) GetByID(ctx context.Context, id int) (*types.Ticket, error) {
+	tickets, _, err := t.fetchTickets(ctx, nil, createTicketQuery(ctx).Where("ticket.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tickets) > 0 {
+		return tickets[0], nil
+	}
+
+	return nil, fmt.Errorf("ticket %d not found", id)
+}
+
+func (t *TicketAdapter) GetDeleted(ctx context.Context, id int) (*types.Ticket, error) {
+	query := data.ScopeQuery(ctx, createQuery().Where("ticket.deleted_at IS NOT NULL"), "ticket.org_id")
+
+	tickets, _, err := t.fetchTickets(ctx, nil, query.Where("ticket.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tickets) > 0 {
+		return tickets[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("deleted ticket %d not found", id)}
+}
+
+func (t *TicketAdapter) Update(ctx context.Context, ticket *types.Ticket) (*types.Ticket, error) {
+	err := data.WithTx(ctx, t.db, func(tx data.DBTX) error {
+		err := tx.QueryRowContext(ctx, "UPDATE ticket SET title = ?, description = ?, author_id = ?, status = ?, resolution = ?, team_id = NULLIF(?, 0), priority = ?, due_at = ?, first_response_due_at = ?, resolution_due_at = ?, resolved_at = ?, category_id = NULLIF(?, 0), labels = ?, fields = ?, version = version + 1, updated_at = "+currentTimestamp+" WHERE id = ? AND version = ? AND deleted_at IS NULL AND org_id = COALESCE(?, org_id) RETURNING updated_at", ticket.Title, ticket.Description, ticket.AuthorID, ticket.Status, ticket.Resolution, ticket.TeamID, ticket.Priority, nullTimestamp(ticket.DueAt), nullTimestamp(ticket.SLA.FirstResponseDueAt), nullTimestamp(ticket.SLA.ResolutionDueAt), nullTimestamp(ticket.SLA.ResolvedAt), ticket.CategoryID, jsonList(ticket.Labels), marshalFields(ticket.Fields), ticket.ID, ticket.Version, data.OrganizationArg(ctx)).Scan(&ticket.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return &types.PreconditionFailed{Message: fmt.Sprintf("ticket %d has changed since version %d", ticket.ID, ticket.Version)}
+		}
+		if err != nil {
+			return fmt.Errorf("error updating ticket")
+		}
+
+		// new assignees start watching, while those who stopped stay away
+		_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO watcher (ticket_id, account_id) SELECT ?, value FROM json_each(?) WHERE value NOT IN (SELECT account_id FROM assignee WHERE ticket_id = ?)", ticket.ID, jsonArray(ticket.AssigneeIDs), ticket.ID)
+		if err != nil {
+			return fmt.Errorf("error creating watcher")
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM assignee WHERE ticket_id = ?", ticket.ID)
+		if err != nil {
+			return fmt.Errorf("error deleting assignee")
+		}
+
+		err = insertAssignees(ctx, tx, ticket)
+		if err != nil {
+			return err
+		}
+
+		ticket.WatcherIDs, err = getWatchers(ctx, tx, ticket.ID)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ticket.Version++
+
+	return ticket, nil
+}
+
+func (t *TicketAdapter) Delete(ctx context.Context, id int) error {
+	_, err := t.db.ExecContext(ctx, "UPDATE ticket SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL AND org_id = COALESCE(?, org_id)", id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting ticket")
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) Restore(ctx context.Context, id int) error {
+	res, err := t.db.ExecContext(ctx, "UPDATE ticket SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL AND org_id = COALESCE(?, org_id)", id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error restoring ticket")
+	}
+
+	return data.ExpectRow(res, fmt.Sprintf("deleted ticket %d not found", id))
+}
+
+// Purge removes the ticket for good, whether or not it was deleted first.
+func (t *TicketAdapter) Purge(ctx context.Context, id int) error {
+	res, err := t.db.ExecContext(ctx, "DELETE FROM ticket WHERE id = ? AND org_id = COALESCE(?, org_id)", id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error purging ticket")
+	}
+
+	return data.ExpectRow(res, fmt.Sprintf("ticket %d not found", id))
+}
+
+// Claim picks and bumps the oldest unassigned ticket in the team's queue in a
+// single statement, which holds sqlite's write lock, so concurrent claims
+// never pick the same ticket.
+func (t *TicketAdapter) Claim(ctx context.Context, teamID int, accountID int) (*types.Ticket, error) {
+	id := 0
+
+	err := data.WithTx(ctx, t.db, func(tx data.DBTX) error {
+		statuses, _ := json.Marshal(types.QueueStatuses)
+
+		err := tx.QueryRowContext(ctx, "UPDATE ticket SET version = version + 1, updated_at = "+currentTimestamp+" WHERE id = (SELECT id FROM ticket WHERE team_id = ? AND deleted_at IS NULL AND org_id = COALESCE(?, org_id) AND status IN (SELECT value FROM json_each(?)) AND NOT EXISTS (SELECT 1 FROM assignee WHERE assignee.ticket_id = ticket.id) ORDER BY "+timestamp("created_at")+", id LIMIT 1) RETURNING id", teamID, data.OrganizationArg(ctx), string(statuses)).Scan(&id)
+		if err == sql.ErrNoRows {
+			return &types.NotFound{Message: fmt.Sprintf("queue of team %d is empty", teamID)}
+		}
+		if err != nil {
+			return fmt.Errorf("error claiming ticket")
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO assignee (ticket_id, account_id) VALUES (?, ?)", id, accountID)
+		if err != nil {
+			return fmt.Errorf("error creating assignee")
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO watcher (ticket_id, account_id) VALUES (?, ?)", id, accountID)
+		if err != nil {
+			return fmt.Errorf("error creating watcher")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.GetByID(ctx, id)
+}
+
+func (t *TicketAdapter) Watch(ctx context.Context, id int, accountID int) error {
+	_, err := t.db.ExecContext(ctx, "INSERT OR IGNORE INTO watcher (ticket_id, account_id) SELECT id, ? FROM ticket WHERE id = ? AND deleted_at IS NULL AND org_id = COALESCE(?, org_id)", accountID, id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error creating watcher")
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) Unwatch(ctx context.Context, id int, accountID int) error {
+	_, err := t.db.ExecContext(ctx, "DELETE FROM watcher WHERE ticket_id = (SELECT id FROM ticket WHERE id = ? AND org_id = COALESCE(?, org_id)) AND account_id = ?", id, data.OrganizationArg(ctx), accountID)
+	if err != nil {
+		return fmt.Errorf("error deleting watcher")
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) RecordFirstResponse(ctx context.Context, id int, at time.Time) error {
+	_, err := t.db.ExecContext(ctx, "UPDATE ticket SET first_response_at = ? WHERE id = ? AND first_response_at IS NULL AND org_id = COALESCE(?, org_id)", bindSQLite(at), id, data.OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error recording first response")
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) MarkBreached(ctx context.Context, id int, metric types.SLAMetric, at time.Time) (bool, error) {
+	column, ok := breachColumns[metric]
+	if !ok {
+		return false, fmt.Errorf("unknown sla metric %s", metric)
+	}
+
+	res, err := t.db.ExecContext(ctx, "UPDATE ticket SET "+column+" = ? WHERE id = ? AND "+column+" IS NULL AND org_id = COALESCE(?, org_id)", bindSQLite(at), id, data.OrganizationArg(ctx))
+	if err != nil {
+		return false, fmt.Errorf("error marking ticket breached")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error reading affected rows")
+	}
+
+	return n > 0, nil
+}
+
+var breachColumns = map[types.SLAMetric]string{
+	types.SLAFirstResponse: "first_response_breached_at",
+	types.SLAResolution:    "resolution_breached_at",
+}
+
+var ticketSortColumns = map[string]string{
+	"id":         "ticket.id",
+	"created_at": timestamp("ticket.created_at"),
+	"updated_at": timestamp("ticket.updated_at"),
+	"status":     "ticket.status",
+}
+
+func createTicketQuery(ctx context.Context) *data.Query {
+	return data.ScopeQuery(ctx, createQuery().Where("ticket.deleted_at IS NULL"), "ticket.org_id")
+}
+
+func filterTickets(query *data.Query, filter *types.TicketFilter) *data.Query {
+	if filter == nil {
+		return query
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses, _ := json.Marshal(filter.Statuses)
+		query.Where("ticket.status IN (SELECT value FROM json_each(?))", string(statuses))
+	}
+
+	if len(filter.AuthorIDs) > 0 {
+		query.Where("ticket.author_id IN (SELECT value FROM json_each(?))", jsonArray(filter.AuthorIDs))
+	}
+
+	if len(filter.TeamIDs) > 0 {
+		query.Where("ticket.team_id IN (SELECT value FROM json_each(?))", jsonArray(filter.TeamIDs))
+	}
+
+	if len(filter.Priorities) > 0 {
+		priorities, _ := json.Marshal(filter.Priorities)
+		query.Where("ticket.priority IN (SELECT value FROM json_each(?))", string(priorities))
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		query.Where("ticket.category_id IN (SELECT value FROM json_each(?))", jsonArray(filter.CategoryIDs))
+	}
+
+	if len(filter.Labels) > 0 {
+		query.Where("EXISTS (SELECT 1 FROM json_each(ticket.labels) WHERE value IN (SELECT value FROM json_each(?)))", jsonList(filter.Labels))
+	}
+
+	// json_each walks a multi-select's options and yields any other value as
+	// a single row
+	for _, field := range filter.Fields {
+		query.Where("EXISTS (SELECT 1 FROM json_each(ticket.fields, ?) WHERE value = ?)", "$."+field.Key, field.Value)
+	}
+
+	assigned := "ticket.id IN (SELECT ticket_id FROM assignee WHERE account_id IN (SELECT value FROM json_each(?)))"
+	unassigned := "NOT EXISTS (SELECT 1 FROM assignee WHERE assignee.ticket_id = ticket.id)"
+
+	switch {
+	case len(filter.AssigneeIDs) > 0 && filter.Unassigned:
+		query.Where("("+assigned+" OR "+unassigned+")", jsonArray(filter.AssigneeIDs))
+	case len(filter.AssigneeIDs) > 0:
+		query.Where(assigned, jsonArray(filter.AssigneeIDs))
+	case filter.Unassigned:
+		query.Where(unassigned)
+	}
+
+	if filter.ParticipantID > 0 {
+		query.Where("(ticket.author_id = ? OR ticket.id IN (SELECT ticket_id FROM assignee WHERE account_id = ?))", filter.ParticipantID, filter.ParticipantID)
+	}
+
+	if filter.CreatedAfter != nil {
+		query.Where(timestamp("ticket.created_at")+" >= ?", *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		query.Where(timestamp("ticket.created_at")+" < ?", *filter.CreatedBefore)
+	}
+
+	if filter.UpdatedAfter != nil {
+		query.Where(timestamp("ticket.updated_at")+" >= ?", *filter.UpdatedAfter)
+	}
+
+	if filter.UpdatedBefore != nil {
+		query.Where(timestamp("ticket.updated_at")+" < ?", *filter.UpdatedBefore)
+	}
+
+	if filter.Title != "" {
+		query.Where("instr(lower(ticket.title), lower(?)) > 0", filter.Title)
+	}
+
+	breached := "(" + timestamp("ticket.first_response_due_at") + " < COALESCE(" + timestamp("ticket.first_response_at") + ", ?) OR " + timestamp("ticket.resolution_due_at") + " < COALESCE(" + timestamp("ticket.resolved_at") + ", ?))"
+	if filter.SLAUnflagged {
+		breached = "((ticket.first_response_breached_at IS NULL AND " + timestamp("ticket.first_response_due_at") + " < COALESCE(" + timestamp("ticket.first_response_at") + ", ?)) OR (ticket.resolution_breached_at IS NULL AND " + timestamp("ticket.resolution_due_at") + " < COALESCE(" + timestamp("ticket.resolved_at") + ", ?)))"
+	}
+
+	switch filter.SLA {
+	case types.SLABreached:
+		query.Where(breached, filter.SLANow, filter.SLANow)
+	case types.SLAAtRisk:
+		deadline := filter.SLANow.Add(filter.SLAWindow)
+		// breached is NULL rather than false when a due time is unset
+		query.Where("NOT COALESCE("+breached+", FALSE)", filter.SLANow, filter.SLANow)
+		query.Where("((ticket.first_response_at IS NULL AND "+timestamp("ticket.first_response_due_at")+" < ?) OR (ticket.resolved_at IS NULL AND "+timestamp("ticket.resolution_due_at")+" < ?))", deadline, deadline)
+	}
+
+	return query
+}
+
+func (t *TicketAdapter) fetchTickets(ctx context.Context, page *types.Page, query *data.Query) ([]*types.Ticket, *types.PageInfo, error) {
+	total, err := data.CountRows(ctx, t.db, page, "SELECT COUNT(*) FROM ticket", query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paged, err := query.Paged(page, ticketSortColumns, "ticket.id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := t.db.QueryContext(ctx, "SELECT ticket.id, ticket.org_id, ticket.title, ticket.description, ticket.status, ticket.resolution, COALESCE(ticket.team_id, 0), COALESCE(ticket.category_id, 0), ticket.labels, ticket.fields, ticket.priority, ticket.due_at, ticket.first_response_due_at, ticket.first_response_at, ticket.first_response_breached_at, ticket.resolution_due_at, ticket.resolved_at, ticket.resolution_breached_at, ticket.version, ticket.author_id, ticket.created_at, ticket.updated_at, COALESCE(json_group_array(assignee.account_id) FILTER (WHERE assignee.account_id IS NOT NULL), '[]'), (SELECT json_group_array(account_id) FROM (SELECT account_id FROM watcher WHERE watcher.ticket_id = ticket.id ORDER BY id)) FROM ticket LEFT JOIN assignee ON ticket.id = assignee.ticket_id"+query.Clause()+" GROUP BY ticket.id"+paged, query.Args()...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching tickets")
+	}
+	defer rows.Close()
+
+	tickets := []*types.Ticket{}
+
+	for rows.Next() {
+		ticket, err := scanIntoTicket(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	tickets, info := data.Paginate(page, tickets, data.TicketSortValue(page))
+	info.Total = total
+
+	return tickets, info, nil
+}
+
+func insertAssignees(ctx context.Context, tx data.DBTX, ticket *types.Ticket) error {
+	if len(ticket.AssigneeIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO assignee (ticket_id, account_id) SELECT ?, value FROM json_each(?)", ticket.ID, jsonArray(ticket.AssigneeIDs))
+	if err != nil {
+		return fmt.Errorf("error creating assignee")
+	}
+
+	return nil
+}
+
+func getWatchers(ctx context.Context, tx data.DBTX, ticketID int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT account_id FROM watcher WHERE ticket_id = ? ORDER BY id", ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting watchers")
+	}
+	defer rows.Close()
+
+	ids := []int{}
+
+	for rows.Next() {
+		id := 0
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("error reading watcher")
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func scanIntoTicket(rows *sql.Rows) (*types.Ticket, error) {
+	assigneeIDs := ""
+	watcherIDs := ""
+	labels := ""
+	fields := ""
+	times := make([]sql.NullTime, 7)
+	ticket := &types.Ticket{
+		AssigneeIDs: []int{},
+		WatcherIDs:  []int{},
+		Labels:      []string{},
+	}
+
+	err := rows.Scan(&ticket.ID, &ticket.OrgID, &ticket.Title, &ticket.Description, &ticket.Status, &ticket.Resolution, &ticket.TeamID, &ticket.CategoryID, &labels, &fields, &ticket.Priority, &times[0], &times[1], &times[2], &times[3], &times[4], &times[5], &times[6], &ticket.Version, &ticket.AuthorID, &ticket.CreatedAt, &ticket.UpdatedAt, &assigneeIDs, &watcherIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ticket")
+	}
+
+	ticket.DueAt = data.TimeOrNil(times[0])
+	ticket.SLA = types.SLA{
+		FirstResponseDueAt:      data.TimeOrNil(times[1]),
+		FirstResponseAt:         data.TimeOrNil(times[2]),
+		FirstResponseBreachedAt: data.TimeOrNil(times[3]),
+		ResolutionDueAt:         data.TimeOrNil(times[4]),
+		ResolvedAt:              data.TimeOrNil(times[5]),
+		ResolutionBreachedAt:    data.TimeOrNil(times[6]),
+	}
+
+	err = json.Unmarshal([]byte(assigneeIDs), &ticket.AssigneeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ticket")
+	}
+
+	err = json.Unmarshal([]byte(watcherIDs), &ticket.WatcherIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ticket")
+	}
+
+	err = json.Unmarshal([]byte(labels), &ticket.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ticket")
+	}
+
+	err = json.Unmarshal([]byte(fields), &ticket.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ticket")
+	}
+
+	return ticket, nil
+}
+
+func jsonArray(ids []int) string {
+	b, _ := json.Marshal(append([]int{}, ids...))
+	return string(b)
+}
+
+func jsonList(items []string) string {
+	b, _ := json.Marshal(append([]string{}, items...))
+	return string(b)
+}
+
+func marshalFields(fields types.Fields) string {
+	if fields == nil {
+		return "{}"
+	}
+
+	b, _ := json.Marshal(fields)
+	return string(b)
+}
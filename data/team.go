@@ -0,0 +1,153 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ticketing-api/types"
+
+	"github.com/lib/pq"
+)
+
+type TeamAdapter struct {
+	db DBTX
+}
+
+func CreateTeamAdapter(db DBTX) *TeamAdapter {
+	return &TeamAdapter{
+		db: db,
+	}
+}
+
+func (a *TeamAdapter) Create(ctx context.Context, team *types.Team) (*types.Team, error) {
+	err := WithTx(ctx, a.db, func(tx DBTX) error {
+		id := 0
+		err := tx.QueryRowContext(ctx, "INSERT INTO team (org_id, name, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id", team.OrgID, team.Name, team.CreatedAt.UTC(), team.UpdatedAt.UTC()).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("error creating team")
+		}
+
+		team.ID = id
+
+		return insertTeamMembers(ctx, tx, team)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+func (a *TeamAdapter) Get(ctx context.Context) ([]*types.Team, error) {
+	return a.fetchTeams(ctx, createTeamQuery(ctx))
+}
+
+func (a *TeamAdapter) GetByID(ctx context.Context, id int) (*types.Team, error) {
+	teams, err := a.fetchTeams(ctx, createTeamQuery(ctx).Where("team.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(teams) > 0 {
+		return teams[0], nil
+	}
+
+	return nil, &types.NotFound{Message: fmt.Sprintf("team %d not found", id)}
+}
+
+func (a *TeamAdapter) GetByAccountID(ctx context.Context, accountID int) ([]*types.Team, error) {
+	return a.fetchTeams(ctx, createTeamQuery(ctx).Where("team.id IN (SELECT team_id FROM team_member WHERE account_id = ?)", accountID))
+}
+
+func (a *TeamAdapter) Update(ctx context.Context, team *types.Team) (*types.Team, error) {
+	err := WithTx(ctx, a.db, func(tx DBTX) error {
+		res, err := tx.ExecContext(ctx, "UPDATE team SET name = $1, updated_at = $2 WHERE id = $3 AND org_id = COALESCE($4, org_id)", team.Name, team.UpdatedAt.UTC(), team.ID, OrganizationArg(ctx))
+		if err != nil {
+			return fmt.Errorf("error updating team")
+		}
+
+		err = ExpectRow(res, fmt.Sprintf("team %d not found", team.ID))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM team_member WHERE team_id = $1", team.ID)
+		if err != nil {
+			return fmt.Errorf("error deleting team member")
+		}
+
+		return insertTeamMembers(ctx, tx, team)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// Delete removes the team. The foreign key on ticket.team_id takes its tickets
+// out of the queue.
+func (a *TeamAdapter) Delete(ctx context.Context, id int) error {
+	res, err := a.db.ExecContext(ctx, "DELETE FROM team WHERE id = $1 AND org_id = COALESCE($2, org_id)", id, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting team")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("team %d not found", id))
+}
+
+func createTeamQuery(ctx context.Context) *Query {
+	return ScopeQuery(ctx, CreateQuery(PostgresPlaceholder, bindPostgres), "team.org_id")
+}
+
+func (a *TeamAdapter) fetchTeams(ctx context.Context, query *Query) ([]*types.Team, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT team.id, team.org_id, team.name, team.created_at, team.updated_at, COALESCE(array_agg(team_member.account_id ORDER BY team_member.account_id) FILTER (WHERE team_member.account_id IS NOT NULL), '{}') FROM team LEFT JOIN team_member ON team.id = team_member.team_id"+query.Clause()+" GROUP BY team.id ORDER BY team.id", query.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting teams")
+	}
+	defer rows.Close()
+
+	teams := []*types.Team{}
+
+	for rows.Next() {
+		team, err := scanIntoTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+func insertTeamMembers(ctx context.Context, tx DBTX, team *types.Team) error {
+	if len(team.MemberIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO team_member (team_id, account_id) SELECT DISTINCT $1::int, unnest($2::int[])", team.ID, pq.Array(team.MemberIDs))
+	if err != nil {
+		return fmt.Errorf("error creating team member")
+	}
+
+	return nil
+}
+
+func scanIntoTeam(rows *sql.Rows) (*types.Team, error) {
+	memberIDs := pq.Int64Array{}
+	team := &types.Team{
+		MemberIDs: []int{},
+	}
+
+	err := rows.Scan(&team.ID, &team.OrgID, &team.Name, &team.CreatedAt, &team.UpdatedAt, &memberIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading team")
+	}
+
+	for _, id := range memberIDs {
+		team.MemberIDs = append(team.MemberIDs, int(id))
+	}
+
+	return team, nil
+}
@@ -1,60 +1,64 @@
 package data
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"ticketing-api/types"
+	"time"
 
 	"github.com/lib/pq"
 )
 
 type TicketAdapter struct {
-	db *sql.DB
+	db DBTX
 }
 
-func CreateTicketAdapter(db *sql.DB) *TicketAdapter {
+func CreateTicketAdapter(db DBTX) *TicketAdapter {
 	return &TicketAdapter{
 		db: db,
 	}
 }
 
-func (t *TicketAdapter) Create(ticket *types.Ticket) (*types.Ticket, error) {
-	id := 0
-	err := t.db.QueryRow("INSERT INTO ticket (title, description, author_id, status) VALUES ($1, $2, $3, $4) RETURNING id", ticket.Title, ticket.Description, ticket.AuthorID, ticket.Status).Scan(&id)
-	if err != nil {
-		return nil, fmt.Errorf("error creating ticket")
-	}
+func (t *TicketAdapter) Create(ctx context.Context, ticket *types.Ticket) (*types.Ticket, error) {
+	err := WithTx(ctx, t.db, func(tx DBTX) error {
+		id := 0
+		err := tx.QueryRowContext(ctx, "INSERT INTO ticket (org_id, title, description, author_id, status, resolution, team_id, priority, due_at, first_response_due_at, resolution_due_at, resolved_at, category_id, labels, fields) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), $8, $9, $10, $11, $12, NULLIF($13, 0), $14, $15) RETURNING id", ticket.OrgID, ticket.Title, ticket.Description, ticket.AuthorID, ticket.Status, ticket.Resolution, ticket.TeamID, ticket.Priority, nullTime(ticket.DueAt), nullTime(ticket.SLA.FirstResponseDueAt), nullTime(ticket.SLA.ResolutionDueAt), nullTime(ticket.SLA.ResolvedAt), ticket.CategoryID, labelArray(ticket.Labels), marshalFields(ticket.Fields)).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("error creating ticket")
+		}
 
-	ticket.ID = id
+		ticket.ID = id
+		ticket.Version = 1
 
-	for _, id := range ticket.AssigneeIDs {
-		_, err := t.db.Exec("INSERT INTO assignee (ticket_id, account_id) VALUES ($1, $2)", ticket.ID, id)
+		err = insertAssignees(ctx, tx, ticket)
 		if err != nil {
-			return nil, fmt.Errorf("error creating assignee")
+			return err
 		}
-	}
 
-	return ticket, nil
-}
+		_, err = tx.ExecContext(ctx, "INSERT INTO watcher (ticket_id, account_id) SELECT $1, unnest($2::int[]) ON CONFLICT DO NOTHING", ticket.ID, pq.Array(append([]int{ticket.AuthorID}, ticket.AssigneeIDs...)))
+		if err != nil {
+			return fmt.Errorf("error creating watcher")
+		}
 
-func (t *TicketAdapter) Get() ([]*types.Ticket, error) {
-	return t.fetchTickets("SELECT ticket.id, ticket.title, ticket.description, ticket.status, ticket.author_id, ticket.created_at, ticket.updated_at, assignee.account_id FROM ticket LEFT JOIN assignee ON ticket.id = assignee.ticket_id")
-}
+		ticket.WatcherIDs, err = getWatchers(ctx, tx, ticket.ID)
 
-func (t *TicketAdapter) GetByAuthorID(authorID int) ([]*types.Ticket, error) {
-	return t.fetchTickets("SELECT ticket.id, ticket.title, ticket.description, ticket.status, ticket.author_id, ticket.created_at, ticket.updated_at, assignee.account_id FROM ticket LEFT JOIN assignee ON ticket.id = assignee.ticket_id WHERE author_id = $1", authorID)
-}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-func (t *TicketAdapter) GetByAssigneeIDs(assigneeIDs []int) ([]*types.Ticket, error) {
-	return t.fetchTickets("SELECT ticket.id, ticket.title, ticket.description, ticket.status, ticket.author_id, ticket.created_at, ticket.updated_at, assignee.account_id FROM ticket LEFT JOIN assignee ON ticket.id = assignee.ticket_id WHERE ticket.id IN (SELECT ticket_id FROM assignee WHERE account_id = ANY($1))", pq.Array(assigneeIDs))
+	return ticket, nil
 }
 
-func (t *TicketAdapter) GetByAuthorIDAssigneeIDs(authorID int, assigneeIDs []int) ([]*types.Ticket, error) {
-	return t.fetchTickets("SELECT ticket.id, ticket.title, ticket.description, ticket.status, ticket.author_id, ticket.created_at, ticket.updated_at, assignee.account_id FROM ticket LEFT JOIN assignee ON ticket.id = assignee.ticket_id WHERE author_id = $1 AND ticket.id IN (SELECT ticket_id FROM assignee WHERE account_id = ANY($2))", authorID, pq.Array(assigneeIDs))
+func (t *TicketAdapter) Get(ctx context.Context, filter *types.TicketFilter, page *types.Page) ([]*types.Ticket, *types.PageInfo, error) {
+	return t.fetchTickets(ctx, page, filterTickets(createTicketQuery(ctx), filter))
 }
 
-func (t *TicketAdapter) GetByID(id int) (*types.Ticket, error) {
-	tickets, err := t.fetchTickets("SELECT ticket.id, ticket.title, ticket.description, ticket.status, ticket.author_id, ticket.created_at, ticket.updated_at, assignee.account_id FROM ticket LEFT JOIN assignee ON ticket.id = assignee.ticket_id WHERE ticket.id = $1", id)
+func (t *TicketAdapter) GetByID(ctx context.Context, id int) (*types.Ticket, error) {
+	tickets, _, err := t.fetchTickets(ctx, nil, createTicketQuery(ctx).Where("ticket.id = ?", id))
 	if err != nil {
 		return nil, err
 	}
@@ -66,30 +70,62 @@ func (t *TicketAdapter) GetByID(id int) (*types.Ticket, error) {
 	return nil, fmt.Errorf("ticket %d not found", id)
 }
 
-func (t *TicketAdapter) Update(ticket *types.Ticket) (*types.Ticket, error) {
-	_, err := t.db.Exec("UPDATE ticket SET title = $1, description = $2, author_id = $3, status = $4 WHERE id = $5", ticket.Title, ticket.Description, ticket.AuthorID, ticket.Status, ticket.ID)
+func (t *TicketAdapter) GetDeleted(ctx context.Context, id int) (*types.Ticket, error) {
+	query := ScopeQuery(ctx, CreateQuery(PostgresPlaceholder, bindPostgres).Where("ticket.deleted_at IS NOT NULL"), "ticket.org_id")
+
+	tickets, _, err := t.fetchTickets(ctx, nil, query.Where("ticket.id = ?", id))
 	if err != nil {
-		return nil, fmt.Errorf("error updating ticket")
+		return nil, err
 	}
 
-	_, err = t.db.Exec("DELETE FROM assignee WHERE ticket_id = $1", ticket.ID)
-	if err != nil {
-		return nil, fmt.Errorf("error deleting assignee")
+	if len(tickets) > 0 {
+		return tickets[0], nil
 	}
 
-	for _, assigneeID := range ticket.AssigneeIDs {
-		_, err := t.db.Exec("INSERT INTO assignee (ticket_id, account_id) VALUES ($1, $2)", ticket.ID, assigneeID)
+	return nil, &types.NotFound{Message: fmt.Sprintf("deleted ticket %d not found", id)}
+}
+
+func (t *TicketAdapter) Update(ctx context.Context, ticket *types.Ticket) (*types.Ticket, error) {
+	err := WithTx(ctx, t.db, func(tx DBTX) error {
+		err := tx.QueryRowContext(ctx, "UPDATE ticket SET title = $1, description = $2, author_id = $3, status = $4, resolution = $5, team_id = NULLIF($6, 0), priority = $7, due_at = $8, first_response_due_at = $9, resolution_due_at = $10, resolved_at = $11, category_id = NULLIF($12, 0), labels = $13, fields = $14, version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $15 AND version = $16 AND deleted_at IS NULL AND org_id = COALESCE($17, org_id) RETURNING updated_at", ticket.Title, ticket.Description, ticket.AuthorID, ticket.Status, ticket.Resolution, ticket.TeamID, ticket.Priority, nullTime(ticket.DueAt), nullTime(ticket.SLA.FirstResponseDueAt), nullTime(ticket.SLA.ResolutionDueAt), nullTime(ticket.SLA.ResolvedAt), ticket.CategoryID, labelArray(ticket.Labels), marshalFields(ticket.Fields), ticket.ID, ticket.Version, OrganizationArg(ctx)).Scan(&ticket.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return &types.PreconditionFailed{Message: fmt.Sprintf("ticket %d has changed since version %d", ticket.ID, ticket.Version)}
+		}
+		if err != nil {
+			return fmt.Errorf("error updating ticket")
+		}
+
+		// new assignees start watching, while those who stopped stay away
+		_, err = tx.ExecContext(ctx, "INSERT INTO watcher (ticket_id, account_id) SELECT $1, id FROM unnest($2::int[]) AS id WHERE id NOT IN (SELECT account_id FROM assignee WHERE ticket_id = $1) ON CONFLICT DO NOTHING", ticket.ID, pq.Array(ticket.AssigneeIDs))
+		if err != nil {
+			return fmt.Errorf("error creating watcher")
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM assignee WHERE ticket_id = $1", ticket.ID)
+		if err != nil {
+			return fmt.Errorf("error deleting assignee")
+		}
+
+		err = insertAssignees(ctx, tx, ticket)
 		if err != nil {
-			return nil, fmt.Errorf("error creating assignee")
+			return err
 		}
 
+		ticket.WatcherIDs, err = getWatchers(ctx, tx, ticket.ID)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	ticket.Version++
+
 	return ticket, nil
 }
 
-func (t *TicketAdapter) Delete(id int) error {
-	_, err := t.db.Exec("DELETE FROM ticket WHERE id = $1", id)
+func (t *TicketAdapter) Delete(ctx context.Context, id int) error {
+	_, err := t.db.ExecContext(ctx, "UPDATE ticket SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL AND org_id = COALESCE($2, org_id)", id, OrganizationArg(ctx))
 	if err != nil {
 		return fmt.Errorf("error deleting ticket")
 	}
@@ -97,50 +133,345 @@ func (t *TicketAdapter) Delete(id int) error {
 	return nil
 }
 
-func (t *TicketAdapter) fetchTickets(query string, args ...any) ([]*types.Ticket, error) {
-	rows, err := t.db.Query(query, args...)
+func (t *TicketAdapter) Restore(ctx context.Context, id int) error {
+	res, err := t.db.ExecContext(ctx, "UPDATE ticket SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL AND org_id = COALESCE($2, org_id)", id, OrganizationArg(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("error fetching tickets")
+		return fmt.Errorf("error restoring ticket")
 	}
 
-	ticketMap := make(map[int]*types.Ticket)
+	return ExpectRow(res, fmt.Sprintf("deleted ticket %d not found", id))
+}
 
-	for rows.Next() {
-		ticket, err := scanIntoTicket(rows)
+// Purge removes the ticket for good, whether or not it was deleted first.
+func (t *TicketAdapter) Purge(ctx context.Context, id int) error {
+	res, err := t.db.ExecContext(ctx, "DELETE FROM ticket WHERE id = $1 AND org_id = COALESCE($2, org_id)", id, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error purging ticket")
+	}
+
+	return ExpectRow(res, fmt.Sprintf("ticket %d not found", id))
+}
+
+// Claim locks the oldest unassigned ticket in the team's queue, skipping any a
+// concurrent claim already holds, and assigns it to the account.
+func (t *TicketAdapter) Claim(ctx context.Context, teamID int, accountID int) (*types.Ticket, error) {
+	id := 0
+
+	err := WithTx(ctx, t.db, func(tx DBTX) error {
+		err := tx.QueryRowContext(ctx, "UPDATE ticket SET version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = (SELECT id FROM ticket WHERE team_id = $1 AND deleted_at IS NULL AND org_id = COALESCE($2, org_id) AND status = ANY($3) AND NOT EXISTS (SELECT 1 FROM assignee WHERE assignee.ticket_id = ticket.id) ORDER BY created_at, id LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING id", teamID, OrganizationArg(ctx), pq.Array(types.QueueStatuses)).Scan(&id)
+		if err == sql.ErrNoRows {
+			return &types.NotFound{Message: fmt.Sprintf("queue of team %d is empty", teamID)}
+		}
+		if err != nil {
+			return fmt.Errorf("error claiming ticket")
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO assignee (ticket_id, account_id) VALUES ($1, $2)", id, accountID)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("error creating assignee")
 		}
 
-		if existingTicket, exists := ticketMap[ticket.ID]; exists {
-			existingTicket.AssigneeIDs = append(existingTicket.AssigneeIDs, ticket.AssigneeIDs...)
-		} else {
-			ticketMap[ticket.ID] = ticket
+		_, err = tx.ExecContext(ctx, "INSERT INTO watcher (ticket_id, account_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", id, accountID)
+		if err != nil {
+			return fmt.Errorf("error creating watcher")
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.GetByID(ctx, id)
+}
+
+func (t *TicketAdapter) Watch(ctx context.Context, id int, accountID int) error {
+	_, err := t.db.ExecContext(ctx, "INSERT INTO watcher (ticket_id, account_id) SELECT id, $2 FROM ticket WHERE id = $1 AND deleted_at IS NULL AND org_id = COALESCE($3, org_id) ON CONFLICT DO NOTHING", id, accountID, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error creating watcher")
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) Unwatch(ctx context.Context, id int, accountID int) error {
+	_, err := t.db.ExecContext(ctx, "DELETE FROM watcher WHERE ticket_id = (SELECT id FROM ticket WHERE id = $1 AND org_id = COALESCE($3, org_id)) AND account_id = $2", id, accountID, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error deleting watcher")
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) RecordFirstResponse(ctx context.Context, id int, at time.Time) error {
+	_, err := t.db.ExecContext(ctx, "UPDATE ticket SET first_response_at = $1 WHERE id = $2 AND first_response_at IS NULL AND org_id = COALESCE($3, org_id)", at.UTC(), id, OrganizationArg(ctx))
+	if err != nil {
+		return fmt.Errorf("error recording first response")
+	}
+
+	return nil
+}
+
+func (t *TicketAdapter) MarkBreached(ctx context.Context, id int, metric types.SLAMetric, at time.Time) (bool, error) {
+	column, ok := breachColumns[metric]
+	if !ok {
+		return false, fmt.Errorf("unknown sla metric %s", metric)
+	}
+
+	res, err := t.db.ExecContext(ctx, "UPDATE ticket SET "+column+" = $1 WHERE id = $2 AND "+column+" IS NULL AND org_id = COALESCE($3, org_id)", at.UTC(), id, OrganizationArg(ctx))
+	if err != nil {
+		return false, fmt.Errorf("error marking ticket breached")
 	}
 
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error reading affected rows")
+	}
+
+	return n > 0, nil
+}
+
+// breachColumns holds when each SLA metric of a ticket was flagged breached.
+var breachColumns = map[types.SLAMetric]string{
+	types.SLAFirstResponse: "first_response_breached_at",
+	types.SLAResolution:    "resolution_breached_at",
+}
+
+var ticketSortColumns = map[string]string{
+	"id":         "ticket.id",
+	"created_at": "ticket.created_at",
+	"updated_at": "ticket.updated_at",
+	"status":     "ticket.status",
+}
+
+func createTicketQuery(ctx context.Context) *Query {
+	return ScopeQuery(ctx, CreateQuery(PostgresPlaceholder, bindPostgres).Where("ticket.deleted_at IS NULL"), "ticket.org_id")
+}
+
+func filterTickets(query *Query, filter *types.TicketFilter) *Query {
+	if filter == nil {
+		return query
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := []string{}
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, string(status))
+		}
+
+		query.Where("ticket.status = ANY(?)", pq.Array(statuses))
+	}
+
+	if len(filter.AuthorIDs) > 0 {
+		query.Where("ticket.author_id = ANY(?)", pq.Array(filter.AuthorIDs))
+	}
+
+	if len(filter.TeamIDs) > 0 {
+		query.Where("ticket.team_id = ANY(?)", pq.Array(filter.TeamIDs))
+	}
+
+	if len(filter.Priorities) > 0 {
+		priorities := []string{}
+		for _, priority := range filter.Priorities {
+			priorities = append(priorities, string(priority))
+		}
+
+		query.Where("ticket.priority = ANY(?)", pq.Array(priorities))
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		query.Where("ticket.category_id = ANY(?)", pq.Array(filter.CategoryIDs))
+	}
+
+	if len(filter.Labels) > 0 {
+		query.Where("ticket.labels && ?::text[]", pq.Array(filter.Labels))
+	}
+
+	for _, field := range filter.Fields {
+		value, _ := json.Marshal(map[string]any{field.Key: field.Value})
+		list, _ := json.Marshal(map[string]any{field.Key: []any{field.Value}})
+		query.Where("(ticket.fields @> ?::jsonb OR ticket.fields @> ?::jsonb)", string(value), string(list))
+	}
+
+	assigned := "ticket.id IN (SELECT ticket_id FROM assignee WHERE account_id = ANY(?))"
+	unassigned := "NOT EXISTS (SELECT 1 FROM assignee WHERE assignee.ticket_id = ticket.id)"
+
+	switch {
+	case len(filter.AssigneeIDs) > 0 && filter.Unassigned:
+		query.Where("("+assigned+" OR "+unassigned+")", pq.Array(filter.AssigneeIDs))
+	case len(filter.AssigneeIDs) > 0:
+		query.Where(assigned, pq.Array(filter.AssigneeIDs))
+	case filter.Unassigned:
+		query.Where(unassigned)
+	}
+
+	if filter.ParticipantID > 0 {
+		query.Where("(ticket.author_id = ? OR ticket.id IN (SELECT ticket_id FROM assignee WHERE account_id = ?))", filter.ParticipantID, filter.ParticipantID)
+	}
+
+	if filter.CreatedAfter != nil {
+		query.Where("ticket.created_at >= ?", *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		query.Where("ticket.created_at < ?", *filter.CreatedBefore)
+	}
+
+	if filter.UpdatedAfter != nil {
+		query.Where("ticket.updated_at >= ?", *filter.UpdatedAfter)
+	}
+
+	if filter.UpdatedBefore != nil {
+		query.Where("ticket.updated_at < ?", *filter.UpdatedBefore)
+	}
+
+	if filter.Title != "" {
+		query.Where("strpos(lower(ticket.title), lower(?)) > 0", filter.Title)
+	}
+
+	breached := "(ticket.first_response_due_at < COALESCE(ticket.first_response_at, ?) OR ticket.resolution_due_at < COALESCE(ticket.resolved_at, ?))"
+	if filter.SLAUnflagged {
+		breached = "((ticket.first_response_breached_at IS NULL AND ticket.first_response_due_at < COALESCE(ticket.first_response_at, ?)) OR (ticket.resolution_breached_at IS NULL AND ticket.resolution_due_at < COALESCE(ticket.resolved_at, ?)))"
+	}
+
+	switch filter.SLA {
+	case types.SLABreached:
+		query.Where(breached, filter.SLANow, filter.SLANow)
+	case types.SLAAtRisk:
+		deadline := filter.SLANow.Add(filter.SLAWindow)
+		// breached is NULL rather than false when a due time is unset
+		query.Where("NOT COALESCE("+breached+", FALSE)", filter.SLANow, filter.SLANow)
+		query.Where("((ticket.first_response_at IS NULL AND ticket.first_response_due_at < ?) OR (ticket.resolved_at IS NULL AND ticket.resolution_due_at < ?))", deadline, deadline)
+	}
+
+	return query
+}
+
+func (t *TicketAdapter) fetchTickets(ctx context.Context, page *types.Page, query *Query) ([]*types.Ticket, *types.PageInfo, error) {
+	total, err := CountRows(ctx, t.db, page, "SELECT COUNT(*) FROM ticket", query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paged, err := query.Paged(page, ticketSortColumns, "ticket.id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := t.db.QueryContext(ctx, "SELECT ticket.id, ticket.org_id, ticket.title, ticket.description, ticket.status, ticket.resolution, COALESCE(ticket.team_id, 0), COALESCE(ticket.category_id, 0), ticket.labels, ticket.fields, ticket.priority, ticket.due_at, ticket.first_response_due_at, ticket.first_response_at, ticket.first_response_breached_at, ticket.resolution_due_at, ticket.resolved_at, ticket.resolution_breached_at, ticket.version, ticket.author_id, ticket.created_at, ticket.updated_at, COALESCE(array_agg(assignee.account_id ORDER BY assignee.id) FILTER (WHERE assignee.account_id IS NOT NULL), '{}'), COALESCE((SELECT array_agg(watcher.account_id ORDER BY watcher.id) FROM watcher WHERE watcher.ticket_id = ticket.id), '{}') FROM ticket LEFT JOIN assignee ON ticket.id = assignee.ticket_id"+query.Clause()+" GROUP BY ticket.id"+paged, query.Args()...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching tickets")
+	}
+	defer rows.Close()
+
 	tickets := []*types.Ticket{}
 
-	for _, ticket := range ticketMap {
+	for rows.Next() {
+		ticket, err := scanIntoTicket(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		tickets = append(tickets, ticket)
 	}
 
-	return tickets, nil
+	tickets, info := Paginate(page, tickets, TicketSortValue(page))
+	info.Total = total
+
+	return tickets, info, nil
+}
+
+func insertAssignees(ctx context.Context, tx DBTX, ticket *types.Ticket) error {
+	if len(ticket.AssigneeIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO assignee (ticket_id, account_id) SELECT $1, unnest($2::int[])", ticket.ID, pq.Array(ticket.AssigneeIDs))
+	if err != nil {
+		return fmt.Errorf("error creating assignee")
+	}
+
+	return nil
+}
+
+func getWatchers(ctx context.Context, tx DBTX, ticketID int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT account_id FROM watcher WHERE ticket_id = $1 ORDER BY id", ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting watchers")
+	}
+	defer rows.Close()
+
+	ids := []int{}
+
+	for rows.Next() {
+		id := 0
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("error reading watcher")
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
 }
 
 func scanIntoTicket(rows *sql.Rows) (*types.Ticket, error) {
-	assigneeID := sql.NullInt64{}
+	assigneeIDs := pq.Int64Array{}
+	watcherIDs := pq.Int64Array{}
+	labels := pq.StringArray{}
+	fields := []byte{}
+	times := make([]sql.NullTime, 7)
 	ticket := &types.Ticket{
 		AssigneeIDs: []int{},
+		WatcherIDs:  []int{},
 	}
 
-	err := rows.Scan(&ticket.ID, &ticket.Title, &ticket.Description, &ticket.Status, &ticket.AuthorID, &ticket.CreatedAt, &ticket.UpdatedAt, &assigneeID)
+	err := rows.Scan(&ticket.ID, &ticket.OrgID, &ticket.Title, &ticket.Description, &ticket.Status, &ticket.Resolution, &ticket.TeamID, &ticket.CategoryID, &labels, &fields, &ticket.Priority, &times[0], &times[1], &times[2], &times[3], &times[4], &times[5], &times[6], &ticket.Version, &ticket.AuthorID, &ticket.CreatedAt, &ticket.UpdatedAt, &assigneeIDs, &watcherIDs)
 	if err != nil {
 		return nil, fmt.Errorf("error reading ticket")
 	}
 
-	if assigneeID.Valid {
-		ticket.AssigneeIDs = append(ticket.AssigneeIDs, int(assigneeID.Int64))
+	ticket.DueAt = TimeOrNil(times[0])
+	ticket.SLA = types.SLA{
+		FirstResponseDueAt:      TimeOrNil(times[1]),
+		FirstResponseAt:         TimeOrNil(times[2]),
+		FirstResponseBreachedAt: TimeOrNil(times[3]),
+		ResolutionDueAt:         TimeOrNil(times[4]),
+		ResolvedAt:              TimeOrNil(times[5]),
+		ResolutionBreachedAt:    TimeOrNil(times[6]),
+	}
+
+	for _, id := range assigneeIDs {
+		ticket.AssigneeIDs = append(ticket.AssigneeIDs, int(id))
+	}
+
+	for _, id := range watcherIDs {
+		ticket.WatcherIDs = append(ticket.WatcherIDs, int(id))
+	}
+
+	ticket.Labels = append([]string{}, labels...)
+
+	err = json.Unmarshal(fields, &ticket.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ticket")
 	}
 
 	return ticket, nil
 }
+
+// labelArray binds labels as a text array, empty rather than NULL when there
+// are none.
+func labelArray(labels []string) any {
+	return pq.Array(append([]string{}, labels...))
+}
+
+func marshalFields(fields types.Fields) string {
+	if fields == nil {
+		return "{}"
+	}
+
+	b, _ := json.Marshal(fields)
+	return string(b)
+}
@@ -0,0 +1,64 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}
+
+type UnitOfWork interface {
+	Transaction(context.Context, func(*DataAdapter) error) error
+}
+
+type SQLUnitOfWork struct {
+	db   *sql.DB
+	bind func(DBTX) *DataAdapter
+}
+
+// CreateSQLUnitOfWork runs transactions against db, using bind to build the
+// sockets that take part in each transaction.
+func CreateSQLUnitOfWork(db *sql.DB, bind func(DBTX) *DataAdapter) *SQLUnitOfWork {
+	return &SQLUnitOfWork{
+		db:   db,
+		bind: bind,
+	}
+}
+
+func (u *SQLUnitOfWork) Transaction(ctx context.Context, fn func(*DataAdapter) error) error {
+	return WithTx(ctx, u.db, func(tx DBTX) error {
+		return fn(u.bind(tx))
+	})
+}
+
+// WithTx runs fn in a new transaction when db is a *sql.DB, or in the
+// enclosing transaction when db is already a *sql.Tx.
+func WithTx(ctx context.Context, db DBTX, fn func(DBTX) error) error {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction")
+	}
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction")
+	}
+
+	return nil
+}
@@ -4,16 +4,17 @@ go 1.22.0
 
 require (
 	github.com/go-sql-driver/mysql v1.7.1
+	github.com/gocql/gocql v1.6.0
 	github.com/golang-jwt/jwt/v5 v5.2.0
 	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.52
 	golang.org/x/crypto v0.21.0
+	golang.org/x/net v0.22.0
 )
 
 require (
-	github.com/gocql/gocql v1.6.0 // indirect
 	github.com/golang/snappy v0.0.3 // indirect
 	github.com/hailocab/go-hostpool v0.0.0-20160125115350-e80d13ce29ed // indirect
-	github.com/lib/pq v1.10.9 // indirect
-	golang.org/x/net v0.22.0 // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
 )
@@ -1,13 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"ticketing-api/api"
+	"ticketing-api/assignment"
+	"ticketing-api/auth"
+	"ticketing-api/blob"
 	"ticketing-api/data"
+	"ticketing-api/data/memory"
+	"ticketing-api/data/sqlite"
+	"ticketing-api/notify"
+	"ticketing-api/search"
+	"ticketing-api/sla"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gocql/gocql"
@@ -22,6 +35,193 @@ func main() {
 		}
 	}
 
+	var dataAdapter *data.DataAdapter
+
+	switch os.Getenv("DATA_BACKEND") {
+	case "memory":
+		dataAdapter = createMemoryDataAdapter()
+	case "sqlite":
+		dataAdapter = createSQLiteDataAdapter()
+	case "", "postgres":
+		dataAdapter = createPostgresDataAdapter()
+	default:
+		log.Fatal("unknown DATA_BACKEND: ", os.Getenv("DATA_BACKEND"))
+	}
+
+	timeouts, err := api.ParseTimeouts(os.Getenv("QUERY_TIMEOUT"), os.Getenv("ROUTE_TIMEOUTS"))
+	if err != nil {
+		log.Fatal("failed to parse timeouts:", err)
+	}
+
+	index := search.CreateIndex()
+
+	err = search.Reindex(context.Background(), dataAdapter, index)
+	if err != nil {
+		log.Fatal("failed to build search index:", err)
+	}
+
+	dataAdapter.UseIndexer(index)
+
+	ticketWorkflow := workflow.Default()
+
+	if path := os.Getenv("WORKFLOW_FILE"); path != "" {
+		ticketWorkflow, err = workflow.Load(path)
+		if err != nil {
+			log.Fatal("failed to load workflow:", err)
+		}
+	}
+
+	for name, ttl := range map[string]*time.Duration{"ACCESS_TOKEN_TTL": &auth.AccessTokenTTL, "REFRESH_TOKEN_TTL": &auth.RefreshTokenTTL} {
+		if value := os.Getenv(name); value != "" {
+			*ttl, err = time.ParseDuration(value)
+			if err != nil {
+				log.Fatal("failed to parse ", name, ":", err)
+			}
+		}
+	}
+
+	auth.UseRevocationList(data.CreateRevocationList(dataAdapter.Session))
+
+	keys, err := auth.GenerateKeySet()
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		keys, err = auth.LoadKeys(dir)
+	}
+	if err != nil {
+		log.Fatal("failed to load signing keys:", err)
+	}
+
+	if value := os.Getenv("JWT_ROTATION_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("failed to parse JWT_ROTATION_INTERVAL:", err)
+		}
+
+		go keys.RotateEvery(context.Background(), interval)
+	}
+
+	auth.UseKeys(keys)
+
+	server := api.CreateAPIServer(fmt.Sprintf(":%s", os.Getenv("PORT")), dataAdapter, timeouts, index, ticketWorkflow)
+
+	if name := os.Getenv("ASSIGNMENT_STRATEGY"); name != "" {
+		strategy, err := assignment.Parse(name)
+		if err != nil {
+			log.Fatal("failed to parse ASSIGNMENT_STRATEGY:", err)
+		}
+
+		server.UseAssigner(assignment.CreateAssigner(strategy))
+	}
+
+	blobs, err := createBlobStore()
+	if err != nil {
+		log.Fatal("failed to open blob store:", err)
+	}
+
+	attachmentLimits, err := api.ParseAttachmentLimits(os.Getenv("ATTACHMENT_MAX_SIZE"), os.Getenv("ATTACHMENT_TYPES"))
+	if err != nil {
+		log.Fatal("failed to parse attachment limits:", err)
+	}
+
+	server.UseBlobStore(blobs, attachmentLimits)
+
+	slaInterval := time.Minute
+
+	if value := os.Getenv("SLA_CHECK_INTERVAL"); value != "" {
+		slaInterval, err = time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("failed to parse SLA_CHECK_INTERVAL:", err)
+		}
+	}
+
+	checker := sla.CreateChecker(dataAdapter, func(breach *sla.Breach) {
+		log.Printf("ticket %d of organization %d breached its %s target due %s", breach.Ticket.ID, breach.Ticket.OrgID, breach.Metric, breach.DueAt.Format(time.RFC3339))
+	})
+
+	go checker.CheckEvery(context.Background(), slaInterval)
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		notifier, interval, err := createNotifier(dataAdapter, host)
+		if err != nil {
+			log.Fatal("failed to set up notifications:", err)
+		}
+
+		server.UseNotifier(notifier)
+
+		go notifier.DeliverEvery(context.Background(), interval)
+	}
+
+	log.Fatal(server.Start())
+}
+
+func createBlobStore() (blob.BlobStore, error) {
+	switch os.Getenv("BLOB_BACKEND") {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "attachments"
+		}
+
+		return blob.CreateLocalStore(dir)
+	case "s3":
+		return blob.CreateS3Store(blob.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	}
+
+	return nil, fmt.Errorf("unknown BLOB_BACKEND: %s", os.Getenv("BLOB_BACKEND"))
+}
+
+// createNotifier sends notifications through the SMTP server at host and
+// returns how often to look for due ones.
+func createNotifier(db *data.DataAdapter, host string) (*notify.Notifier, time.Duration, error) {
+	port := 0
+
+	if value := os.Getenv("SMTP_PORT"); value != "" {
+		var err error
+		port, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid SMTP_PORT: %s", value)
+		}
+	}
+
+	channel, err := notify.CreateSMTPChannel(notify.SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	retry := notify.DefaultRetry
+	interval := time.Minute
+
+	for name, value := range map[string]*time.Duration{"NOTIFY_INTERVAL": &interval, "NOTIFY_RETRY_DELAY": &retry.Delay} {
+		if os.Getenv(name) != "" {
+			*value, err = time.ParseDuration(os.Getenv(name))
+			if err != nil {
+				return nil, 0, fmt.Errorf("invalid %s: %s", name, os.Getenv(name))
+			}
+		}
+	}
+
+	if value := os.Getenv("NOTIFY_MAX_ATTEMPTS"); value != "" {
+		retry.MaxAttempts, err = strconv.Atoi(value)
+		if err != nil || retry.MaxAttempts < 1 {
+			return nil, 0, fmt.Errorf("invalid NOTIFY_MAX_ATTEMPTS: %s", value)
+		}
+	}
+
+	return notify.CreateNotifier(db, channel, retry), interval, nil
+}
+
+func createPostgresDataAdapter() *data.DataAdapter {
 	postgres, err := sql.Open("postgres", os.Getenv("POSTGRES_DSN"))
 	if err != nil {
 		log.Fatal("failed to open postgres db connection:", err)
@@ -39,12 +239,131 @@ func main() {
 		log.Fatal("failed to open scylla db connection:", err)
 	}
 
-	dataAdapter := data.CreateDataAdapter(
+	message := data.CreateMessageAdapter(scylla)
+
+	return data.CreateDataAdapter(
 		data.CreateAccountAdapter(postgres),
 		data.CreateTicketAdapter(postgres),
-		data.CreateMessageAdapter(scylla),
+		message,
+		data.CreateHistoryAdapter(postgres),
+		data.CreateSessionAdapter(postgres),
+		data.CreateRoleAdapter(postgres),
+		data.CreateOrganizationAdapter(postgres),
+		data.CreateMembershipAdapter(postgres),
+		data.CreateTeamAdapter(postgres),
+		data.CreateAgentAdapter(postgres),
+		data.CreateAssignmentAdapter(postgres),
+		data.CreateSLAPolicyAdapter(postgres),
+		data.CreateCategoryAdapter(postgres),
+		data.CreateCustomFieldAdapter(postgres),
+		data.CreateAttachmentAdapter(postgres),
+		data.CreateLinkAdapter(postgres),
+		data.CreateNotificationAdapter(postgres),
+		data.CreateSQLUnitOfWork(postgres, func(tx data.DBTX) *data.DataAdapter {
+			return data.CreateDataAdapter(data.CreateAccountAdapter(tx), data.CreateTicketAdapter(tx), message, data.CreateHistoryAdapter(tx), data.CreateSessionAdapter(tx), data.CreateRoleAdapter(tx), data.CreateOrganizationAdapter(tx), data.CreateMembershipAdapter(tx), data.CreateTeamAdapter(tx), data.CreateAgentAdapter(tx), data.CreateAssignmentAdapter(tx), data.CreateSLAPolicyAdapter(tx), data.CreateCategoryAdapter(tx), data.CreateCustomFieldAdapter(tx), data.CreateAttachmentAdapter(tx), data.CreateLinkAdapter(tx), data.CreateNotificationAdapter(tx), nil)
+		}),
 	)
+}
 
-	server := api.CreateAPIServer(fmt.Sprintf(":%s", os.Getenv("PORT")), dataAdapter)
-	log.Fatal(server.Start())
+func createSQLiteDataAdapter() *data.DataAdapter {
+	db, err := sqlite.Open(os.Getenv("SQLITE_PATH"))
+	if err != nil {
+		log.Fatal("failed to open sqlite db:", err)
+	}
+
+	err = sqlite.Migrate(db)
+	if err != nil {
+		log.Fatal("failed to migrate sqlite db:", err)
+	}
+
+	dataAdapter := data.CreateDataAdapter(
+		sqlite.CreateAccountAdapter(db),
+		sqlite.CreateTicketAdapter(db),
+		sqlite.CreateMessageAdapter(db),
+		sqlite.CreateHistoryAdapter(db),
+		sqlite.CreateSessionAdapter(db),
+		sqlite.CreateRoleAdapter(db),
+		sqlite.CreateOrganizationAdapter(db),
+		sqlite.CreateMembershipAdapter(db),
+		sqlite.CreateTeamAdapter(db),
+		sqlite.CreateAgentAdapter(db),
+		sqlite.CreateAssignmentAdapter(db),
+		sqlite.CreateSLAPolicyAdapter(db),
+		sqlite.CreateCategoryAdapter(db),
+		sqlite.CreateCustomFieldAdapter(db),
+		sqlite.CreateAttachmentAdapter(db),
+		sqlite.CreateLinkAdapter(db),
+		sqlite.CreateNotificationAdapter(db),
+		data.CreateSQLUnitOfWork(db, func(tx data.DBTX) *data.DataAdapter {
+			return data.CreateDataAdapter(sqlite.CreateAccountAdapter(tx), sqlite.CreateTicketAdapter(tx), sqlite.CreateMessageAdapter(tx), sqlite.CreateHistoryAdapter(tx), sqlite.CreateSessionAdapter(tx), sqlite.CreateRoleAdapter(tx), sqlite.CreateOrganizationAdapter(tx), sqlite.CreateMembershipAdapter(tx), sqlite.CreateTeamAdapter(tx), sqlite.CreateAgentAdapter(tx), sqlite.CreateAssignmentAdapter(tx), sqlite.CreateSLAPolicyAdapter(tx), sqlite.CreateCategoryAdapter(tx), sqlite.CreateCustomFieldAdapter(tx), sqlite.CreateAttachmentAdapter(tx), sqlite.CreateLinkAdapter(tx), sqlite.CreateNotificationAdapter(tx), nil)
+		}),
+	)
+
+	err = seedAdmin(dataAdapter)
+	if err != nil {
+		log.Fatal("failed to seed admin account:", err)
+	}
+
+	return dataAdapter
+}
+
+func createMemoryDataAdapter() *data.DataAdapter {
+	store := memory.CreateStore()
+
+	dataAdapter := data.CreateDataAdapter(
+		memory.CreateAccountAdapter(store),
+		memory.CreateTicketAdapter(store),
+		memory.CreateMessageAdapter(store),
+		memory.CreateHistoryAdapter(store),
+		memory.CreateSessionAdapter(store),
+		memory.CreateRoleAdapter(store),
+		memory.CreateOrganizationAdapter(store),
+		memory.CreateMembershipAdapter(store),
+		memory.CreateTeamAdapter(store),
+		memory.CreateAgentAdapter(store),
+		memory.CreateAssignmentAdapter(store),
+		memory.CreateSLAPolicyAdapter(store),
+		memory.CreateCategoryAdapter(store),
+		memory.CreateCustomFieldAdapter(store),
+		memory.CreateAttachmentAdapter(store),
+		memory.CreateLinkAdapter(store),
+		memory.CreateNotificationAdapter(store),
+		store,
+	)
+
+	err := seedAdmin(dataAdapter)
+	if err != nil {
+		log.Fatal("failed to seed admin account:", err)
+	}
+
+	return dataAdapter
+}
+
+func seedAdmin(dataAdapter *data.DataAdapter) error {
+	username := os.Getenv("ADMIN_USERNAME")
+	if username == "" {
+		return nil
+	}
+
+	passwordHash, err := auth.CreateHash(os.Getenv("ADMIN_PASSWORD"))
+	if err != nil {
+		return err
+	}
+
+	account, err := types.CreateAccount(username, passwordHash, types.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	// the seeded admin runs the default organization and manages the others
+	account.OrgID = types.DefaultOrganizationID
+	account.SuperAdmin = true
+
+	_, err = dataAdapter.Account.GetByUsername(context.Background(), username)
+	if err == nil {
+		return nil
+	}
+
+	_, err = dataAdapter.Account.Create(context.Background(), account)
+	return err
 }
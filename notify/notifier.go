@@ -0,0 +1,357 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"slices"
+	"strings"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+// summaryLength is how much of a chat message a notification quotes.
+const summaryLength = 200
+
+// Retry is how often and how patiently a notification is sent. Each failed
+// attempt doubles the delay before the next one.
+type Retry struct {
+	MaxAttempts int
+	Delay       time.Duration
+}
+
+var DefaultRetry = Retry{MaxAttempts: 5, Delay: time.Minute}
+
+// Notifier tells the watchers of a ticket about what happens to it. Events
+// are queued in the database when they happen and delivered in the
+// background, each account getting them right away or in digests as its
+// preferences say.
+type Notifier struct {
+	db       *data.DataAdapter
+	channel  Channel
+	retry    Retry
+	presence func(orgID int, ticketID int, accountID int) bool
+	wake     chan struct{}
+}
+
+func CreateNotifier(db *data.DataAdapter, channel Channel, retry Retry) *Notifier {
+	return &Notifier{
+		db:      db,
+		channel: channel,
+		retry:   retry,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// UsePresence tells the notifier who is connected to the chat of a ticket, so
+// that they are not sent the messages they already see.
+func (n *Notifier) UsePresence(presence func(orgID int, ticketID int, accountID int) bool) {
+	n.presence = presence
+}
+
+// TicketChanged queues the notifications for a ticket created by actorID, when
+// before is nil, or updated from before to after. Watchers other than the
+// actor learn of new tickets and status changes, and new assignees of being
+// assigned, each getting one notification for the most relevant event.
+func (n *Notifier) TicketChanged(ctx context.Context, actorID int, before *types.Ticket, after *types.Ticket) error {
+	events := map[int][]types.Event{}
+
+	for _, id := range after.WatcherIDs {
+		if before == nil {
+			events[id] = append(events[id], types.EventTicketCreated)
+		} else if before.Status != after.Status {
+			events[id] = append(events[id], types.EventStatusChanged)
+		}
+	}
+
+	for _, id := range after.AssigneeIDs {
+		if before == nil || !slices.Contains(before.AssigneeIDs, id) {
+			events[id] = append([]types.Event{types.EventTicketAssigned}, events[id]...)
+		}
+	}
+
+	for _, accountID := range sortedKeys(events) {
+		if accountID == actorID {
+			continue
+		}
+
+		prefs, err := n.preferences(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events[accountID] {
+			if !slices.Contains(prefs.Events, event) {
+				continue
+			}
+
+			err = n.queue(ctx, prefs, after, event, ticketSummary(before, after, event))
+			if err != nil {
+				return err
+			}
+
+			break
+		}
+	}
+
+	n.signal()
+
+	return nil
+}
+
+// MessageCreated queues notifications of a chat message for the watchers of
+// its ticket, leaving out its author and whoever is connected to the chat.
+func (n *Notifier) MessageCreated(ctx context.Context, message *types.Message) error {
+	ticket, err := n.db.Ticket.GetByID(data.WithOrganization(ctx, message.OrgID), message.TicketID)
+	if err != nil {
+		return err
+	}
+
+	for _, accountID := range ticket.WatcherIDs {
+		if accountID == message.AuthorID || (n.presence != nil && n.presence(ticket.OrgID, ticket.ID, accountID)) {
+			continue
+		}
+
+		prefs, err := n.preferences(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(prefs.Events, types.EventMessageCreated) {
+			continue
+		}
+
+		err = n.queue(ctx, prefs, ticket, types.EventMessageCreated, fmt.Sprintf("New message on ticket #%d %q: %s", ticket.ID, ticket.Title, truncate(message.Content, summaryLength)))
+		if err != nil {
+			return err
+		}
+	}
+
+	n.signal()
+
+	return nil
+}
+
+// DeliverEvery delivers due notifications every interval, and as soon as new
+// ones are queued, until ctx is done.
+func (n *Notifier) DeliverEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-n.wake:
+		}
+
+		_, err := n.Deliver(ctx, time.Now())
+		if err != nil {
+			log.Println("failed to deliver notifications:", err)
+		}
+	}
+}
+
+// Deliver sends the notifications due as of now and returns how many were
+// sent. Accounts with digests on get all of theirs in one message. Sending is
+// retried by later calls until it succeeds or runs out of attempts, and
+// notifications for accounts without an address are dropped.
+func (n *Notifier) Deliver(ctx context.Context, now time.Time) (int, error) {
+	due, err := n.db.Notification.GetDue(ctx, now, n.retry.MaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	batches := map[int][]*types.Notification{}
+	for _, notification := range due {
+		batches[notification.AccountID] = append(batches[notification.AccountID], notification)
+	}
+
+	sent := 0
+
+	for _, accountID := range sortedKeys(batches) {
+		prefs, err := n.preferences(ctx, accountID)
+		if err != nil {
+			return sent, err
+		}
+
+		to, err := n.address(ctx, prefs)
+		if err != nil {
+			return sent, err
+		}
+
+		if to == "" {
+			for _, notification := range batches[accountID] {
+				err = n.db.Notification.MarkSent(ctx, notification.ID, now)
+				if err != nil {
+					return sent, err
+				}
+			}
+
+			continue
+		}
+
+		batch := [][]*types.Notification{batches[accountID]}
+		if prefs.Digest == types.DigestOff {
+			batch = [][]*types.Notification{}
+			for _, notification := range batches[accountID] {
+				batch = append(batch, []*types.Notification{notification})
+			}
+		}
+
+		for _, notifications := range batch {
+			count, err := n.send(ctx, to, notifications, now)
+			if err != nil {
+				return sent, err
+			}
+
+			sent += count
+		}
+	}
+
+	return sent, nil
+}
+
+// send claims notifications and sends the ones it got as one message,
+// returning how many went out. A failed send is logged and left to be
+// retried once its delay is over.
+func (n *Notifier) send(ctx context.Context, to string, notifications []*types.Notification, now time.Time) (int, error) {
+	claimed := []*types.Notification{}
+
+	for _, notification := range notifications {
+		ok, err := n.db.Notification.Claim(ctx, notification, now.Add(n.retry.Delay<<notification.Attempts))
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			claimed = append(claimed, notification)
+		}
+	}
+
+	if len(claimed) == 0 {
+		return 0, nil
+	}
+
+	err := n.channel.Send(ctx, compose(to, claimed))
+	if err != nil {
+		log.Printf("failed to send %d notifications to account %d: %v", len(claimed), claimed[0].AccountID, err)
+		return 0, nil
+	}
+
+	for _, notification := range claimed {
+		err = n.db.Notification.MarkSent(ctx, notification.ID, now)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(claimed), nil
+}
+
+// queue stores a notification of event for prefs' account, to be delivered
+// right away or at the end of the current digest window.
+func (n *Notifier) queue(ctx context.Context, prefs *types.NotificationPreferences, ticket *types.Ticket, event types.Event, summary string) error {
+	deliverAt := time.Now()
+	if interval := prefs.Digest.Interval(); interval > 0 {
+		deliverAt = deliverAt.Truncate(interval).Add(interval)
+	}
+
+	_, err := n.db.Notification.Create(ctx, types.CreateNotification(ticket.OrgID, prefs.AccountID, ticket.ID, event, summary, deliverAt))
+
+	return err
+}
+
+func (n *Notifier) preferences(ctx context.Context, accountID int) (*types.NotificationPreferences, error) {
+	prefs, err := n.db.Notification.GetPreferences(ctx, accountID)
+
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return types.DefaultNotificationPreferences(accountID), nil
+	}
+
+	return prefs, err
+}
+
+// address returns where notifications of prefs' account go, its username
+// when no email is set and the username is an address, or nothing.
+func (n *Notifier) address(ctx context.Context, prefs *types.NotificationPreferences) (string, error) {
+	if prefs.Email != "" {
+		return prefs.Email, nil
+	}
+
+	account, err := n.db.Account.GetByID(ctx, prefs.AccountID)
+
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	addr, err := mail.ParseAddress(account.Username)
+	if err != nil {
+		return "", nil
+	}
+
+	return addr.Address, nil
+}
+
+// signal wakes DeliverEvery without waiting for it.
+func (n *Notifier) signal() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// compose turns notifications into a message, a digest when there are
+// several.
+func compose(to string, notifications []*types.Notification) *Message {
+	if len(notifications) == 1 {
+		return &Message{To: to, Subject: notifications[0].Summary, Body: notifications[0].Summary + "\n"}
+	}
+
+	body := &strings.Builder{}
+	for _, notification := range notifications {
+		fmt.Fprintf(body, "- %s\n", notification.Summary)
+	}
+
+	return &Message{To: to, Subject: fmt.Sprintf("%d updates on your tickets", len(notifications)), Body: body.String()}
+}
+
+func ticketSummary(before *types.Ticket, after *types.Ticket, event types.Event) string {
+	switch event {
+	case types.EventTicketCreated:
+		return fmt.Sprintf("Ticket #%d %q was created", after.ID, after.Title)
+	case types.EventTicketAssigned:
+		return fmt.Sprintf("You were assigned ticket #%d %q", after.ID, after.Title)
+	}
+
+	return fmt.Sprintf("Ticket #%d %q moved from %s to %s", after.ID, after.Title, before.Status, after.Status)
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+
+	return string(runes[:length]) + "…"
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,16 @@
+package notify
+
+import "context"
+
+// Message is a notification ready to go out to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Channel delivers messages, by email or any other means. An error means the
+// message was not delivered and may be sent again.
+type Channel interface {
+	Send(ctx context.Context, message *Message) error
+}
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout bounds a whole conversation with the server when ctx has no
+// earlier deadline.
+const smtpTimeout = 30 * time.Second
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPChannel sends messages as plain text emails. The connection is upgraded
+// with STARTTLS whenever the server offers it, and credentials are only sent
+// over TLS or to a server on localhost.
+type SMTPChannel struct {
+	config SMTPConfig
+	from   *mail.Address
+}
+
+func CreateSMTPChannel(config SMTPConfig) (*SMTPChannel, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("missing SMTP host")
+	}
+
+	if config.Port == 0 {
+		config.Port = 587
+	}
+
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP sender: %s", config.From)
+	}
+
+	return &SMTPChannel{
+		config: config,
+		from:   from,
+	}, nil
+}
+
+func (c *SMTPChannel) Send(ctx context.Context, message *Message) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %s", message.To)
+	}
+
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+
+	dialer := &net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > smtpTimeout {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		return fmt.Errorf("error greeting %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: c.config.Host})
+		if err != nil {
+			return fmt.Errorf("error starting tls with %s: %w", addr, err)
+		}
+	}
+
+	if c.config.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host))
+		if err != nil {
+			return fmt.Errorf("error authenticating with %s: %w", addr, err)
+		}
+	}
+
+	err = client.Mail(c.from.Address)
+	if err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+
+	err = client.Rcpt(to.Address)
+	if err != nil {
+		return fmt.Errorf("error sending mail to %s: %w", to.Address, err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+
+	_, err = w.Write(c.compose(to, message))
+	if err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// compose renders message as an email. The subject is encoded, so that it can
+// neither break the header block nor hold characters a header cannot.
+func (c *SMTPChannel) compose(to *mail.Address, message *Message) []byte {
+	b := &bytes.Buffer{}
+
+	fmt.Fprintf(b, "From: %s\r\n", c.from.String())
+	fmt.Fprintf(b, "To: %s\r\n", to.String())
+	fmt.Fprintf(b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(b)
+	w.Write([]byte(message.Body))
+	w.Close()
+
+	return b.Bytes()
+}
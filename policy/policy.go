@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"ticketing-api/auth"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+// Engine decides what the account behind a request may do. Roles map to
+// permissions through the role store, so mappings can change at runtime,
+// except for admin which always holds every permission. Super-admins also
+// manage organizations.
+type Engine struct {
+	roles data.RoleSocket
+	teams data.TeamSocket
+}
+
+// Subject is the account behind a request, acting in the organization its
+// token was issued for with the role it holds there, and the teams it works
+// in that organization.
+type Subject struct {
+	ID          int
+	OrgID       int
+	Role        types.Role
+	SuperAdmin  bool
+	Permissions []types.Permission
+	TeamIDs     []int
+}
+
+func CreateEngine(roles data.RoleSocket, teams data.TeamSocket) *Engine {
+	return &Engine{
+		roles: roles,
+		teams: teams,
+	}
+}
+
+func (e *Engine) Subject(r *http.Request) (*Subject, error) {
+	id, err := auth.GetAccountID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := auth.GetRole(r)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := auth.GetOrganizationID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	superAdmin, err := auth.IsSuperAdmin(r)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := e.Permissions(data.WithOrganization(r.Context(), orgID), role)
+	if err != nil {
+		return nil, err
+	}
+
+	if superAdmin {
+		permissions = append(slices.Clone(types.Permissions), types.PermissionOrgManage)
+	}
+
+	teams, err := e.teams.GetByAccountID(data.WithOrganization(r.Context(), orgID), id)
+	if err != nil {
+		return nil, err
+	}
+
+	teamIDs := []int{}
+	for _, team := range teams {
+		teamIDs = append(teamIDs, team.ID)
+	}
+
+	return &Subject{ID: id, OrgID: orgID, Role: role, SuperAdmin: superAdmin, Permissions: permissions, TeamIDs: teamIDs}, nil
+}
+
+// Permissions returns the permissions granted to role in the organization ctx
+// is scoped to. Unknown roles, such as one deleted while accounts still hold
+// it, grant nothing.
+func (e *Engine) Permissions(ctx context.Context, role types.Role) ([]types.Permission, error) {
+	if role == types.RoleAdmin {
+		return types.Permissions, nil
+	}
+
+	definition, err := e.roles.GetByName(ctx, role)
+	if err != nil {
+		if errors.As(err, new(*types.NotFound)) {
+			return []types.Permission{}, nil
+		}
+
+		return nil, err
+	}
+
+	return definition.Permissions, nil
+}
+
+// Authorize is the single question handlers ask: may the account behind r use
+// permission, on resource if one is given.
+func (e *Engine) Authorize(r *http.Request, permission types.Permission, resource any) error {
+	subject, err := e.Subject(r)
+	if err != nil {
+		return err
+	}
+
+	return subject.Can(permission, resource)
+}
+
+func (s *Subject) Can(permission types.Permission, resource any) error {
+	if !s.inOrganization(permission, resource) {
+		return &types.Forbidden{Message: "resource belongs to another organization"}
+	}
+
+	if slices.Contains(s.Permissions, permission) || s.owns(permission, resource) {
+		return nil
+	}
+
+	return &types.Forbidden{Message: fmt.Sprintf("missing permission %s", permission)}
+}
+
+// inOrganization keeps every subject, super-admins included, to the
+// organization it is acting in. Accounts can be read in any organization they
+// are a member of, but only managed by their home organization or themselves.
+func (s *Subject) inOrganization(permission types.Permission, resource any) bool {
+	switch resource := resource.(type) {
+	case *types.Ticket:
+		return resource.OrgID == s.OrgID
+	case *types.Message:
+		return resource.OrgID == s.OrgID
+	case *types.Attachment:
+		return resource.OrgID == s.OrgID
+	case *types.Account:
+		return permission != types.PermissionAccountManage || resource.ID == s.ID || resource.OrgID == s.OrgID
+	}
+
+	return true
+}
+
+// owns grants permissions over an account's own records without the role
+// holding them: tickets it wrote or works on, itself, its chat messages and
+// attachments.
+// Assignees can read and work a ticket but not rewrite or delete it, and
+// members of a team can read the tickets routed to it, like watchers can read
+// the tickets they watch.
+func (s *Subject) owns(permission types.Permission, resource any) bool {
+	switch resource := resource.(type) {
+	case *types.Ticket:
+		author := resource.AuthorID == s.ID
+		assignee := slices.Contains(resource.AssigneeIDs, s.ID)
+		team := resource.TeamID != 0 && slices.Contains(s.TeamIDs, resource.TeamID)
+		watcher := slices.Contains(resource.WatcherIDs, s.ID)
+
+		switch permission {
+		case types.PermissionTicketRead:
+			return author || assignee || team || watcher
+		case types.PermissionTicketUpdate, types.PermissionTicketDelete:
+			return author
+		case types.PermissionTicketStatus:
+			return assignee
+		}
+	case *types.Account:
+		return resource.ID == s.ID && (permission == types.PermissionAccountRead || permission == types.PermissionAccountManage)
+	case *types.Message:
+		return resource.AuthorID == s.ID && permission == types.PermissionMessageModerate
+	case *types.Attachment:
+		return resource.AuthorID == s.ID && permission == types.PermissionMessageModerate
+	}
+
+	return false
+}
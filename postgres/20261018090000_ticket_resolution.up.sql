@@ -0,0 +1 @@
+ALTER TABLE ticket ADD COLUMN resolution TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS history;
+
+DROP FUNCTION IF EXISTS history_immutable();
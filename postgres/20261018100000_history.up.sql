@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS history (
+    id SERIAL PRIMARY KEY,
+    entity VARCHAR(255) NOT NULL,
+    entity_id INT NOT NULL,
+    actor_id INT NOT NULL,
+    field VARCHAR(255) NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS history_entity ON history (entity, entity_id);
+
+CREATE OR REPLACE FUNCTION history_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'history is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER history_immutable BEFORE UPDATE OR DELETE ON history
+    FOR EACH ROW EXECUTE FUNCTION history_immutable();
@@ -0,0 +1,3 @@
+ALTER TABLE ticket DROP COLUMN deleted_at;
+
+ALTER TABLE account DROP COLUMN deleted_at;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS membership;
+
+ALTER TABLE account_session DROP COLUMN org_id;
+ALTER TABLE history DROP COLUMN org_id;
+ALTER TABLE ticket DROP COLUMN org_id;
+ALTER TABLE account DROP COLUMN super_admin;
+ALTER TABLE account DROP COLUMN org_id;
+
+DROP TABLE IF EXISTS organization;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS organization (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- existing accounts and tickets move into a default organization
+INSERT INTO organization (id, name) VALUES (1, 'Default') ON CONFLICT (id) DO NOTHING;
+SELECT setval('organization_id_seq', (SELECT MAX(id) FROM organization));
+
+ALTER TABLE account ADD COLUMN org_id INT NOT NULL DEFAULT 1 REFERENCES organization(id);
+ALTER TABLE account ADD COLUMN super_admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE ticket ADD COLUMN org_id INT NOT NULL DEFAULT 1 REFERENCES organization(id);
+ALTER TABLE history ADD COLUMN org_id INT NOT NULL DEFAULT 1;
+ALTER TABLE account_session ADD COLUMN org_id INT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS account_org_id ON account (org_id);
+CREATE INDEX IF NOT EXISTS ticket_org_id ON ticket (org_id);
+
+CREATE TABLE IF NOT EXISTS membership (
+    org_id INT NOT NULL,
+    account_id INT NOT NULL,
+    role VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, account_id),
+    FOREIGN KEY (org_id) REFERENCES organization(id) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES account(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS membership_account_id ON membership (account_id);
@@ -0,0 +1,6 @@
+UPDATE account_role SET permissions = array_remove(permissions, 'team:manage') WHERE name = 'admin';
+
+ALTER TABLE ticket DROP COLUMN team_id;
+
+DROP TABLE IF EXISTS team_member;
+DROP TABLE IF EXISTS team;
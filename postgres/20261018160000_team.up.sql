@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS team (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES organization(id),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (org_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS team_member (
+    team_id INT NOT NULL,
+    account_id INT NOT NULL,
+    PRIMARY KEY (team_id, account_id),
+    FOREIGN KEY (team_id) REFERENCES team(id) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES account(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS team_member_account_id ON team_member (account_id);
+
+ALTER TABLE ticket ADD COLUMN team_id INT REFERENCES team(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS ticket_team_id ON ticket (team_id, created_at);
+
+UPDATE account_role SET permissions = array_append(permissions, 'team:manage') WHERE name = 'admin' AND NOT 'team:manage' = ANY(permissions);
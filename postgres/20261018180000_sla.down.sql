@@ -0,0 +1,15 @@
+UPDATE account_role SET permissions = array_remove(permissions, 'sla:manage') WHERE name = 'admin';
+
+DROP TABLE IF EXISTS sla_policy;
+
+DROP INDEX IF EXISTS ticket_resolution_due_at;
+DROP INDEX IF EXISTS ticket_first_response_due_at;
+
+ALTER TABLE ticket DROP COLUMN resolution_breached_at;
+ALTER TABLE ticket DROP COLUMN resolved_at;
+ALTER TABLE ticket DROP COLUMN resolution_due_at;
+ALTER TABLE ticket DROP COLUMN first_response_breached_at;
+ALTER TABLE ticket DROP COLUMN first_response_at;
+ALTER TABLE ticket DROP COLUMN first_response_due_at;
+ALTER TABLE ticket DROP COLUMN due_at;
+ALTER TABLE ticket DROP COLUMN priority;
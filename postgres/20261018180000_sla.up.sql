@@ -0,0 +1,28 @@
+ALTER TABLE ticket ADD COLUMN priority VARCHAR(255) NOT NULL DEFAULT 'normal';
+ALTER TABLE ticket ADD COLUMN due_at TIMESTAMP;
+ALTER TABLE ticket ADD COLUMN first_response_due_at TIMESTAMP;
+ALTER TABLE ticket ADD COLUMN first_response_at TIMESTAMP;
+ALTER TABLE ticket ADD COLUMN first_response_breached_at TIMESTAMP;
+ALTER TABLE ticket ADD COLUMN resolution_due_at TIMESTAMP;
+ALTER TABLE ticket ADD COLUMN resolved_at TIMESTAMP;
+ALTER TABLE ticket ADD COLUMN resolution_breached_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS ticket_first_response_due_at ON ticket (first_response_due_at) WHERE first_response_at IS NULL;
+CREATE INDEX IF NOT EXISTS ticket_resolution_due_at ON ticket (resolution_due_at) WHERE resolved_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS sla_policy (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES organization(id),
+    name VARCHAR(255) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    calendar JSONB NOT NULL DEFAULT '{}',
+    targets JSONB NOT NULL DEFAULT '[]',
+    at_risk_minutes INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (org_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS sla_policy_default ON sla_policy (org_id) WHERE is_default;
+
+UPDATE account_role SET permissions = array_append(permissions, 'sla:manage') WHERE name = 'admin' AND NOT 'sla:manage' = ANY(permissions);
@@ -0,0 +1,13 @@
+UPDATE account_role SET permissions = array_remove(permissions, 'field:manage') WHERE name = 'admin';
+UPDATE account_role SET permissions = array_remove(permissions, 'category:manage') WHERE name = 'admin';
+
+DROP INDEX IF EXISTS ticket_fields;
+DROP INDEX IF EXISTS ticket_labels;
+DROP INDEX IF EXISTS ticket_category_id;
+
+ALTER TABLE ticket DROP COLUMN fields;
+ALTER TABLE ticket DROP COLUMN labels;
+ALTER TABLE ticket DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS custom_field;
+DROP TABLE IF EXISTS category;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS category (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES organization(id),
+    parent_id INT REFERENCES category(id),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS category_parent_id ON category (org_id, parent_id);
+
+CREATE TABLE IF NOT EXISTS custom_field (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES organization(id),
+    field_key VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    options JSONB NOT NULL DEFAULT '[]',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (org_id, field_key)
+);
+
+ALTER TABLE ticket ADD COLUMN category_id INT REFERENCES category(id) ON DELETE SET NULL;
+ALTER TABLE ticket ADD COLUMN labels TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE ticket ADD COLUMN fields JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS ticket_category_id ON ticket (category_id);
+CREATE INDEX IF NOT EXISTS ticket_labels ON ticket USING GIN (labels);
+CREATE INDEX IF NOT EXISTS ticket_fields ON ticket USING GIN (fields);
+
+UPDATE account_role SET permissions = array_append(permissions, 'category:manage') WHERE name = 'admin' AND NOT 'category:manage' = ANY(permissions);
+UPDATE account_role SET permissions = array_append(permissions, 'field:manage') WHERE name = 'admin' AND NOT 'field:manage' = ANY(permissions);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS attachment_ticket_id;
+
+DROP TABLE IF EXISTS attachment;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS attachment (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES organization(id),
+    ticket_id INT NOT NULL REFERENCES ticket(id) ON DELETE CASCADE,
+    author_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(1024) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS attachment_ticket_id ON attachment (ticket_id);
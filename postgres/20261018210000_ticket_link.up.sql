@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS ticket_link (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES organization(id),
+    source_id INT NOT NULL REFERENCES ticket(id) ON DELETE CASCADE,
+    target_id INT NOT NULL REFERENCES ticket(id) ON DELETE CASCADE,
+    type VARCHAR(255) NOT NULL,
+    created_by INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_id, target_id, type)
+);
+
+CREATE INDEX IF NOT EXISTS ticket_link_target_id ON ticket_link (target_id);
+
+-- a ticket is a duplicate of one ticket and the child of one ticket at most
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_link_duplicate_of ON ticket_link (source_id) WHERE type = 'duplicate_of';
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_link_parent_of ON ticket_link (target_id) WHERE type = 'parent_of';
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS watcher (
+    id SERIAL PRIMARY KEY,
+    ticket_id INT NOT NULL REFERENCES ticket(id) ON DELETE CASCADE,
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (ticket_id, account_id)
+);
+
+CREATE INDEX IF NOT EXISTS watcher_account_id ON watcher (account_id);
+
+-- authors and assignees of existing tickets watch them, like they would now
+INSERT INTO watcher (ticket_id, account_id) SELECT id, author_id FROM ticket ORDER BY id ON CONFLICT DO NOTHING;
+INSERT INTO watcher (ticket_id, account_id) SELECT ticket_id, account_id FROM assignee ORDER BY id ON CONFLICT DO NOTHING;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS notification (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES organization(id),
+    account_id INT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    ticket_id INT NOT NULL REFERENCES ticket(id) ON DELETE CASCADE,
+    event VARCHAR(255) NOT NULL,
+    summary TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    deliver_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notification_deliver_at ON notification (deliver_at) WHERE sent_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_preference (
+    account_id INT PRIMARY KEY REFERENCES account(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    events TEXT[] NOT NULL DEFAULT '{}',
+    digest VARCHAR(255) NOT NULL DEFAULT 'off',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+-- the roles of the default organization become the shared ones again
+DELETE FROM account_role WHERE org_id <> 1;
+
+ALTER TABLE account_role DROP CONSTRAINT IF EXISTS account_role_pkey;
+ALTER TABLE account_role DROP COLUMN org_id;
+ALTER TABLE account_role ADD PRIMARY KEY (name);
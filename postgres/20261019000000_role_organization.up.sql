@@ -0,0 +1,11 @@
+ALTER TABLE account_role DROP CONSTRAINT IF EXISTS account_role_pkey;
+ALTER TABLE account_role ADD COLUMN org_id INT REFERENCES organization(id) ON DELETE CASCADE;
+
+-- every organization gets its own copy of the roles it used to share
+INSERT INTO account_role (org_id, name, permissions, created_at, updated_at)
+SELECT organization.id, account_role.name, account_role.permissions, account_role.created_at, account_role.updated_at FROM account_role CROSS JOIN organization WHERE account_role.org_id IS NULL;
+
+DELETE FROM account_role WHERE org_id IS NULL;
+
+ALTER TABLE account_role ALTER COLUMN org_id SET NOT NULL;
+ALTER TABLE account_role ADD PRIMARY KEY (org_id, name);
@@ -0,0 +1,14 @@
+-- Messages are partitioned by organization as well as ticket. CQL cannot copy
+-- rows between tables, so existing messages have to be exported from message
+-- and loaded into org_message with org_id 1 (for example with dsbulk) before
+-- message is dropped.
+CREATE TABLE IF NOT EXISTS org_message (
+    id UUID,
+    org_id INT,
+    ticket_id INT,
+    author_id INT,
+    content TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    PRIMARY KEY ((org_id, ticket_id), created_at, id)
+) WITH CLUSTERING ORDER BY (created_at DESC, id ASC);
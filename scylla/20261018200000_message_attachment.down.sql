@@ -0,0 +1 @@
+ALTER TABLE org_message DROP attachment_ids;
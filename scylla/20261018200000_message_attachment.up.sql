@@ -0,0 +1 @@
+ALTER TABLE org_message ADD attachment_ids LIST<INT>;
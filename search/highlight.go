@@ -0,0 +1,90 @@
+package search
+
+import (
+	"html"
+	"slices"
+	"strings"
+)
+
+const snippetRadius = 80
+
+// highlight picks the field with the most matching words and returns a
+// window of its text around the first match, HTML escaped, with every
+// matching word wrapped in <mark>.
+func highlight(fields []field, queryTerms []string) (string, string) {
+	best := fields[0]
+	bestTokens := []token{}
+
+	for _, f := range fields {
+		matches := []token{}
+
+		for _, token := range tokenize(f.text) {
+			if slices.Contains(queryTerms, token.term) {
+				matches = append(matches, token)
+			}
+		}
+
+		if len(matches) > len(bestTokens) {
+			best = f
+			bestTokens = matches
+		}
+	}
+
+	text := best.text
+	if len(bestTokens) == 0 {
+		return best.name, html.EscapeString(truncate(text, 0, min(len(text), 2*snippetRadius)))
+	}
+
+	start := wordStart(text, max(0, bestTokens[0].start-snippetRadius))
+	end := wordEnd(text, min(len(text), bestTokens[0].end+snippetRadius))
+
+	b := strings.Builder{}
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	pos := start
+	for _, token := range bestTokens {
+		if token.start < start || token.end > end {
+			continue
+		}
+
+		b.WriteString(html.EscapeString(text[pos:token.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[token.start:token.end]))
+		b.WriteString("</mark>")
+		pos = token.end
+	}
+
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	return best.name, b.String()
+}
+
+func truncate(text string, start int, end int) string {
+	end = wordEnd(text, end)
+	if end < len(text) {
+		return text[start:end] + "…"
+	}
+
+	return text[start:end]
+}
+
+func wordStart(text string, i int) int {
+	for i > 0 && text[i-1] != ' ' && text[i-1] != '\n' {
+		i--
+	}
+
+	return i
+}
+
+func wordEnd(text string, i int) int {
+	for i < len(text) && text[i] != ' ' && text[i] != '\n' {
+		i++
+	}
+
+	return i
+}
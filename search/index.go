@@ -0,0 +1,269 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"ticketing-api/types"
+)
+
+type Kind string
+
+const (
+	KindTicket  Kind = "ticket"
+	KindMessage Kind = "message"
+)
+
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+type field struct {
+	name   string
+	text   string
+	weight float64
+}
+
+type document struct {
+	key       string
+	kind      Kind
+	ticketID  int
+	messageID string
+	fields    []field
+	terms     map[string]float64
+	length    float64
+}
+
+type Hit struct {
+	Kind      Kind    `json:"type"`
+	TicketID  int     `json:"ticket_id"`
+	MessageID string  `json:"message_id,omitempty"`
+	Field     string  `json:"field"`
+	Score     float64 `json:"score"`
+	Snippet   string  `json:"snippet"`
+}
+
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]*document
+	postings map[string]map[string]bool
+	messages map[int]map[string]bool
+	length   float64
+}
+
+func CreateIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]bool),
+		messages: make(map[int]map[string]bool),
+	}
+}
+
+func (i *Index) IndexTicket(ticket *types.Ticket) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.add(&document{
+		key:      ticketKey(ticket.ID),
+		kind:     KindTicket,
+		ticketID: ticket.ID,
+		fields: []field{
+			{name: "title", text: ticket.Title, weight: 2},
+			{name: "description", text: ticket.Description, weight: 1},
+			{name: "labels", text: strings.Join(ticket.Labels, " "), weight: 1},
+			{name: "fields", text: fieldText(ticket.Fields), weight: 1},
+		},
+	})
+}
+
+// fieldText joins the text of a ticket's custom fields, so that values such
+// as customer ids can be searched for.
+func fieldText(fields types.Fields) string {
+	keys := []string{}
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	texts := []string{}
+
+	for _, key := range keys {
+		switch value := fields[key].(type) {
+		case string:
+			texts = append(texts, value)
+		case []any:
+			for _, item := range value {
+				if text, ok := item.(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+	}
+
+	return strings.Join(texts, " ")
+}
+
+// RemoveTicket drops the ticket along with any messages indexed for it.
+func (i *Index) RemoveTicket(id int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(ticketKey(id))
+
+	for key := range i.messages[id] {
+		i.remove(key)
+	}
+
+	delete(i.messages, id)
+}
+
+func (i *Index) IndexMessage(message *types.Message) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.add(&document{
+		key:       messageKey(message.ID),
+		kind:      KindMessage,
+		ticketID:  message.TicketID,
+		messageID: message.ID,
+		fields: []field{
+			{name: "content", text: message.Content, weight: 1},
+		},
+	})
+}
+
+// RemoveMessage leaves a message indexed under another ticket alone, since a
+// message moved by a merge is indexed under its new ticket before it is
+// deleted from the old one.
+func (i *Index) RemoveMessage(message *types.Message) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if doc, ok := i.docs[messageKey(message.ID)]; ok && doc.ticketID != message.TicketID {
+		return
+	}
+
+	i.remove(messageKey(message.ID))
+}
+
+// Search ranks documents matching any term of query with BM25 and returns
+// every hit, best first, with a highlighted snippet.
+func (i *Index) Search(query string) []*Hit {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	queryTerms := Terms(query)
+	if len(queryTerms) == 0 || len(i.docs) == 0 {
+		return []*Hit{}
+	}
+
+	avgLength := i.length / float64(len(i.docs))
+	scores := map[string]float64{}
+
+	for _, term := range queryTerms {
+		postings := i.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+
+		n := float64(len(postings))
+		idf := math.Log(1 + (float64(len(i.docs))-n+0.5)/(n+0.5))
+
+		for key := range postings {
+			doc := i.docs[key]
+			tf := doc.terms[term]
+			scores[key] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*doc.length/avgLength))
+		}
+	}
+
+	hits := []*Hit{}
+
+	for key, score := range scores {
+		doc := i.docs[key]
+		name, snippet := highlight(doc.fields, queryTerms)
+
+		hits = append(hits, &Hit{
+			Kind:      doc.kind,
+			TicketID:  doc.ticketID,
+			MessageID: doc.messageID,
+			Field:     name,
+			Score:     score,
+			Snippet:   snippet,
+		})
+	}
+
+	sort.Slice(hits, func(x, y int) bool {
+		if hits[x].Score != hits[y].Score {
+			return hits[x].Score > hits[y].Score
+		}
+
+		return hits[x].TicketID < hits[y].TicketID
+	})
+
+	return hits
+}
+
+func (i *Index) add(doc *document) {
+	i.remove(doc.key)
+
+	doc.terms = map[string]float64{}
+
+	for _, f := range doc.fields {
+		for _, token := range tokenize(f.text) {
+			doc.terms[token.term] += f.weight
+			doc.length += f.weight
+		}
+	}
+
+	for term := range doc.terms {
+		if i.postings[term] == nil {
+			i.postings[term] = map[string]bool{}
+		}
+
+		i.postings[term][doc.key] = true
+	}
+
+	if doc.kind == KindMessage {
+		if i.messages[doc.ticketID] == nil {
+			i.messages[doc.ticketID] = map[string]bool{}
+		}
+
+		i.messages[doc.ticketID][doc.key] = true
+	}
+
+	i.docs[doc.key] = doc
+	i.length += doc.length
+}
+
+func (i *Index) remove(key string) {
+	doc, ok := i.docs[key]
+	if !ok {
+		return
+	}
+
+	for term := range doc.terms {
+		delete(i.postings[term], key)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+
+	if doc.kind == KindMessage {
+		delete(i.messages[doc.ticketID], key)
+	}
+
+	delete(i.docs, key)
+	i.length -= doc.length
+}
+
+func ticketKey(id int) string {
+	return "ticket:" + strconv.Itoa(id)
+}
+
+func messageKey(id string) string {
+	return "message:" + id
+}
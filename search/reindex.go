@@ -0,0 +1,29 @@
+package search
+
+import (
+	"context"
+	"ticketing-api/data"
+)
+
+// Reindex loads every ticket and its messages from db into index.
+func Reindex(ctx context.Context, db *data.DataAdapter, index *Index) error {
+	tickets, _, err := db.Ticket.Get(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, ticket := range tickets {
+		index.IndexTicket(ticket)
+
+		messages, err := db.Message.Get(data.WithOrganization(ctx, ticket.OrgID), ticket.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			index.IndexMessage(message)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// tokenize splits text into lower case, lightly stemmed terms, keeping the
+// byte offsets of each word so that matches can be highlighted.
+func tokenize(text string) []token {
+	tokens := []token{}
+	start := -1
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 {
+			tokens = append(tokens, token{term: stem(strings.ToLower(text[start:i])), start: start, end: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, token{term: stem(strings.ToLower(text[start:])), start: start, end: len(text)})
+	}
+
+	return tokens
+}
+
+// Terms returns the distinct terms of text, stemmed the same way the index
+// stems them.
+func Terms(text string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+
+	for _, token := range tokenize(text) {
+		if !seen[token.term] {
+			seen[token.term] = true
+			terms = append(terms, token.term)
+		}
+	}
+
+	return terms
+}
+
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+
+	return word
+}
@@ -0,0 +1,157 @@
+package sla
+
+import (
+	"fmt"
+	"sort"
+	"ticketing-api/types"
+	"time"
+)
+
+// maxDays bounds how far ahead Add looks for business hours, so that a
+// calendar whose hours are all taken by holidays cannot loop forever.
+const maxDays = 3660
+
+// Add returns the time d of business time after start in calendar. Time
+// outside business hours and on holidays does not count, so a target of one
+// hour set at 16:30 on a Friday with hours until 17:00 falls due at 09:30 on
+// Monday.
+func Add(calendar types.Calendar, start time.Time, d time.Duration) time.Time {
+	if len(calendar.Hours) == 0 {
+		return start.Add(d)
+	}
+
+	loc := location(calendar)
+	t := start.In(loc)
+
+	for range maxDays {
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+		if !holiday(calendar, date) {
+			for _, span := range spans(calendar, date) {
+				from, to := span[0], span[1]
+				if !t.Before(to) {
+					continue
+				}
+
+				if from.Before(t) {
+					from = t
+				}
+
+				if available := to.Sub(from); d <= available {
+					return from.Add(d)
+				}
+
+				d -= to.Sub(from)
+			}
+		}
+
+		t = date.AddDate(0, 0, 1)
+	}
+
+	return t
+}
+
+// validateCalendar checks that calendar names a known timezone, that its hours are
+// well formed spans within a day and that its holidays are dates.
+func validateCalendar(calendar types.Calendar) error {
+	if calendar.Timezone != "" {
+		_, err := time.LoadLocation(calendar.Timezone)
+		if err != nil {
+			return fmt.Errorf("unknown timezone %s", calendar.Timezone)
+		}
+	}
+
+	for _, hours := range calendar.Hours {
+		if hours.Weekday < time.Sunday || hours.Weekday > time.Saturday {
+			return fmt.Errorf("weekday must be between 0 and 6, got %d", hours.Weekday)
+		}
+
+		start, err := parseClock(hours.Start)
+		if err != nil {
+			return err
+		}
+
+		end, err := parseClock(hours.End)
+		if err != nil {
+			return err
+		}
+
+		if start >= end {
+			return fmt.Errorf("business hours on %s must end after they start", hours.Weekday)
+		}
+	}
+
+	for _, day := range calendar.Holidays {
+		_, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return fmt.Errorf("holiday %s must be a date like 2006-01-02", day)
+		}
+	}
+
+	return nil
+}
+
+func location(calendar types.Calendar) *time.Location {
+	loc, err := time.LoadLocation(calendar.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+func holiday(calendar types.Calendar, date time.Time) bool {
+	day := date.Format(time.DateOnly)
+
+	for _, holiday := range calendar.Holidays {
+		if holiday == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// spans returns the business hours of date as start and end times, earliest
+// first.
+func spans(calendar types.Calendar, date time.Time) [][2]time.Time {
+	result := [][2]time.Time{}
+
+	for _, hours := range calendar.Hours {
+		if hours.Weekday != date.Weekday() {
+			continue
+		}
+
+		start, _ := parseClock(hours.Start)
+		end, _ := parseClock(hours.End)
+
+		result = append(result, [2]time.Time{clockOn(date, start), clockOn(date, end)})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i][0].Before(result[j][0])
+	})
+
+	return result
+}
+
+// clockOn returns the wall clock time of day on date, which differs from
+// adding it to midnight on days the clocks change.
+func clockOn(date time.Time, clock time.Duration) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, int(clock.Minutes()), 0, 0, date.Location())
+}
+
+// parseClock reads a 15:04 time of day as the duration since midnight,
+// accepting 24:00 as the end of the day.
+func parseClock(value string) (time.Duration, error) {
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("business hours must be given as 15:04, got %q", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
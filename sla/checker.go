@@ -0,0 +1,124 @@
+package sla
+
+import (
+	"context"
+	"log"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+// Breach is a ticket missing the target of one of its SLA metrics.
+type Breach struct {
+	Ticket *types.Ticket
+	Metric types.SLAMetric
+	DueAt  time.Time
+}
+
+// Checker flags tickets of every organization that missed an SLA target and
+// hands each breach to its handler once, however many checkers run.
+type Checker struct {
+	db       *data.DataAdapter
+	onBreach func(*Breach)
+}
+
+func CreateChecker(db *data.DataAdapter, onBreach func(*Breach)) *Checker {
+	return &Checker{
+		db:       db,
+		onBreach: onBreach,
+	}
+}
+
+// Check flags the metrics breached as of now that were not flagged yet,
+// recording each in the ticket's history, and returns the new breaches. The
+// tickets to flag are read a page at a time, in order of id, so that ones
+// flagged meanwhile do not shift the pages still to come.
+func (c *Checker) Check(ctx context.Context, now time.Time) ([]*Breach, error) {
+	filter := &types.TicketFilter{SLA: types.SLABreached, SLANow: now, SLAUnflagged: true}
+	page := &types.Page{Limit: data.MaxPageLimit}
+
+	breaches := []*Breach{}
+
+	for {
+		tickets, info, err := c.db.Ticket.Get(ctx, filter, page)
+		if err != nil {
+			return breaches, err
+		}
+
+		for _, ticket := range tickets {
+			for _, metric := range ticket.SLA.Unflagged(now) {
+				breach, err := c.flag(data.WithOrganization(ctx, ticket.OrgID), ticket, metric, now)
+				if err != nil {
+					return breaches, err
+				}
+
+				if breach != nil {
+					breaches = append(breaches, breach)
+
+					if c.onBreach != nil {
+						c.onBreach(breach)
+					}
+				}
+			}
+		}
+
+		if info.NextCursor == "" {
+			return breaches, nil
+		}
+
+		page.Cursor = info.NextCursor
+	}
+}
+
+// CheckEvery checks for breaches every interval until ctx is done.
+func (c *Checker) CheckEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			_, err := c.Check(ctx, now)
+			if err != nil {
+				log.Println("failed to check sla breaches:", err)
+			}
+		}
+	}
+}
+
+// flag marks metric of ticket breached, returning nil when another check
+// flagged it first.
+func (c *Checker) flag(ctx context.Context, ticket *types.Ticket, metric types.SLAMetric, now time.Time) (*Breach, error) {
+	var breach *Breach
+
+	err := c.db.Transaction(ctx, func(tx *data.DataAdapter) error {
+		marked, err := tx.Ticket.MarkBreached(ctx, ticket.ID, metric, now)
+		if err != nil || !marked {
+			return err
+		}
+
+		breach = &Breach{Ticket: ticket, Metric: metric, DueAt: dueAt(ticket, metric)}
+
+		change := types.CreateChange(types.EntityTicket, ticket.ID, 0, string(metric)+"_breached_at", "", now.UTC().Format(time.RFC3339))
+		change.OrgID = ticket.OrgID
+
+		_, err = tx.History.Create(ctx, change)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return breach, nil
+}
+
+func dueAt(ticket *types.Ticket, metric types.SLAMetric) time.Time {
+	if metric == types.SLAFirstResponse {
+		return *ticket.SLA.FirstResponseDueAt
+	}
+
+	return *ticket.SLA.ResolutionDueAt
+}
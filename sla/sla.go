@@ -0,0 +1,123 @@
+package sla
+
+import (
+	"fmt"
+	"slices"
+	"ticketing-api/types"
+	"time"
+)
+
+// DefaultAtRiskWindow is how long before a target tickets count as at risk
+// when the organization's policy does not say.
+var DefaultAtRiskWindow = time.Hour
+
+// Default returns the default policy among policies, or nil when there is
+// none.
+func Default(policies []*types.SLAPolicy) *types.SLAPolicy {
+	for _, policy := range policies {
+		if policy.Default {
+			return policy
+		}
+	}
+
+	return nil
+}
+
+// AtRiskWindow returns how long before a target tickets under policy count as
+// at risk.
+func AtRiskWindow(policy *types.SLAPolicy) time.Duration {
+	if policy == nil || policy.AtRiskMinutes <= 0 {
+		return DefaultAtRiskWindow
+	}
+
+	return time.Duration(policy.AtRiskMinutes) * time.Minute
+}
+
+// Validate checks that policy has a name, a valid calendar and at most one
+// target per known priority.
+func Validate(policy *types.SLAPolicy) error {
+	if policy.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if policy.AtRiskMinutes < 0 {
+		return fmt.Errorf("at_risk_minutes must not be negative")
+	}
+
+	err := validateCalendar(policy.Calendar)
+	if err != nil {
+		return err
+	}
+
+	seen := []types.Priority{}
+
+	for _, target := range policy.Targets {
+		if !slices.Contains(types.Priorities, target.Priority) {
+			return fmt.Errorf("unknown priority %s", target.Priority)
+		}
+
+		if slices.Contains(seen, target.Priority) {
+			return fmt.Errorf("priority %s has more than one target", target.Priority)
+		}
+
+		if target.FirstResponse < 0 || target.Resolution < 0 {
+			return fmt.Errorf("targets must not be negative")
+		}
+
+		seen = append(seen, target.Priority)
+	}
+
+	return nil
+}
+
+// Apply updates the SLA timers of after, a ticket changed from before or
+// created when before is nil, under policy, which is nil when the
+// organization has none. Due times are set from the ticket's creation when it
+// is created or its priority or due date change, a due date taking the place
+// of the resolution target, and so are not moved by later policy changes.
+// Resolution time is kept while the ticket is out of work.
+func Apply(policy *types.SLAPolicy, before *types.Ticket, after *types.Ticket, now time.Time) {
+	if before == nil || before.Priority != after.Priority || !sameTime(before.DueAt, after.DueAt) {
+		after.SLA.FirstResponseDueAt = nil
+		after.SLA.ResolutionDueAt = nil
+
+		if policy != nil {
+			if target := policy.Target(after.Priority); target != nil {
+				after.SLA.FirstResponseDueAt = due(policy.Calendar, after.CreatedAt, target.FirstResponse)
+				after.SLA.ResolutionDueAt = due(policy.Calendar, after.CreatedAt, target.Resolution)
+			}
+		}
+
+		if after.DueAt != nil {
+			dueAt := *after.DueAt
+			after.SLA.ResolutionDueAt = &dueAt
+		}
+	}
+
+	working := slices.Contains(types.OpenStatuses, after.Status)
+
+	switch {
+	case working:
+		after.SLA.ResolvedAt = nil
+	case after.SLA.ResolvedAt == nil:
+		after.SLA.ResolvedAt = &now
+	}
+}
+
+func due(calendar types.Calendar, start time.Time, minutes int) *time.Time {
+	if minutes <= 0 {
+		return nil
+	}
+
+	dueAt := Add(calendar, start, time.Duration(minutes)*time.Minute)
+
+	return &dueAt
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
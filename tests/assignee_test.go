@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func TestAssigneeAccess(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{Username: "author", Role: types.RoleUser})
+	assignee, _ := db.Account.Create(ctx, &types.Account{Username: "assignee", Role: types.RoleUser})
+	stranger, _ := db.Account.Create(ctx, &types.Account{Username: "stranger", Role: types.RoleUser})
+
+	ticket, _ := db.Ticket.Create(ctx, types.CreateTicket("title", "description", author.ID, types.StatusOpen, []int{assignee.ID}))
+	db.Ticket.Create(ctx, types.CreateTicket("own", "description", assignee.ID, types.StatusOpen, []int{}))
+	db.Ticket.Create(ctx, types.CreateTicket("other", "description", author.ID, types.StatusOpen, []int{}))
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	assigneeToken := createToken(t, db, assignee)
+	strangerToken := createToken(t, db, stranger)
+
+	for _, path := range []string{fmt.Sprintf("/ticket/%d", ticket.ID), fmt.Sprintf("/ticket/%d/chat/message", ticket.ID), fmt.Sprintf("/ticket/%d/history", ticket.ID)} {
+		res := doRequest(t, server, http.MethodGet, path, assigneeToken, nil)
+		if res.Status != http.StatusOK {
+			t.Fatalf("expected assignee to read %s, got %d: %s", path, res.Status, res.Message)
+		}
+
+		res = doRequest(t, server, http.MethodGet, path, strangerToken, nil)
+		if res.Status != http.StatusForbidden {
+			t.Fatalf("expected stranger to be refused %s, got %d: %s", path, res.Status, res.Message)
+		}
+	}
+
+	res := doRequest(t, server, http.MethodPut, fmt.Sprintf("/ticket/%d", ticket.ID), assigneeToken, &api.CreateTicketRequest{Status: types.StatusActive})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected assignee to start work, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/ticket/%d", ticket.ID), assigneeToken, &api.CreateTicketRequest{Status: types.StatusResolved, Resolution: "fixed"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected assignee to resolve, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/ticket/%d", ticket.ID), assigneeToken, &api.CreateTicketRequest{Status: types.StatusClosed})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected closing to be left to the author, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/ticket/%d", ticket.ID), assigneeToken, &api.CreateTicketRequest{Title: "renamed"})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected assignee to be unable to rewrite the ticket, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("/ticket/%d", ticket.ID), assigneeToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected assignee to be unable to delete the ticket, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/me/tickets", assigneeToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 2 {
+		t.Fatalf("expected the assigned and authored tickets, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/me/tickets?status=resolved", assigneeToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 {
+		t.Fatalf("expected filters to apply, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/me/tickets", strangerToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 0 {
+		t.Fatalf("expected no tickets, got %d: %v", res.Status, res.Data)
+	}
+}
@@ -0,0 +1,223 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/assignment"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+)
+
+func testAgents(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+	agent, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "agent", Role: types.RoleEditor})
+
+	_, err := db.Agent.Set(ctx, types.CreateAgent(types.DefaultOrganizationID, agent.ID, types.AvailabilityAway, 2, []string{"billing"}))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Agent.Set(ctx, types.CreateAgent(types.DefaultOrganizationID, agent.ID, types.AvailabilityAvailable, 3, []string{"network"}))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "open", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{agent.ID}})
+	db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "closed", AuthorID: author.ID, Status: types.StatusClosed, AssigneeIDs: []int{agent.ID}})
+
+	found, err := db.Agent.GetByID(ctx, agent.ID)
+	if err != nil || found.Availability != types.AvailabilityAvailable || found.Capacity != 3 || len(found.Skills) != 1 || found.Skills[0] != "network" {
+		t.Fatalf("expected the updated agent, got %v (%v)", found, err)
+	}
+
+	if found.Load != 1 || found.LastAssignedAt != nil {
+		t.Fatalf("expected one open ticket and no assignment yet, got %d and %v", found.Load, found.LastAssignedAt)
+	}
+
+	_, err = db.Assignment.Create(ctx, types.CreateAssignment(types.DefaultOrganizationID, 1, agent.ID, "round_robin", "next in rotation"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Assignment.Create(ctx, types.CreateAssignment(types.DefaultOrganizationID, 1, 0, "round_robin", "no eligible agent"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	agents, err := db.Agent.Get(ctx)
+	if err != nil || len(agents) != 1 || agents[0].LastAssignedAt == nil || time.Since(*agents[0].LastAssignedAt) > time.Minute {
+		t.Fatalf("expected the agent to have been assigned just now, got %v (%v)", agents, err)
+	}
+
+	assignments, err := db.Assignment.Get(ctx, 1)
+	if err != nil || len(assignments) != 2 || assignments[0].AccountID != agent.ID || assignments[1].AccountID != 0 {
+		t.Fatalf("expected both decisions, got %v (%v)", assignments, err)
+	}
+
+	_, err = db.Agent.GetByID(data.WithOrganization(ctx, 2), agent.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected the agent to be hidden from other organizations, got: %v", err)
+	}
+}
+
+func TestMemoryAgents(t *testing.T) {
+	testAgents(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteAgents(t *testing.T) {
+	testAgents(t, createSQLiteDataAdapter(t))
+}
+
+func TestAssignmentStrategies(t *testing.T) {
+	earlier := time.Now().Add(-time.Hour)
+	later := time.Now()
+
+	agents := []*types.Agent{
+		{AccountID: 1, Availability: types.AvailabilityAvailable, Load: 1, LastAssignedAt: &later, Skills: []string{"billing"}},
+		{AccountID: 2, Availability: types.AvailabilityAvailable, Load: 3, LastAssignedAt: &earlier, Skills: []string{"network outage"}},
+		{AccountID: 3, Availability: types.AvailabilityAway},
+		{AccountID: 4, Availability: types.AvailabilityAvailable, Capacity: 2, Load: 2},
+		{AccountID: 5, Availability: types.AvailabilityAvailable},
+	}
+
+	ticket := &types.Ticket{AuthorID: 5, Title: "Network is out", Description: "an outage since this morning"}
+
+	tests := []struct {
+		strategy assignment.Strategy
+		expected int
+		reason   string
+	}{
+		{assignment.RoundRobin{}, 2, "next in rotation"},
+		{assignment.LeastLoaded{}, 1, "fewest open tickets (1)"},
+		{assignment.SkillBased{}, 2, "matched skills network outage"},
+	}
+
+	for _, test := range tests {
+		decision := assignment.CreateAssigner(test.strategy).Decide(ticket, nil, agents)
+		if decision.Agent == nil || decision.Agent.AccountID != test.expected || !strings.Contains(decision.Reason, test.reason) {
+			t.Errorf("expected %s to pick agent %d (%s), got %v: %s", test.strategy.Name(), test.expected, test.reason, decision.Agent, decision.Reason)
+		}
+	}
+
+	decision := assignment.CreateAssigner(assignment.SkillBased{}).Decide(&types.Ticket{Title: "Printer jam"}, nil, agents)
+	if decision.Agent == nil || decision.Agent.AccountID != 5 || !strings.HasPrefix(decision.Reason, "no skill matched") {
+		t.Errorf("expected skill based assignment to fall back to the least loaded agent, got %v: %s", decision.Agent, decision.Reason)
+	}
+
+	decision = assignment.CreateAssigner(assignment.RoundRobin{}).Decide(ticket, &types.Team{Name: "Tier 2", MemberIDs: []int{3, 4}}, agents)
+	if decision.Agent != nil || decision.Reason != "no eligible agent: 1 away, 1 at capacity in team Tier 2" {
+		t.Errorf("expected nobody to be picked, got %v: %s", decision.Agent, decision.Reason)
+	}
+
+	_, err := assignment.Parse("random")
+	if err == nil {
+		t.Errorf("expected an unknown strategy to be rejected")
+	}
+}
+
+func TestAutoAssign(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+	first, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "first", Role: types.RoleEditor})
+	second, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "second", Role: types.RoleEditor})
+
+	server := api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default())
+	server.UseAssigner(assignment.CreateAssigner(assignment.RoundRobin{}))
+
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	adminToken := createToken(t, db, admin)
+	userToken := createToken(t, db, user)
+	firstToken := createToken(t, db, first)
+
+	res := doRequest(t, httpServer, http.MethodPut, fmt.Sprintf("/agent/%d", first.ID), userToken, &api.AgentRequest{})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected users to be unable to manage other agents, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, httpServer, http.MethodPut, fmt.Sprintf("/agent/%d", user.ID), userToken, &api.AgentRequest{})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected users to be unable to enroll themselves as agents, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, httpServer, http.MethodPut, fmt.Sprintf("/agent/%d", user.ID), adminToken, &api.AgentRequest{})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected accounts that cannot work tickets to be ineligible, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, httpServer, http.MethodPut, fmt.Sprintf("/agent/%d", first.ID), firstToken, &api.AgentRequest{Availability: "busy"})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected an unknown availability to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	for _, agent := range []*types.Account{first, second} {
+		res = doRequest(t, httpServer, http.MethodPut, fmt.Sprintf("/agent/%d", agent.ID), adminToken, &api.AgentRequest{Capacity: 5})
+		if res.Status != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+		}
+	}
+
+	assigned := []int{}
+
+	for i := range 3 {
+		res = doRequest(t, httpServer, http.MethodPost, "/ticket", userToken, &api.CreateTicketRequest{Title: fmt.Sprintf("ticket %d", i), Description: "description"})
+		if res.Status != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+		}
+
+		assignees := res.Data.(map[string]any)["assignee_ids"].([]any)
+		if len(assignees) != 1 {
+			t.Fatalf("expected the ticket to be assigned, got %v", res.Data)
+		}
+
+		assigned = append(assigned, int(assignees[0].(float64)))
+	}
+
+	if assigned[0] != first.ID || assigned[1] != second.ID || assigned[2] != first.ID {
+		t.Fatalf("expected tickets to rotate between agents, got %v", assigned)
+	}
+
+	res = doRequest(t, httpServer, http.MethodGet, "/ticket/1/assignments", userToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 || !strings.Contains(res.Data.([]any)[0].(map[string]any)["reason"].(string), "rotation") {
+		t.Fatalf("expected the decision to be recorded, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, httpServer, http.MethodPatch, "/ticket/1", adminToken, map[string]any{"status": types.StatusResolved, "resolution": "fixed"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, httpServer, http.MethodPut, fmt.Sprintf("/agent/%d", first.ID), firstToken, &api.AgentRequest{Availability: types.AvailabilityAway})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected agents to set their own availability, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, httpServer, http.MethodPatch, "/ticket/1", userToken, map[string]any{"status": types.StatusOpen})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	assignees := res.Data.(map[string]any)["assignee_ids"].([]any)
+	if len(assignees) != 1 || int(assignees[0].(float64)) != second.ID {
+		t.Fatalf("expected the reopened ticket to go to the available agent, got %v", res.Data)
+	}
+
+	res = doRequest(t, httpServer, http.MethodPost, "/ticket", adminToken, &api.CreateTicketRequest{Title: "manual", Description: "description", AssigneeIDs: []int{first.ID}})
+	if res.Status != http.StatusOK || len(res.Data.(map[string]any)["assignee_ids"].([]any)) != 1 || int(res.Data.(map[string]any)["assignee_ids"].([]any)[0].(float64)) != first.ID {
+		t.Fatalf("expected explicit assignees to be kept, got %d: %v", res.Status, res.Data)
+	}
+}
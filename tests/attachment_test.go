@@ -0,0 +1,333 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/blob"
+	"ticketing-api/chat"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func testAttachments(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+	ticket, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "crash", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityNormal, Labels: []string{}, Fields: types.Fields{}})
+
+	screenshot, err := db.Attachment.Create(ctx, types.CreateAttachment(ticket.OrgID, ticket.ID, author.ID, "screenshot.png", "image/png", 2048, "1/1/a"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	db.Attachment.Create(ctx, types.CreateAttachment(ticket.OrgID, ticket.ID, author.ID, "crash.log", "text/plain", 100, "1/1/b"))
+
+	attachment, err := db.Attachment.GetByID(ctx, screenshot.ID)
+	if err != nil || attachment.Name != "screenshot.png" || attachment.Size != 2048 || attachment.Key != "1/1/a" || attachment.ContentType != "image/png" {
+		t.Fatalf("expected the attachment to be read back, got %v (%v)", attachment, err)
+	}
+
+	_, err = db.Attachment.GetByID(data.WithOrganization(ctx, 2), screenshot.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected the attachment to be hidden from other organizations, got: %v", err)
+	}
+
+	message, _ := types.CreateMessage("4a8e2f7e-6c1b-4f0e-9a36-6f4f0f3c1d2a", ticket.ID, author.ID, "see attached")
+	message.OrgID = ticket.OrgID
+	message.AttachmentIDs = []int{screenshot.ID}
+	message.CreatedAt = message.CreatedAt.Truncate(time.Millisecond)
+
+	_, err = db.Message.Create(ctx, message)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	messages, _ := db.Message.Get(ctx, ticket.ID)
+	if len(messages) != 1 || len(messages[0].AttachmentIDs) != 1 || messages[0].AttachmentIDs[0] != screenshot.ID {
+		t.Fatalf("expected the message to reference the attachment, got %v", messages)
+	}
+
+	err = db.Attachment.Delete(ctx, screenshot.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	attachments, _ := db.Attachment.Get(ctx, ticket.ID)
+	if len(attachments) != 1 || attachments[0].Name != "crash.log" {
+		t.Fatalf("expected one attachment to be left, got %v", attachments)
+	}
+
+	err = db.Ticket.Purge(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	attachments, _ = db.Attachment.Get(ctx, ticket.ID)
+	if len(attachments) != 0 {
+		t.Fatalf("expected purging the ticket to remove its attachments, got %v", attachments)
+	}
+}
+
+func TestMemoryAttachments(t *testing.T) {
+	testAttachments(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteAttachments(t *testing.T) {
+	testAttachments(t, createSQLiteDataAdapter(t))
+}
+
+// png is the start of a PNG image, enough for content sniffing.
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01")
+
+func TestAttachmentHandlers(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+	outsider, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "outsider", Role: types.RoleUser})
+
+	store, _ := blob.CreateLocalStore(t.TempDir())
+
+	apiServer := api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default())
+	apiServer.UseBlobStore(store, &api.AttachmentLimits{MaxSize: 1024, ContentTypes: []string{"image/png", "text/plain", "application/json"}})
+
+	server := httptest.NewServer(apiServer.Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	authorToken := createToken(t, db, author)
+	outsiderToken := createToken(t, db, outsider)
+
+	ticket := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "crash", Description: "the app crashes on start"})
+	path := fmt.Sprintf("/ticket/%d/attachment", ticket)
+
+	res := uploadFiles(t, server, path, authorToken, &uploadFile{"screen.png", "application/octet-stream", png}, &uploadFile{`C:\logs\crash.json`, "application/json", []byte(`{"error":"boom"}`)})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	uploaded := res.Data.([]any)
+	screenshot := uploaded[0].(map[string]any)
+	logFile := uploaded[1].(map[string]any)
+
+	if screenshot["content_type"] != "image/png" || logFile["content_type"] != "application/json" || logFile["name"] != "crash.json" || logFile["size"] != 16.0 {
+		t.Fatalf("expected the files to be typed and named, got %v", uploaded)
+	}
+
+	rejected := []struct {
+		file   *uploadFile
+		status int
+	}{
+		{&uploadFile{"page.html", "text/plain", []byte("<html><script>alert(1)</script></html>")}, http.StatusUnsupportedMediaType},
+		{&uploadFile{"disguised.png", "image/png", []byte("%PDF-1.7\n")}, http.StatusUnsupportedMediaType},
+		{&uploadFile{"big.log", "text/plain", bytes.Repeat([]byte("a"), 1025)}, http.StatusRequestEntityTooLarge},
+		{&uploadFile{"", "text/plain", []byte("nameless")}, http.StatusBadRequest},
+	}
+
+	for _, test := range rejected {
+		res = uploadFiles(t, server, path, authorToken, test.file)
+		if res.Status != test.status {
+			t.Errorf("expected uploading %q to fail with %d, got %d: %s", test.file.name, test.status, res.Status, res.Message)
+		}
+	}
+
+	res = uploadFiles(t, server, path, authorToken, &uploadFile{"a.log", "text/plain", bytes.Repeat([]byte("a"), 600)}, &uploadFile{"b.log", "text/plain", bytes.Repeat([]byte("b"), 600)})
+	if res.Status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected the limit to cover the whole upload, got %d: %s", res.Status, res.Message)
+	}
+
+	res = uploadFiles(t, server, path, outsiderToken, &uploadFile{"screen.png", "image/png", png})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected outsiders to be unable to upload, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, path, adminToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 2 {
+		t.Fatalf("expected only the first upload to be stored, got %d: %v", res.Status, res.Data)
+	}
+
+	download := fmt.Sprintf("%s/%d", path, int(screenshot["id"].(float64)))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+download, nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	downloadRes, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	content, _ := io.ReadAll(downloadRes.Body)
+	downloadRes.Body.Close()
+
+	if downloadRes.StatusCode != http.StatusOK || !bytes.Equal(content, png) {
+		t.Fatalf("expected the screenshot to be downloaded, got %d: %q", downloadRes.StatusCode, content)
+	}
+
+	headers := map[string]string{
+		"Content-Type":           "image/png",
+		"Content-Disposition":    `attachment; filename=screen.png`,
+		"Content-Length":         fmt.Sprint(len(png)),
+		"X-Content-Type-Options": "nosniff",
+	}
+
+	for name, expected := range headers {
+		if value := downloadRes.Header.Get(name); value != expected {
+			t.Errorf("expected %s to be %q, got %q", name, expected, value)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodGet, download, outsiderToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected outsiders to be unable to download, got %d: %s", res.Status, res.Message)
+	}
+
+	other := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "other", Description: "another problem"})
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d/attachment/%d", other, int(screenshot["id"].(float64))), authorToken, nil)
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected attachments to be reached through their own ticket, got %d: %s", res.Status, res.Message)
+	}
+
+	conn := dialChat(t, server, fmt.Sprintf("/ticket/%d", ticket), authorToken)
+	defer conn.Close()
+
+	wsRes := sendChatAttachments(t, conn, 9999)
+	if wsRes.Status != chat.StatusError {
+		t.Fatalf("expected an unknown attachment to be refused, got %v", wsRes)
+	}
+
+	wsRes = sendChatAttachments(t, conn, int(screenshot["id"].(float64)), int(logFile["id"].(float64)), int(screenshot["id"].(float64)))
+	if wsRes.Status != chat.StatusSuccess || fmt.Sprint(wsRes.Data.(map[string]any)["attachment_ids"]) != fmt.Sprintf("[%v %v]", screenshot["id"], logFile["id"]) {
+		t.Fatalf("expected the message to reference both attachments once, got %v", wsRes)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, download, outsiderToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected outsiders to be unable to delete, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, download, authorToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected the uploader to delete the attachment, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, download, adminToken, nil)
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected the attachment to be gone, got %d: %s", res.Status, res.Message)
+	}
+
+	attachments, _ := db.Attachment.Get(ctx, ticket)
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("/ticket/%d", ticket), adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, fmt.Sprintf("/ticket/%d/purge", ticket), adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	_, err = store.Get(ctx, attachments[0].Key)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected purging to remove the blobs, got: %v", err)
+	}
+
+	if attachments, _ := db.Attachment.Get(ctx, ticket); len(attachments) != 0 {
+		t.Fatalf("expected purging to remove the attachments, got %v", attachments)
+	}
+}
+
+type uploadFile struct {
+	name        string
+	contentType string
+	content     []byte
+}
+
+// uploadFiles posts files as a multipart/form-data body.
+func uploadFiles(t *testing.T, server *httptest.Server, path string, token string, files ...*uploadFile) *api.APIResponse {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, strings.ReplaceAll(file.name, `\`, `\\`)))
+		header.Set("Content-Type", file.contentType)
+
+		part, _ := writer.CreatePart(header)
+		part.Write(file.content)
+	}
+
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+path, body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	defer res.Body.Close()
+
+	apiRes := &api.APIResponse{}
+	json.NewDecoder(res.Body).Decode(apiRes)
+
+	return apiRes
+}
+
+func dialChat(t *testing.T, server *httptest.Server, path string, token string) *websocket.Conn {
+	t.Helper()
+
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+path+"/chat", server.URL)
+	if err != nil {
+		t.Fatalf("failed to configure websocket: %v", err)
+	}
+
+	config.Header.Set("Authorization", "Bearer "+token)
+
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("failed to connect to chat: %v", err)
+	}
+
+	return conn
+}
+
+func sendChatAttachments(t *testing.T, conn *websocket.Conn, ids ...int) *chat.WSMessage {
+	t.Helper()
+
+	body, _ := json.Marshal(&chat.CreateMessageRequest{Content: "see attached", AttachmentIDs: ids})
+
+	err := websocket.JSON.Send(conn, &chat.MessageRequest{Action: chat.ActionCreate, Data: body})
+	if err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+
+	res := &chat.WSMessage{}
+
+	err = websocket.JSON.Receive(conn, res)
+	if err != nil {
+		t.Fatalf("failed to receive message: %v", err)
+	}
+
+	return res
+}
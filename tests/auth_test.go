@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"net/http/httptest"
 	"testing"
 	"ticketing-api/auth"
@@ -9,9 +10,9 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func TestGenerateJWT(t *testing.T) {
+func TestGenerateSessionJWT(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "admin"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -22,9 +23,14 @@ func TestGenerateJWT(t *testing.T) {
 	}
 
 	claims := token.Claims.(jwt.MapClaims)
-	if claims["id"].(float64) != float64(account.ID) || claims["role"].(string) != string(account.Role) {
+	if claims["id"].(float64) != float64(account.ID) || claims["role"].(string) != string(account.Role) || claims["sid"] != "session" {
 		t.Fatalf("token claims do not match expected values")
 	}
+
+	_, err = auth.GenerateSessionJWT(account, "")
+	if err == nil {
+		t.Fatalf("expected tokens to need a session, got none")
+	}
 }
 
 func TestValidateJWTInvalidToken(t *testing.T) {
@@ -36,13 +42,14 @@ func TestValidateJWTInvalidToken(t *testing.T) {
 
 func TestGetRole(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "admin"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
 
 	role, err := auth.GetRole(r)
 	if err != nil {
@@ -57,6 +64,7 @@ func TestGetRole(t *testing.T) {
 func TestGetRoleInvalidToken(t *testing.T) {
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "invalid")
+	r = auth.Authenticate(r)
 
 	_, err := auth.GetRole(r)
 	if err == nil {
@@ -66,13 +74,14 @@ func TestGetRoleInvalidToken(t *testing.T) {
 
 func TestGetAccountID(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "admin"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
 
 	id, err := auth.GetAccountID(r)
 	if err != nil {
@@ -87,6 +96,7 @@ func TestGetAccountID(t *testing.T) {
 func TestGetAccountIDInvalidToken(t *testing.T) {
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "invalid")
+	r = auth.Authenticate(r)
 
 	_, err := auth.GetAccountID(r)
 	if err == nil {
@@ -96,13 +106,14 @@ func TestGetAccountIDInvalidToken(t *testing.T) {
 
 func TestIsAuthenticated(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "admin"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
 
 	err = auth.IsAuthenticated(r)
 	if err != nil {
@@ -113,6 +124,7 @@ func TestIsAuthenticated(t *testing.T) {
 func TestIsAuthenticatedInvalidToken(t *testing.T) {
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "invalid")
+	r = auth.Authenticate(r)
 
 	err := auth.IsAuthenticated(r)
 	if err == nil {
@@ -122,13 +134,14 @@ func TestIsAuthenticatedInvalidToken(t *testing.T) {
 
 func TestIsRole(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "admin"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
 
 	err = auth.IsRole(r, types.RoleAdmin)
 	if err != nil {
@@ -138,13 +151,14 @@ func TestIsRole(t *testing.T) {
 
 func TestIsRoleInvalidRole(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "editor"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
 
 	err = auth.IsRole(r, types.RoleAdmin)
 	if err == nil {
@@ -154,13 +168,14 @@ func TestIsRoleInvalidRole(t *testing.T) {
 
 func TestIsAccountID(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "admin"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
 
 	err = auth.IsAccountID(r, 1)
 	if err != nil {
@@ -170,13 +185,14 @@ func TestIsAccountID(t *testing.T) {
 
 func TestIsAccountIDInvalidID(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "admin"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
 
 	err = auth.IsAccountID(r, 2)
 	if err == nil {
@@ -186,13 +202,14 @@ func TestIsAccountIDInvalidID(t *testing.T) {
 
 func TestIsAccountIDInvalidIDValidRole(t *testing.T) {
 	account := &types.Account{ID: 1, Role: "admin"}
-	tokenString, err := auth.GenerateJWT(account)
+	tokenString, err := auth.GenerateSessionJWT(account, "session")
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
 	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
 
 	err = auth.IsAccountID(r, 2, types.RoleAdmin)
 	if err != nil {
@@ -226,3 +243,63 @@ func TestCompareHashAndPasswordInvalid(t *testing.T) {
 		t.Error("Expected error for incorrect password, got nil")
 	}
 }
+
+type countingRevocationList struct {
+	checks  int
+	revoked bool
+}
+
+func (l *countingRevocationList) IsRevoked(ctx context.Context, sessionID string) bool {
+	l.checks++
+	return l.revoked
+}
+
+func TestAuthenticateChecksTokenOnce(t *testing.T) {
+	revocations := &countingRevocationList{}
+	auth.UseRevocationList(revocations)
+	t.Cleanup(func() { auth.UseRevocationList(nil) })
+
+	tokenString, err := auth.GenerateSessionJWT(&types.Account{ID: 1, OrgID: types.DefaultOrganizationID, Role: types.RoleEditor}, "session")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Add("Authorization", "Bearer "+tokenString)
+
+	_, err = auth.GetAccountID(r)
+	if err == nil {
+		t.Fatalf("expected requests to need authenticating first")
+	}
+
+	r = auth.Authenticate(r)
+
+	// revoking the session mid-request does not change its answers
+	revocations.revoked = true
+
+	id, err := auth.GetAccountID(r)
+	if err != nil || id != 1 {
+		t.Fatalf("expected account 1, got %d (%v)", id, err)
+	}
+
+	role, err := auth.GetRole(r)
+	if err != nil || role != types.RoleEditor {
+		t.Fatalf("expected role editor, got %s (%v)", role, err)
+	}
+
+	orgID, err := auth.GetOrganizationID(r)
+	if err != nil || orgID != types.DefaultOrganizationID {
+		t.Fatalf("expected the default organization, got %d (%v)", orgID, err)
+	}
+
+	if revocations.checks != 1 {
+		t.Fatalf("expected the session to be checked once, got %d checks", revocations.checks)
+	}
+
+	r = auth.Authenticate(r)
+
+	_, err = auth.GetAccountID(r)
+	if _, ok := err.(*types.Unauthorized); !ok {
+		t.Fatalf("expected the revoked session to be refused by the next request, got: %v", err)
+	}
+}
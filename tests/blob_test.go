@@ -0,0 +1,202 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"ticketing-api/blob"
+	"ticketing-api/types"
+)
+
+func testBlobStore(t *testing.T, store blob.BlobStore) {
+	ctx := context.Background()
+	content := []byte("2026-10-18 12:00:00 ERROR connection refused\n")
+
+	err := store.Put(ctx, "1/7/log file.txt", bytes.NewReader(content), int64(len(content)), "text/plain")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	body, err := store.Get(ctx, "1/7/log file.txt")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	read, _ := io.ReadAll(body)
+	body.Close()
+
+	if !bytes.Equal(read, content) {
+		t.Fatalf("expected the blob to be read back, got %q", read)
+	}
+
+	err = store.Delete(ctx, "1/7/log file.txt")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = store.Get(ctx, "1/7/log file.txt")
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected a deleted blob to be missing, got: %v", err)
+	}
+
+	err = store.Delete(ctx, "1/7/log file.txt")
+	if err != nil {
+		t.Fatalf("expected deleting a missing blob to succeed, got: %v", err)
+	}
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := blob.CreateLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	testBlobStore(t, store)
+
+	err = store.Put(context.Background(), "../escaped", strings.NewReader("x"), 1, "text/plain")
+	if err == nil {
+		t.Fatalf("expected a key outside the directory to be rejected")
+	}
+}
+
+func TestS3BlobStore(t *testing.T) {
+	fake := createFakeS3("tickets", "AKIDEXAMPLE", "secret")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := blob.CreateS3Store(blob.S3Config{Endpoint: server.URL, Region: "eu-west-1", Bucket: "tickets", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	testBlobStore(t, store)
+
+	if fake.contentTypes["1/7/log file.txt"] != "text/plain" {
+		t.Fatalf("expected the content type to be stored, got %v", fake.contentTypes)
+	}
+
+	forged, _ := blob.CreateS3Store(blob.S3Config{Endpoint: server.URL, Region: "eu-west-1", Bucket: "tickets", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "guessed"})
+
+	err = forged.Put(context.Background(), "1/7/other.txt", strings.NewReader("x"), 1, "text/plain")
+	if err == nil {
+		t.Fatalf("expected a request signed with the wrong secret to be refused")
+	}
+}
+
+// fakeS3 stands in for an S3 compatible service. It keeps objects of a single
+// bucket in memory and checks the Signature Version 4 of every request.
+type fakeS3 struct {
+	mu           sync.Mutex
+	bucket       string
+	accessKeyID  string
+	secret       string
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func createFakeS3(bucket string, accessKeyID string, secret string) *fakeS3 {
+	return &fakeS3{
+		bucket:       bucket,
+		accessKeyID:  accessKeyID,
+		secret:       secret,
+		objects:      make(map[string][]byte),
+		contentTypes: make(map[string]string),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.verify(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		content, _ := io.ReadAll(r.Body)
+		if int64(len(content)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.objects[key] = content
+		f.contentTypes[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		content, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write(content)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) verify(r *http.Request) bool {
+	credential, rest, ok := strings.Cut(strings.TrimPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="), ", SignedHeaders=")
+	if !ok {
+		return false
+	}
+
+	signedHeaders, signature, ok := strings.Cut(rest, ", Signature=")
+	if !ok {
+		return false
+	}
+
+	scope := strings.SplitN(credential, "/", 2)
+	if len(scope) != 2 || scope[0] != f.accessKeyID {
+		return false
+	}
+
+	names := strings.Split(signedHeaders, ";")
+	if !sort.StringsAreSorted(names) {
+		return false
+	}
+
+	canonicalHeaders := ""
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+
+		canonicalHeaders += name + ":" + value + "\n"
+	}
+
+	canonicalRequest := strings.Join([]string{r.Method, r.URL.EscapedPath(), r.URL.RawQuery, canonicalHeaders, signedHeaders, r.Header.Get("X-Amz-Content-Sha256")}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", r.Header.Get("X-Amz-Date"), scope[1], hex.EncodeToString(hash[:])}, "\n")
+
+	key := []byte("AWS4" + f.secret)
+	for _, part := range strings.Split(scope[1], "/") {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+
+	return hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
@@ -0,0 +1,237 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+)
+
+func testAccountDeleteKeepsTickets(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{Username: "author", Role: types.RoleUser})
+	assignee, _ := db.Account.Create(ctx, &types.Account{Username: "assignee", Role: types.RoleEditor})
+
+	authored, err := db.Ticket.Create(ctx, types.CreateTicket("a", "a", author.ID, types.StatusOpen, []int{assignee.ID}))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	assigned, err := db.Ticket.Create(ctx, types.CreateTicket("b", "b", assignee.ID, types.StatusOpen, []int{author.ID, assignee.ID}))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	err = db.Account.Delete(ctx, author.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Account.GetByID(ctx, author.ID)
+	if err == nil {
+		t.Fatalf("expected deleted account to be hidden")
+	}
+
+	_, err = db.Account.GetByUsername(ctx, "author")
+	if err == nil {
+		t.Fatalf("expected deleted account to be hidden from username lookups")
+	}
+
+	accounts, _, _ := db.Account.Get(ctx, nil)
+	if len(accounts) != 1 {
+		t.Fatalf("expected 1 account, got %d", len(accounts))
+	}
+
+	_, err = db.Ticket.GetByID(ctx, authored.ID)
+	if err != nil {
+		t.Fatalf("expected authored ticket to be kept, got: %v", err)
+	}
+
+	tickets, _, err := db.Ticket.Get(ctx, &types.TicketFilter{AssigneeIDs: []int{author.ID}}, nil)
+	if err != nil || len(tickets) != 1 || tickets[0].ID != assigned.ID {
+		t.Fatalf("expected ticket %d, got %v (%v)", assigned.ID, tickets, err)
+	}
+
+	err = db.Account.Restore(ctx, author.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Account.GetByUsername(ctx, "author")
+	if err != nil {
+		t.Fatalf("expected restored account, got: %v", err)
+	}
+
+	err = db.Account.Restore(ctx, author.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected not found restoring a live account, got: %v", err)
+	}
+}
+
+func testTicketSoftDelete(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{Username: "author", Role: types.RoleUser})
+	ticket, _ := db.Ticket.Create(ctx, types.CreateTicket("a", "a", author.ID, types.StatusOpen, []int{}))
+	other, _ := db.Ticket.Create(ctx, types.CreateTicket("b", "b", author.ID, types.StatusOpen, []int{}))
+	db.Message.Create(ctx, &types.Message{ID: "m", TicketID: ticket.ID, AuthorID: author.ID, CreatedAt: time.Now()})
+
+	err := db.Ticket.Delete(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Ticket.GetByID(ctx, ticket.ID)
+	if err == nil {
+		t.Fatalf("expected deleted ticket to be hidden")
+	}
+
+	deleted, err := db.Ticket.GetDeleted(ctx, ticket.ID)
+	if err != nil || deleted.ID != ticket.ID {
+		t.Fatalf("expected the deleted ticket, got %v (%v)", deleted, err)
+	}
+
+	_, err = db.Ticket.GetDeleted(ctx, other.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected a live ticket not to count as deleted, got: %v", err)
+	}
+
+	tickets, info, _ := db.Ticket.Get(ctx, nil, &types.Page{Total: true})
+	if len(tickets) != 1 || tickets[0].ID != other.ID || *info.Total != 1 {
+		t.Fatalf("expected only ticket %d, got %v", other.ID, tickets)
+	}
+
+	err = db.Ticket.Restore(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Ticket.GetByID(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("expected restored ticket, got: %v", err)
+	}
+
+	err = db.Message.DeleteByTicketID(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	err = db.Ticket.Purge(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	messages, _ := db.Message.Get(ctx, ticket.ID)
+	if len(messages) != 0 {
+		t.Fatalf("expected messages to be purged, got %d", len(messages))
+	}
+
+	err = db.Ticket.Restore(ctx, ticket.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected purged ticket to be gone, got: %v", err)
+	}
+}
+
+func TestMemoryAccountDeleteKeepsTickets(t *testing.T) {
+	testAccountDeleteKeepsTickets(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteAccountDeleteKeepsTickets(t *testing.T) {
+	testAccountDeleteKeepsTickets(t, createSQLiteDataAdapter(t))
+}
+
+func TestMemoryTicketSoftDelete(t *testing.T) {
+	testTicketSoftDelete(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteTicketSoftDelete(t *testing.T) {
+	testTicketSoftDelete(t, createSQLiteDataAdapter(t))
+}
+
+func TestRestoreAndPurgeHandlers(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	index := search.CreateIndex()
+	db.UseIndexer(index)
+
+	admin, _ := db.Account.Create(ctx, &types.Account{Username: "admin", Role: types.RoleAdmin})
+	user, _ := db.Account.Create(ctx, &types.Account{Username: "user", Role: types.RoleUser})
+	ticket, _ := db.Ticket.Create(ctx, types.CreateTicket("printer jammed", "", user.ID, types.StatusOpen, []int{}))
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, index, workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	userToken := createToken(t, db, user)
+	path := fmt.Sprintf("/ticket/%d", ticket.ID)
+
+	res := doRequest(t, server, http.MethodDelete, path, userToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	if len(index.Search("printer")) != 0 {
+		t.Fatalf("expected deleted ticket to leave the search index")
+	}
+
+	res = doRequest(t, server, http.MethodPost, path+"/restore", userToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, path+"/restore", adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	if len(index.Search("printer")) != 1 {
+		t.Fatalf("expected restored ticket to be searchable")
+	}
+
+	message, _ := types.CreateMessage("5f0c3a4e-8b1d-4c2e-9a7f-3d6b2e1c0a9f", ticket.ID, user.ID, "still jammed")
+	db.Message.Create(ctx, message)
+
+	res = doRequest(t, server, http.MethodPost, path+"/purge", adminToken, nil)
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected only deleted tickets to be purged, got %d: %s", res.Status, res.Message)
+	}
+
+	messages, _ := db.Message.Get(ctx, ticket.ID)
+	if len(messages) != 1 {
+		t.Fatalf("expected a refused purge to leave the messages alone, got %v", messages)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, path, adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, path+"/purge", adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, path+"/restore", adminToken, nil)
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, path+"/history", adminToken, nil)
+	fields := []string{}
+	for _, change := range res.Data.([]any) {
+		fields = append(fields, change.(map[string]any)["field"].(string))
+	}
+
+	if fmt.Sprint(fields) != "[deleted deleted deleted purged]" {
+		t.Fatalf("unexpected history: %v", fields)
+	}
+}
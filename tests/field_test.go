@@ -0,0 +1,300 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func testTicketFields(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	billing, err := db.Category.Create(ctx, types.CreateCategory(types.DefaultOrganizationID, 0, "Billing"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	refunds, err := db.Category.Create(ctx, types.CreateCategory(types.DefaultOrganizationID, billing.ID, "Refunds"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	category, err := db.Category.GetByID(ctx, refunds.ID)
+	if err != nil || category.ParentID != billing.ID || category.Name != "Refunds" {
+		t.Fatalf("expected the subcategory to be read back, got %v (%v)", category, err)
+	}
+
+	_, err = db.Category.GetByID(data.WithOrganization(ctx, 2), billing.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected the category to be hidden from other organizations, got: %v", err)
+	}
+
+	plan, err := db.CustomField.Create(ctx, types.CreateCustomField(types.DefaultOrganizationID, "plan", "Plan", types.FieldSelect, []string{"free", "pro"}, true))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.CustomField.Create(ctx, types.CreateCustomField(types.DefaultOrganizationID, "plan", "Other plan", types.FieldText, []string{}, false))
+	if err == nil {
+		t.Fatalf("expected a repeated key to be rejected")
+	}
+
+	plan.Options = append(plan.Options, "enterprise")
+	plan.Required = false
+
+	_, err = db.CustomField.Update(ctx, plan)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	field, err := db.CustomField.GetByID(ctx, plan.ID)
+	if err != nil || len(field.Options) != 3 || field.Required || field.Key != "plan" {
+		t.Fatalf("expected the updated field to be read back, got %v (%v)", field, err)
+	}
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+
+	first, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "refund", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityNormal, CategoryID: refunds.ID, Labels: []string{"vip", "urgent"}, Fields: types.Fields{"plan": "pro", "seats": 10.0, "products": []any{"chat", "mail"}}})
+	db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "invoice", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityNormal, CategoryID: billing.ID, Labels: []string{}, Fields: types.Fields{"plan": "free"}})
+	db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "login", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityNormal, Labels: []string{"vip"}, Fields: types.Fields{}})
+
+	ticket, err := db.Ticket.GetByID(ctx, first.ID)
+	if err != nil || ticket.CategoryID != refunds.ID || len(ticket.Labels) != 2 || ticket.Fields["plan"] != "pro" || ticket.Fields["seats"] != 10.0 || len(ticket.Fields["products"].([]any)) != 2 {
+		t.Fatalf("expected the ticket's labels, category and fields to be read back, got %v (%v)", ticket, err)
+	}
+
+	filters := []struct {
+		name     string
+		filter   *types.TicketFilter
+		expected int
+	}{
+		{"labels", &types.TicketFilter{Labels: []string{"urgent", "missing"}}, 1},
+		{"any label", &types.TicketFilter{Labels: []string{"vip"}}, 2},
+		{"categories", &types.TicketFilter{CategoryIDs: []int{billing.ID, refunds.ID}}, 2},
+		{"select", &types.TicketFilter{Fields: []types.FieldFilter{{Key: "plan", Value: "free"}}}, 1},
+		{"number", &types.TicketFilter{Fields: []types.FieldFilter{{Key: "seats", Value: 10.0}}}, 1},
+		{"multi-select", &types.TicketFilter{Fields: []types.FieldFilter{{Key: "products", Value: "mail"}}}, 1},
+		{"missing option", &types.TicketFilter{Fields: []types.FieldFilter{{Key: "products", Value: "phone"}}}, 0},
+		{"every field", &types.TicketFilter{Fields: []types.FieldFilter{{Key: "plan", Value: "pro"}, {Key: "seats", Value: 10.0}}}, 1},
+	}
+
+	for _, test := range filters {
+		tickets, _, err := db.Ticket.Get(ctx, test.filter, nil)
+		if err != nil || len(tickets) != test.expected {
+			t.Errorf("expected filtering by %s to find %d tickets, got %d (%v)", test.name, test.expected, len(tickets), err)
+		}
+	}
+
+	ticket.Labels = []string{"vip"}
+	ticket.Fields = types.Fields{"plan": "enterprise"}
+
+	_, err = db.Ticket.Update(ctx, ticket)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	ticket, _ = db.Ticket.GetByID(ctx, first.ID)
+	if len(ticket.Labels) != 1 || len(ticket.Fields) != 1 || ticket.Fields["plan"] != "enterprise" {
+		t.Fatalf("expected the labels and fields to be replaced, got %v %v", ticket.Labels, ticket.Fields)
+	}
+
+	err = db.Category.Delete(ctx, refunds.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	ticket, _ = db.Ticket.GetByID(ctx, first.ID)
+	if ticket.CategoryID != 0 {
+		t.Fatalf("expected deleting the category to uncategorize its tickets, got %d", ticket.CategoryID)
+	}
+
+	err = db.CustomField.Delete(ctx, plan.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
+
+func TestMemoryTicketFields(t *testing.T) {
+	testTicketFields(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteTicketFields(t *testing.T) {
+	testTicketFields(t, createSQLiteDataAdapter(t))
+}
+
+func TestTicketFieldHandlers(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+
+	index := search.CreateIndex()
+	db.UseIndexer(index)
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, index, workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	userToken := createToken(t, db, user)
+
+	res := doRequest(t, server, http.MethodPost, "/category", userToken, &api.CategoryRequest{Name: "Billing"})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected users to be unable to manage categories, got %d: %s", res.Status, res.Message)
+	}
+
+	billing := createEntity(t, server, "/category", adminToken, &api.CategoryRequest{Name: "Billing"})
+	refunds := createEntity(t, server, "/category", adminToken, &api.CategoryRequest{Name: "Refunds", ParentID: billing})
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/category/%d", billing), adminToken, &api.CategoryRequest{Name: "Billing", ParentID: refunds})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected moving a category under its subcategory to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/category", adminToken, &api.CategoryRequest{Name: "refunds", ParentID: billing})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected a repeated sibling name to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	invalid := []*api.CustomFieldRequest{
+		{Key: "Customer ID", Name: "Customer", Type: types.FieldText},
+		{Key: "plan", Name: "Plan", Type: types.FieldSelect},
+		{Key: "seats", Name: "Seats", Type: types.FieldNumber, Options: []string{"1"}},
+		{Key: "color", Name: "Color", Type: "color"},
+	}
+
+	for _, field := range invalid {
+		res = doRequest(t, server, http.MethodPost, "/field", adminToken, field)
+		if res.Status != http.StatusBadRequest {
+			t.Errorf("expected %s to be rejected, got %d: %s", field.Key, res.Status, res.Message)
+		}
+	}
+
+	createEntity(t, server, "/field", adminToken, &api.CustomFieldRequest{Key: "customer_id", Name: "Customer", Type: types.FieldText, Required: true})
+	createEntity(t, server, "/field", adminToken, &api.CustomFieldRequest{Key: "seats", Name: "Seats", Type: types.FieldNumber})
+	createEntity(t, server, "/field", adminToken, &api.CustomFieldRequest{Key: "renewal", Name: "Renewal", Type: types.FieldDate})
+	createEntity(t, server, "/field", adminToken, &api.CustomFieldRequest{Key: "plan", Name: "Plan", Type: types.FieldSelect, Options: []string{"free", "pro"}})
+	products := createEntity(t, server, "/field", adminToken, &api.CustomFieldRequest{Key: "products", Name: "Products", Type: types.FieldMultiSelect, Options: []string{"chat", "mail", "phone"}})
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/field/%d", products), adminToken, &api.CustomFieldRequest{Key: "product", Name: "Products", Options: []string{"chat"}})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected the key to be fixed, got %d: %s", res.Status, res.Message)
+	}
+
+	rejected := []types.Fields{
+		{},
+		{"customer_id": "ACME", "seats": "ten"},
+		{"customer_id": "ACME", "renewal": "next year"},
+		{"customer_id": "ACME", "plan": "gold"},
+		{"customer_id": "ACME", "products": "chat"},
+		{"customer_id": "ACME", "region": "eu"},
+	}
+
+	for _, fields := range rejected {
+		res = doRequest(t, server, http.MethodPost, "/ticket", userToken, &api.CreateTicketRequest{Title: "refund", Description: "charged twice", Fields: fields})
+		if res.Status != http.StatusBadRequest {
+			t.Errorf("expected fields %v to be rejected, got %d: %s", fields, res.Status, res.Message)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", userToken, &api.CreateTicketRequest{Title: "refund", Description: "charged twice", CategoryID: refunds, Labels: []string{" vip", "billing", "vip"}, Fields: types.Fields{"customer_id": "ACME", "seats": 10, "renewal": "2027-01-31", "plan": "pro", "products": []string{"phone", "chat"}}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	ticket := res.Data.(map[string]any)
+	path := fmt.Sprintf("/ticket/%d", int(ticket["id"].(float64)))
+
+	if fmt.Sprint(ticket["labels"]) != "[billing vip]" || fmt.Sprint(ticket["fields"].(map[string]any)["products"]) != "[chat phone]" {
+		t.Fatalf("expected labels and options in canonical order, got %v %v", ticket["labels"], ticket["fields"])
+	}
+
+	createEntity(t, server, "/ticket", userToken, &api.CreateTicketRequest{Title: "invoice", Description: "missing", CategoryID: billing, Fields: types.Fields{"customer_id": "Initech", "plan": "free"}})
+
+	filters := map[string]int{
+		"category=" + fmt.Sprint(billing): 2,
+		"category=" + fmt.Sprint(refunds): 1,
+		"label=vip":                       1,
+		"field.customer_id=ACME":          1,
+		"field.seats=10":                  1,
+		"field.renewal=2027-01-31":        1,
+		"field.products=chat":             1,
+		"field.plan=free&label=vip":       0,
+	}
+
+	for query, expected := range filters {
+		res = doRequest(t, server, http.MethodGet, "/ticket?"+query, adminToken, nil)
+		if res.Status != http.StatusOK || len(res.Data.([]any)) != expected {
+			t.Errorf("expected ?%s to find %d tickets, got %d: %v", query, expected, res.Status, res.Data)
+		}
+	}
+
+	for _, query := range []string{"field.region=eu", "field.seats=ten", "field.plan=gold", "category=first"} {
+		res = doRequest(t, server, http.MethodGet, "/ticket?"+query, adminToken, nil)
+		if res.Status != http.StatusBadRequest {
+			t.Errorf("expected ?%s to be rejected, got %d: %s", query, res.Status, res.Message)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodPatch, path, adminToken, map[string]any{"labels": []string{"vip"}, "fields": map[string]any{"seats": 12, "renewal": nil}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	fields := res.Data.(map[string]any)["fields"].(map[string]any)
+	if fields["seats"] != 12.0 || fields["renewal"] != nil || fields["customer_id"] != "ACME" {
+		t.Fatalf("expected the patch to merge into the fields, got %v", fields)
+	}
+
+	res = doRequest(t, server, http.MethodPut, path, adminToken, &api.CreateTicketRequest{Fields: types.Fields{"plan": "free"}})
+	if res.Status != http.StatusOK || res.Data.(map[string]any)["fields"].(map[string]any)["plan"] != "free" {
+		t.Fatalf("expected the update to change the plan, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, path+"/history", adminToken, nil)
+	recorded := map[string]bool{}
+	for _, change := range res.Data.([]any) {
+		recorded[change.(map[string]any)["field"].(string)] = true
+	}
+
+	for _, field := range []string{"category_id", "labels", "fields.customer_id", "fields.seats", "fields.renewal", "fields.plan"} {
+		if !recorded[field] {
+			t.Errorf("expected a change of %s to be recorded, got %v", field, recorded)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/search?q="+url.QueryEscape("acme"), adminToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 {
+		t.Fatalf("expected the customer id to be searchable, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("/category/%d", billing), adminToken, nil)
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected a category with subcategories to be kept, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("/category/%d", refunds), adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+}
+
+// createEntity posts body to path and returns the id of what was created.
+func createEntity(t *testing.T, server *httptest.Server, path string, token string, body any) int {
+	t.Helper()
+
+	res := doRequest(t, server, http.MethodPost, path, token, body)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected creating at %s to succeed, got %d: %s", path, res.Status, res.Message)
+	}
+
+	return int(res.Data.(map[string]any)["id"].(float64))
+}
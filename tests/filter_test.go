@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"ticketing-api/data"
+	"ticketing-api/types"
+	"time"
+)
+
+func testTicketFilter(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{Username: "author", Role: types.RoleUser})
+	agent, _ := db.Account.Create(ctx, &types.Account{Username: "agent", Role: types.RoleEditor})
+
+	db.Ticket.Create(ctx, types.CreateTicket("Printer on fire", "", author.ID, types.StatusOpen, []int{agent.ID}))
+	vpn, _ := db.Ticket.Create(ctx, types.CreateTicket("VPN down", "", author.ID, types.StatusPending, []int{}))
+	db.Ticket.Create(ctx, types.CreateTicket("Printer jammed", "", agent.ID, types.StatusClosed, []int{}))
+
+	// edits are found past the latest time any ticket was saved at
+	saved, _, _ := db.Ticket.Get(ctx, nil, nil)
+
+	edited := saved[0].UpdatedAt
+	for _, ticket := range saved {
+		if ticket.UpdatedAt.After(edited) {
+			edited = ticket.UpdatedAt
+		}
+	}
+
+	edited = edited.Add(time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	vpn, _ = db.Ticket.GetByID(ctx, vpn.ID)
+	vpn.Status = types.StatusActive
+
+	vpn, err := db.Ticket.Update(ctx, vpn)
+	if err != nil || vpn.UpdatedAt.Before(edited) {
+		t.Fatalf("expected editing to bump updated_at past %v, got %v (%v)", edited, vpn, err)
+	}
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	tests := []struct {
+		name   string
+		filter *types.TicketFilter
+		count  int
+	}{
+		{"no filter", nil, 3},
+		{"status", &types.TicketFilter{Statuses: []types.Status{types.StatusOpen, types.StatusActive}}, 2},
+		{"author", &types.TicketFilter{AuthorIDs: []int{agent.ID}}, 1},
+		{"assignee", &types.TicketFilter{AssigneeIDs: []int{agent.ID}}, 1},
+		{"unassigned", &types.TicketFilter{Unassigned: true}, 2},
+		{"assignee or unassigned", &types.TicketFilter{AssigneeIDs: []int{agent.ID}, Unassigned: true}, 3},
+		{"participant", &types.TicketFilter{ParticipantID: agent.ID}, 2},
+		{"participant and status", &types.TicketFilter{ParticipantID: agent.ID, Statuses: []types.Status{types.StatusClosed}}, 1},
+		{"title", &types.TicketFilter{Title: "printer"}, 2},
+		{"created range", &types.TicketFilter{CreatedAfter: &yesterday, CreatedBefore: &tomorrow}, 3},
+		{"created after", &types.TicketFilter{CreatedAfter: &tomorrow}, 0},
+		{"updated after an edit", &types.TicketFilter{UpdatedAfter: &edited}, 1},
+		{"updated before an edit", &types.TicketFilter{UpdatedBefore: &edited}, 2},
+		{"combined", &types.TicketFilter{Title: "printer", AuthorIDs: []int{author.ID}, Statuses: []types.Status{types.StatusOpen}}, 1},
+	}
+
+	for _, test := range tests {
+		tickets, _, err := db.Ticket.Get(ctx, test.filter, nil)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", test.name, err)
+		}
+
+		if len(tickets) != test.count {
+			t.Fatalf("%s: expected %d tickets, got %d", test.name, test.count, len(tickets))
+		}
+	}
+}
+
+func TestMemoryTicketFilter(t *testing.T) {
+	testTicketFilter(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteTicketFilter(t *testing.T) {
+	testTicketFilter(t, createSQLiteDataAdapter(t))
+}
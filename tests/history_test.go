@@ -0,0 +1,134 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func testHistory(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	before := types.CreateTicket("title", "description", 1, types.StatusOpen, []int{})
+	before.ID = 7
+	after := *before
+	after.Status = types.StatusActive
+	after.AssigneeIDs = []int{2, 3}
+
+	for _, change := range types.TicketChanges(1, before, &after) {
+		_, err := db.History.Create(ctx, change)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	db.History.Create(ctx, types.CreateChange(types.EntityAccount, 7, 1, "role", "user", "editor"))
+
+	changes, err := db.History.Get(ctx, types.EntityTicket, 7)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+
+	if changes[0].Field != "status" || changes[0].OldValue != "open" || changes[0].NewValue != "active" || changes[0].ActorID != 1 {
+		t.Fatalf("unexpected status change: %+v", changes[0])
+	}
+
+	if changes[1].Field != "assignee_ids" || changes[1].OldValue != "" || changes[1].NewValue != "[2,3]" {
+		t.Fatalf("unexpected assignee change: %+v", changes[1])
+	}
+}
+
+func TestMemoryHistory(t *testing.T) {
+	testHistory(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteHistory(t *testing.T) {
+	testHistory(t, createSQLiteDataAdapter(t))
+}
+
+func TestHistoryHandlers(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+	other, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "other", Role: types.RoleUser})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	userToken := createToken(t, db, user)
+	otherToken := createToken(t, db, other)
+
+	res := doRequest(t, server, http.MethodPost, "/ticket", adminToken, &api.CreateTicketRequest{Title: "title", Description: "description", AuthorID: user.ID})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	path := fmt.Sprintf("/ticket/%d", int(res.Data.(map[string]any)["id"].(float64)))
+
+	res = doRequest(t, server, http.MethodPut, path, adminToken, &api.CreateTicketRequest{Title: "new title", Status: types.StatusActive})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, path+"/history", userToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	fields := []string{}
+	for _, change := range res.Data.([]any) {
+		fields = append(fields, change.(map[string]any)["field"].(string))
+	}
+
+	if fmt.Sprint(fields) != "[title description status author_id title status]" {
+		t.Fatalf("unexpected history: %v", fields)
+	}
+
+	res = doRequest(t, server, http.MethodGet, path+"/history", otherToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d: %s", res.Status, res.Message)
+	}
+
+	accountPath := fmt.Sprintf("/account/%d", user.ID)
+
+	res = doRequest(t, server, http.MethodPut, accountPath, adminToken, &api.UpdateAccountRequest{Role: types.RoleEditor, Password: "secret"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, accountPath+"/history", adminToken, nil)
+	changes := res.Data.([]any)
+	if len(changes) != 2 || int(changes[0].(map[string]any)["actor_id"].(float64)) != admin.ID || changes[1].(map[string]any)["new_value"] != "editor" {
+		t.Fatalf("expected a password and a role change by admin, got %v", changes)
+	}
+
+	password := changes[0].(map[string]any)
+	if password["field"] != "password" || password["old_value"] != "[redacted]" || password["new_value"] != "[redacted]" {
+		t.Fatalf("expected the password change to be redacted, got %v", password)
+	}
+
+	res = doPatch(t, server, accountPath, adminToken, "application/merge-patch+json", `{"password": "another secret"}`)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, accountPath+"/history", adminToken, nil)
+	changes = res.Data.([]any)
+	if len(changes) != 3 || changes[2].(map[string]any)["field"] != "password" || changes[2].(map[string]any)["new_value"] != "[redacted]" {
+		t.Fatalf("expected patching the password to be recorded, got %v", changes)
+	}
+}
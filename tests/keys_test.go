@@ -0,0 +1,302 @@
+package test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/auth"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeKey(t *testing.T, path string, block *pem.Block) {
+	t.Helper()
+
+	err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+}
+
+func useKeys(t *testing.T, keys *auth.KeySet) {
+	auth.UseKeys(keys)
+	t.Cleanup(func() { auth.UseKeys(nil) })
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writeKey(t, filepath.Join(dir, "a.pem"), &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	b, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	writeKey(t, filepath.Join(dir, "b.pem"), &pem.Block{Type: "PRIVATE KEY", Bytes: b})
+
+	keys, err := auth.LoadKeys(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	useKeys(t, keys)
+
+	tokenString, err := auth.GenerateSessionJWT(&types.Account{ID: 1, Role: types.RoleUser}, "session")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	token, err := auth.ValidateJWT(tokenString)
+	if err != nil || !token.Valid {
+		t.Fatalf("expected valid token, got: %v", err)
+	}
+
+	if token.Header["kid"] != "b" || token.Header["alg"] != "EdDSA" {
+		t.Fatalf("expected token signed by b with EdDSA, got %v", token.Header)
+	}
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kty != "RSA" || jwks.Keys[0].Alg != "RS256" || jwks.Keys[1].Kty != "OKP" {
+		t.Fatalf("expected RSA and OKP keys, got %+v", jwks.Keys)
+	}
+}
+
+func TestLoadKeysRejectsInvalidPEM(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "bad.pem"), []byte("not a key"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	_, err = auth.LoadKeys(dir)
+	if err == nil {
+		t.Fatalf("expected error for invalid key, got none")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	keys, err := auth.LoadKeys(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	useKeys(t, keys)
+
+	old, _ := auth.GenerateSessionJWT(&types.Account{ID: 1, Role: types.RoleUser}, "session")
+
+	err = keys.Rotate()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 key files, got %d", len(files))
+	}
+
+	current, _ := auth.GenerateSessionJWT(&types.Account{ID: 1, Role: types.RoleUser}, "session")
+
+	oldToken, err := auth.ValidateJWT(old)
+	if err != nil {
+		t.Fatalf("expected token from retired key to verify, got: %v", err)
+	}
+
+	currentToken, err := auth.ValidateJWT(current)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if oldToken.Header["kid"] == currentToken.Header["kid"] {
+		t.Fatalf("expected rotation to change the kid")
+	}
+
+	keys.Prune()
+
+	if len(keys.JWKS().Keys) != 2 {
+		t.Fatalf("expected retired key to be kept until its tokens expire")
+	}
+
+	ttl := auth.AccessTokenTTL
+	auth.AccessTokenTTL = time.Millisecond
+	defer func() { auth.AccessTokenTTL = ttl }()
+
+	time.Sleep(2 * time.Millisecond)
+	keys.Prune()
+
+	if len(keys.JWKS().Keys) != 1 {
+		t.Fatalf("expected retired key to be pruned")
+	}
+
+	files, _ = filepath.Glob(filepath.Join(dir, "*.pem"))
+	if len(files) != 1 {
+		t.Fatalf("expected retired key file to be removed, got %d files", len(files))
+	}
+}
+
+func TestReloadedKeysStayRetired(t *testing.T) {
+	dir := t.TempDir()
+
+	keys, err := auth.LoadKeys(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	useKeys(t, keys)
+
+	old, _ := auth.GenerateSessionJWT(&types.Account{ID: 1, Role: types.RoleUser}, "session")
+
+	err = keys.Rotate()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 key files, got %d", len(files))
+	}
+
+	oldToken, _ := auth.ValidateJWT(old)
+	oldFile := filepath.Join(dir, oldToken.Header["kid"].(string)+".pem")
+
+	// pretend the rotation happened long enough ago for the old tokens to expire
+	for _, file := range files {
+		written := time.Now().Add(-time.Hour)
+		if file == oldFile {
+			written = written.Add(-time.Hour)
+		}
+
+		err = os.Chtimes(file, written, written)
+		if err != nil {
+			t.Fatalf("failed to change key times: %v", err)
+		}
+	}
+
+	keys, err = auth.LoadKeys(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	useKeys(t, keys)
+
+	current, _ := auth.GenerateSessionJWT(&types.Account{ID: 1, Role: types.RoleUser}, "session")
+
+	currentToken, err := auth.ValidateJWT(current)
+	if err != nil || currentToken.Header["kid"] == oldToken.Header["kid"] {
+		t.Fatalf("expected the newest key to sign after reloading, got %v (%v)", currentToken, err)
+	}
+
+	keys.Prune()
+
+	if len(keys.JWKS().Keys) != 1 {
+		t.Fatalf("expected the reloaded retired key to be pruned, got %+v", keys.JWKS().Keys)
+	}
+
+	remaining, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if len(remaining) != 1 || remaining[0] == oldFile {
+		t.Fatalf("expected the retired key file to be removed, got %v", remaining)
+	}
+
+	_, err = auth.ValidateJWT(old)
+	if err == nil {
+		t.Fatalf("expected tokens from the pruned key to be rejected")
+	}
+}
+
+func TestValidateJWTRejectsUnknownKey(t *testing.T) {
+	other, _ := auth.GenerateKeySet()
+	useKeys(t, other)
+
+	tokenString, _ := auth.GenerateSessionJWT(&types.Account{ID: 1, Role: types.RoleAdmin}, "session")
+
+	keys, _ := auth.GenerateKeySet()
+	auth.UseKeys(keys)
+
+	_, err := auth.ValidateJWT(tokenString)
+	if err == nil {
+		t.Fatalf("expected error for unknown key, got none")
+	}
+
+	hmac, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": 1, "role": "admin"}).SignedString([]byte(""))
+
+	_, err = auth.ValidateJWT(hmac)
+	if err == nil {
+		t.Fatalf("expected error for HMAC token, got none")
+	}
+}
+
+func TestAuthenticateRejectsTokensWithoutSession(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	b, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	writeKey(t, filepath.Join(dir, "a.pem"), &pem.Block{Type: "PRIVATE KEY", Bytes: b})
+
+	keys, err := auth.LoadKeys(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	useKeys(t, keys)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{"exp": time.Now().Add(time.Minute).Unix(), "id": 1, "org": types.DefaultOrganizationID, "role": "admin"})
+	token.Header["kid"] = keys.JWKS().Keys[0].Kid
+
+	tokenString, _ := token.SignedString(edKey)
+
+	_, err = auth.ValidateJWT(tokenString)
+	if err != nil {
+		t.Fatalf("expected the token to be validly signed, got: %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Add("Authorization", "Bearer "+tokenString)
+	r = auth.Authenticate(r)
+
+	_, err = auth.GetAccountID(r)
+	if _, ok := err.(*types.Unauthorized); !ok {
+		t.Fatalf("expected a token without a session to be refused, got: %v", err)
+	}
+}
+
+func TestJWKSEndpoint(t *testing.T) {
+	keys, _ := auth.GenerateKeySet()
+	useKeys(t, keys)
+
+	server := httptest.NewServer(api.CreateAPIServer("", createMemoryDataAdapter(), nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	tokenString, _ := auth.GenerateSessionJWT(&types.Account{ID: 1, Role: types.RoleUser}, "session")
+	token, _ := auth.ValidateJWT(tokenString)
+
+	res, err := http.Get(server.URL + "/.well-known/jwks.json")
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+
+	jwks := &auth.JWKS{}
+
+	err = json.NewDecoder(res.Body).Decode(jwks)
+	if err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK || len(jwks.Keys) != 1 || jwks.Keys[0].Kid != token.Header["kid"] || jwks.Keys[0].Crv != "Ed25519" {
+		t.Fatalf("expected the signing key in the JWKS, got %d: %+v", res.StatusCode, jwks.Keys)
+	}
+}
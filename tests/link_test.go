@@ -0,0 +1,400 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/blob"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+)
+
+func testTicketLinks(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+
+	tickets := []*types.Ticket{}
+	for _, title := range []string{"outage", "login fails", "slow pages"} {
+		ticket, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: title, AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityNormal, Labels: []string{}, Fields: types.Fields{}})
+		tickets = append(tickets, ticket)
+	}
+
+	blocks, err := db.Link.Create(ctx, types.CreateTicketLink(types.DefaultOrganizationID, tickets[0].ID, tickets[1].ID, types.LinkBlocks, author.ID))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Link.Create(ctx, types.CreateTicketLink(types.DefaultOrganizationID, tickets[0].ID, tickets[1].ID, types.LinkBlocks, author.ID))
+	if err == nil {
+		t.Fatalf("expected a repeated link to be rejected")
+	}
+
+	db.Link.Create(ctx, types.CreateTicketLink(types.DefaultOrganizationID, tickets[2].ID, tickets[0].ID, types.LinkDuplicateOf, author.ID))
+
+	_, err = db.Link.Create(ctx, types.CreateTicketLink(types.DefaultOrganizationID, tickets[2].ID, tickets[1].ID, types.LinkDuplicateOf, author.ID))
+	if err == nil {
+		t.Fatalf("expected a ticket to be a duplicate of one ticket only")
+	}
+
+	link, err := db.Link.GetByID(ctx, blocks.ID)
+	if err != nil || link.SourceID != tickets[0].ID || link.TargetID != tickets[1].ID || link.Type != types.LinkBlocks || link.CreatedBy != author.ID {
+		t.Fatalf("expected the link to be read back, got %v (%v)", link, err)
+	}
+
+	_, err = db.Link.GetByID(data.WithOrganization(ctx, 2), blocks.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected the link to be hidden from other organizations, got: %v", err)
+	}
+
+	links, _ := db.Link.Get(ctx, tickets[0].ID)
+	if len(links) != 2 {
+		t.Fatalf("expected the links of both ends, got %v", links)
+	}
+
+	linkType, otherID := links[1].LinkAs(tickets[0].ID)
+	if linkType != "duplicated_by" || otherID != tickets[2].ID {
+		t.Fatalf("expected the duplicate to show as duplicated_by, got %s %d", linkType, otherID)
+	}
+
+	err = db.Link.Delete(ctx, blocks.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	links, _ = db.Link.Get(ctx, tickets[1].ID)
+	if len(links) != 0 {
+		t.Fatalf("expected the link to be deleted, got %v", links)
+	}
+
+	err = db.Ticket.Purge(ctx, tickets[2].ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	links, _ = db.Link.Get(ctx, tickets[0].ID)
+	if len(links) != 0 {
+		t.Fatalf("expected purging a ticket to remove its links, got %v", links)
+	}
+}
+
+func TestMemoryTicketLinks(t *testing.T) {
+	testTicketLinks(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteTicketLinks(t *testing.T) {
+	testTicketLinks(t, createSQLiteDataAdapter(t))
+}
+
+func TestTicketLinkHandlers(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+	outsider, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "outsider", Role: types.RoleUser})
+
+	index := search.CreateIndex()
+	db.UseIndexer(index)
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, index, workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	authorToken := createToken(t, db, author)
+	outsiderToken := createToken(t, db, outsider)
+
+	epic := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "checkout rework", Description: "new checkout"})
+	task := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "payment form", Description: "card input"})
+	subtask := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "card validation", Description: "luhn check"})
+	private := createEntity(t, server, "/ticket", outsiderToken, &api.CreateTicketRequest{Title: "private", Description: "not shared"})
+
+	links := fmt.Sprintf("/ticket/%d/links", task)
+
+	res := doRequest(t, server, http.MethodPost, links, authorToken, &api.LinkRequest{Type: "child_of", TicketID: epic})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	link := res.Data.(map[string]any)
+	if link["type"] != "child_of" || link["ticket_id"] != float64(epic) {
+		t.Fatalf("expected the link as seen from the task, got %v", link)
+	}
+
+	createEntity(t, server, fmt.Sprintf("/ticket/%d/links", subtask), authorToken, &api.LinkRequest{Type: types.LinkBlocks, TicketID: task})
+	createEntity(t, server, fmt.Sprintf("/ticket/%d/links", task), authorToken, &api.LinkRequest{Type: types.LinkBlocks, TicketID: epic})
+
+	rejected := []struct {
+		path    string
+		request *api.LinkRequest
+		reason  string
+	}{
+		{links, &api.LinkRequest{Type: types.LinkBlocks, TicketID: task}, "a link to itself"},
+		{links, &api.LinkRequest{Type: "caused_by", TicketID: epic}, "an unknown type"},
+		{links, &api.LinkRequest{Type: types.LinkBlocks, TicketID: 999}, "a missing ticket"},
+		{links, &api.LinkRequest{Type: "blocked_by", TicketID: subtask}, "a repeated link"},
+		{links, &api.LinkRequest{Type: types.LinkParentOf, TicketID: epic}, "a parent cycle"},
+		{fmt.Sprintf("/ticket/%d/links", epic), &api.LinkRequest{Type: types.LinkBlocks, TicketID: subtask}, "a blocker cycle"},
+		{fmt.Sprintf("/ticket/%d/links", subtask), &api.LinkRequest{Type: "child_of", TicketID: subtask}, "a ticket as its own parent"},
+	}
+
+	for _, test := range rejected {
+		res = doRequest(t, server, http.MethodPost, test.path, authorToken, test.request)
+		if res.Status != http.StatusBadRequest {
+			t.Errorf("expected %s to be rejected, got %d: %s", test.reason, res.Status, res.Message)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodPost, links, authorToken, &api.LinkRequest{Type: types.LinkRelated, TicketID: private})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected linking to an unreadable ticket to be forbidden, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, fmt.Sprintf("/ticket/%d/links", private), adminToken, &api.LinkRequest{Type: types.LinkRelated, TicketID: task})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, links, adminToken, &api.LinkRequest{Type: types.LinkRelated, TicketID: private})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected a related link to be the same both ways, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d?embed=links", task), authorToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	ticket := res.Data.(map[string]any)
+	embedded := ticket["links"].([]any)
+
+	if ticket["title"] != "payment form" || len(embedded) != 4 {
+		t.Fatalf("expected the ticket with its links, got %v", ticket)
+	}
+
+	seen := map[string]bool{}
+	for _, item := range embedded {
+		link := item.(map[string]any)
+		_, readable := link["ticket"]
+		seen[fmt.Sprintf("%s #%v %t", link["type"], link["ticket_id"], readable)] = true
+	}
+
+	for _, expected := range []string{
+		fmt.Sprintf("child_of #%d true", epic),
+		fmt.Sprintf("blocked_by #%d true", subtask),
+		fmt.Sprintf("blocks #%d true", epic),
+		fmt.Sprintf("related #%d false", private),
+	} {
+		if !seen[expected] {
+			t.Errorf("expected link %q, got %v", expected, seen)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d", task), authorToken, nil)
+	if _, ok := res.Data.(map[string]any)["links"]; ok {
+		t.Fatalf("expected links to be embedded on request only")
+	}
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("%s/%d", fmt.Sprintf("/ticket/%d/links", subtask), int(link["id"].(float64))), authorToken, nil)
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected a link of other tickets to be missing, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("%s/%d", links, int(link["id"].(float64))), authorToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, links, authorToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 3 {
+		t.Fatalf("expected three links to be left, got %d: %v", res.Status, res.Data)
+	}
+
+	changes, _ := db.History.Get(ctx, types.EntityTicket, task)
+	linkChanges := 0
+	for _, change := range changes {
+		if change.Field == "links" {
+			linkChanges++
+		}
+	}
+
+	if linkChanges != 5 {
+		t.Fatalf("expected linking and unlinking to be recorded, got %d changes", linkChanges)
+	}
+}
+
+func TestMergeTicket(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+	first, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "first", Role: types.RoleAdmin})
+	second, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "second", Role: types.RoleAdmin})
+
+	index := search.CreateIndex()
+	db.UseIndexer(index)
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, index, workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	authorToken := createToken(t, db, author)
+
+	primary, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "cannot log in", AuthorID: author.ID, Status: types.StatusActive, AssigneeIDs: []int{first.ID}, Priority: types.PriorityNormal, Labels: []string{}, Fields: types.Fields{}})
+	duplicate, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "login broken", AuthorID: author.ID, Status: types.StatusActive, AssigneeIDs: []int{first.ID, second.ID}, Priority: types.PriorityNormal, Labels: []string{}, Fields: types.Fields{}})
+
+	for i, content := range []string{"it says invalid password", "even after a reset"} {
+		message, _ := types.CreateMessage(fmt.Sprintf("0d6c7e0a-3f43-4b7a-9d0e-5b1c2a8f7e1%d", i), duplicate.ID, author.ID, content)
+		message.OrgID = duplicate.OrgID
+		message.CreatedAt = message.CreatedAt.Add(time.Duration(i) * time.Second).Truncate(time.Millisecond)
+		db.Message.Create(ctx, message)
+	}
+
+	merge := fmt.Sprintf("/ticket/%d/merge", duplicate.ID)
+
+	res := doRequest(t, server, http.MethodPost, merge, authorToken, &api.MergeRequest{TicketID: primary.ID})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected merging to need the status permission, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, merge, adminToken, &api.MergeRequest{TicketID: duplicate.ID})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected merging a ticket into itself to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, merge, adminToken, &api.MergeRequest{TicketID: primary.ID})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	merged, _ := db.Ticket.GetByID(ctx, primary.ID)
+	if len(merged.AssigneeIDs) != 2 || merged.AssigneeIDs[0] != first.ID || merged.AssigneeIDs[1] != second.ID {
+		t.Fatalf("expected the assignees to be moved, got %v", merged.AssigneeIDs)
+	}
+
+	closed, _ := db.Ticket.GetByID(ctx, duplicate.ID)
+	if closed.Status != types.StatusClosed || len(closed.AssigneeIDs) != 0 || closed.Resolution != fmt.Sprintf("duplicate of #%d", primary.ID) {
+		t.Fatalf("expected the duplicate to be closed with a pointer, got %s %v %q", closed.Status, closed.AssigneeIDs, closed.Resolution)
+	}
+
+	messages, _ := db.Message.Get(ctx, primary.ID)
+	if len(messages) != 2 || messages[0].Content != "even after a reset" || messages[0].TicketID != primary.ID {
+		t.Fatalf("expected the messages to be moved, got %v", messages)
+	}
+
+	messages, _ = db.Message.Get(ctx, duplicate.ID)
+	if len(messages) != 0 {
+		t.Fatalf("expected no messages to be left on the duplicate, got %v", messages)
+	}
+
+	hits := index.Search("reset")
+	if len(hits) != 1 || hits[0].TicketID != primary.ID {
+		t.Fatalf("expected moved messages to be found under the primary ticket, got %v", hits)
+	}
+
+	links, _ := db.Link.Get(ctx, duplicate.ID)
+	if len(links) != 1 || links[0].Type != types.LinkDuplicateOf || links[0].TargetID != primary.ID {
+		t.Fatalf("expected the duplicate to be linked to the primary ticket, got %v", links)
+	}
+
+	res = doRequest(t, server, http.MethodPost, merge, adminToken, &api.MergeRequest{TicketID: primary.ID})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected merging again to be harmless, got %d: %s", res.Status, res.Message)
+	}
+
+	other := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "password reset", Description: "no mail"})
+
+	res = doRequest(t, server, http.MethodPost, merge, adminToken, &api.MergeRequest{TicketID: other})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected a duplicate to be merged into one ticket only, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, fmt.Sprintf("/ticket/%d/merge", primary.ID), adminToken, &api.MergeRequest{TicketID: duplicate.ID})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected merging back to be rejected as a cycle, got %d: %s", res.Status, res.Message)
+	}
+}
+
+func TestMergeTicketMovesAttachments(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+
+	store, _ := blob.CreateLocalStore(t.TempDir())
+
+	apiServer := api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default())
+	apiServer.UseBlobStore(store, &api.AttachmentLimits{MaxSize: 1024, ContentTypes: []string{"image/png"}})
+
+	server := httptest.NewServer(apiServer.Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	authorToken := createToken(t, db, author)
+
+	primary := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "cannot log in", Description: "invalid password"})
+	duplicate := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "login broken", Description: "see screenshot"})
+
+	res := uploadFiles(t, server, fmt.Sprintf("/ticket/%d/attachment", duplicate), authorToken, &uploadFile{"screen.png", "image/png", png})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	attachmentID := int(res.Data.([]any)[0].(map[string]any)["id"].(float64))
+
+	conn := dialChat(t, server, fmt.Sprintf("/ticket/%d", duplicate), authorToken)
+	sendChatAttachments(t, conn, attachmentID)
+	conn.Close()
+
+	res = doRequest(t, server, http.MethodPost, fmt.Sprintf("/ticket/%d/merge", duplicate), adminToken, &api.MergeRequest{TicketID: primary})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	messages, _ := db.Message.Get(ctx, primary)
+	if len(messages) != 1 || len(messages[0].AttachmentIDs) != 1 || messages[0].AttachmentIDs[0] != attachmentID {
+		t.Fatalf("expected the message to be moved with its attachment, got %v", messages)
+	}
+
+	download := func() {
+		t.Helper()
+
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/ticket/%d/attachment/%d", server.URL, primary, attachmentID), nil)
+		req.Header.Set("Authorization", "Bearer "+authorToken)
+
+		downloadRes, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		defer downloadRes.Body.Close()
+
+		content, _ := io.ReadAll(downloadRes.Body)
+		if downloadRes.StatusCode != http.StatusOK || !bytes.Equal(content, png) {
+			t.Fatalf("expected the attachment to be downloaded through the primary ticket, got %d: %q", downloadRes.StatusCode, content)
+		}
+	}
+
+	download()
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("/ticket/%d", duplicate), adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, fmt.Sprintf("/ticket/%d/purge", duplicate), adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	download()
+}
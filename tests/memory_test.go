@@ -0,0 +1,197 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/auth"
+	"ticketing-api/data"
+	"ticketing-api/data/memory"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+)
+
+func createMemoryDataAdapter() *data.DataAdapter {
+	store := memory.CreateStore()
+
+	return data.CreateDataAdapter(
+		memory.CreateAccountAdapter(store),
+		memory.CreateTicketAdapter(store),
+		memory.CreateMessageAdapter(store),
+		memory.CreateHistoryAdapter(store),
+		memory.CreateSessionAdapter(store),
+		memory.CreateRoleAdapter(store),
+		memory.CreateOrganizationAdapter(store),
+		memory.CreateMembershipAdapter(store),
+		memory.CreateTeamAdapter(store),
+		memory.CreateAgentAdapter(store),
+		memory.CreateAssignmentAdapter(store),
+		memory.CreateSLAPolicyAdapter(store),
+		memory.CreateCategoryAdapter(store),
+		memory.CreateCustomFieldAdapter(store),
+		memory.CreateAttachmentAdapter(store),
+		memory.CreateLinkAdapter(store),
+		memory.CreateNotificationAdapter(store),
+		store,
+	)
+}
+
+func TestMemoryAccountNotFound(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	_, err := db.Account.GetByID(ctx, 1)
+	if err == nil {
+		t.Fatalf("expected error for missing account, got none")
+	}
+
+	_, err = db.Account.GetByUsername(ctx, "missing")
+	if err == nil {
+		t.Fatalf("expected error for missing username, got none")
+	}
+}
+
+func TestMemoryAccountUniqueUsername(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	_, err := db.Account.Create(ctx, &types.Account{Username: "joe", Role: types.RoleUser})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Account.Create(ctx, &types.Account{Username: "joe", Role: types.RoleUser})
+	if err == nil {
+		t.Fatalf("expected error for duplicate username, got none")
+	}
+}
+
+func TestMemoryTicketRequiresAuthor(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	_, err := db.Ticket.Create(ctx, types.CreateTicket("a", "a", 1, types.StatusOpen, []int{}))
+	if err == nil {
+		t.Fatalf("expected error for missing author, got none")
+	}
+}
+
+func TestMemoryMessageOrdering(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, id := range []string{"b", "a", "c"} {
+		_, err := db.Message.Create(ctx, &types.Message{ID: id, TicketID: 1, CreatedAt: now.Add(time.Duration(i%2) * time.Second)})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	messages, err := db.Message.Get(ctx, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	ids := ""
+	for _, message := range messages {
+		ids += message.ID
+	}
+
+	if ids != "abc" {
+		t.Fatalf("expected order abc, got %s", ids)
+	}
+}
+
+func TestMemoryHandlers(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	passwordHash, _ := auth.CreateHash("password")
+	admin, _ := db.Account.Create(ctx, &types.Account{Username: "admin", Password: passwordHash, Role: types.RoleAdmin})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	res := doRequest(t, server, http.MethodPost, "/account/login", "", &api.LoginRequest{Username: "admin", Password: "password"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	token := res.Data.(map[string]any)["token"].(string)
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", token, &api.CreateTicketRequest{Title: "title", Description: "description", AuthorID: admin.ID})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/ticket", token, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 {
+		t.Fatalf("expected one ticket, got %d: %v", res.Status, res.Data)
+	}
+}
+
+func doRequest(t *testing.T, server *httptest.Server, method string, path string, token string, body any) *api.APIResponse {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	if body != nil {
+		json.NewEncoder(buf).Encode(body)
+	}
+
+	req, err := http.NewRequest(method, server.URL+path, buf)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+
+	apiResponse := &api.APIResponse{}
+
+	err = json.NewDecoder(res.Body).Decode(apiResponse)
+	if err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	return apiResponse
+}
+
+// createToken starts a session for account, as logging in would, and returns
+// an access token for it.
+func createToken(t *testing.T, db *data.DataAdapter, account *types.Account) string {
+	t.Helper()
+
+	sessionID, err := auth.GenerateSessionID()
+	if err != nil {
+		t.Fatalf("failed to generate session id: %v", err)
+	}
+
+	session := types.CreateSession(sessionID, account.ID, "", time.Now().Add(auth.RefreshTokenTTL))
+	session.OrgID = account.OrgID
+
+	_, err = db.Session.Create(context.Background(), session)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	token, err := auth.GenerateSessionJWT(account, sessionID)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	return token
+}
@@ -0,0 +1,407 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/notify"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+)
+
+func testNotifications(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+	now := time.Now()
+
+	first, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "first", Role: types.RoleUser})
+	second, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "second", Role: types.RoleUser})
+
+	ticket := types.CreateTicket("printer jam", "tray 2", first.ID, types.StatusOpen, []int{})
+	ticket.OrgID = types.DefaultOrganizationID
+	ticket, _ = db.Ticket.Create(ctx, ticket)
+
+	_, err := db.Notification.GetPreferences(ctx, first.ID)
+
+	var notFound *types.NotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected unset preferences to be not found, got: %v", err)
+	}
+
+	for _, digest := range []types.Digest{types.DigestDaily, types.DigestHourly} {
+		_, err = db.Notification.SetPreferences(ctx, &types.NotificationPreferences{AccountID: first.ID, Email: "first@example.com", Events: []types.Event{types.EventStatusChanged}, Digest: digest, UpdatedAt: now})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	prefs, err := db.Notification.GetPreferences(ctx, first.ID)
+	if err != nil || prefs.Email != "first@example.com" || !slices.Equal(prefs.Events, []types.Event{types.EventStatusChanged}) || prefs.Digest != types.DigestHourly {
+		t.Fatalf("expected the latest preferences, got %+v (%v)", prefs, err)
+	}
+
+	due, _ := db.Notification.Create(ctx, types.CreateNotification(ticket.OrgID, first.ID, ticket.ID, types.EventStatusChanged, "due", now.Add(-time.Minute)))
+	db.Notification.Create(ctx, types.CreateNotification(ticket.OrgID, first.ID, ticket.ID, types.EventStatusChanged, "later", now.Add(time.Hour)))
+	other, _ := db.Notification.Create(ctx, types.CreateNotification(ticket.OrgID, second.ID, ticket.ID, types.EventTicketCreated, "other", now.Add(-2*time.Minute)))
+
+	notifications, err := db.Notification.GetDue(ctx, now, 3)
+	if err != nil || len(notifications) != 2 || notifications[0].ID != due.ID || notifications[1].ID != other.ID {
+		t.Fatalf("expected the due notifications by account, got %v (%v)", notifications, err)
+	}
+
+	stale := *notifications[0]
+
+	claimed, err := db.Notification.Claim(ctx, notifications[0], now.Add(time.Minute))
+	if err != nil || !claimed || notifications[0].Attempts != 1 {
+		t.Fatalf("expected the notification to be claimed, got %v (%v)", claimed, err)
+	}
+
+	claimed, _ = db.Notification.Claim(ctx, &stale, now.Add(time.Minute))
+	if claimed {
+		t.Fatal("expected a notification to be claimed once per attempt")
+	}
+
+	notifications, _ = db.Notification.GetDue(ctx, now, 3)
+	if len(notifications) != 1 || notifications[0].ID != other.ID {
+		t.Fatalf("expected a claimed notification to wait for its retry, got %v", notifications)
+	}
+
+	notifications, _ = db.Notification.GetDue(ctx, now.Add(2*time.Minute), 1)
+	if len(notifications) != 1 || notifications[0].ID != other.ID {
+		t.Fatalf("expected notifications out of attempts to be left out, got %v", notifications)
+	}
+
+	err = db.Notification.MarkSent(ctx, other.ID, now)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	claimed, _ = db.Notification.Claim(ctx, other, now.Add(time.Minute))
+	if claimed {
+		t.Fatal("expected sent notifications not to be claimed")
+	}
+
+	notifications, _ = db.Notification.GetDue(ctx, now.Add(2*time.Minute), 3)
+	if len(notifications) != 1 || notifications[0].ID != due.ID || notifications[0].Attempts != 1 {
+		t.Fatalf("expected only the retried notification to be due, got %v", notifications)
+	}
+}
+
+func TestMemoryNotifications(t *testing.T) {
+	testNotifications(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteNotifications(t *testing.T) {
+	testNotifications(t, createSQLiteDataAdapter(t))
+}
+
+func TestNotificationDelivery(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin@example.com", Role: types.RoleAdmin})
+	agent, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "agent", Role: types.RoleUser})
+	customer, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "customer@example.com", Role: types.RoleUser})
+	colleague, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "colleague@example.com", Role: types.RoleUser})
+
+	smtp := startFakeSMTP(t)
+
+	channel, err := notify.CreateSMTPChannel(notify.SMTPConfig{Host: "127.0.0.1", Port: smtp.port(), From: "Helpdesk <helpdesk@example.com>"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	notifier := notify.CreateNotifier(db, channel, notify.Retry{MaxAttempts: 2, Delay: time.Minute})
+
+	apiServer := api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default())
+	apiServer.UseNotifier(notifier)
+
+	server := httptest.NewServer(apiServer.Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	agentToken := createToken(t, db, agent)
+	customerToken := createToken(t, db, customer)
+	colleagueToken := createToken(t, db, colleague)
+
+	preferences := "/me/notifications/preferences"
+
+	for _, req := range []map[string]any{{"email": "agent"}, {"events": []string{"ticket_deleted"}}, {"digest": "weekly"}} {
+		res := doRequest(t, server, http.MethodPut, preferences, agentToken, req)
+		if res.Status != http.StatusBadRequest {
+			t.Fatalf("expected %v to be rejected, got %d: %s", req, res.Status, res.Message)
+		}
+	}
+
+	res := doRequest(t, server, http.MethodPut, preferences, agentToken, &api.NotificationPreferencesRequest{Email: "Agent <agent@example.com>"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, preferences, agentToken, nil)
+	if prefs := res.Data.(map[string]any); prefs["email"] != "agent@example.com" || len(prefs["events"].([]any)) != len(types.Events) || prefs["digest"] != string(types.DigestOff) {
+		t.Fatalf("expected the address and every event right away, got %v", prefs)
+	}
+
+	deliver := func(now time.Time, expected int) {
+		t.Helper()
+
+		sent, err := notifier.Deliver(ctx, now)
+		if err != nil || sent != expected {
+			t.Fatalf("expected %d notifications sent, got %d (%v)", expected, sent, err)
+		}
+	}
+
+	id := createEntity(t, server, "/ticket", customerToken, &api.CreateTicketRequest{Title: "vpn drops", Description: "every hour"})
+	ticket := fmt.Sprintf("/ticket/%d", id)
+
+	deliver(time.Now(), 0)
+
+	res = doRequest(t, server, http.MethodPut, ticket, adminToken, &api.CreateTicketRequest{AssigneeIDs: []int{agent.ID}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	deliver(time.Now(), 1)
+	smtp.expect(t, "agent@example.com", fmt.Sprintf("You were assigned ticket #%d \"vpn drops\"", id))
+
+	doRequest(t, server, http.MethodPost, ticket+"/watchers", adminToken, &api.WatcherRequest{AccountID: colleague.ID})
+	doRequest(t, server, http.MethodPut, preferences, customerToken, &api.NotificationPreferencesRequest{Digest: types.DigestHourly})
+
+	doRequest(t, server, http.MethodPut, ticket, agentToken, &api.CreateTicketRequest{Status: types.StatusActive})
+	doRequest(t, server, http.MethodPut, ticket, agentToken, &api.CreateTicketRequest{Status: types.StatusResolved, Resolution: "new cable"})
+
+	deliver(time.Now(), 2)
+	smtp.expect(t, "colleague@example.com", fmt.Sprintf("Ticket #%d \"vpn drops\" moved from open to active", id))
+	smtp.expect(t, "colleague@example.com", fmt.Sprintf("Ticket #%d \"vpn drops\" moved from active to resolved", id))
+
+	deliver(time.Now().Add(time.Hour), 2)
+	body := smtp.expect(t, "customer@example.com", "2 updates on your tickets")
+	if !strings.Contains(body, "moved from open to active") || !strings.Contains(body, "moved from active to resolved") {
+		t.Fatalf("expected the digest to list both changes, got %q", body)
+	}
+
+	doRequest(t, server, http.MethodPut, preferences, customerToken, &api.NotificationPreferencesRequest{})
+	doRequest(t, server, http.MethodPut, preferences, colleagueToken, &api.NotificationPreferencesRequest{Events: []types.Event{types.EventMessageCreated}})
+
+	conn := dialChat(t, server, ticket, colleagueToken)
+	defer conn.Close()
+
+	sendChatMessage(t, server, ticket, agentToken, "is it still dropping?\r\nBcc: everyone@example.com")
+
+	deliver(time.Now(), 1)
+	body = smtp.expect(t, "customer@example.com", fmt.Sprintf("New message on ticket #%d \"vpn drops\": is it still dropping?\r\nBcc: everyone@example.com", id))
+	if !strings.Contains(body, "is it still dropping?") {
+		t.Fatalf("expected the message to be quoted, got %q", body)
+	}
+
+	smtp.fail(1)
+
+	sendChatMessage(t, server, ticket, agentToken, "any news?")
+
+	deliver(time.Now(), 0)
+	deliver(time.Now(), 0)
+	deliver(time.Now().Add(time.Minute), 1)
+	smtp.expect(t, "customer@example.com", fmt.Sprintf("New message on ticket #%d \"vpn drops\": any news?", id))
+
+	smtp.fail(2)
+
+	sendChatMessage(t, server, ticket, agentToken, "closing this then")
+
+	deliver(time.Now(), 0)
+	deliver(time.Now().Add(time.Minute), 0)
+	deliver(time.Now().Add(time.Hour), 0)
+
+	if messages := smtp.messages(); len(messages) != 0 {
+		t.Fatalf("expected nothing else sent, got %v", messages)
+	}
+}
+
+// fakeSMTP is an SMTP server that accepts any mail, enough of the protocol
+// for net/smtp, and can be told to refuse the next few.
+type fakeSMTP struct {
+	listener net.Listener
+	mu       sync.Mutex
+	failures int
+	received []*receivedMail
+}
+
+type receivedMail struct {
+	to   string
+	data string
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	f := &fakeSMTP{listener: listener}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go f.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	return f
+}
+
+func (f *fakeSMTP) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) fail(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = n
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	mail := &receivedMail{}
+
+	fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			fmt.Fprint(conn, "250-localhost\r\n250 HELP\r\n")
+		case "MAIL":
+			f.mu.Lock()
+			failing := f.failures > 0
+			if failing {
+				f.failures--
+			}
+			f.mu.Unlock()
+
+			if failing {
+				fmt.Fprint(conn, "451 try again later\r\n")
+				continue
+			}
+
+			fmt.Fprint(conn, "250 OK\r\n")
+		case "RCPT":
+			mail.to = strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			fmt.Fprint(conn, "250 OK\r\n")
+		case "DATA":
+			fmt.Fprint(conn, "354 go ahead\r\n")
+
+			data := &strings.Builder{}
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if line == ".\r\n" {
+					break
+				}
+
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+
+			mail.data = data.String()
+
+			f.mu.Lock()
+			f.received = append(f.received, mail)
+			f.mu.Unlock()
+
+			mail = &receivedMail{}
+
+			fmt.Fprint(conn, "250 OK\r\n")
+		case "QUIT":
+			fmt.Fprint(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "250 OK\r\n")
+		}
+	}
+}
+
+// messages takes the mail received so far.
+func (f *fakeSMTP) messages() []*receivedMail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	received := f.received
+	f.received = nil
+
+	return received
+}
+
+// expect takes the oldest mail received, checks who it went to and its
+// subject, and returns its decoded body.
+func (f *fakeSMTP) expect(t *testing.T, to string, subject string) string {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.received) == 0 {
+		t.Fatalf("expected mail to %s, got none", to)
+	}
+
+	received := f.received[0]
+	f.received = f.received[1:]
+
+	message, err := mail.ReadMessage(strings.NewReader(received.data))
+	if err != nil {
+		t.Fatalf("failed to parse mail: %v", err)
+	}
+
+	if len(message.Header["Subject"]) != 1 || len(message.Header["Bcc"]) != 0 {
+		t.Fatalf("expected exactly one subject header and no others injected, got %v", message.Header)
+	}
+
+	decoded, err := (&mime.WordDecoder{}).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || received.to != to || decoded != subject {
+		t.Fatalf("expected mail to %s about %q, got mail to %s about %q (%v)", to, subject, received.to, decoded, err)
+	}
+
+	if from := message.Header.Get("From"); from != "\"Helpdesk\" <helpdesk@example.com>" {
+		t.Fatalf("expected mail from the helpdesk, got %s", from)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(message.Body))
+	if err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+
+	return string(body)
+}
@@ -0,0 +1,224 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/auth"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testOrganizations(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	org, err := db.Organization.Create(ctx, types.CreateOrganization("acme"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	orgs, err := db.Organization.Get(ctx)
+	if err != nil || len(orgs) != 2 || orgs[0].ID != types.DefaultOrganizationID {
+		t.Fatalf("expected the default organization and acme, got %v (%v)", orgs, err)
+	}
+
+	home := data.WithOrganization(ctx, types.DefaultOrganizationID)
+	acme := data.WithOrganization(ctx, org.ID)
+
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+	guest, _ := db.Account.Create(ctx, &types.Account{OrgID: org.ID, Username: "guest", Role: types.RoleUser})
+
+	ticket, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "title", AuthorID: user.ID, Status: types.StatusOpen, AssigneeIDs: []int{}})
+
+	_, err = db.Ticket.GetByID(acme, ticket.ID)
+	if err == nil {
+		t.Fatalf("expected ticket to be hidden from other organizations")
+	}
+
+	tickets, _, err := db.Ticket.Get(acme, nil, nil)
+	if err != nil || len(tickets) != 0 {
+		t.Fatalf("expected no tickets in acme, got %d (%v)", len(tickets), err)
+	}
+
+	ticket.Title = "renamed"
+
+	_, err = db.Ticket.Update(acme, ticket)
+	if _, ok := err.(*types.PreconditionFailed); !ok {
+		t.Fatalf("expected updating from another organization to fail, got: %v", err)
+	}
+
+	_, err = db.Ticket.GetByID(home, ticket.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Account.GetByID(home, guest.ID)
+	if err == nil {
+		t.Fatalf("expected account to be hidden from other organizations")
+	}
+
+	_, err = db.Membership.Set(ctx, types.CreateMembership(types.DefaultOrganizationID, guest.ID, types.RoleEditor))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Account.GetByID(home, guest.ID)
+	if err != nil {
+		t.Fatalf("expected members to be visible, got: %v", err)
+	}
+
+	accounts, _, err := db.Account.Get(home, nil)
+	if err != nil || len(accounts) != 2 {
+		t.Fatalf("expected user and guest, got %d (%v)", len(accounts), err)
+	}
+
+	membership, err := db.Membership.GetByID(ctx, types.DefaultOrganizationID, guest.ID)
+	if err != nil || membership.Role != types.RoleEditor {
+		t.Fatalf("expected editor membership, got %v (%v)", membership, err)
+	}
+
+	err = db.Membership.Delete(ctx, types.DefaultOrganizationID, guest.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	err = db.Membership.Delete(ctx, types.DefaultOrganizationID, guest.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected deleting a missing membership to fail, got: %v", err)
+	}
+}
+
+func TestMemoryOrganizations(t *testing.T) {
+	testOrganizations(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteOrganizations(t *testing.T) {
+	testOrganizations(t, createSQLiteDataAdapter(t))
+}
+
+func TestOrganizationIsolation(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	passwordHash, _ := auth.CreateHash("password")
+	root, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "root", Password: passwordHash, Role: types.RoleAdmin, SuperAdmin: true})
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Password: passwordHash, Role: types.RoleAdmin})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	rootToken := createToken(t, db, root)
+	adminToken := createToken(t, db, admin)
+
+	res := doRequest(t, server, http.MethodPost, "/org", adminToken, &api.OrganizationRequest{Name: "acme"})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected org admins to be unable to create organizations, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/org", rootToken, &api.OrganizationRequest{Name: "acme"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	orgID := int(res.Data.(map[string]any)["id"].(float64))
+
+	outsider, _ := db.Account.Create(ctx, &types.Account{OrgID: orgID, Username: "outsider", Password: passwordHash, Role: types.RoleAdmin})
+	outsiderToken := createToken(t, db, outsider)
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", adminToken, &api.CreateTicketRequest{Title: "title", Description: "description", AssigneeIDs: []int{outsider.ID}})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected assigning an account from another organization to fail, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", adminToken, &api.CreateTicketRequest{Title: "title", Description: "description"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	ticketID := int(res.Data.(map[string]any)["id"].(float64))
+
+	res = doRequest(t, server, http.MethodGet, "/ticket", outsiderToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 0 {
+		t.Fatalf("expected no tickets from another organization, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d", ticketID), outsiderToken, nil)
+	if res.Status == http.StatusOK {
+		t.Fatalf("expected a ticket from another organization to be hidden")
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/account/%d", admin.ID), outsiderToken, &api.UpdateAccountRequest{Password: "stolen"})
+	if res.Status == http.StatusOK {
+		t.Fatalf("expected an account from another organization to be out of reach")
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/account/login", "", &api.LoginRequest{Username: "admin", Password: "password", OrgID: orgID})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected login to an organization without membership to fail, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/org/%d/member/%d", orgID, admin.ID), rootToken, &api.MemberRequest{Role: types.RoleUser})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/account/login", "", &api.LoginRequest{Username: "admin", Password: "password", OrgID: orgID})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	memberToken := res.Data.(map[string]any)["token"].(string)
+
+	token, _ := auth.ValidateJWT(memberToken)
+	if claims := token.Claims.(jwt.MapClaims); int(claims["org"].(float64)) != orgID || claims["role"] != string(types.RoleUser) {
+		t.Fatalf("expected a user token for acme, got %v", claims)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/account", memberToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected the membership role to apply in the organization, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", memberToken, &api.CreateTicketRequest{Title: "acme", Description: "description"})
+	if res.Status != http.StatusOK || int(res.Data.(map[string]any)["org_id"].(float64)) != orgID {
+		t.Fatalf("expected ticket created in acme, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/ticket", outsiderToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 {
+		t.Fatalf("expected the member's ticket in acme, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/org/%d/member", orgID), rootToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 {
+		t.Fatalf("expected one member, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/org/%d/member/%d", orgID, admin.ID), rootToken, &api.MemberRequest{Role: types.RoleEditor})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("/org/%d/member/%d", orgID, admin.ID), rootToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	changes, err := db.History.Get(data.WithOrganization(ctx, orgID), types.EntityAccount, admin.ID)
+	if err != nil || len(changes) != 3 {
+		t.Fatalf("expected every membership role change to be recorded, got %v (%v)", changes, err)
+	}
+
+	for i, expected := range [][2]string{{"", "user"}, {"user", "editor"}, {"editor", ""}} {
+		if changes[i].Field != "role" || changes[i].OldValue != expected[0] || changes[i].NewValue != expected[1] || changes[i].ActorID != root.ID || changes[i].OrgID != orgID {
+			t.Fatalf("expected role change %s to %s, got %+v", expected[0], expected[1], changes[i])
+		}
+	}
+}
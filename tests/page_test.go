@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+func testTicketPagination(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{Username: "author", Role: types.RoleUser})
+
+	statuses := []types.Status{types.StatusOpen, types.StatusClosed, types.StatusPending, types.StatusOpen, types.StatusResolved}
+	for _, status := range statuses {
+		_, err := db.Ticket.Create(ctx, types.CreateTicket("title", "description", author.ID, status, []int{author.ID}))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	for _, sort := range data.TicketSorts {
+		page := &types.Page{Limit: 2, Sort: sort, Desc: true, Total: true}
+		seen := map[int]bool{}
+		last := ""
+
+		for {
+			tickets, info, err := db.Ticket.Get(ctx, nil, page)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if info.Total == nil || *info.Total != len(statuses) {
+				t.Fatalf("expected total %d, got %v", len(statuses), info.Total)
+			}
+
+			for _, ticket := range tickets {
+				if seen[ticket.ID] {
+					t.Fatalf("ticket %d returned twice sorting by %s", ticket.ID, sort)
+				}
+				seen[ticket.ID] = true
+
+				if len(ticket.AssigneeIDs) != 1 {
+					t.Fatalf("expected one assignee, got %v", ticket.AssigneeIDs)
+				}
+
+				if sort == "status" && last != "" && string(ticket.Status) > last {
+					t.Fatalf("expected descending status, got %s after %s", ticket.Status, last)
+				}
+				last = string(ticket.Status)
+			}
+
+			if info.NextCursor == "" {
+				break
+			}
+
+			page.Cursor = info.NextCursor
+		}
+
+		if len(seen) != len(statuses) {
+			t.Fatalf("expected %d tickets sorting by %s, got %d", len(statuses), sort, len(seen))
+		}
+	}
+}
+
+func testInvalidCursor(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	_, info, err := db.Account.Get(ctx, &types.Page{Limit: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, _, err = db.Account.Get(ctx, &types.Page{Limit: 1, Sort: "username", Cursor: info.NextCursor})
+	if _, ok := err.(*types.BadRequest); !ok {
+		t.Fatalf("expected bad request for cursor from another sort, got: %v", err)
+	}
+}
+
+func TestMemoryTicketPagination(t *testing.T) {
+	testTicketPagination(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteTicketPagination(t *testing.T) {
+	testTicketPagination(t, createSQLiteDataAdapter(t))
+}
+
+func TestMemoryInvalidCursor(t *testing.T) {
+	db := createMemoryDataAdapter()
+	db.Account.Create(context.Background(), &types.Account{Username: "a"})
+	db.Account.Create(context.Background(), &types.Account{Username: "b"})
+
+	testInvalidCursor(t, db)
+}
+
+func TestSQLiteInvalidCursor(t *testing.T) {
+	db := createSQLiteDataAdapter(t)
+	db.Account.Create(context.Background(), &types.Account{Username: "a"})
+	db.Account.Create(context.Background(), &types.Account{Username: "b"})
+
+	testInvalidCursor(t, db)
+}
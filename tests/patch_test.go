@@ -0,0 +1,161 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/auth"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func doPatch(t *testing.T, server *httptest.Server, path string, token string, contentType string, body string) *api.APIResponse {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPatch, server.URL+path, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+
+	apiResponse := &api.APIResponse{}
+	json.NewDecoder(res.Body).Decode(apiResponse)
+
+	return apiResponse
+}
+
+func TestPatchTicket(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+	editor, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "editor", Role: types.RoleEditor})
+	ticket := types.CreateTicket("title", "description", user.ID, types.StatusOpen, []int{editor.ID})
+	ticket.OrgID = types.DefaultOrganizationID
+	ticket, _ = db.Ticket.Create(ctx, ticket)
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	userToken := createToken(t, db, user)
+	editorToken := createToken(t, db, editor)
+	path := fmt.Sprintf("/ticket/%d", ticket.ID)
+	merge := "application/merge-patch+json"
+
+	res := doPatch(t, server, path, userToken, merge, `{"description": null}`)
+	if res.Status != http.StatusOK || res.Data.(map[string]any)["description"] != "" {
+		t.Fatalf("expected description to be cleared, got %d: %s %v", res.Status, res.Message, res.Data)
+	}
+
+	res = doPatch(t, server, path, userToken, merge, `{"assignee_ids": []}`)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected users to be unable to unassign, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doPatch(t, server, path, editorToken, merge, `{"assignee_ids": []}`)
+	if res.Status != http.StatusOK || len(res.Data.(map[string]any)["assignee_ids"].([]any)) != 0 {
+		t.Fatalf("expected everyone to be unassigned, got %d: %s %v", res.Status, res.Message, res.Data)
+	}
+
+	res = doPatch(t, server, path, editorToken, merge, `{"id": 5}`)
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected id to be read only, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doPatch(t, server, path, editorToken, merge, `{"title": null}`)
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected title to be required, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doPatch(t, server, path, editorToken, merge, `{"status": "closed", "resolution": "duplicate"}`)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doPatch(t, server, path, editorToken, merge, `{"status": "active"}`)
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected the workflow to reject closed to active, got %d: %s", res.Status, res.Message)
+	}
+
+	jsonPatch := "application/json-patch+json"
+
+	res = doPatch(t, server, path, editorToken, jsonPatch, fmt.Sprintf(`[
+		{"op": "test", "path": "/title", "value": "title"},
+		{"op": "replace", "path": "/title", "value": "new title"},
+		{"op": "add", "path": "/assignee_ids/-", "value": %d},
+		{"op": "copy", "from": "/title", "path": "/description"}
+	]`, editor.ID))
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	data := res.Data.(map[string]any)
+	if data["title"] != "new title" || data["description"] != "new title" || len(data["assignee_ids"].([]any)) != 1 {
+		t.Fatalf("unexpected ticket: %v", data)
+	}
+
+	res = doPatch(t, server, path, editorToken, jsonPatch, `[{"op": "test", "path": "/title", "value": "title"}, {"op": "remove", "path": "/description"}]`)
+	if res.Status != http.StatusPreconditionFailed {
+		t.Fatalf("expected failed test to return 412, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doPatch(t, server, path, editorToken, jsonPatch, `[{"op": "remove", "path": "/assignee_ids/3"}]`)
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected missing path to return 400, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doPatch(t, server, path, editorToken, "text/plain", `{}`)
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected unsupported content type to return 400, got %d: %s", res.Status, res.Message)
+	}
+}
+
+func TestPatchAccount(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	userToken := createToken(t, db, user)
+	path := fmt.Sprintf("/account/%d", user.ID)
+
+	res := doPatch(t, server, path, userToken, "application/merge-patch+json", `{"role": "admin"}`)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected users to be unable to change their role, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doPatch(t, server, path, userToken, "application/merge-patch+json", `{"password": "secret"}`)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	account, _ := db.Account.GetByID(ctx, user.ID)
+	if auth.CompareHashAndPassword(account.Password, "secret") != nil {
+		t.Fatalf("expected password to be hashed and stored")
+	}
+
+	res = doPatch(t, server, path, adminToken, "application/merge-patch+json", `{"role": "superuser"}`)
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected unknown role to return 400, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doPatch(t, server, path, adminToken, "application/merge-patch+json", `{"role": "editor"}`)
+	if res.Status != http.StatusOK || res.Data.(map[string]any)["role"] != "editor" {
+		t.Fatalf("expected role to be editor, got %d: %s", res.Status, res.Message)
+	}
+}
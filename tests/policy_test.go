@@ -0,0 +1,275 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func testRoles(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	roles, err := db.Role.Get(ctx)
+	if err != nil || len(roles) != 3 {
+		t.Fatalf("expected the 3 built in roles, got %d (%v)", len(roles), err)
+	}
+
+	editor, err := db.Role.GetByName(ctx, types.RoleEditor)
+	if err != nil || !slices.Contains(editor.Permissions, types.PermissionTicketAssign) {
+		t.Fatalf("expected editor to hold ticket:assign, got %v (%v)", editor, err)
+	}
+
+	support := types.CreateRoleDefinition("support", []types.Permission{types.PermissionTicketRead})
+	support.OrgID = types.DefaultOrganizationID
+
+	_, err = db.Role.Create(ctx, support)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	support.Permissions = []types.Permission{types.PermissionTicketRead, types.PermissionTicketStatus}
+
+	_, err = db.Role.Update(ctx, support)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	support, err = db.Role.GetByName(ctx, "support")
+	if err != nil || len(support.Permissions) != 2 {
+		t.Fatalf("expected updated permissions, got %v (%v)", support, err)
+	}
+
+	err = db.Role.Delete(ctx, "support")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Role.GetByName(ctx, "support")
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected deleted role to be missing, got: %v", err)
+	}
+
+	err = db.Role.Delete(ctx, "support")
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected deleting a missing role to fail, got: %v", err)
+	}
+
+	org, err := db.Organization.Create(ctx, types.CreateOrganization("acme"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	acme := data.WithOrganization(ctx, org.ID)
+
+	roles, err = db.Role.Get(acme)
+	if err != nil || len(roles) != 3 || roles[0].OrgID != org.ID {
+		t.Fatalf("expected a new organization to get its own built in roles, got %v (%v)", roles, err)
+	}
+
+	editor, err = db.Role.GetByName(acme, types.RoleEditor)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	editor.Permissions = []types.Permission{}
+
+	_, err = db.Role.Update(data.WithOrganization(ctx, types.DefaultOrganizationID), editor)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected another organization's role to be out of reach, got: %v", err)
+	}
+
+	_, err = db.Role.Update(acme, editor)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	editor, err = db.Role.GetByName(data.WithOrganization(ctx, types.DefaultOrganizationID), types.RoleEditor)
+	if err != nil || !slices.Contains(editor.Permissions, types.PermissionTicketAssign) {
+		t.Fatalf("expected the default organization's editor to be untouched, got %v (%v)", editor, err)
+	}
+}
+
+func TestMemoryRoles(t *testing.T) {
+	testRoles(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteRoles(t *testing.T) {
+	testRoles(t, createSQLiteDataAdapter(t))
+}
+
+func TestPolicyFixesAuthorChecks(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+	other, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "other", Role: types.RoleUser})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	token := createToken(t, db, user)
+
+	res := doRequest(t, server, http.MethodPost, "/ticket", token, &api.CreateTicketRequest{Title: "title", Description: "description", AuthorID: user.ID, AssigneeIDs: []int{other.ID}})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected an author to need ticket:assign to assign, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", token, &api.CreateTicketRequest{Title: "title", Description: "description"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	id := int(res.Data.(map[string]any)["id"].(float64))
+
+	if author := int(res.Data.(map[string]any)["author_id"].(float64)); author != user.ID {
+		t.Fatalf("expected the author to default to the caller, got %d", author)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/ticket/%d", id), token, &api.CreateTicketRequest{AuthorID: user.ID, AssigneeIDs: []int{other.ID}})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected an author to need ticket:assign to assign, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/account/%d", user.ID), token, &api.UpdateAccountRequest{Role: types.RoleAdmin})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected a user to be unable to promote themselves, got %d: %s", res.Status, res.Message)
+	}
+}
+
+func TestCustomRoles(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{Username: "admin", Role: types.RoleAdmin})
+	author, _ := db.Account.Create(ctx, &types.Account{Username: "author", Role: types.RoleUser})
+	ticket, _ := db.Ticket.Create(ctx, types.CreateTicket("title", "description", author.ID, types.StatusOpen, []int{}))
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+
+	res := doRequest(t, server, http.MethodPost, "/role", adminToken, &api.RoleRequest{Name: "support", Permissions: []types.Permission{"ticket:fly"}})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected unknown permission to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/role", adminToken, &api.RoleRequest{Name: "support", Permissions: []types.Permission{types.PermissionTicketRead, types.PermissionTicketStatus}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/account", adminToken, &api.CreateAccountRequest{Username: "ghost", Password: "password", Role: "missing"})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected unknown role to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/account", adminToken, &api.CreateAccountRequest{Username: "agent", Password: "password", Role: "support"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	agent := &types.Account{ID: int(res.Data.(map[string]any)["id"].(float64)), Role: "support"}
+	agentToken := createToken(t, db, agent)
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d", ticket.ID), agentToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected support to read any ticket, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/ticket/%d", ticket.ID), agentToken, &api.CreateTicketRequest{Status: types.StatusActive})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected support to move the ticket through the workflow, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/ticket/%d", ticket.ID), agentToken, &api.CreateTicketRequest{Title: "renamed"})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected support to need ticket:update, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, "/role/support", adminToken, &api.RoleRequest{Permissions: []types.Permission{}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d", ticket.ID), agentToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected the mapping change to apply immediately, got %d: %s", res.Status, res.Message)
+	}
+
+	for path, method := range map[string]string{"/role/admin": http.MethodPut, "/role/user": http.MethodDelete} {
+		res = doRequest(t, server, method, path, adminToken, &api.RoleRequest{})
+		if res.Status != http.StatusBadRequest {
+			t.Fatalf("expected %s %s to be rejected, got %d: %s", method, path, res.Status, res.Message)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/role", agentToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected role management to need role:manage, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, "/role/support", adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+}
+
+func TestRolesAreScopedToOrganizations(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	org, _ := db.Organization.Create(ctx, types.CreateOrganization("acme"))
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	intruder, _ := db.Account.Create(ctx, &types.Account{OrgID: org.ID, Username: "intruder", Role: types.RoleAdmin})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	intruderToken := createToken(t, db, intruder)
+
+	res := doRequest(t, server, http.MethodPost, "/role", adminToken, &api.RoleRequest{Name: "support", Permissions: []types.Permission{types.PermissionTicketRead}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/role/support", intruderToken, nil)
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected another organization's role to be hidden, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, "/role/support", intruderToken, &api.RoleRequest{Permissions: []types.Permission{types.PermissionTicketDelete}})
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected another organization's role to be out of reach, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodDelete, "/role/support", intruderToken, nil)
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected another organization's role to be out of reach, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, "/role/editor", intruderToken, &api.RoleRequest{Permissions: []types.Permission{}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	editor, err := db.Role.GetByName(data.WithOrganization(ctx, types.DefaultOrganizationID), types.RoleEditor)
+	if err != nil || !slices.Contains(editor.Permissions, types.PermissionTicketAssign) {
+		t.Fatalf("expected the default organization's editor to be untouched, got %v (%v)", editor, err)
+	}
+
+	support, err := db.Role.GetByName(data.WithOrganization(ctx, types.DefaultOrganizationID), "support")
+	if err != nil || !slices.Equal(support.Permissions, []types.Permission{types.PermissionTicketRead}) {
+		t.Fatalf("expected the default organization's support role to be untouched, got %v (%v)", support, err)
+	}
+}
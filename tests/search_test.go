@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func TestSearchRanksTitleMatches(t *testing.T) {
+	index := search.CreateIndex()
+	index.IndexTicket(&types.Ticket{ID: 1, Title: "Email not syncing", Description: "The printer is fine"})
+	index.IndexTicket(&types.Ticket{ID: 2, Title: "Printer jammed", Description: "Paper stuck in the printer tray"})
+	index.IndexTicket(&types.Ticket{ID: 3, Title: "VPN", Description: "Cannot connect"})
+
+	hits := index.Search("printers")
+	if len(hits) != 2 || hits[0].TicketID != 2 {
+		t.Fatalf("expected ticket 2 to rank first of two hits, got %+v", hits)
+	}
+
+	if hits[0].Field != "title" || hits[0].Snippet != "<mark>Printer</mark> jammed" {
+		t.Fatalf("expected highlighted title snippet, got %s: %s", hits[0].Field, hits[0].Snippet)
+	}
+
+	if hits[1].Field != "description" || !strings.Contains(hits[1].Snippet, "<mark>printer</mark>") {
+		t.Fatalf("expected highlighted description snippet, got %s: %s", hits[1].Field, hits[1].Snippet)
+	}
+}
+
+func TestSearchEscapesSnippets(t *testing.T) {
+	index := search.CreateIndex()
+	index.IndexMessage(&types.Message{ID: "m", TicketID: 1, Content: "<script>alert(1)</script> crash on login"})
+
+	hits := index.Search("crash")
+	if len(hits) != 1 || strings.Contains(hits[0].Snippet, "<script>") {
+		t.Fatalf("expected escaped snippet, got %+v", hits)
+	}
+}
+
+func TestSearchRemoveTicketRemovesMessages(t *testing.T) {
+	index := search.CreateIndex()
+	index.IndexTicket(&types.Ticket{ID: 1, Title: "Login"})
+	index.IndexMessage(&types.Message{ID: "m", TicketID: 1, Content: "login fails"})
+
+	index.RemoveTicket(1)
+
+	if hits := index.Search("login"); len(hits) != 0 {
+		t.Fatalf("expected no hits, got %+v", hits)
+	}
+}
+
+func TestSearchHandlerVisibility(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	index := search.CreateIndex()
+	db.UseIndexer(index)
+
+	alice, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "alice", Role: types.RoleUser})
+	bob, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "bob", Role: types.RoleUser})
+
+	alices := types.CreateTicket("Laptop overheating", "", alice.ID, types.StatusOpen, []int{})
+	alices.OrgID = types.DefaultOrganizationID
+	db.Ticket.Create(ctx, alices)
+
+	bobs := types.CreateTicket("Monitor flicker", "", bob.ID, types.StatusOpen, []int{})
+	bobs.OrgID = types.DefaultOrganizationID
+	bobs, _ = db.Ticket.Create(ctx, bobs)
+	message, _ := types.CreateMessage("m", bobs.ID, bob.ID, "my laptop also overheating")
+	db.Message.Create(ctx, message)
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, index, workflow.Default()).Handler())
+	defer server.Close()
+
+	token := createToken(t, db, alice)
+
+	res := doRequest(t, server, http.MethodGet, "/search?q=laptop", token, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	results := res.Data.([]any)
+	if len(results) != 1 || results[0].(map[string]any)["type"] != "ticket" {
+		t.Fatalf("expected only alice's ticket, got %v", results)
+	}
+
+	token = createToken(t, db, &types.Account{OrgID: types.DefaultOrganizationID, ID: 99, Role: types.RoleEditor})
+
+	res = doRequest(t, server, http.MethodGet, "/search?q=laptop", token, nil)
+	if len(res.Data.([]any)) != 2 {
+		t.Fatalf("expected editors to see both results, got %v", res.Data)
+	}
+}
@@ -0,0 +1,215 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/auth"
+	"ticketing-api/chat"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func testSessions(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	account, _ := db.Account.Create(ctx, &types.Account{Username: "user", Role: types.RoleUser})
+	other, _ := db.Account.Create(ctx, &types.Account{Username: "other", Role: types.RoleUser})
+
+	db.Session.Create(ctx, types.CreateSession("a", account.ID, "hash", time.Now().Add(time.Hour)))
+	db.Session.Create(ctx, types.CreateSession("b", account.ID, "hash", time.Now().Add(time.Hour)))
+	db.Session.Create(ctx, types.CreateSession("c", other.ID, "hash", time.Now().Add(time.Hour)))
+
+	err := db.Session.Rotate(ctx, "a", "hash", "rotated")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	err = db.Session.Rotate(ctx, "a", "hash", "again")
+	if _, ok := err.(*types.PreconditionFailed); !ok {
+		t.Fatalf("expected rotating a stale hash to fail, got: %v", err)
+	}
+
+	session, err := db.Session.GetByID(ctx, "a")
+	if err != nil || session.TokenHash != "rotated" || session.RevokedAt != nil {
+		t.Fatalf("unexpected session: %v (%v)", session, err)
+	}
+
+	err = db.Session.RevokeByAccountID(ctx, account.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	revocations := data.CreateRevocationList(db.Session)
+
+	for id, revoked := range map[string]bool{"a": true, "b": true, "c": false, "missing": true} {
+		if revocations.IsRevoked(ctx, id) != revoked {
+			t.Fatalf("expected session %s revoked to be %t", id, revoked)
+		}
+	}
+}
+
+func TestMemorySessions(t *testing.T) {
+	testSessions(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteSessions(t *testing.T) {
+	testSessions(t, createSQLiteDataAdapter(t))
+}
+
+func TestRefreshAndRevoke(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	auth.UseRevocationList(data.CreateRevocationList(db.Session))
+	t.Cleanup(func() { auth.UseRevocationList(nil) })
+
+	passwordHash, _ := auth.CreateHash("password")
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Password: passwordHash, Role: types.RoleAdmin})
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Password: passwordHash, Role: types.RoleEditor})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+
+	login := func() (string, string) {
+		res := doRequest(t, server, http.MethodPost, "/account/login", "", &api.LoginRequest{Username: "user", Password: "password"})
+		if res.Status != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+		}
+
+		data := res.Data.(map[string]any)
+		return data["token"].(string), data["refresh_token"].(string)
+	}
+
+	refresh := func(refreshToken string) *api.APIResponse {
+		return doRequest(t, server, http.MethodPost, "/account/refresh", "", &api.RefreshRequest{RefreshToken: refreshToken})
+	}
+
+	token, refreshToken := login()
+
+	res := refresh(refreshToken)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	rotated := res.Data.(map[string]any)["refresh_token"].(string)
+	if rotated == refreshToken {
+		t.Fatalf("expected refresh token to rotate")
+	}
+
+	res = refresh(refreshToken)
+	if res.Status != http.StatusUnauthorized {
+		t.Fatalf("expected reused refresh token to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	res = refresh(rotated)
+	if res.Status != http.StatusUnauthorized {
+		t.Fatalf("expected reuse to revoke the session, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/ticket", token, nil)
+	if res.Status != http.StatusForbidden && res.Status != http.StatusUnauthorized {
+		t.Fatalf("expected access token of revoked session to be rejected, got %d", res.Status)
+	}
+
+	token, _ = login()
+
+	res = doRequest(t, server, http.MethodPost, "/account/logout", token, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/account/%d", user.ID), token, nil)
+	if res.Status != http.StatusUnauthorized {
+		t.Fatalf("expected logged out token to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	token, _ = login()
+
+	res = doRequest(t, server, http.MethodDelete, fmt.Sprintf("/account/%d/sessions", user.ID), adminToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/account/%d", user.ID), token, nil)
+	if res.Status != http.StatusUnauthorized {
+		t.Fatalf("expected revoked sessions to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	token, _ = login()
+
+	res = doRequest(t, server, http.MethodPut, fmt.Sprintf("/account/%d", user.ID), adminToken, &api.UpdateAccountRequest{Role: types.RoleUser})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/account/%d", user.ID), token, nil)
+	if res.Status != http.StatusUnauthorized {
+		t.Fatalf("expected role change to revoke sessions, got %d: %s", res.Status, res.Message)
+	}
+}
+
+func TestChatEndsWithSession(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	auth.UseRevocationList(data.CreateRevocationList(db.Session))
+	t.Cleanup(func() { auth.UseRevocationList(nil) })
+
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+	ticket, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "chat", AuthorID: user.ID, Status: types.StatusOpen})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	token := createToken(t, db, user)
+
+	conn := dialChat(t, server, fmt.Sprintf("/ticket/%d", ticket.ID), token)
+	defer conn.Close()
+
+	res := sendChatAttachments(t, conn)
+	if res.Status != chat.StatusSuccess {
+		t.Fatalf("expected the message to be created, got %s: %s", res.Status, res.Message)
+	}
+
+	err := db.Session.RevokeByAccountID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	body, _ := json.Marshal(&chat.CreateMessageRequest{Content: "still here"})
+
+	err = websocket.JSON.Send(conn, &chat.MessageRequest{Action: chat.ActionCreate, Data: body})
+	if err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+
+	for {
+		res := &chat.WSMessage{}
+
+		err = websocket.JSON.Receive(conn, res)
+		if err != nil {
+			break
+		}
+
+		if res.Status == chat.StatusSuccess {
+			t.Fatalf("expected a revoked session to be unable to chat, got %v", res.Data)
+		}
+	}
+
+	messages, err := db.Message.Get(data.WithOrganization(ctx, types.DefaultOrganizationID), ticket.ID)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("expected only the message sent before revocation, got %v (%v)", messages, err)
+	}
+}
@@ -0,0 +1,350 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/chat"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/sla"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func testSLA(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	first, err := db.SLAPolicy.Create(ctx, types.CreateSLAPolicy(types.DefaultOrganizationID, "Standard", true, types.Calendar{Timezone: "Europe/Berlin", Hours: []types.BusinessHours{{Weekday: time.Monday, Start: "09:00", End: "17:00"}}}, []types.SLATarget{{Priority: types.PriorityUrgent, FirstResponse: 30, Resolution: 240}}, 15))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	second, err := db.SLAPolicy.Create(ctx, types.CreateSLAPolicy(types.DefaultOrganizationID, "Premium", true, types.Calendar{}, nil, 0))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	policies, err := db.SLAPolicy.Get(ctx)
+	if err != nil || len(policies) != 2 || policies[0].Default || !policies[1].Default {
+		t.Fatalf("expected the newer policy to take the default, got %v (%v)", policies, err)
+	}
+
+	found, err := db.SLAPolicy.GetByID(ctx, first.ID)
+	if err != nil || found.Calendar.Timezone != "Europe/Berlin" || len(found.Calendar.Hours) != 1 || found.Targets[0].Resolution != 240 || found.AtRiskMinutes != 15 {
+		t.Fatalf("expected the policy to be read back, got %v (%v)", found, err)
+	}
+
+	_, err = db.SLAPolicy.GetByID(data.WithOrganization(ctx, 2), second.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected the policy to be hidden from other organizations, got: %v", err)
+	}
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+
+	now := time.Now().UTC().Truncate(time.Second)
+	past := now.Add(-time.Hour)
+	soon := now.Add(10 * time.Minute)
+	later := now.Add(24 * time.Hour)
+
+	breached, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "breached", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityUrgent, SLA: types.SLA{FirstResponseDueAt: &past, ResolutionDueAt: &later}})
+	atRisk, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "at risk", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityHigh, DueAt: &soon, SLA: types.SLA{ResolutionDueAt: &soon}})
+	db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "on track", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityNormal, SLA: types.SLA{ResolutionDueAt: &later}})
+
+	ticket, err := db.Ticket.GetByID(ctx, atRisk.ID)
+	if err != nil || ticket.Priority != types.PriorityHigh || ticket.DueAt == nil || !ticket.DueAt.Equal(soon) || ticket.SLA.ResolutionDueAt == nil || ticket.SLA.FirstResponseDueAt != nil {
+		t.Fatalf("expected the due times to be read back, got %v (%v)", ticket, err)
+	}
+
+	tickets, _, err := db.Ticket.Get(ctx, &types.TicketFilter{SLA: types.SLABreached, SLANow: now}, nil)
+	if err != nil || len(tickets) != 1 || tickets[0].ID != breached.ID {
+		t.Fatalf("expected only the breached ticket, got %v (%v)", tickets, err)
+	}
+
+	tickets, _, err = db.Ticket.Get(ctx, &types.TicketFilter{SLA: types.SLAAtRisk, SLANow: now, SLAWindow: time.Hour}, nil)
+	if err != nil || len(tickets) != 1 || tickets[0].ID != atRisk.ID {
+		t.Fatalf("expected only the ticket at risk, got %v (%v)", tickets, err)
+	}
+
+	tickets, _, err = db.Ticket.Get(ctx, &types.TicketFilter{Priorities: []types.Priority{types.PriorityUrgent, types.PriorityHigh}}, nil)
+	if err != nil || len(tickets) != 2 {
+		t.Fatalf("expected the urgent and high priority tickets, got %v (%v)", tickets, err)
+	}
+
+	err = db.Ticket.RecordFirstResponse(ctx, breached.ID, now)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	db.Ticket.RecordFirstResponse(ctx, breached.ID, later)
+
+	ticket, _ = db.Ticket.GetByID(ctx, breached.ID)
+	if ticket.SLA.FirstResponseAt == nil || !ticket.SLA.FirstResponseAt.Equal(now) {
+		t.Fatalf("expected the first response to be kept, got %v", ticket.SLA.FirstResponseAt)
+	}
+
+	tickets, _, _ = db.Ticket.Get(ctx, &types.TicketFilter{SLA: types.SLABreached, SLANow: now}, nil)
+	if len(tickets) != 1 {
+		t.Fatalf("expected a late response to stay breached, got %v", tickets)
+	}
+
+	for i, expected := range []bool{true, false} {
+		marked, err := db.Ticket.MarkBreached(ctx, breached.ID, types.SLAFirstResponse, now)
+		if err != nil || marked != expected {
+			t.Fatalf("expected marking %d to report %v, got %v (%v)", i, expected, marked, err)
+		}
+	}
+
+	tickets, _, _ = db.Ticket.Get(ctx, &types.TicketFilter{SLA: types.SLABreached, SLANow: now, SLAUnflagged: true}, nil)
+	if len(tickets) != 0 {
+		t.Fatalf("expected flagged breaches to be left out, got %v", tickets)
+	}
+
+	tickets, _, _ = db.Ticket.Get(ctx, &types.TicketFilter{SLA: types.SLABreached, SLANow: now}, nil)
+	if len(tickets) != 1 {
+		t.Fatalf("expected a flagged ticket to stay breached, got %v", tickets)
+	}
+
+	ticket.Priority = types.PriorityLow
+	ticket.SLA.ResolvedAt = &now
+
+	ticket, err = db.Ticket.Update(ctx, ticket)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	ticket, _ = db.Ticket.GetByID(ctx, breached.ID)
+	if ticket.Priority != types.PriorityLow || ticket.SLA.ResolvedAt == nil || ticket.SLA.FirstResponseAt == nil || ticket.SLA.FirstResponseBreachedAt == nil {
+		t.Fatalf("expected the update to keep the recorded timers, got %v", ticket.SLA)
+	}
+
+	err = db.SLAPolicy.Delete(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
+
+func TestMemorySLA(t *testing.T) {
+	testSLA(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteSLA(t *testing.T) {
+	testSLA(t, createSQLiteDataAdapter(t))
+}
+
+func TestBusinessHours(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	weekdays := []types.BusinessHours{}
+	for day := time.Monday; day <= time.Friday; day++ {
+		weekdays = append(weekdays, types.BusinessHours{Weekday: day, Start: "09:00", End: "17:00"})
+	}
+
+	calendar := types.Calendar{Timezone: "Europe/Berlin", Hours: weekdays, Holidays: []string{"2026-10-19"}}
+
+	tests := []struct {
+		start    time.Time
+		d        time.Duration
+		expected time.Time
+	}{
+		{time.Date(2026, 10, 14, 10, 0, 0, 0, berlin), 2 * time.Hour, time.Date(2026, 10, 14, 12, 0, 0, 0, berlin)},
+		{time.Date(2026, 10, 14, 7, 0, 0, 0, berlin), time.Hour, time.Date(2026, 10, 14, 10, 0, 0, 0, berlin)},
+		{time.Date(2026, 10, 14, 16, 0, 0, 0, berlin), 2 * time.Hour, time.Date(2026, 10, 15, 10, 0, 0, 0, berlin)},
+		{time.Date(2026, 10, 16, 16, 30, 0, 0, berlin), time.Hour, time.Date(2026, 10, 20, 9, 30, 0, 0, berlin)},
+		{time.Date(2026, 10, 14, 10, 0, 0, 0, berlin).UTC(), 16 * time.Hour, time.Date(2026, 10, 16, 10, 0, 0, 0, berlin)},
+	}
+
+	for _, test := range tests {
+		actual := sla.Add(calendar, test.start, test.d)
+		if !actual.Equal(test.expected) {
+			t.Errorf("expected %s after %s to be %s, got %s", test.d, test.start, test.expected, actual.In(berlin))
+		}
+	}
+
+	start := time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC)
+	if actual := sla.Add(types.Calendar{}, start, 3*time.Hour); !actual.Equal(start.Add(3 * time.Hour)) {
+		t.Errorf("expected a calendar without hours to count every hour, got %s", actual)
+	}
+
+	err := sla.Validate(&types.SLAPolicy{Name: "Broken", Calendar: types.Calendar{Hours: []types.BusinessHours{{Weekday: time.Monday, Start: "17:00", End: "09:00"}}}})
+	if err == nil {
+		t.Errorf("expected hours ending before they start to be rejected")
+	}
+}
+
+func TestSLAChecker(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+
+	ticket, _ := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "late", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityUrgent, SLA: types.SLA{FirstResponseDueAt: &past, ResolutionDueAt: &past}})
+
+	emitted := []*sla.Breach{}
+	checker := sla.CreateChecker(db, func(breach *sla.Breach) {
+		emitted = append(emitted, breach)
+	})
+
+	breaches, err := checker.Check(ctx, now)
+	if err != nil || len(breaches) != 2 || len(emitted) != 2 || emitted[0].Metric != types.SLAFirstResponse || emitted[1].Metric != types.SLAResolution {
+		t.Fatalf("expected both metrics to breach, got %v (%v)", emitted, err)
+	}
+
+	breaches, err = checker.Check(ctx, now.Add(time.Minute))
+	if err != nil || len(breaches) != 0 || len(emitted) != 2 {
+		t.Fatalf("expected breaches to be emitted once, got %v (%v)", breaches, err)
+	}
+
+	changes, _ := db.History.Get(ctx, types.EntityTicket, ticket.ID)
+	if len(changes) != 2 || changes[0].Field != "first_response_breached_at" {
+		t.Fatalf("expected the breaches to be recorded, got %v", changes)
+	}
+
+	org, _ := db.Organization.Create(ctx, types.CreateOrganization("acme"))
+
+	for i := 0; i <= data.MaxPageLimit; i++ {
+		db.Ticket.Create(ctx, &types.Ticket{OrgID: org.ID, Title: fmt.Sprintf("late %d", i), AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, Priority: types.PriorityUrgent, SLA: types.SLA{FirstResponseDueAt: &past}})
+	}
+
+	breaches, err = checker.Check(ctx, now.Add(time.Minute))
+	if err != nil || len(breaches) != data.MaxPageLimit+1 {
+		t.Fatalf("expected the breaches past the first page to be flagged, got %d (%v)", len(breaches), err)
+	}
+
+	breaches, err = checker.Check(ctx, now.Add(2*time.Minute))
+	if err != nil || len(breaches) != 0 {
+		t.Fatalf("expected breaches to be emitted once, got %d (%v)", len(breaches), err)
+	}
+}
+
+func TestSLAHandlers(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	user, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "user", Role: types.RoleUser})
+	editor, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "editor", Role: types.RoleEditor})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	userToken := createToken(t, db, user)
+	editorToken := createToken(t, db, editor)
+
+	policy := &api.SLAPolicyRequest{Name: "Standard", Default: true, Targets: []types.SLATarget{{Priority: types.PriorityUrgent, FirstResponse: 120, Resolution: 480}}}
+
+	res := doRequest(t, server, http.MethodPost, "/sla", userToken, policy)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected users to be unable to manage sla policies, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/sla", adminToken, &api.SLAPolicyRequest{Name: "Broken", Targets: []types.SLATarget{{Priority: "critical"}}})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected an unknown priority to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/sla", adminToken, policy)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", userToken, &api.CreateTicketRequest{Title: "outage", Description: "everything is down", Priority: types.PriorityUrgent})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected setting a priority to need ticket:assign, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", userToken, &api.CreateTicketRequest{Title: "outage", Description: "everything is down"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	ticket := res.Data.(map[string]any)
+	path := fmt.Sprintf("/ticket/%d", int(ticket["id"].(float64)))
+
+	if ticket["priority"] != string(types.PriorityNormal) || len(ticket["sla"].(map[string]any)) != 0 {
+		t.Fatalf("expected a normal ticket without targets, got %v", ticket)
+	}
+
+	res = doRequest(t, server, http.MethodPatch, path, editorToken, map[string]any{"priority": types.PriorityUrgent})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	timers := res.Data.(map[string]any)["sla"].(map[string]any)
+	if timers["first_response_due_at"] == nil || timers["resolution_due_at"] == nil {
+		t.Fatalf("expected raising the priority to set due times, got %v", timers)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/ticket?sla=at_risk", adminToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 0 {
+		t.Fatalf("expected nothing at risk yet, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/ticket?sla=late", adminToken, nil)
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected an unknown sla state to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	sendChatMessage(t, server, path, editorToken, "on it")
+
+	res = doRequest(t, server, http.MethodGet, path, adminToken, nil)
+	timers = res.Data.(map[string]any)["sla"].(map[string]any)
+	if timers["first_response_at"] == nil {
+		t.Fatalf("expected the editor's message to count as first response, got %v", timers)
+	}
+
+	res = doRequest(t, server, http.MethodPatch, path, editorToken, map[string]any{"status": types.StatusResolved, "resolution": "restarted"})
+	if res.Status != http.StatusOK || res.Data.(map[string]any)["sla"].(map[string]any)["resolved_at"] == nil {
+		t.Fatalf("expected resolving to stop the resolution timer, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, "/ticket?sla=breached", adminToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 0 {
+		t.Fatalf("expected nothing breached, got %d: %v", res.Status, res.Data)
+	}
+}
+
+// sendChatMessage posts a message to the ticket's chat and waits for it to be
+// broadcast back.
+func sendChatMessage(t *testing.T, server *httptest.Server, path string, token string, content string) {
+	t.Helper()
+
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+path+"/chat", server.URL)
+	if err != nil {
+		t.Fatalf("failed to configure websocket: %v", err)
+	}
+
+	config.Header.Set("Authorization", "Bearer "+token)
+
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("failed to connect to chat: %v", err)
+	}
+	defer conn.Close()
+
+	body, _ := json.Marshal(&chat.CreateMessageRequest{Content: content})
+
+	err = websocket.JSON.Send(conn, &chat.MessageRequest{Action: chat.ActionCreate, Data: body})
+	if err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+
+	res := &chat.WSMessage{}
+
+	err = websocket.JSON.Receive(conn, res)
+	if err != nil || res.Status != chat.StatusSuccess {
+		t.Fatalf("expected the message to be created, got %v (%v)", res, err)
+	}
+}
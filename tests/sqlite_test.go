@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"ticketing-api/data"
+	"ticketing-api/data/sqlite"
+	"ticketing-api/types"
+	"time"
+)
+
+func createSQLiteDataAdapter(t *testing.T) *data.DataAdapter {
+	t.Helper()
+
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open sqlite db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	err = sqlite.Migrate(db)
+	if err != nil {
+		t.Fatalf("failed to migrate sqlite db: %v", err)
+	}
+
+	return data.CreateDataAdapter(
+		sqlite.CreateAccountAdapter(db),
+		sqlite.CreateTicketAdapter(db),
+		sqlite.CreateMessageAdapter(db),
+		sqlite.CreateHistoryAdapter(db),
+		sqlite.CreateSessionAdapter(db),
+		sqlite.CreateRoleAdapter(db),
+		sqlite.CreateOrganizationAdapter(db),
+		sqlite.CreateMembershipAdapter(db),
+		sqlite.CreateTeamAdapter(db),
+		sqlite.CreateAgentAdapter(db),
+		sqlite.CreateAssignmentAdapter(db),
+		sqlite.CreateSLAPolicyAdapter(db),
+		sqlite.CreateCategoryAdapter(db),
+		sqlite.CreateCustomFieldAdapter(db),
+		sqlite.CreateAttachmentAdapter(db),
+		sqlite.CreateLinkAdapter(db),
+		sqlite.CreateNotificationAdapter(db),
+		data.CreateSQLUnitOfWork(db, func(tx data.DBTX) *data.DataAdapter {
+			return data.CreateDataAdapter(sqlite.CreateAccountAdapter(tx), sqlite.CreateTicketAdapter(tx), sqlite.CreateMessageAdapter(tx), sqlite.CreateHistoryAdapter(tx), sqlite.CreateSessionAdapter(tx), sqlite.CreateRoleAdapter(tx), sqlite.CreateOrganizationAdapter(tx), sqlite.CreateMembershipAdapter(tx), sqlite.CreateTeamAdapter(tx), sqlite.CreateAgentAdapter(tx), sqlite.CreateAssignmentAdapter(tx), sqlite.CreateSLAPolicyAdapter(tx), sqlite.CreateCategoryAdapter(tx), sqlite.CreateCustomFieldAdapter(tx), sqlite.CreateAttachmentAdapter(tx), sqlite.CreateLinkAdapter(tx), sqlite.CreateNotificationAdapter(tx), nil)
+		}),
+	)
+}
+
+func TestSQLiteMigrateTwice(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open sqlite db: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 2; i++ {
+		err = sqlite.Migrate(db)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+}
+
+func TestSQLiteMessageOrdering(t *testing.T) {
+	db := createSQLiteDataAdapter(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, id := range []string{"b", "a", "c"} {
+		_, err := db.Message.Create(ctx, &types.Message{ID: id, TicketID: 1, CreatedAt: now.Add(time.Duration(i%2) * time.Second)})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	messages, err := db.Message.Get(ctx, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	ids := ""
+	for _, message := range messages {
+		ids += message.ID
+	}
+
+	if ids != "abc" {
+		t.Fatalf("expected order abc, got %s", ids)
+	}
+
+	message, err := db.Message.GetByID(ctx, "a", messages[0].CreatedAt.In(time.FixedZone("x", 3600)), 1)
+	if err != nil || message.ID != "a" {
+		t.Fatalf("expected message a, got %v (%v)", message, err)
+	}
+}
@@ -0,0 +1,210 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func testTeams(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+	agents := []*types.Account{}
+
+	for i := range 3 {
+		agent, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: fmt.Sprintf("agent%d", i), Role: types.RoleUser})
+		agents = append(agents, agent)
+	}
+
+	team, err := db.Team.Create(ctx, types.CreateTeam(types.DefaultOrganizationID, "Billing", []int{agents[0].ID, agents[1].ID, agents[2].ID}))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	teams, err := db.Team.GetByAccountID(ctx, agents[1].ID)
+	if err != nil || len(teams) != 1 || teams[0].ID != team.ID || len(teams[0].MemberIDs) != 3 {
+		t.Fatalf("expected the agent to be in billing, got %v (%v)", teams, err)
+	}
+
+	_, err = db.Team.GetByID(data.WithOrganization(ctx, 2), team.ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected team to be hidden from other organizations, got: %v", err)
+	}
+
+	queued := []int{}
+
+	for i := range 3 {
+		ticket, err := db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: fmt.Sprintf("ticket %d", i), AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}, TeamID: team.ID})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		queued = append(queued, ticket.ID)
+	}
+
+	db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "assigned", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{agents[0].ID}, TeamID: team.ID})
+	db.Ticket.Create(ctx, &types.Ticket{OrgID: types.DefaultOrganizationID, Title: "elsewhere", AuthorID: author.ID, Status: types.StatusOpen, AssigneeIDs: []int{}})
+
+	queue, _, err := db.Ticket.Get(ctx, &types.TicketFilter{Statuses: types.QueueStatuses, TeamIDs: []int{team.ID}, Unassigned: true}, &types.Page{Sort: "created_at"})
+	if err != nil || len(queue) != 3 || queue[0].ID != queued[0] || queue[0].TeamID != team.ID {
+		t.Fatalf("expected the three queued tickets oldest first, got %v (%v)", queue, err)
+	}
+
+	claimed := make([]int, len(agents))
+	wg := sync.WaitGroup{}
+
+	for i, agent := range agents {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ticket, err := db.Ticket.Claim(ctx, team.ID, agent.ID)
+			if err != nil {
+				t.Errorf("expected no error, got: %v", err)
+				return
+			}
+
+			if len(ticket.AssigneeIDs) != 1 || ticket.AssigneeIDs[0] != agent.ID || ticket.Version != 2 {
+				t.Errorf("expected the ticket to be assigned to the agent, got %v", ticket)
+			}
+
+			claimed[i] = ticket.ID
+		}()
+	}
+
+	wg.Wait()
+
+	slices.Sort(claimed)
+	if !slices.Equal(claimed, queued) {
+		t.Fatalf("expected every queued ticket to be claimed once, got %v", claimed)
+	}
+
+	_, err = db.Ticket.Claim(ctx, team.ID, agents[0].ID)
+	if _, ok := err.(*types.NotFound); !ok {
+		t.Fatalf("expected claiming from an empty queue to fail, got: %v", err)
+	}
+
+	err = db.Team.Delete(ctx, team.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	ticket, err := db.Ticket.GetByID(ctx, queued[0])
+	if err != nil || ticket.TeamID != 0 {
+		t.Fatalf("expected deleting the team to take tickets out of its queue, got %v (%v)", ticket, err)
+	}
+}
+
+func TestMemoryTeams(t *testing.T) {
+	testTeams(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteTeams(t *testing.T) {
+	testTeams(t, createSQLiteDataAdapter(t))
+}
+
+func TestTeamQueue(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	admin, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "admin", Role: types.RoleAdmin})
+	agent, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "agent", Role: types.RoleUser})
+	outsider, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "outsider", Role: types.RoleUser})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	adminToken := createToken(t, db, admin)
+	agentToken := createToken(t, db, agent)
+	outsiderToken := createToken(t, db, outsider)
+
+	res := doRequest(t, server, http.MethodPost, "/team", agentToken, &api.TeamRequest{Name: "Network"})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected agents to be unable to create teams, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/team", adminToken, &api.TeamRequest{Name: "Network", MemberIDs: []int{agent.ID}})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	teamID := int(res.Data.(map[string]any)["id"].(float64))
+
+	res = doRequest(t, server, http.MethodPost, "/team", adminToken, &api.TeamRequest{Name: "network"})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected a duplicate team name to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", outsiderToken, &api.CreateTicketRequest{Title: "title", Description: "description", TeamID: teamID})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected routing to a team to need ticket:assign, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", adminToken, &api.CreateTicketRequest{Title: "title", Description: "description", TeamID: teamID + 1})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected routing to a missing team to fail, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, "/ticket", adminToken, &api.CreateTicketRequest{Title: "title", Description: "description", TeamID: teamID})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	ticketID := int(res.Data.(map[string]any)["id"].(float64))
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/team/%d/queue", teamID), agentToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 {
+		t.Fatalf("expected one ticket in the queue, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/team/%d/queue", teamID), outsiderToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected the queue to be hidden from non-members, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d", ticketID), agentToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected team members to read queued tickets, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d", ticketID), outsiderToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected queued tickets to be hidden from non-members, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, fmt.Sprintf("/team/%d/queue/claim", teamID), outsiderToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected non-members to be unable to claim, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, fmt.Sprintf("/team/%d/queue/claim", teamID), agentToken, nil)
+	if res.Status != http.StatusOK || int(res.Data.(map[string]any)["id"].(float64)) != ticketID {
+		t.Fatalf("expected the agent to claim the ticket, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodPost, fmt.Sprintf("/team/%d/queue/claim", teamID), agentToken, nil)
+	if res.Status != http.StatusNotFound {
+		t.Fatalf("expected the queue to be empty, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket/%d/history", ticketID), adminToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) == 0 {
+		t.Fatalf("expected the claim to be recorded, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, fmt.Sprintf("/ticket?team=%d", teamID), adminToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 {
+		t.Fatalf("expected the ticket to stay routed to the team, got %d: %v", res.Status, res.Data)
+	}
+}
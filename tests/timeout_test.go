@@ -0,0 +1,66 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+	"time"
+)
+
+type slowTicketSocket struct {
+	data.TicketSocket
+}
+
+func (s *slowTicketSocket) Get(ctx context.Context, filter *types.TicketFilter, page *types.Page) ([]*types.Ticket, *types.PageInfo, error) {
+	<-ctx.Done()
+	return nil, nil, ctx.Err()
+}
+
+func TestParseTimeouts(t *testing.T) {
+	timeouts, err := api.ParseTimeouts("5s", "GET /ticket=30s, GET /account/{id}=1s")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if timeouts.For("GET /ticket") != 30*time.Second {
+		t.Fatalf("expected 30s, got %s", timeouts.For("GET /ticket"))
+	}
+
+	if timeouts.For("GET /account/{id}") != time.Second {
+		t.Fatalf("expected 1s, got %s", timeouts.For("GET /account/{id}"))
+	}
+
+	if timeouts.For("GET /ping") != 5*time.Second {
+		t.Fatalf("expected 5s, got %s", timeouts.For("GET /ping"))
+	}
+}
+
+func TestParseTimeoutsInvalid(t *testing.T) {
+	_, err := api.ParseTimeouts("", "GET /ticket")
+	if err == nil {
+		t.Fatalf("expected error for invalid route timeout, got none")
+	}
+}
+
+func TestRouteTimeout(t *testing.T) {
+	db := createMemoryDataAdapter()
+	db.Ticket = &slowTicketSocket{TicketSocket: db.Ticket}
+
+	timeouts, _ := api.ParseTimeouts("", "GET /ticket=10ms")
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, timeouts, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	token := createToken(t, db, &types.Account{ID: 1, Role: types.RoleAdmin})
+
+	res := doRequest(t, server, http.MethodGet, "/ticket", token, nil)
+	if res.Status != http.StatusGatewayTimeout {
+		t.Fatalf("expected status 504, got %d: %s", res.Status, res.Message)
+	}
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"ticketing-api/data"
+	"ticketing-api/types"
+)
+
+func testTransactionRollback(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	err := db.Transaction(ctx, func(tx *data.DataAdapter) error {
+		_, err := tx.Account.Create(ctx, &types.Account{Username: "rolled back", Role: types.RoleUser})
+		if err != nil {
+			return err
+		}
+
+		return fmt.Errorf("abort")
+	})
+	if err == nil {
+		t.Fatalf("expected error from transaction, got none")
+	}
+
+	_, err = db.Account.GetByUsername(ctx, "rolled back")
+	if err == nil {
+		t.Fatalf("expected account to be rolled back")
+	}
+}
+
+func testTicketUpdateAtomic(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{Username: "author", Role: types.RoleUser})
+
+	ticket, err := db.Ticket.Create(ctx, types.CreateTicket("title", "description", author.ID, types.StatusOpen, []int{author.ID}))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	ticket.Title = "changed"
+	ticket.AssigneeIDs = []int{author.ID, author.ID + 100}
+
+	_, err = db.Ticket.Update(ctx, ticket)
+	if err == nil {
+		t.Fatalf("expected error for missing assignee, got none")
+	}
+
+	ticket, err = db.Ticket.GetByID(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if ticket.Title != "title" || len(ticket.AssigneeIDs) != 1 || ticket.AssigneeIDs[0] != author.ID {
+		t.Fatalf("expected ticket to be unchanged, got %+v", ticket)
+	}
+}
+
+func TestMemoryTransactionRollback(t *testing.T) {
+	testTransactionRollback(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteTransactionRollback(t *testing.T) {
+	testTransactionRollback(t, createSQLiteDataAdapter(t))
+}
+
+func TestMemoryTicketUpdateAtomic(t *testing.T) {
+	testTicketUpdateAtomic(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteTicketUpdateAtomic(t *testing.T) {
+	testTicketUpdateAtomic(t, createSQLiteDataAdapter(t))
+}
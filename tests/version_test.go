@@ -0,0 +1,123 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func testStaleUpdate(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	account, _ := db.Account.Create(ctx, &types.Account{Username: "author", Role: types.RoleUser})
+	db.Ticket.Create(ctx, types.CreateTicket("title", "description", account.ID, types.StatusOpen, []int{}))
+
+	first, _ := db.Ticket.GetByID(ctx, 1)
+	second, _ := db.Ticket.GetByID(ctx, 1)
+
+	first.Title = "first"
+	first, err := db.Ticket.Update(ctx, first)
+	if err != nil || first.Version != 2 {
+		t.Fatalf("expected version 2, got %v (%v)", first, err)
+	}
+
+	second.Title = "second"
+	_, err = db.Ticket.Update(ctx, second)
+	if _, ok := err.(*types.PreconditionFailed); !ok {
+		t.Fatalf("expected precondition failed, got: %v", err)
+	}
+
+	ticket, _ := db.Ticket.GetByID(ctx, 1)
+	if ticket.Title != "first" || ticket.Version != 2 {
+		t.Fatalf("expected first update to win, got %v", ticket)
+	}
+
+	stale, _ := db.Account.GetByID(ctx, account.ID)
+	account.Role = types.RoleEditor
+
+	_, err = db.Account.Update(ctx, account)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, err = db.Account.Update(ctx, stale)
+	if _, ok := err.(*types.PreconditionFailed); !ok {
+		t.Fatalf("expected precondition failed, got: %v", err)
+	}
+}
+
+func TestMemoryStaleUpdate(t *testing.T) {
+	testStaleUpdate(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteStaleUpdate(t *testing.T) {
+	testStaleUpdate(t, createSQLiteDataAdapter(t))
+}
+
+func TestIfMatch(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	editor, _ := db.Account.Create(ctx, &types.Account{Username: "editor", Role: types.RoleEditor})
+	ticket, _ := db.Ticket.Create(ctx, types.CreateTicket("title", "description", editor.ID, types.StatusOpen, []int{}))
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	token := createToken(t, db, editor)
+	path := fmt.Sprintf("%s/ticket/%d", server.URL, ticket.ID)
+
+	send := func(method string, ifMatch string, body any) *http.Response {
+		buf := &bytes.Buffer{}
+		json.NewEncoder(buf).Encode(body)
+
+		req, _ := http.NewRequest(method, path, buf)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		res.Body.Close()
+
+		return res
+	}
+
+	res := send(http.MethodGet, "", nil)
+	etag := res.Header.Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf(`expected ETag "1", got %s`, etag)
+	}
+
+	res = send(http.MethodPut, etag, &api.CreateTicketRequest{Title: "first"})
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") != `"2"` {
+		t.Fatalf(`expected status 200 with ETag "2", got %d %s`, res.StatusCode, res.Header.Get("ETag"))
+	}
+
+	res = send(http.MethodPut, etag, &api.CreateTicketRequest{Title: "second"})
+	if res.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("expected status 412, got %d", res.StatusCode)
+	}
+
+	res = send(http.MethodPut, `"1", "2"`, &api.CreateTicketRequest{Title: "third"})
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+
+	res = send(http.MethodPut, "", &api.CreateTicketRequest{Title: "fourth"})
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") != `"4"` {
+		t.Fatalf(`expected status 200 with ETag "4", got %d %s`, res.StatusCode, res.Header.Get("ETag"))
+	}
+}
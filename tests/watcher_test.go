@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/data"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func testWatchers(t *testing.T, db *data.DataAdapter) {
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+	assignee, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "assignee", Role: types.RoleUser})
+	follower, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "follower", Role: types.RoleUser})
+
+	ticket := types.CreateTicket("outage", "site is down", author.ID, types.StatusOpen, []int{assignee.ID, author.ID})
+	ticket.OrgID = types.DefaultOrganizationID
+
+	ticket, err := db.Ticket.Create(ctx, ticket)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if !slices.Equal(ticket.WatcherIDs, []int{author.ID, assignee.ID}) {
+		t.Fatalf("expected the author and assignees to watch the new ticket, got %v", ticket.WatcherIDs)
+	}
+
+	err = db.Ticket.Watch(ctx, ticket.ID, follower.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	err = db.Ticket.Watch(ctx, ticket.ID, follower.ID)
+	if err != nil {
+		t.Fatalf("expected watching twice to succeed, got: %v", err)
+	}
+
+	db.Ticket.Watch(data.WithOrganization(ctx, 2), ticket.ID, author.ID)
+	db.Ticket.Unwatch(ctx, ticket.ID, author.ID)
+
+	ticket, _ = db.Ticket.GetByID(ctx, ticket.ID)
+	if !slices.Equal(ticket.WatcherIDs, []int{assignee.ID, follower.ID}) {
+		t.Fatalf("expected the follower to watch and the author to have stopped, got %v", ticket.WatcherIDs)
+	}
+
+	db.Ticket.Unwatch(ctx, ticket.ID, assignee.ID)
+
+	ticket.AssigneeIDs = []int{assignee.ID, follower.ID}
+	ticket.WatcherIDs = []int{}
+
+	ticket, err = db.Ticket.Update(ctx, ticket)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if !slices.Equal(ticket.WatcherIDs, []int{follower.ID}) {
+		t.Fatalf("expected updates to leave watchers be, got %v", ticket.WatcherIDs)
+	}
+
+	ticket.AssigneeIDs = []int{author.ID}
+
+	ticket, _ = db.Ticket.Update(ctx, ticket)
+
+	tickets, _, _ := db.Ticket.Get(ctx, nil, nil)
+	if !slices.Equal(ticket.WatcherIDs, []int{follower.ID, author.ID}) || !slices.Equal(tickets[0].WatcherIDs, ticket.WatcherIDs) {
+		t.Fatalf("expected a new assignee to start watching, got %v and %v", ticket.WatcherIDs, tickets[0].WatcherIDs)
+	}
+}
+
+func TestMemoryWatchers(t *testing.T) {
+	testWatchers(t, createMemoryDataAdapter())
+}
+
+func TestSQLiteWatchers(t *testing.T) {
+	testWatchers(t, createSQLiteDataAdapter(t))
+}
+
+func TestWatcherHandlers(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	author, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "author", Role: types.RoleUser})
+	colleague, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "colleague", Role: types.RoleUser})
+	stranger, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "stranger", Role: types.RoleUser})
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	authorToken := createToken(t, db, author)
+	colleagueToken := createToken(t, db, colleague)
+	strangerToken := createToken(t, db, stranger)
+
+	ticket := createEntity(t, server, "/ticket", authorToken, &api.CreateTicketRequest{Title: "vpn drops", Description: "every hour"})
+	watchers := fmt.Sprintf("/ticket/%d/watchers", ticket)
+	paths := []string{fmt.Sprintf("/ticket/%d", ticket), fmt.Sprintf("/ticket/%d/chat/message", ticket)}
+
+	res := doRequest(t, server, http.MethodPost, watchers, strangerToken, nil)
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected watching a ticket to need reading it, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, watchers, colleagueToken, &api.WatcherRequest{AccountID: stranger.ID})
+	if res.Status != http.StatusForbidden {
+		t.Fatalf("expected adding others to need updating the ticket, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, watchers, authorToken, &api.WatcherRequest{AccountID: 999})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected an unknown account to be rejected, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, watchers, authorToken, &api.WatcherRequest{AccountID: colleague.ID})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	if ids := res.Data.([]any); len(ids) != 2 || ids[0] != float64(author.ID) || ids[1] != float64(colleague.ID) {
+		t.Fatalf("expected the author and the colleague to watch, got %v", ids)
+	}
+
+	for _, path := range paths {
+		res = doRequest(t, server, http.MethodGet, path, colleagueToken, nil)
+		if res.Status != http.StatusOK {
+			t.Fatalf("expected a watcher to read %s, got %d: %s", path, res.Status, res.Message)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodDelete, watchers, colleagueToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	for _, path := range paths {
+		res = doRequest(t, server, http.MethodGet, path, colleagueToken, nil)
+		if res.Status != http.StatusForbidden {
+			t.Fatalf("expected a former watcher to be refused %s, got %d: %s", path, res.Status, res.Message)
+		}
+	}
+
+	res = doRequest(t, server, http.MethodDelete, watchers, authorToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 0 {
+		t.Fatalf("expected the author to stop watching, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodGet, paths[0], authorToken, nil)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected the author to keep reading the ticket, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPost, watchers, authorToken, nil)
+	if res.Status != http.StatusOK || len(res.Data.([]any)) != 1 {
+		t.Fatalf("expected the author to watch again, got %d: %v", res.Status, res.Data)
+	}
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"ticketing-api/api"
+	"ticketing-api/search"
+	"ticketing-api/types"
+	"ticketing-api/workflow"
+)
+
+func TestWorkflowCheck(t *testing.T) {
+	w := workflow.Default()
+	author := &workflow.Actor{ID: 1, Role: types.RoleUser}
+	assignee := &workflow.Actor{ID: 2, Role: types.RoleUser}
+	stranger := &workflow.Actor{ID: 3, Role: types.RoleUser}
+	editor := &workflow.Actor{ID: 4, Role: types.RoleEditor}
+
+	ticket := types.CreateTicket("title", "description", 1, types.StatusOpen, []int{2})
+
+	tests := []struct {
+		name       string
+		actor      *workflow.Actor
+		from       types.Status
+		to         types.Status
+		resolution string
+		ok         bool
+	}{
+		{"assignee starts work", assignee, types.StatusOpen, types.StatusActive, "", true},
+		{"stranger starts work", stranger, types.StatusOpen, types.StatusActive, "", false},
+		{"resolve without resolution", editor, types.StatusActive, types.StatusResolved, "", false},
+		{"resolve with resolution", assignee, types.StatusActive, types.StatusResolved, "fixed", true},
+		{"author closes", author, types.StatusResolved, types.StatusClosed, "", true},
+		{"assignee closes", assignee, types.StatusResolved, types.StatusClosed, "", false},
+		{"author reopens closed", author, types.StatusClosed, types.StatusOpen, "", false},
+		{"editor reopens closed", editor, types.StatusClosed, types.StatusOpen, "", true},
+		{"unchanged", stranger, types.StatusClosed, types.StatusClosed, "", true},
+	}
+
+	for _, test := range tests {
+		ticket.Resolution = test.resolution
+
+		err := w.Check(test.actor, ticket, test.from, test.to)
+		if test.ok && err != nil {
+			t.Fatalf("%s: expected no error, got: %v", test.name, err)
+		}
+
+		if !test.ok && err == nil {
+			t.Fatalf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestWorkflowCheckInitial(t *testing.T) {
+	w := workflow.Default()
+
+	status, err := w.CheckInitial("")
+	if err != nil || status != types.StatusOpen {
+		t.Fatalf("expected open, got %s (%v)", status, err)
+	}
+
+	_, err = w.CheckInitial(types.StatusClosed)
+	if err == nil {
+		t.Fatalf("expected error creating closed ticket")
+	}
+
+	_, err = w.CheckInitial("bogus")
+	if err == nil {
+		t.Fatalf("expected error creating ticket with unknown status")
+	}
+}
+
+func TestWorkflowLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.json")
+
+	os.WriteFile(path, []byte(`{"initial": ["open"], "transitions": [{"from": ["open"], "to": "done"}]}`), 0o644)
+
+	_, err := workflow.Load(path)
+	if err == nil {
+		t.Fatalf("expected error loading workflow with unknown status")
+	}
+
+	os.WriteFile(path, []byte(`{"initial": ["open", "pending"], "transitions": [{"from": ["open"], "to": "closed", "author": true}]}`), 0o644)
+
+	w, err := workflow.Load(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	next := w.Next(&workflow.Actor{ID: 1, Role: types.RoleUser}, types.CreateTicket("", "", 1, types.StatusOpen, []int{}), types.StatusOpen)
+	if len(next) != 1 || next[0] != types.StatusClosed {
+		t.Fatalf("expected [closed], got %v", next)
+	}
+}
+
+func TestWorkflowHandler(t *testing.T) {
+	db := createMemoryDataAdapter()
+	ctx := context.Background()
+
+	editor, _ := db.Account.Create(ctx, &types.Account{OrgID: types.DefaultOrganizationID, Username: "editor", Role: types.RoleEditor})
+	ticket := types.CreateTicket("title", "description", editor.ID, types.StatusOpen, []int{})
+	ticket.OrgID = types.DefaultOrganizationID
+	ticket, _ = db.Ticket.Create(ctx, ticket)
+
+	server := httptest.NewServer(api.CreateAPIServer("", db, nil, search.CreateIndex(), workflow.Default()).Handler())
+	defer server.Close()
+
+	token := createToken(t, db, editor)
+
+	res := doRequest(t, server, http.MethodPost, "/ticket", token, &api.CreateTicketRequest{Title: "title", AuthorID: editor.ID, Status: "bogus"})
+	if res.Status != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", res.Status, res.Message)
+	}
+
+	path := fmt.Sprintf("/ticket/%d", ticket.ID)
+
+	res = doRequest(t, server, http.MethodPut, path, token, &api.CreateTicketRequest{Status: types.StatusResolved})
+	if res.Status != http.StatusBadRequest || !strings.Contains(res.Message, "resolution") {
+		t.Fatalf("expected resolution to be required, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, path, token, &api.CreateTicketRequest{Status: types.StatusResolved, Resolution: "fixed"})
+	if res.Status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, path, token, &api.CreateTicketRequest{Status: types.StatusActive})
+	if res.Status != http.StatusBadRequest || !strings.Contains(res.Message, "allowed: closed, open") {
+		t.Fatalf("expected allowed statuses to be listed, got %d: %s", res.Status, res.Message)
+	}
+
+	res = doRequest(t, server, http.MethodPut, path, token, &api.CreateTicketRequest{Status: types.StatusOpen})
+	if res.Status != http.StatusOK || res.Data.(map[string]any)["resolution"] != "" {
+		t.Fatalf("expected reopening to clear the resolution, got %d: %v", res.Status, res.Data)
+	}
+
+	res = doRequest(t, server, http.MethodPut, path, token, &api.CreateTicketRequest{Status: types.StatusResolved})
+	if res.Status != http.StatusBadRequest || !strings.Contains(res.Message, "resolution") {
+		t.Fatalf("expected a reopened ticket to need a new resolution, got %d: %s", res.Status, res.Message)
+	}
+}
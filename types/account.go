@@ -12,13 +12,19 @@ const (
 	RoleEditor Role = "editor"
 )
 
+var Roles = []Role{RoleAdmin, RoleUser, RoleEditor}
+
 type Account struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"password"`
-	Role      Role      `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int        `json:"id"`
+	OrgID      int        `json:"org_id"`
+	Username   string     `json:"username"`
+	Password   string     `json:"password"`
+	Role       Role       `json:"role"`
+	SuperAdmin bool       `json:"super_admin"`
+	Version    int        `json:"version"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
 }
 
 func CreateAccount(username string, password string, role Role) (*Account, error) {
@@ -0,0 +1,65 @@
+package types
+
+import "time"
+
+type Availability string
+
+const (
+	AvailabilityAvailable Availability = "available"
+	AvailabilityAway      Availability = "away"
+	AvailabilityOffline   Availability = "offline"
+)
+
+var Availabilities = []Availability{AvailabilityAvailable, AvailabilityAway, AvailabilityOffline}
+
+// OpenStatuses are the statuses of tickets still being worked, which count
+// against an agent's capacity.
+var OpenStatuses = []Status{StatusOpen, StatusPending, StatusActive}
+
+// Agent makes an account eligible for automatic assignment in an
+// organization. Capacity caps the open tickets it is assigned, zero meaning
+// no limit. Load and LastAssignedAt are worked out when agents are read.
+type Agent struct {
+	OrgID          int          `json:"org_id"`
+	AccountID      int          `json:"account_id"`
+	Availability   Availability `json:"availability"`
+	Capacity       int          `json:"capacity"`
+	Skills         []string     `json:"skills"`
+	Load           int          `json:"load"`
+	LastAssignedAt *time.Time   `json:"last_assigned_at,omitempty"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+func CreateAgent(orgID int, accountID int, availability Availability, capacity int, skills []string) *Agent {
+	return &Agent{
+		OrgID:        orgID,
+		AccountID:    accountID,
+		Availability: availability,
+		Capacity:     capacity,
+		Skills:       skills,
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// Assignment records why automatic assignment gave a ticket to an agent, or
+// to nobody, in which case AccountID is zero.
+type Assignment struct {
+	ID        int       `json:"id"`
+	OrgID     int       `json:"org_id"`
+	TicketID  int       `json:"ticket_id"`
+	AccountID int       `json:"account_id,omitempty"`
+	Strategy  string    `json:"strategy"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func CreateAssignment(orgID int, ticketID int, accountID int, strategy string, reason string) *Assignment {
+	return &Assignment{
+		OrgID:     orgID,
+		TicketID:  ticketID,
+		AccountID: accountID,
+		Strategy:  strategy,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,30 @@
+package types
+
+import "time"
+
+// Attachment describes a file uploaded to a ticket. The content itself lives
+// in a blob store under Key.
+type Attachment struct {
+	ID          int       `json:"id"`
+	OrgID       int       `json:"org_id"`
+	TicketID    int       `json:"ticket_id"`
+	AuthorID    int       `json:"author_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Key         string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func CreateAttachment(orgID int, ticketID int, authorID int, name string, contentType string, size int64, key string) *Attachment {
+	return &Attachment{
+		OrgID:       orgID,
+		TicketID:    ticketID,
+		AuthorID:    authorID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+		Key:         key,
+		CreatedAt:   time.Now(),
+	}
+}
@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+// Category is a node of an organization's category tree. A category without
+// a parent sits at the root.
+type Category struct {
+	ID        int       `json:"id"`
+	OrgID     int       `json:"org_id"`
+	ParentID  int       `json:"parent_id,omitempty"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func CreateCategory(orgID int, parentID int, name string) *Category {
+	return &Category{
+		OrgID:     orgID,
+		ParentID:  parentID,
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
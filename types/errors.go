@@ -33,6 +33,17 @@ func (e *NotFound) Error() string {
 	return e.Message
 }
 
+type PreconditionFailed struct {
+	Message string
+}
+
+func (e *PreconditionFailed) Error() string {
+	if e.Message == "" {
+		return "precondition failed"
+	}
+	return e.Message
+}
+
 type BadRequest struct {
 	Message string
 }
@@ -43,3 +54,25 @@ func (e *BadRequest) Error() string {
 	}
 	return e.Message
 }
+
+type TooLarge struct {
+	Message string
+}
+
+func (e *TooLarge) Error() string {
+	if e.Message == "" {
+		return "request entity too large"
+	}
+	return e.Message
+}
+
+type UnsupportedMediaType struct {
+	Message string
+}
+
+func (e *UnsupportedMediaType) Error() string {
+	if e.Message == "" {
+		return "unsupported media type"
+	}
+	return e.Message
+}
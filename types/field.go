@@ -0,0 +1,50 @@
+package types
+
+import "time"
+
+type FieldType string
+
+const (
+	FieldText        FieldType = "text"
+	FieldNumber      FieldType = "number"
+	FieldDate        FieldType = "date"
+	FieldSelect      FieldType = "select"
+	FieldMultiSelect FieldType = "multi_select"
+)
+
+var FieldTypes = []FieldType{FieldText, FieldNumber, FieldDate, FieldSelect, FieldMultiSelect}
+
+// CustomField defines a value tickets of an organization can hold under Key
+// in their fields. Select fields only take one of their options, and
+// required fields must be given when a ticket is created.
+type CustomField struct {
+	ID        int       `json:"id"`
+	OrgID     int       `json:"org_id"`
+	Key       string    `json:"key"`
+	Name      string    `json:"name"`
+	Type      FieldType `json:"type"`
+	Options   []string  `json:"options"`
+	Required  bool      `json:"required"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FieldFilter keeps tickets whose field Key equals Value, or holds it when
+// the field is a multi-select.
+type FieldFilter struct {
+	Key   string
+	Value any
+}
+
+func CreateCustomField(orgID int, key string, name string, fieldType FieldType, options []string, required bool) *CustomField {
+	return &CustomField{
+		OrgID:     orgID,
+		Key:       key,
+		Name:      name,
+		Type:      fieldType,
+		Options:   options,
+		Required:  required,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
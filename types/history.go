@@ -0,0 +1,177 @@
+package types
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Entity string
+
+const (
+	EntityTicket  Entity = "ticket"
+	EntityAccount Entity = "account"
+)
+
+type Change struct {
+	ID        int       `json:"id"`
+	OrgID     int       `json:"org_id"`
+	Entity    Entity    `json:"entity"`
+	EntityID  int       `json:"entity_id"`
+	ActorID   int       `json:"actor_id"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func CreateChange(entity Entity, entityID int, actorID int, field string, oldValue string, newValue string) *Change {
+	return &Change{
+		Entity:    entity,
+		EntityID:  entityID,
+		ActorID:   actorID,
+		Field:     field,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		CreatedAt: time.Now(),
+	}
+}
+
+// TicketChanges lists the fields that differ between before and after, each
+// custom field as fields.<key>. A nil before records every field of a newly
+// created ticket, except a normal priority.
+func TicketChanges(actorID int, before *Ticket, after *Ticket) []*Change {
+	if before == nil {
+		before = &Ticket{Priority: PriorityNormal}
+	}
+
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"title", before.Title, after.Title},
+		{"description", before.Description, after.Description},
+		{"status", string(before.Status), string(after.Status)},
+		{"resolution", before.Resolution, after.Resolution},
+		{"author_id", formatID(before.AuthorID), formatID(after.AuthorID)},
+		{"assignee_ids", formatIDs(before.AssigneeIDs), formatIDs(after.AssigneeIDs)},
+		{"team_id", formatID(before.TeamID), formatID(after.TeamID)},
+		{"priority", string(before.Priority), string(after.Priority)},
+		{"due_at", formatTime(before.DueAt), formatTime(after.DueAt)},
+		{"category_id", formatID(before.CategoryID), formatID(after.CategoryID)},
+		{"labels", formatList(before.Labels), formatList(after.Labels)},
+	}
+
+	changes := []*Change{}
+
+	for _, field := range fields {
+		if field.old != field.new {
+			changes = append(changes, CreateChange(EntityTicket, after.ID, actorID, field.name, field.old, field.new))
+		}
+	}
+
+	for _, key := range fieldKeys(before.Fields, after.Fields) {
+		oldValue, newValue := formatValue(before.Fields[key]), formatValue(after.Fields[key])
+		if oldValue != newValue {
+			changes = append(changes, CreateChange(EntityTicket, after.ID, actorID, "fields."+key, oldValue, newValue))
+		}
+	}
+
+	return changes
+}
+
+// redacted stands in for values history must not hold.
+const redacted = "[redacted]"
+
+// AccountChanges lists the username, password and role changes between before
+// and after, a nil before standing for a new account. Password changes are
+// recorded with both values redacted, so that no hash ever reaches the history.
+func AccountChanges(actorID int, before *Account, after *Account) []*Change {
+	created := before == nil
+	if created {
+		before = &Account{}
+	}
+
+	changes := []*Change{}
+
+	if before.Username != after.Username {
+		changes = append(changes, CreateChange(EntityAccount, after.ID, actorID, "username", before.Username, after.Username))
+	}
+
+	if !created && before.Password != after.Password {
+		changes = append(changes, CreateChange(EntityAccount, after.ID, actorID, "password", redacted, redacted))
+	}
+
+	if before.Role != after.Role {
+		changes = append(changes, CreateChange(EntityAccount, after.ID, actorID, "role", string(before.Role), string(after.Role)))
+	}
+
+	return changes
+}
+
+func formatID(id int) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(id)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatList(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	b, _ := json.Marshal(items)
+	return string(b)
+}
+
+func formatValue(value any) string {
+	if value == nil {
+		return ""
+	}
+
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+// fieldKeys returns the keys set in either fields, sorted.
+func fieldKeys(before Fields, after Fields) []string {
+	keys := []string{}
+
+	for key := range before {
+		keys = append(keys, key)
+	}
+
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func formatIDs(ids []int) string {
+	if len(ids) == 0 {
+		return ""
+	}
+
+	b, _ := json.Marshal(ids)
+	return string(b)
+}
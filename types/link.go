@@ -0,0 +1,57 @@
+package types
+
+import "time"
+
+type LinkType string
+
+const (
+	LinkDuplicateOf LinkType = "duplicate_of"
+	LinkBlocks      LinkType = "blocks"
+	LinkRelated     LinkType = "related"
+	LinkParentOf    LinkType = "parent_of"
+)
+
+var LinkTypes = []LinkType{LinkDuplicateOf, LinkBlocks, LinkRelated, LinkParentOf}
+
+// LinkInverses name each link type as seen from its target, so that a ticket
+// that blocks another shows up there as blocked_by. Related links read the
+// same both ways.
+var LinkInverses = map[LinkType]LinkType{
+	LinkDuplicateOf: "duplicated_by",
+	LinkBlocks:      "blocked_by",
+	LinkRelated:     LinkRelated,
+	LinkParentOf:    "child_of",
+}
+
+// TicketLink relates the source ticket to the target ticket, e.g. the source
+// is a duplicate of, blocks or is the parent of the target.
+type TicketLink struct {
+	ID        int       `json:"id"`
+	OrgID     int       `json:"org_id"`
+	SourceID  int       `json:"source_id"`
+	TargetID  int       `json:"target_id"`
+	Type      LinkType  `json:"type"`
+	CreatedBy int       `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func CreateTicketLink(orgID int, sourceID int, targetID int, linkType LinkType, createdBy int) *TicketLink {
+	return &TicketLink{
+		OrgID:     orgID,
+		SourceID:  sourceID,
+		TargetID:  targetID,
+		Type:      linkType,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+}
+
+// LinkAs returns the link from ticketID's side: the other ticket and the type
+// or its inverse.
+func (l *TicketLink) LinkAs(ticketID int) (LinkType, int) {
+	if l.SourceID == ticketID {
+		return l.Type, l.TargetID
+	}
+
+	return LinkInverses[l.Type], l.SourceID
+}
@@ -5,21 +5,24 @@ import (
 )
 
 type Message struct {
-	ID        string    `json:"id"`
-	TicketID  int       `json:"ticket_id"`
-	AuthorID  int       `json:"author_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	OrgID         int       `json:"org_id"`
+	TicketID      int       `json:"ticket_id"`
+	AuthorID      int       `json:"author_id"`
+	Content       string    `json:"content"`
+	AttachmentIDs []int     `json:"attachment_ids"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 func CreateMessage(id string, ticketID int, authorID int, content string) (*Message, error) {
 	return &Message{
-		ID:        id,
-		TicketID:  ticketID,
-		AuthorID:  authorID,
-		Content:   content,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:            id,
+		TicketID:      ticketID,
+		AuthorID:      authorID,
+		Content:       content,
+		AttachmentIDs: []int{},
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}, nil
 }
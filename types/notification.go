@@ -0,0 +1,85 @@
+package types
+
+import "time"
+
+type Event string
+
+const (
+	EventTicketCreated  Event = "ticket_created"
+	EventTicketAssigned Event = "ticket_assigned"
+	EventStatusChanged  Event = "status_changed"
+	EventMessageCreated Event = "message_created"
+)
+
+var Events = []Event{EventTicketCreated, EventTicketAssigned, EventStatusChanged, EventMessageCreated}
+
+// Digest is how often notifications of an account are batched into one
+// email, off meaning each is sent right away.
+type Digest string
+
+const (
+	DigestOff    Digest = "off"
+	DigestHourly Digest = "hourly"
+	DigestDaily  Digest = "daily"
+)
+
+var Digests = []Digest{DigestOff, DigestHourly, DigestDaily}
+
+func (d Digest) Interval() time.Duration {
+	switch d {
+	case DigestHourly:
+		return time.Hour
+	case DigestDaily:
+		return 24 * time.Hour
+	}
+
+	return 0
+}
+
+// NotificationPreferences are what an account is notified of and how. Email
+// is the address notifications go to, the username when it is left empty
+// and is an address itself.
+type NotificationPreferences struct {
+	AccountID int       `json:"account_id"`
+	Email     string    `json:"email"`
+	Events    []Event   `json:"events"`
+	Digest    Digest    `json:"digest"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationPreferences apply to accounts that never set theirs.
+func DefaultNotificationPreferences(accountID int) *NotificationPreferences {
+	return &NotificationPreferences{
+		AccountID: accountID,
+		Events:    Events,
+		Digest:    DigestOff,
+	}
+}
+
+// Notification tells an account about an event on a ticket. It waits until
+// DeliverAt, the end of the account's digest window for batched ones, and is
+// retried until SentAt is set.
+type Notification struct {
+	ID        int        `json:"id"`
+	OrgID     int        `json:"org_id"`
+	AccountID int        `json:"account_id"`
+	TicketID  int        `json:"ticket_id"`
+	Event     Event      `json:"event"`
+	Summary   string     `json:"summary"`
+	Attempts  int        `json:"attempts"`
+	DeliverAt time.Time  `json:"deliver_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func CreateNotification(orgID int, accountID int, ticketID int, event Event, summary string, deliverAt time.Time) *Notification {
+	return &Notification{
+		OrgID:     orgID,
+		AccountID: accountID,
+		TicketID:  ticketID,
+		Event:     event,
+		Summary:   summary,
+		DeliverAt: deliverAt,
+		CreatedAt: time.Now(),
+	}
+}
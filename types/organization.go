@@ -0,0 +1,40 @@
+package types
+
+import "time"
+
+// DefaultOrganizationID is the organization existing data was moved into when
+// organizations were introduced.
+const DefaultOrganizationID = 1
+
+type Organization struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func CreateOrganization(name string) *Organization {
+	return &Organization{
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// Membership gives an account a role in an organization other than its home
+// organization, where the account's own role applies.
+type Membership struct {
+	OrgID     int       `json:"org_id"`
+	AccountID int       `json:"account_id"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func CreateMembership(orgID int, accountID int, role Role) *Membership {
+	return &Membership{
+		OrgID:     orgID,
+		AccountID: accountID,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,14 @@
+package types
+
+type Page struct {
+	Limit  int
+	Cursor string
+	Sort   string
+	Desc   bool
+	Total  bool
+}
+
+type PageInfo struct {
+	NextCursor string
+	Total      *int
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"time"
+)
+
+type Permission string
+
+const (
+	PermissionTicketCreate    Permission = "ticket:create"
+	PermissionTicketRead      Permission = "ticket:read"
+	PermissionTicketUpdate    Permission = "ticket:update"
+	PermissionTicketAssign    Permission = "ticket:assign"
+	PermissionTicketStatus    Permission = "ticket:status"
+	PermissionTicketDelete    Permission = "ticket:delete"
+	PermissionTicketRestore   Permission = "ticket:restore"
+	PermissionTicketPurge     Permission = "ticket:purge"
+	PermissionAccountRead     Permission = "account:read"
+	PermissionAccountManage   Permission = "account:manage"
+	PermissionMessageModerate Permission = "message:moderate"
+	PermissionRoleManage      Permission = "role:manage"
+	PermissionTeamManage      Permission = "team:manage"
+	PermissionSLAManage       Permission = "sla:manage"
+	PermissionCategoryManage  Permission = "category:manage"
+	PermissionFieldManage     Permission = "field:manage"
+
+	// PermissionOrgManage belongs to super-admins only and cannot be granted
+	// to a role.
+	PermissionOrgManage Permission = "org:manage"
+)
+
+var Permissions = []Permission{
+	PermissionTicketCreate,
+	PermissionTicketRead,
+	PermissionTicketUpdate,
+	PermissionTicketAssign,
+	PermissionTicketStatus,
+	PermissionTicketDelete,
+	PermissionTicketRestore,
+	PermissionTicketPurge,
+	PermissionAccountRead,
+	PermissionAccountManage,
+	PermissionMessageModerate,
+	PermissionRoleManage,
+	PermissionTeamManage,
+	PermissionSLAManage,
+	PermissionCategoryManage,
+	PermissionFieldManage,
+}
+
+// RoleDefinition maps a role to the permissions its accounts hold in one
+// organization.
+type RoleDefinition struct {
+	OrgID       int          `json:"org_id"`
+	Name        Role         `json:"name"`
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func CreateRoleDefinition(name Role, permissions []Permission) *RoleDefinition {
+	return &RoleDefinition{
+		Name:        name,
+		Permissions: permissions,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// DefaultRoles are the built in roles every organization starts with. They
+// match the rows seeded by the role migrations.
+func DefaultRoles(orgID int) []*RoleDefinition {
+	roles := []*RoleDefinition{
+		CreateRoleDefinition(RoleAdmin, Permissions),
+		CreateRoleDefinition(RoleEditor, []Permission{
+			PermissionTicketCreate,
+			PermissionTicketRead,
+			PermissionTicketUpdate,
+			PermissionTicketAssign,
+			PermissionTicketStatus,
+			PermissionTicketDelete,
+			PermissionAccountRead,
+		}),
+		CreateRoleDefinition(RoleUser, []Permission{
+			PermissionTicketCreate,
+		}),
+	}
+
+	for _, role := range roles {
+		role.OrgID = orgID
+	}
+
+	return roles
+}
@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+// Session is a server side login backing a rotating refresh token. Only a
+// hash of the current refresh token secret is kept. OrgID is the organization
+// the session was logged into, which refreshed tokens stay scoped to.
+type Session struct {
+	ID        string     `json:"id"`
+	AccountID int        `json:"account_id"`
+	OrgID     int        `json:"org_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func CreateSession(id string, accountID int, tokenHash string, expiresAt time.Time) *Session {
+	return &Session{
+		ID:        id,
+		AccountID: accountID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,153 @@
+package types
+
+import "time"
+
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+var Priorities = []Priority{PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent}
+
+// SLAMetric names one of the timers an SLA tracks.
+type SLAMetric string
+
+const (
+	SLAFirstResponse SLAMetric = "first_response"
+	SLAResolution    SLAMetric = "resolution"
+)
+
+// SLAState is what GET /ticket?sla= filters on: tickets that missed a target,
+// or that will unless someone acts within the policy's at risk window.
+type SLAState string
+
+const (
+	SLABreached SLAState = "breached"
+	SLAAtRisk   SLAState = "at_risk"
+)
+
+// SLA holds a ticket's timers. Due times come from its organization's default
+// SLA policy, or from the ticket's own due date for resolution, and are only
+// set when there is a target to meet.
+type SLA struct {
+	FirstResponseDueAt      *time.Time `json:"first_response_due_at,omitempty"`
+	FirstResponseAt         *time.Time `json:"first_response_at,omitempty"`
+	FirstResponseBreachedAt *time.Time `json:"first_response_breached_at,omitempty"`
+	ResolutionDueAt         *time.Time `json:"resolution_due_at,omitempty"`
+	ResolvedAt              *time.Time `json:"resolved_at,omitempty"`
+	ResolutionBreachedAt    *time.Time `json:"resolution_breached_at,omitempty"`
+}
+
+// SLAPolicy sets first response and resolution targets per priority, counted
+// in the business hours of its calendar. Each organization has at most one
+// default policy, which applies to all of its tickets.
+type SLAPolicy struct {
+	ID            int         `json:"id"`
+	OrgID         int         `json:"org_id"`
+	Name          string      `json:"name"`
+	Default       bool        `json:"default"`
+	Calendar      Calendar    `json:"calendar"`
+	Targets       []SLATarget `json:"targets"`
+	AtRiskMinutes int         `json:"at_risk_minutes"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// SLATarget is the business time allowed for each metric at a priority, in
+// minutes. Zero means there is no target.
+type SLATarget struct {
+	Priority      Priority `json:"priority"`
+	FirstResponse int      `json:"first_response"`
+	Resolution    int      `json:"resolution"`
+}
+
+// Calendar lists the business hours SLA time is counted in. A calendar
+// without hours counts every hour of every day.
+type Calendar struct {
+	Timezone string          `json:"timezone"`
+	Hours    []BusinessHours `json:"hours"`
+	Holidays []string        `json:"holidays"`
+}
+
+// BusinessHours is a span of a weekday, Sunday being 0, given as 15:04 times.
+type BusinessHours struct {
+	Weekday time.Weekday `json:"weekday"`
+	Start   string       `json:"start"`
+	End     string       `json:"end"`
+}
+
+func CreateSLAPolicy(orgID int, name string, isDefault bool, calendar Calendar, targets []SLATarget, atRiskMinutes int) *SLAPolicy {
+	return &SLAPolicy{
+		OrgID:         orgID,
+		Name:          name,
+		Default:       isDefault,
+		Calendar:      calendar,
+		Targets:       targets,
+		AtRiskMinutes: atRiskMinutes,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+}
+
+// Target returns the policy's target for priority, or nil when it has none.
+func (p *SLAPolicy) Target(priority Priority) *SLATarget {
+	for i := range p.Targets {
+		if p.Targets[i].Priority == priority {
+			return &p.Targets[i]
+		}
+	}
+
+	return nil
+}
+
+// Breached lists the metrics whose target was missed as of now, whether the
+// ticket was responded to or resolved late or is still waiting past it.
+func (s SLA) Breached(now time.Time) []SLAMetric {
+	metrics := []SLAMetric{}
+
+	if missed(s.FirstResponseDueAt, s.FirstResponseAt, now) {
+		metrics = append(metrics, SLAFirstResponse)
+	}
+
+	if missed(s.ResolutionDueAt, s.ResolvedAt, now) {
+		metrics = append(metrics, SLAResolution)
+	}
+
+	return metrics
+}
+
+// Unflagged lists the metrics breached as of now that were not flagged yet.
+func (s SLA) Unflagged(now time.Time) []SLAMetric {
+	metrics := []SLAMetric{}
+
+	for _, metric := range s.Breached(now) {
+		if metric == SLAFirstResponse && s.FirstResponseBreachedAt == nil || metric == SLAResolution && s.ResolutionBreachedAt == nil {
+			metrics = append(metrics, metric)
+		}
+	}
+
+	return metrics
+}
+
+// AtRisk reports whether a timer that is still running falls due before
+// deadline.
+func (s SLA) AtRisk(deadline time.Time) bool {
+	return (s.FirstResponseAt == nil && s.FirstResponseDueAt != nil && s.FirstResponseDueAt.Before(deadline)) ||
+		(s.ResolvedAt == nil && s.ResolutionDueAt != nil && s.ResolutionDueAt.Before(deadline))
+}
+
+func missed(dueAt *time.Time, doneAt *time.Time, now time.Time) bool {
+	if dueAt == nil {
+		return false
+	}
+
+	if doneAt != nil {
+		return dueAt.Before(*doneAt)
+	}
+
+	return dueAt.Before(now)
+}
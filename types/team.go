@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+// Team groups agents that work a shared queue of tickets routed to the team.
+type Team struct {
+	ID        int       `json:"id"`
+	OrgID     int       `json:"org_id"`
+	Name      string    `json:"name"`
+	MemberIDs []int     `json:"member_ids"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QueueStatuses are the statuses a ticket can wait in a team queue with.
+var QueueStatuses = []Status{StatusOpen, StatusPending}
+
+func CreateTeam(orgID int, name string, memberIDs []int) *Team {
+	return &Team{
+		OrgID:     orgID,
+		Name:      name,
+		MemberIDs: memberIDs,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
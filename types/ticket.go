@@ -7,20 +7,65 @@ type Status string
 const (
 	StatusOpen     Status = "open"
 	StatusPending  Status = "pending"
-	StutusActive   Status = "active"
+	StatusActive   Status = "active"
 	StatusResolved Status = "resolved"
 	StatusClosed   Status = "closed"
 )
 
+var Statuses = []Status{StatusOpen, StatusPending, StatusActive, StatusResolved, StatusClosed}
+
 type Ticket struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      Status    `json:"status"`
-	AuthorID    int       `json:"author_id"`
-	AssigneeIDs []int     `json:"assignee_ids"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	OrgID       int        `json:"org_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      Status     `json:"status"`
+	AuthorID    int        `json:"author_id"`
+	AssigneeIDs []int      `json:"assignee_ids"`
+	WatcherIDs  []int      `json:"watcher_ids"`
+	TeamID      int        `json:"team_id,omitempty"`
+	CategoryID  int        `json:"category_id,omitempty"`
+	Labels      []string   `json:"labels"`
+	Fields      Fields     `json:"fields"`
+	Priority    Priority   `json:"priority"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	SLA         SLA        `json:"sla"`
+	Resolution  string     `json:"resolution"`
+	Version     int        `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Fields holds a ticket's custom field values by key: strings for text, date
+// and select fields, numbers as float64 and multi-selects as lists.
+type Fields map[string]any
+
+// TicketFilter narrows a ticket listing. ParticipantID keeps tickets the
+// account authored or is assigned to. Labels keeps tickets with any of the
+// labels. SLA keeps tickets in that SLA state as of SLANow, at risk meaning a
+// target falls due within SLAWindow, and SLAUnflagged narrows breached tickets
+// to those with a breach not flagged yet.
+type TicketFilter struct {
+	Statuses      []Status
+	AuthorIDs     []int
+	AssigneeIDs   []int
+	TeamIDs       []int
+	Priorities    []Priority
+	CategoryIDs   []int
+	Labels        []string
+	Fields        []FieldFilter
+	Unassigned    bool
+	ParticipantID int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Title         string
+	SLA           SLAState
+	SLANow        time.Time
+	SLAWindow     time.Duration
+	SLAUnflagged  bool
 }
 
 func CreateTicket(title string, description string, authorID int, status Status, assigneeIDs []int) *Ticket {
@@ -30,6 +75,10 @@ func CreateTicket(title string, description string, authorID int, status Status,
 		AuthorID:    authorID,
 		Status:      status,
 		AssigneeIDs: assigneeIDs,
+		WatcherIDs:  []int{},
+		Labels:      []string{},
+		Fields:      Fields{},
+		Priority:    PriorityNormal,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -0,0 +1,203 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"ticketing-api/types"
+)
+
+type Transition struct {
+	From       []types.Status   `json:"from"`
+	To         types.Status     `json:"to"`
+	Roles      []types.Role     `json:"roles"`
+	Permission types.Permission `json:"permission"`
+	Author     bool             `json:"author"`
+	Assignee   bool             `json:"assignee"`
+	Requires   []string         `json:"requires"`
+}
+
+type Workflow struct {
+	Initial     []types.Status `json:"initial"`
+	Transitions []*Transition  `json:"transitions"`
+}
+
+type Actor struct {
+	ID          int
+	Role        types.Role
+	Permissions []types.Permission
+}
+
+var requirable = []string{"title", "description", "resolution", "assignee_ids"}
+
+func Default() *Workflow {
+	staff := []types.Role{types.RoleAdmin, types.RoleEditor}
+	status := types.PermissionTicketStatus
+
+	return &Workflow{
+		Initial: []types.Status{types.StatusOpen},
+		Transitions: []*Transition{
+			{From: []types.Status{types.StatusOpen, types.StatusActive}, To: types.StatusPending, Roles: staff, Permission: status, Assignee: true},
+			{From: []types.Status{types.StatusOpen, types.StatusPending}, To: types.StatusActive, Roles: staff, Permission: status, Assignee: true},
+			{From: []types.Status{types.StatusOpen, types.StatusPending, types.StatusActive}, To: types.StatusResolved, Roles: staff, Permission: status, Assignee: true, Requires: []string{"resolution"}},
+			{From: []types.Status{types.StatusOpen, types.StatusResolved}, To: types.StatusClosed, Roles: staff, Permission: status, Author: true},
+			{From: []types.Status{types.StatusPending, types.StatusResolved}, To: types.StatusOpen, Roles: staff, Permission: status, Author: true},
+			{From: []types.Status{types.StatusClosed}, To: types.StatusOpen, Roles: staff},
+		},
+	}
+}
+
+// Load reads a workflow from a JSON file with the same shape as Default.
+func Load(path string) (*Workflow, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workflow: %w", err)
+	}
+
+	w := &Workflow{}
+
+	err = json.Unmarshal(b, w)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing workflow: %w", err)
+	}
+
+	err = w.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *Workflow) Validate() error {
+	if len(w.Initial) == 0 {
+		return fmt.Errorf("workflow must declare at least one initial status")
+	}
+
+	for _, status := range w.Initial {
+		if !slices.Contains(types.Statuses, status) {
+			return fmt.Errorf("unknown initial status: %s", status)
+		}
+	}
+
+	for _, t := range w.Transitions {
+		for _, status := range append(slices.Clone(t.From), t.To) {
+			if !slices.Contains(types.Statuses, status) {
+				return fmt.Errorf("unknown status in transition: %s", status)
+			}
+		}
+
+		if t.Permission != "" && !slices.Contains(types.Permissions, t.Permission) {
+			return fmt.Errorf("unknown permission in transition to %s: %s", t.To, t.Permission)
+		}
+
+		for _, field := range t.Requires {
+			if !slices.Contains(requirable, field) {
+				return fmt.Errorf("unknown required field in transition to %s: %s", t.To, field)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckInitial returns the status a new ticket starts in, defaulting to the
+// first initial status when none is requested.
+func (w *Workflow) CheckInitial(status types.Status) (types.Status, error) {
+	if status == "" {
+		return w.Initial[0], nil
+	}
+
+	if !slices.Contains(w.Initial, status) {
+		return "", &types.BadRequest{Message: fmt.Sprintf("tickets cannot be created as %s, allowed: %s", status, join(w.Initial))}
+	}
+
+	return status, nil
+}
+
+// Check decides whether actor may move ticket, with its pending changes
+// applied, from its current status to the status to.
+func (w *Workflow) Check(actor *Actor, ticket *types.Ticket, from types.Status, to types.Status) error {
+	if from == to {
+		return nil
+	}
+
+	var transition *Transition
+	for _, t := range w.Transitions {
+		if t.To == to && slices.Contains(t.From, from) && t.permits(actor, ticket) {
+			transition = t
+			break
+		}
+	}
+
+	if transition == nil {
+		return &types.BadRequest{Message: fmt.Sprintf("cannot move ticket from %s to %s, allowed: %s", from, to, join(w.Next(actor, ticket, from)))}
+	}
+
+	for _, field := range transition.Requires {
+		if isEmpty(ticket, field) {
+			return &types.BadRequest{Message: fmt.Sprintf("%s is required to move ticket to %s", field, to)}
+		}
+	}
+
+	return nil
+}
+
+// Next lists the statuses actor may move ticket to from status from.
+func (w *Workflow) Next(actor *Actor, ticket *types.Ticket, from types.Status) []types.Status {
+	next := []types.Status{}
+
+	for _, t := range w.Transitions {
+		if slices.Contains(t.From, from) && t.permits(actor, ticket) && !slices.Contains(next, t.To) {
+			next = append(next, t.To)
+		}
+	}
+
+	return next
+}
+
+func (t *Transition) permits(actor *Actor, ticket *types.Ticket) bool {
+	if slices.Contains(t.Roles, actor.Role) {
+		return true
+	}
+
+	if t.Permission != "" && slices.Contains(actor.Permissions, t.Permission) {
+		return true
+	}
+
+	if t.Author && ticket.AuthorID == actor.ID {
+		return true
+	}
+
+	return t.Assignee && slices.Contains(ticket.AssigneeIDs, actor.ID)
+}
+
+func isEmpty(ticket *types.Ticket, field string) bool {
+	switch field {
+	case "title":
+		return strings.TrimSpace(ticket.Title) == ""
+	case "description":
+		return strings.TrimSpace(ticket.Description) == ""
+	case "resolution":
+		return strings.TrimSpace(ticket.Resolution) == ""
+	case "assignee_ids":
+		return len(ticket.AssigneeIDs) == 0
+	}
+
+	return false
+}
+
+func join(statuses []types.Status) string {
+	if len(statuses) == 0 {
+		return "none"
+	}
+
+	s := []string{}
+	for _, status := range statuses {
+		s = append(s, string(status))
+	}
+
+	return strings.Join(s, ", ")
+}